@@ -0,0 +1,146 @@
+// Command enckeyctl manages the master keyring referenced by ENCRYPTION_KEYS_FILE, which
+// wraps the data keys of secrets stored in the database.
+//
+//	enckeyctl import                   start a keyring with $ENCRYPTION_KEY as version 1
+//	enckeyctl generate                 add a key; the first key becomes active
+//	enckeyctl activate -version <n>    make an existing key active
+//	enckeyctl reencrypt                rewrap every stored secret with the active key
+//	enckeyctl remove -version <n>      drop a key that is no longer active
+//	enckeyctl list                     show the keys in the ring
+//
+// Servers load the keyring at startup. To rotate: generate a key and restart every server
+// so that all of them can decrypt with it, activate it and restart again, run reencrypt
+// (it needs DATABASE_URL and JWT_SECRET like the server), then remove the old key. Adding
+// and activating are separate steps so that no server that has not loaded the new key
+// yet meets values wrapped by it. A removed key cannot be recovered, so keep a backup
+// until reencrypt has succeeded.
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/config"
+	"github.com/NWhite12/EquipChain/internal/envelope"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/NWhite12/EquipChain/internal/service"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	command := os.Args[1]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	file := flags.String("file", os.Getenv("ENCRYPTION_KEYS_FILE"), "keyring path (default $ENCRYPTION_KEYS_FILE)")
+	version := flags.Int("version", 0, "key version")
+	flags.Parse(os.Args[2:])
+
+	if *file == "" {
+		fail("no keyring path: pass -file or set ENCRYPTION_KEYS_FILE")
+	}
+
+	ring, err := envelope.LoadKeyring(*file)
+	if err != nil {
+		fail(err.Error())
+	}
+
+	switch command {
+	case "import":
+		if len(ring.Keys) > 0 {
+			fail("import needs an empty keyring: legacy ciphertexts expect ENCRYPTION_KEY as version 1")
+		}
+		key, err := base64.StdEncoding.DecodeString(os.Getenv("ENCRYPTION_KEY"))
+		if err != nil || len(key) == 0 {
+			fail("ENCRYPTION_KEY must be set to the base64 key to import")
+		}
+		imported, err := ring.Add(key)
+		if err != nil {
+			fail(err.Error())
+		}
+		save(ring, *file)
+		fmt.Printf("imported ENCRYPTION_KEY as key version %d; unset ENCRYPTION_KEY and set ENCRYPTION_KEYS_FILE\n", imported)
+	case "generate":
+		generated, err := ring.Generate()
+		if err != nil {
+			fail(err.Error())
+		}
+		save(ring, *file)
+		if generated == ring.ActiveVersion {
+			fmt.Printf("generated key version %d (active)\n", generated)
+		} else {
+			fmt.Printf("generated key version %d (active: %d); restart every server, then activate -version %d\n", generated, ring.ActiveVersion, generated)
+		}
+	case "activate":
+		if err := ring.Activate(*version); err != nil {
+			fail(err.Error())
+		}
+		save(ring, *file)
+		fmt.Printf("key version %d is now active; restart every server, then run reencrypt\n", *version)
+	case "reencrypt":
+		reencrypt(ring)
+	case "remove":
+		if err := ring.Remove(*version); err != nil {
+			fail(err.Error())
+		}
+		save(ring, *file)
+		fmt.Printf("removed key version %d\n", *version)
+	case "list":
+		for _, key := range ring.Keys {
+			state := "decrypt-only"
+			if key.Version == ring.ActiveVersion {
+				state = "active"
+			}
+			fmt.Printf("%4d  created %s  %s\n", key.Version, key.CreatedAt.Format(time.RFC3339), state)
+		}
+	default:
+		usage()
+	}
+}
+
+func reencrypt(ring *envelope.Keyring) {
+	cipher, err := envelope.FromKeyring(ring)
+	if err != nil {
+		fail(err.Error())
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fail(err.Error())
+	}
+	ctx := context.Background()
+	db, err := config.InitDB(ctx, cfg)
+	if err != nil {
+		fail(err.Error())
+	}
+
+	reencryption := service.NewSecretReencryptionService(repository.NewSecretColumnRepository(db), cipher)
+	results, err := reencryption.Reencrypt(ctx)
+	for _, result := range results {
+		fmt.Printf("%-50s %d rewrapped\n", result.Column, result.Rewrapped)
+	}
+	if err != nil {
+		fail(err.Error())
+	}
+	fmt.Printf("every stored secret is wrapped by key version %d\n", cipher.ActiveVersion())
+}
+
+func save(ring *envelope.Keyring, path string) {
+	if err := ring.Save(path); err != nil {
+		fail(err.Error())
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: enckeyctl import|generate|activate|reencrypt|remove|list [-file path] [-version n]")
+	os.Exit(2)
+}
+
+func fail(message string) {
+	fmt.Fprintln(os.Stderr, "enckeyctl:", message)
+	os.Exit(1)
+}
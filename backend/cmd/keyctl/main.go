@@ -0,0 +1,102 @@
+// Command keyctl manages the JWT signing keyring referenced by JWT_KEYS_FILE.
+//
+//	keyctl generate [-alg EdDSA|RS256]   add a key; the first key becomes active
+//	keyctl rotate [-alg EdDSA|RS256]     add a key, make it active and retire the old one
+//	keyctl activate -kid <kid>           make an existing key active
+//	keyctl prune [-older-than 192h]      drop keys retired longer ago than the duration
+//	keyctl list                          show the keys in the ring
+//
+// Running servers pick up changes within 30 seconds. With several servers, generate the
+// new key first, wait until every server publishes it, then activate it. Prune only after
+// every token signed with a retired key has expired (invitation tokens live 7 days).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/service"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	command := os.Args[1]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	file := flags.String("file", os.Getenv("JWT_KEYS_FILE"), "keyring path (default $JWT_KEYS_FILE)")
+	algorithm := flags.String("alg", service.JWTAlgorithmEdDSA, "key algorithm: EdDSA or RS256")
+	kid := flags.String("kid", "", "key id")
+	olderThan := flags.Duration("older-than", 8*24*time.Hour, "minimum time since retirement for prune")
+	flags.Parse(os.Args[2:])
+
+	if *file == "" {
+		fail("no keyring path: pass -file or set JWT_KEYS_FILE")
+	}
+
+	ring, err := service.LoadJWTKeyring(*file)
+	if err != nil {
+		fail(err.Error())
+	}
+
+	switch command {
+	case "generate":
+		newKID, err := ring.Generate(*algorithm)
+		if err != nil {
+			fail(err.Error())
+		}
+		save(ring, *file)
+		fmt.Printf("generated %s key %s (active: %s)\n", *algorithm, newKID, ring.ActiveKID)
+	case "rotate":
+		newKID, err := ring.Generate(*algorithm)
+		if err != nil {
+			fail(err.Error())
+		}
+		if err := ring.Activate(newKID); err != nil {
+			fail(err.Error())
+		}
+		save(ring, *file)
+		fmt.Printf("rotated: %s key %s is now active\n", *algorithm, newKID)
+	case "activate":
+		if err := ring.Activate(*kid); err != nil {
+			fail(err.Error())
+		}
+		save(ring, *file)
+		fmt.Printf("key %s is now active\n", *kid)
+	case "prune":
+		removed := ring.Prune(*olderThan)
+		save(ring, *file)
+		fmt.Printf("pruned %d key(s) %v\n", len(removed), removed)
+	case "list":
+		for _, key := range ring.Keys {
+			state := "verify-only"
+			if key.KID == ring.ActiveKID {
+				state = "active"
+			} else if key.RetiredAt != nil {
+				state = "retired " + key.RetiredAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%s  %-5s  created %s  %s\n", key.KID, key.Algorithm, key.CreatedAt.Format(time.RFC3339), state)
+		}
+	default:
+		usage()
+	}
+}
+
+func save(ring *service.JWTKeyring, path string) {
+	if err := ring.Save(path); err != nil {
+		fail(err.Error())
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: keyctl generate|rotate|activate|prune|list [-file path] [-alg EdDSA|RS256] [-kid kid] [-older-than 192h]")
+	os.Exit(2)
+}
+
+func fail(message string) {
+	fmt.Fprintln(os.Stderr, "keyctl:", message)
+	os.Exit(1)
+}
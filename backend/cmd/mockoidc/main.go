@@ -0,0 +1,35 @@
+// Command mockoidc serves the minimal OpenID Connect provider of internal/mockoidc for
+// local SSO testing. It approves every authorization request without a login page and
+// signs ID tokens with an ephemeral RSA key. Never expose it outside a development machine.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/NWhite12/EquipChain/internal/mockoidc"
+)
+
+func main() {
+	addr := flag.String("addr", ":9400", "listen address")
+	issuer := flag.String("issuer", "http://localhost:9400", "issuer URL, must match the address clients use")
+	clientID := flag.String("client-id", "equipchain", "accepted client id")
+	clientSecret := flag.String("client-secret", "equipchain-secret", "accepted client secret")
+	email := flag.String("email", "sso.user@example.com", "email asserted when the request has no login_hint")
+	roles := flag.String("roles", "", "comma separated values of the \"groups\" claim")
+	flag.Parse()
+
+	p, err := mockoidc.New(*issuer, *clientID, *clientSecret)
+	if err != nil {
+		log.Fatalf("Failed to generate signing key: %v", err)
+	}
+	p.Email = *email
+	if *roles != "" {
+		p.SetRoles(strings.Split(*roles, ",")...)
+	}
+
+	log.Printf("mock OIDC provider %s listening on %s", p.Issuer, *addr)
+	log.Fatal(http.ListenAndServe(*addr, p.Handler()))
+}
@@ -0,0 +1,41 @@
+// Command mockprocore serves the in-memory Procore API of internal/mockprocore: the
+// client credentials token endpoint, a project's managed equipment and its equipment
+// logs. Point a procore integration's settings.base_url at it to exercise the sync
+// locally, and edit its equipment with the same REST calls to simulate changes made in
+// Procore. Never expose it outside a development machine.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/NWhite12/EquipChain/internal/mockprocore"
+)
+
+func main() {
+	addr := flag.String("addr", ":9500", "listen address")
+	clientID := flag.String("client-id", "equipchain", "accepted client id")
+	clientSecret := flag.String("client-secret", "equipchain-secret", "accepted client secret")
+	companyID := flag.Int64("company", 1, "accepted Procore-Company-Id")
+	projectID := flag.Int64("project", 1, "served project id")
+	seed := flag.String("equipment", "", "comma separated serial=location pairs of managed equipment to start with")
+	flag.Parse()
+
+	s := mockprocore.New(*clientID, *clientSecret, *companyID, *projectID)
+	for _, pair := range strings.Split(*seed, ",") {
+		serial, location, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		if serial == "" {
+			continue
+		}
+		fields := map[string]interface{}{"name": serial, "serial_number": serial}
+		if location != "" {
+			fields["location"] = location
+		}
+		s.AddEquipment(fields)
+	}
+
+	log.Printf("mock Procore API for company %d, project %d listening on %s", s.CompanyID, s.ProjectID, *addr)
+	log.Fatal(http.ListenAndServe(*addr, s.Handler()))
+}
@@ -2,12 +2,19 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/NWhite12/EquipChain/internal/api"
 	"github.com/NWhite12/EquipChain/internal/config"
+	"github.com/NWhite12/EquipChain/internal/events"
+	"github.com/NWhite12/EquipChain/internal/jobs"
 	"github.com/NWhite12/EquipChain/internal/middleware"
+	"github.com/NWhite12/EquipChain/internal/model"
 	"github.com/NWhite12/EquipChain/internal/repository"
 	"github.com/NWhite12/EquipChain/internal/service"
 	"github.com/gin-contrib/cors"
@@ -23,7 +30,11 @@ func main() {
 	logger, _ := zap.NewProduction()
 	defer logger.Sync()
 
-	ctx := context.Background()
+	// Cancelled on SIGINT/SIGTERM: stops the background jobs, the event listener and open
+	// event streams, then the server drains its requests
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	db, err := config.InitDB(ctx, cfg)
 	if err != nil {
 		panic(err)
@@ -32,15 +43,101 @@ func main() {
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	equipmentRepo := repository.NewEquipmentRepository(db)
+	securitySettingsRepo := repository.NewSecuritySettingsRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	mfaRepo := repository.NewMFARepository(db)
+	maintenanceRepo := repository.NewMaintenanceRepository(db)
+	organizationRepo := repository.NewOrganizationRepository(db)
+	oidcRepo := repository.NewOIDCRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	invitationRepo := repository.NewInvitationRepository(db)
+	technicianRepo := repository.NewTechnicianRepository(db)
+	licenseAlertRepo := repository.NewLicenseAlertRepository(db)
+	assignmentRepo := repository.NewAssignmentRepository(db)
+	scheduleRepo := repository.NewScheduleRepository(db)
+	meterRepo := repository.NewMeterReadingRepository(db)
+	maintenanceAlertRepo := repository.NewMaintenanceAlertRepository(db)
+	calendarFeedRepo := repository.NewCalendarFeedRepository(db)
+	webhookDeliveryRepo := repository.NewWebhookDeliveryRepository(db)
+	inboundWebhookRepo := repository.NewInboundWebhookRepository(db)
+	integrationSyncRepo := repository.NewIntegrationSyncRepository(db)
+	domainEventRepo := repository.NewDomainEventRepository(db)
 
 	// Initialize services
-	jwtService := service.NewJWTService(cfg)
-	authService := service.NewAuthService(userRepo, jwtService)
-	equipmentService := service.NewEquipmentService(equipmentRepo)
+	jwtService, err := service.NewJWTService(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize token signing: %v", err)
+	}
+	secretCipher, err := service.NewSecretCipher(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize secret encryption: %v", err)
+	}
+	integrationRepo := repository.NewIntegrationRepository(db, secretCipher)
+	auditService := service.NewAuditService(auditRepo)
+	eventBus := events.NewBus()
+	outboundPolicy := service.NewOutboundPolicy(cfg)
+	webhookDeliveryService := service.NewWebhookDeliveryService(integrationRepo, webhookDeliveryRepo, outboundPolicy, cfg.WebhookMaxAttempts)
+	eventBus.Subscribe(webhookDeliveryService.Enqueue)
+	eventStreamService := service.NewEventStreamService(domainEventRepo, cfg.EventStreamRetention)
+	eventBus.Subscribe(eventStreamService.Record)
+	organizationService := service.NewOrganizationService(organizationRepo)
+	permissionService := service.NewPermissionService(roleRepo, cfg.PermissionCacheTTL)
+	passwordPolicyService := service.NewPasswordPolicyService(securitySettingsRepo, userRepo)
+	lockoutService := service.NewLockoutService(userRepo, auditService, service.LockoutPolicyFromConfig(cfg))
+	mfaService := service.NewMFAService(mfaRepo, userRepo, securitySettingsRepo, permissionService, auditService, lockoutService, secretCipher, cfg.MFAIssuer)
+	authService := service.NewAuthService(userRepo, jwtService, passwordPolicyService, lockoutService, mfaService, organizationService)
+	onboardingService := service.NewOnboardingService(organizationRepo, invitationRepo, userRepo, roleRepo, securitySettingsRepo, authService, jwtService, auditService, cfg.InvitationAcceptURL)
+	userManagementService := service.NewUserManagementService(userRepo, roleRepo, lockoutService, cfg.PasswordResetURL)
+	platformService := service.NewPlatformService(organizationRepo, userRepo, roleRepo, onboardingService, auditService)
+	equipmentService := service.NewEquipmentService(equipmentRepo, eventBus)
+	technicianService := service.NewTechnicianService(technicianRepo, userRepo, auditService)
+	licenseAlertService := service.NewLicenseAlertService(organizationRepo, technicianRepo, licenseAlertRepo, userRepo, auditService, cfg.LicenseAlertThresholds)
+	meterService := service.NewMeterService(meterRepo, equipmentRepo, scheduleRepo)
+	maintenanceService := service.NewMaintenanceService(maintenanceRepo, equipmentRepo, technicianRepo, scheduleRepo, meterService, eventBus)
+	assignmentService := service.NewAssignmentService(assignmentRepo, maintenanceRepo, equipmentRepo, technicianRepo, userRepo, auditService, eventBus)
+	scheduleService := service.NewScheduleService(scheduleRepo, equipmentRepo, maintenanceRepo, meterService, auditService)
+	maintenanceAlertService := service.NewMaintenanceAlertService(organizationRepo, maintenanceAlertRepo, userRepo, eventBus, cfg.MaintenanceDueSoonDays, cfg.MaintenanceEscalationDays)
+	calendarFeedService := service.NewCalendarFeedService(calendarFeedRepo, userRepo, permissionService, organizationService, auditService, cfg.CalendarFeedURL, cfg.CalendarLinkBaseURL)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo, permissionService, auditService)
+	integrationService := service.NewIntegrationService(integrationRepo, webhookDeliveryRepo, webhookDeliveryService, auditService, outboundPolicy)
+	inboundWebhookService := service.NewInboundWebhookService(inboundWebhookRepo, integrationRepo, maintenanceRepo, organizationService, auditService)
+	procoreSyncService := service.NewProcoreSyncService(integrationRepo, integrationSyncRepo, equipmentRepo, auditService, eventBus, outboundPolicy)
+	oidcService := service.NewOIDCService(oidcRepo, organizationRepo, userRepo, roleRepo, jwtService, lockoutService, auditService, secretCipher, service.NewOIDCClient(nil), cfg)
+
+	// Background jobs
+	scheduler := jobs.NewScheduler()
+	scheduler.Register(jobs.Job{Name: "license_expiration_alerts", Interval: cfg.LicenseAlertInterval, Run: licenseAlertService.Run})
+	scheduler.Register(jobs.Job{Name: "maintenance_alerts", Interval: cfg.MaintenanceAlertInterval, Run: maintenanceAlertService.Run})
+	scheduler.Register(jobs.Job{Name: "webhook_deliveries", Interval: cfg.WebhookDeliveryInterval, Run: webhookDeliveryService.Run})
+	scheduler.Register(jobs.Job{Name: "procore_sync", Interval: cfg.ProcoreSyncInterval, Run: procoreSyncService.Run})
+	scheduler.Register(jobs.Job{Name: "domain_event_pruning", Interval: time.Hour, Run: eventStreamService.Prune})
+	scheduler.Start(ctx)
+
+	// Live events of every replica for /api/events
+	go eventStreamService.Listen(ctx)
 
 	// Initialize handlers
-	authHandler := api.NewAuthHandler(authService)
-	equipmentHandler := api.NewEquipmentHandler(equipmentService)
+	authHandler := api.NewAuthHandler(authService, onboardingService, organizationService)
+	equipmentHandler := api.NewEquipmentHandler(equipmentService, meterService)
+	securitySettingsHandler := api.NewSecuritySettingsHandler(passwordPolicyService, mfaService, onboardingService)
+	mfaHandler := api.NewMFAHandler(mfaService, authService)
+	maintenanceHandler := api.NewMaintenanceHandler(maintenanceService)
+	userHandler := api.NewUserHandler(userManagementService)
+	oidcHandler := api.NewOIDCHandler(oidcService, cfg.OIDCFrontendCallbackURL)
+	apiKeyHandler := api.NewAPIKeyHandler(apiKeyService)
+	integrationHandler := api.NewIntegrationHandler(integrationService)
+	inboundWebhookHandler := api.NewInboundWebhookHandler(inboundWebhookService)
+	integrationSyncHandler := api.NewIntegrationSyncHandler(procoreSyncService)
+	eventStreamHandler := api.NewEventStreamHandler(eventStreamService)
+	jwksHandler := api.NewJWKSHandler(jwtService)
+	invitationHandler := api.NewInvitationHandler(onboardingService)
+	platformHandler := api.NewPlatformHandler(platformService)
+	technicianHandler := api.NewTechnicianHandler(technicianService)
+	assignmentHandler := api.NewAssignmentHandler(assignmentService)
+	scheduleHandler := api.NewScheduleHandler(scheduleService)
+	meterReadingHandler := api.NewMeterReadingHandler(meterService)
+	calendarHandler := api.NewCalendarHandler(calendarFeedService)
 
 	router := gin.Default()
 
@@ -48,25 +145,144 @@ func main() {
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173"}, // TODO make a configuration for AllowedOrigins
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", middleware.SigningTokenHeader},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 	}))
 
 	// Public routes
+	router.GET("/.well-known/jwks.json", jwksHandler.Get)
 	router.POST("/api/auth/register", authHandler.Register)
 	router.POST("/api/auth/login", authHandler.Login)
+	router.POST("/api/auth/password-reset", authHandler.ResetPassword)
+	router.GET("/api/auth/invitations", invitationHandler.Preview)
+	router.POST("/api/auth/invitations/accept", invitationHandler.Accept)
+	router.POST("/api/auth/mfa/verify", authHandler.VerifyMFA)
+	router.GET("/api/auth/oidc/:organization_code/login", oidcHandler.Login)
+	router.GET("/api/auth/oidc/callback", oidcHandler.Callback)
+
+	// Calendar clients cannot send credentials, the feed token is in the path
+	router.GET("/api/calendar/:token/maintenance.ics", calendarHandler.Maintenance)
+	router.POST("/api/integrations/:id/inbound", inboundWebhookHandler.Receive)
+
+	// MFA enrollment accepts the MFA-pending token from login as well as access tokens
+	mfaEnrollment := router.Group("/api/auth/mfa")
+	mfaEnrollment.Use(middleware.MFAEnrollmentMiddleware(jwtService, authService, organizationService))
+	{
+		mfaEnrollment.POST("/enroll", mfaHandler.Enroll)
+		mfaEnrollment.POST("/enroll/confirm", mfaHandler.ConfirmEnrollment)
+	}
 
 	// Protected routes
 	protected := router.Group("/api")
-	protected.Use(middleware.AuthMiddleware(jwtService))
+	protected.Use(middleware.AuthMiddleware(jwtService, authService, permissionService, apiKeyService, organizationService))
 	{
+		// Account endpoints act on the caller's own account, so every signed-in user may
+		// use them without a permission
+		protected.POST("/auth/change-password", authHandler.ChangePassword)
+		protected.GET("/auth/mfa", mfaHandler.Status)
+		protected.POST("/auth/mfa/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+		protected.POST("/auth/mfa/disable", mfaHandler.Disable)
+		protected.POST("/auth/step-up", authHandler.StepUp)
+
+		// Organization security settings. Every member reads the password and MFA policies
+		// to choose a compliant password and to know whether MFA is required of them.
+		protected.GET("/organization/password-policy", securitySettingsHandler.GetPasswordPolicy)
+		protected.PUT("/organization/password-policy", middleware.RequirePermission(model.PermissionManageOrganization), securitySettingsHandler.UpdatePasswordPolicy)
+		protected.GET("/organization/mfa-policy", securitySettingsHandler.GetMFAPolicy)
+		protected.PUT("/organization/mfa-policy", middleware.RequirePermission(model.PermissionManageOrganization), securitySettingsHandler.UpdateMFAPolicy)
+		protected.GET("/organization/oidc", middleware.RequirePermission(model.PermissionManageOrganization), oidcHandler.GetProvider)
+		protected.PUT("/organization/oidc", middleware.RequirePermission(model.PermissionManageOrganization), oidcHandler.UpdateProvider)
+		protected.DELETE("/organization/oidc", middleware.RequirePermission(model.PermissionManageOrganization), oidcHandler.DeleteProvider)
+		protected.GET("/organization/registration-policy", middleware.RequirePermission(model.PermissionManageOrganization), securitySettingsHandler.GetRegistrationPolicy)
+		protected.PUT("/organization/registration-policy", middleware.RequirePermission(model.PermissionManageOrganization), securitySettingsHandler.UpdateRegistrationPolicy)
+		protected.GET("/organization/invitations", middleware.RequirePermission(model.PermissionManageUsers), invitationHandler.List)
+		protected.POST("/organization/invitations", middleware.RequirePermission(model.PermissionManageUsers), invitationHandler.Create)
+		protected.DELETE("/organization/invitations/:id", middleware.RequirePermission(model.PermissionManageUsers), invitationHandler.Revoke)
+		protected.GET("/organization/api-keys", middleware.RequirePermission(model.PermissionManageOrganization), apiKeyHandler.List)
+		protected.POST("/organization/api-keys", middleware.RequirePermission(model.PermissionManageOrganization), apiKeyHandler.Create)
+		protected.DELETE("/organization/api-keys/:id", middleware.RequirePermission(model.PermissionManageOrganization), apiKeyHandler.Revoke)
+		protected.GET("/organization/integrations", middleware.RequirePermission(model.PermissionManageOrganization), integrationHandler.List)
+		protected.POST("/organization/integrations", middleware.RequirePermission(model.PermissionManageOrganization), integrationHandler.Create)
+		protected.GET("/organization/integrations/:id", middleware.RequirePermission(model.PermissionManageOrganization), integrationHandler.Get)
+		protected.PATCH("/organization/integrations/:id", middleware.RequirePermission(model.PermissionManageOrganization), integrationHandler.Update)
+		protected.DELETE("/organization/integrations/:id", middleware.RequirePermission(model.PermissionManageOrganization), integrationHandler.Delete)
+		protected.POST("/organization/integrations/:id/rotate-secret", middleware.RequirePermission(model.PermissionManageOrganization), integrationHandler.RotateSecret)
+		protected.POST("/organization/integrations/:id/test", middleware.RequirePermission(model.PermissionManageOrganization), integrationHandler.Test)
+		protected.GET("/organization/integrations/:id/deliveries", middleware.RequirePermission(model.PermissionManageOrganization), integrationHandler.ListDeliveries)
+		protected.GET("/organization/integrations/:id/deliveries/:delivery_id", middleware.RequirePermission(model.PermissionManageOrganization), integrationHandler.GetDelivery)
+		protected.POST("/organization/integrations/:id/deliveries/:delivery_id/redeliver", middleware.RequirePermission(model.PermissionManageOrganization), integrationHandler.Redeliver)
+		protected.GET("/organization/integrations/:id/inbound", middleware.RequirePermission(model.PermissionManageOrganization), inboundWebhookHandler.List)
+		protected.GET("/organization/integrations/:id/inbound/:inbound_id", middleware.RequirePermission(model.PermissionManageOrganization), inboundWebhookHandler.Get)
+		protected.GET("/organization/integrations/:id/sync", middleware.RequirePermission(model.PermissionManageOrganization), integrationSyncHandler.Get)
+
+		// User administration; unlocking accounts is for admins (manage:organization) only
+		protected.GET("/users", middleware.RequirePermission(model.PermissionManageUsers), userHandler.List)
+		protected.GET("/users/:id", middleware.RequirePermission(model.PermissionManageUsers), userHandler.Get)
+		protected.PUT("/users/:id/role", middleware.RequirePermission(model.PermissionManageUsers), userHandler.ChangeRole)
+		protected.POST("/users/:id/deactivate", middleware.RequirePermission(model.PermissionManageUsers), userHandler.Deactivate)
+		protected.POST("/users/:id/reactivate", middleware.RequirePermission(model.PermissionManageUsers), userHandler.Reactivate)
+		protected.POST("/users/:id/unlock", middleware.RequirePermission(model.PermissionManageOrganization), userHandler.Unlock)
+		protected.POST("/users/:id/password-reset", middleware.RequirePermission(model.PermissionManageUsers), userHandler.ForcePasswordReset)
+
+		// Technician profiles
+		protected.GET("/technicians", middleware.RequirePermission(model.PermissionManageUsers), technicianHandler.List)
+		protected.POST("/technicians", middleware.RequirePermission(model.PermissionManageUsers), technicianHandler.Create)
+		// Own profile; anyone may read their own, whatever their role
+		protected.GET("/technicians/me", technicianHandler.GetOwn)
+		protected.GET("/technicians/me/assignments", middleware.RequirePermission(model.PermissionCreateMaintenance), assignmentHandler.Mine)
+		protected.GET("/technicians/search", middleware.RequirePermission(model.PermissionManageUsers), technicianHandler.Search)
+		protected.GET("/technicians/expiring-licenses", middleware.RequirePermission(model.PermissionManageUsers), technicianHandler.ExpiringLicenses)
+		protected.GET("/technicians/:user_id", middleware.RequirePermission(model.PermissionManageUsers), technicianHandler.Get)
+		protected.PATCH("/technicians/:user_id", middleware.RequirePermission(model.PermissionManageUsers), technicianHandler.Update)
+		protected.DELETE("/technicians/:user_id", middleware.RequirePermission(model.PermissionManageUsers), technicianHandler.Delete)
+
 		// Equipment endpoints
-		protected.GET("/equipment", equipmentHandler.List)
-		protected.GET("/equipment/:id", equipmentHandler.Get)
-		protected.POST("/equipment", equipmentHandler.Create)
-		protected.PATCH("/equipment/:id", equipmentHandler.Update)
-		protected.DELETE("/equipment/:id", equipmentHandler.Delete)
+		protected.GET("/equipment", middleware.RequirePermission(model.PermissionViewEquipment), equipmentHandler.List)
+		protected.GET("/equipment/:id", middleware.RequirePermission(model.PermissionViewEquipment), equipmentHandler.Get)
+		protected.POST("/equipment", middleware.RequirePermission(model.PermissionCreateEquipment), equipmentHandler.Create)
+		protected.PATCH("/equipment/:id", middleware.RequirePermission(model.PermissionUpdateEquipment), equipmentHandler.Update)
+		protected.DELETE("/equipment/:id", middleware.RequirePermission(model.PermissionDeleteEquipment), equipmentHandler.Delete)
+
+		// Meter readings (hour meters, odometers, cycle counters) feed meter-based schedules
+		protected.GET("/equipment/:id/meter-readings", middleware.RequirePermission(model.PermissionViewEquipment), meterReadingHandler.List)
+		protected.POST("/equipment/:id/meter-readings", middleware.RequirePermission(model.PermissionRecordMeters), meterReadingHandler.Record)
+		protected.POST("/meter-readings/batch", middleware.RequirePermission(model.PermissionRecordMeters), meterReadingHandler.RecordBatch)
+
+		// Maintenance endpoints; approval decisions are signatures and need a step-up token
+		protected.GET("/maintenance", middleware.RequirePermission(model.PermissionViewReports), maintenanceHandler.List)
+		protected.GET("/maintenance/:id", middleware.RequirePermission(model.PermissionViewReports), maintenanceHandler.Get)
+		protected.GET("/maintenance/:id/approvals", middleware.RequirePermission(model.PermissionViewReports), maintenanceHandler.ApprovalHistory)
+		protected.GET("/maintenance/:id/references", middleware.RequirePermission(model.PermissionViewReports), inboundWebhookHandler.References)
+		protected.POST("/maintenance", middleware.RequirePermission(model.PermissionCreateMaintenance), maintenanceHandler.Create)
+		protected.POST("/maintenance/:id/submit", middleware.RequirePermission(model.PermissionCreateMaintenance), maintenanceHandler.Submit)
+		protected.POST("/maintenance/:id/approve", middleware.RequirePermission(model.PermissionApproveMaintenance), middleware.RequireSigningToken(jwtService), maintenanceHandler.Approve)
+		protected.POST("/maintenance/:id/reject", middleware.RequirePermission(model.PermissionApproveMaintenance), middleware.RequireSigningToken(jwtService), maintenanceHandler.Reject)
+		// Only the blockchain confirmation worker, through an API key granted confirm:maintenance
+		protected.POST("/maintenance/:id/confirm", middleware.RequireAPIKey(), middleware.RequirePermission(model.PermissionConfirmMaintenance), maintenanceHandler.Confirm)
+
+		// Dispatch: supervisors assign draft or rejected records to technicians
+		protected.GET("/maintenance/:id/assignment", middleware.RequirePermission(model.PermissionViewReports), assignmentHandler.Get)
+		protected.GET("/maintenance/:id/assignment/candidates", middleware.RequirePermission(model.PermissionApproveMaintenance), assignmentHandler.Candidates)
+		protected.POST("/maintenance/:id/assignment", middleware.RequirePermission(model.PermissionApproveMaintenance), assignmentHandler.Assign)
+
+		// Preventive maintenance schedules; confirmed records roll them forward
+		protected.GET("/maintenance-schedules", middleware.RequirePermission(model.PermissionViewReports), scheduleHandler.List)
+		protected.GET("/maintenance-schedules/due", middleware.RequirePermission(model.PermissionViewReports), scheduleHandler.Due)
+		protected.GET("/maintenance-schedules/:id", middleware.RequirePermission(model.PermissionViewReports), scheduleHandler.Get)
+		protected.POST("/maintenance-schedules", middleware.RequirePermission(model.PermissionUpdateEquipment), scheduleHandler.Create)
+		protected.PATCH("/maintenance-schedules/:id", middleware.RequirePermission(model.PermissionUpdateEquipment), scheduleHandler.Update)
+		protected.DELETE("/maintenance-schedules/:id", middleware.RequirePermission(model.PermissionUpdateEquipment), scheduleHandler.Delete)
+
+		// Personal .ics feed of upcoming maintenance for Outlook and Google Calendar
+		protected.GET("/calendar/feed", middleware.RequirePermission(model.PermissionViewReports), calendarHandler.GetFeed)
+		protected.POST("/calendar/feed", middleware.RequirePermission(model.PermissionViewReports), calendarHandler.CreateFeed)
+		// Revoking only removes access, so users who lost view:reports can still revoke
+		protected.DELETE("/calendar/feed", calendarHandler.RevokeFeed)
+
+		// Live domain events (Server-Sent Events). Each event type needs view:equipment or
+		// view:reports; the handler checks them and refuses callers with neither.
+		protected.GET("/events", eventStreamHandler.Stream)
 
 		// Health check
 		protected.GET("/health", func(c *gin.Context) {
@@ -74,9 +290,38 @@ func main() {
 		})
 	}
 
-	if err := router.Run(":8080"); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
-	} else {
-		fmt.Println("Server running on :8080")
+	// Platform administration across organizations
+	platform := protected.Group("/platform")
+	platform.Use(middleware.RequirePlatformAdmin(platformService))
+	{
+		platform.GET("/organizations", platformHandler.ListOrganizations)
+		platform.POST("/organizations", platformHandler.CreateOrganization)
+		platform.GET("/organizations/:id", platformHandler.GetOrganization)
+		platform.POST("/organizations/:id/suspend", platformHandler.SuspendOrganization)
+		platform.POST("/organizations/:id/reactivate", platformHandler.ReactivateOrganization)
+		platform.DELETE("/organizations/:id", platformHandler.DeleteOrganization)
+		platform.POST("/organizations/:id/admin-invitations", platformHandler.InviteAdmin)
+	}
+
+	// Requests are not tied to ctx, so in-flight ones finish during Shutdown; event
+	// streams end when eventStreamService.Listen stops and closes their subscriptions.
+	server := &http.Server{
+		Addr:    ":8080",
+		Handler: router,
+	}
+	go func() {
+		log.Println("Server running on :8080")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown: %v", err)
 	}
+	scheduler.Wait()
 }
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+type CreateAPIKeyRequest struct {
+	Name        string     `json:"name" binding:"required"`
+	Permissions []string   `json:"permissions" binding:"required"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKeyResponse includes the plaintext key, which is only ever returned here.
+type CreateAPIKeyResponse struct {
+	service.APIKeyView
+	Key string `json:"key"`
+}
+
+func (h *APIKeyHandler) List(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.apiKeyService.ListKeys(c.Request.Context(), organizationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	roleID, ok := roleIDFromContext(c)
+	if !ok {
+		return
+	}
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	input := service.APIKeyInput{
+		Name:        req.Name,
+		Permissions: req.Permissions,
+		ExpiresAt:   req.ExpiresAt,
+	}
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+
+	view, key, err := h.apiKeyService.CreateKey(c.Request.Context(), organizationID, userID, roleID, input, meta)
+	if err != nil {
+		switch err {
+		case service.ErrAPIKeyNameRequired, service.ErrInvalidAPIKeyExpiry, service.ErrInvalidAPIKeyPermissions:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateAPIKeyResponse{APIKeyView: *view, Key: key})
+}
+
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid api key id"})
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	if err := h.apiKeyService.RevokeKey(c.Request.Context(), organizationID, keyID, userID, meta); err != nil {
+		switch err {
+		case service.ErrAPIKeyNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AssignmentHandler struct {
+	assignmentService *service.AssignmentService
+}
+
+func NewAssignmentHandler(assignmentService *service.AssignmentService) *AssignmentHandler {
+	return &AssignmentHandler{assignmentService: assignmentService}
+}
+
+// Candidates suggests technicians for a record; ?certification= ranks holders first.
+func (h *AssignmentHandler) Candidates(c *gin.Context) {
+	recordID, ok := maintenanceRecordIDFromParam(c)
+	if !ok {
+		return
+	}
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	candidates, err := h.assignmentService.Candidates(c.Request.Context(), organizationID, recordID, c.Query("certification"))
+	if err != nil {
+		writeAssignmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"candidates": candidates, "total": len(candidates)})
+}
+
+func (h *AssignmentHandler) Get(c *gin.Context) {
+	recordID, ok := maintenanceRecordIDFromParam(c)
+	if !ok {
+		return
+	}
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	assignment, err := h.assignmentService.GetAssignment(c.Request.Context(), organizationID, recordID)
+	if err != nil {
+		writeAssignmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
+
+// Assign dispatches the record to a technician, replacing any current assignment.
+func (h *AssignmentHandler) Assign(c *gin.Context) {
+	recordID, ok := maintenanceRecordIDFromParam(c)
+	if !ok {
+		return
+	}
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req service.AssignmentInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	assignment, err := h.assignmentService.Assign(c.Request.Context(), organizationID, userID, recordID, req, meta)
+	if err != nil {
+		writeAssignmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, assignment)
+}
+
+// Mine lists the caller's open assignments.
+func (h *AssignmentHandler) Mine(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	assignments, err := h.assignmentService.MyAssignments(c.Request.Context(), organizationID, userID)
+	if err != nil {
+		writeAssignmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"assignments": assignments, "total": len(assignments)})
+}
+
+func maintenanceRecordIDFromParam(c *gin.Context) (uuid.UUID, bool) {
+	recordID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid maintenance record id"})
+		return uuid.Nil, false
+	}
+	return recordID, true
+}
+
+func writeAssignmentError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidAssignment) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err {
+	case service.ErrMaintenanceNotFound, service.ErrEquipmentNotFound, service.ErrAssignmentNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrTechnicianNotAssignable, service.ErrCertificationRequired:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrInvalidMaintenanceStatus, service.ErrAlreadyAssigned:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
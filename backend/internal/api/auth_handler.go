@@ -1,6 +1,8 @@
 package api
 
 import (
+	"errors"
+
 	"github.com/NWhite12/EquipChain/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -8,23 +10,38 @@ import (
 )
 
 type AuthHandler struct {
-	authService service.AuthService
+	authService         service.AuthService
+	onboardingService   *service.OnboardingService
+	organizationService *service.OrganizationService
 }
 
-func NewAuthHandler(authService *service.AuthService) *AuthHandler {
-	return &AuthHandler{authService: *authService}
+func NewAuthHandler(authService *service.AuthService, onboardingService *service.OnboardingService, organizationService *service.OrganizationService) *AuthHandler {
+	return &AuthHandler{authService: *authService, onboardingService: onboardingService, organizationService: organizationService}
 }
 
 type RegisterRequest struct {
-	Email          string `json:"email" binding:"required,email"`
-	Password       string `json:"password" binding:"required,min=12"`
-	OrganizationID string `json:"organization_id" binding:"required"`
+	Email            string `json:"email" binding:"required,email"`
+	Password         string `json:"password" binding:"required"`
+	OrganizationCode string `json:"organization_code" binding:"required"`
 }
 
+// LoginRequest identifies the organization by organization_code; organization_id is still
+// accepted for existing clients.
 type LoginRequest struct {
-	Email          string `json:"email" binding:"required"`
-	Password       string `json:"password" binding:"required"`
-	OrganizationID string `json:"organization_id" binding:"required"`
+	Email            string `json:"email" binding:"required"`
+	Password         string `json:"password" binding:"required"`
+	OrganizationCode string `json:"organization_code"`
+	OrganizationID   string `json:"organization_id"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
 }
 
 type AuthResponse struct {
@@ -32,6 +49,34 @@ type AuthResponse struct {
 	Email string `json:"email"`
 }
 
+// MFAChallengeResponse is returned by login instead of AuthResponse when a second factor
+// is needed. mfa_token is sent to /api/auth/mfa/verify, or used as the bearer token for
+// the enrollment endpoints when mfa_enrollment_required is set.
+type MFAChallengeResponse struct {
+	MFARequired           bool   `json:"mfa_required"`
+	MFAEnrollmentRequired bool   `json:"mfa_enrollment_required"`
+	MFAToken              string `json:"mfa_token"`
+	Email                 string `json:"email"`
+}
+
+type StepUpRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+type StepUpResponse struct {
+	SigningToken string `json:"signing_token"`
+	AuthFactor   string `json:"auth_factor"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// Register self-registers a viewer. It only succeeds for organizations whose registration
+// policy allows the email's domain; everyone else joins by invitation.
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -39,57 +84,247 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	organizationID, err := uuid.Parse(req.OrganizationID)
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	result, err := h.onboardingService.Register(c.Request.Context(), req.OrganizationCode, req.Email, req.Password, meta)
+	if err != nil {
+		writeRegistrationError(c, err)
+		return
+	}
+
+	writeLoginResult(c, http.StatusCreated, result, req.Email)
+}
+
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var organizationID uuid.UUID
+	switch {
+	case req.OrganizationCode != "":
+		resolved, err := h.organizationService.ResolveCode(c.Request.Context(), req.OrganizationCode)
+		if err != nil && err != service.ErrOrganizationNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
+		// An unknown code falls through to "invalid credentials" below
+		organizationID = resolved
+	case req.OrganizationID != "":
+		parsed, err := uuid.Parse(req.OrganizationID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization_id"})
+			return
+		}
+		organizationID = parsed
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "organization_code is required"})
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	result, err := h.authService.LoginUser(c.Request.Context(), organizationID, req.Email, req.Password, meta)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization_id"})
+		// Don't leak which org exists
+		switch err {
+		case service.ErrAccountLocked:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "account temporarily locked"})
+		case service.ErrAccountDisabled:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "account disabled"})
+		case service.ErrPasswordResetRequired:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case service.ErrOrganizationSuspended:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			// Unexpected failures (e.g. auditing a lockout) also read as bad credentials,
+			// but are reported in the request log
+			if err != service.ErrInvalidCredentials {
+				c.Error(err)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		}
+		return
+	}
+
+	writeLoginResult(c, http.StatusOK, result, req.Email)
+}
+
+// VerifyMFA completes a login that returned mfa_required.
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	user, token, err := h.authService.RegisterUserAndGenerateToken(c.Request.Context(), organizationID, req.Email, req.Password)
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	user, token, err := h.authService.CompleteMFALogin(c.Request.Context(), req.MFAToken, req.Code, meta)
 	if err != nil {
-		// Check error type to determine status code
 		switch err {
-		case service.ErrEmailExists, service.ErrWeakPassword, service.ErrInvalidCredentials:
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case service.ErrAccountLocked:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "account temporarily locked"})
+		case service.ErrAccountDisabled:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "account disabled"})
+		case service.ErrInvalidMFACode, service.ErrInvalidMFAToken, service.ErrMFANotEnrolled:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case service.ErrOrganizationSuspended:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case service.ErrOrganizationNotFound:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
 		}
 		return
 	}
 
-	c.JSON(http.StatusCreated, AuthResponse{
+	c.JSON(http.StatusOK, AuthResponse{
 		Token: token,
 		Email: user.Email,
 	})
 }
 
-func (h *AuthHandler) Login(c *gin.Context) {
-	var req LoginRequest
-	if err := c.ShouldBindBodyWithJSON(&req); err != nil {
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	organizationID, err := uuid.Parse(req.OrganizationID)
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	err := h.authService.ChangePassword(c.Request.Context(), userID, req.CurrentPassword, req.NewPassword, meta)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid org_id"})
+		var policyErr *service.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrWeakPassword.Error(), "violations": policyErr.Violations})
+			return
+		}
+
+		switch err {
+		case service.ErrInvalidCredentials:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "current password is incorrect"})
+		case service.ErrAccountLocked:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "account temporarily locked"})
+		case service.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// ResetPassword sets a new password with the token from a password reset email.
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	token, err := h.authService.LoginUser(c.Request.Context(), organizationID, req.Email, req.Password)
+	err := h.authService.ResetPassword(c.Request.Context(), req.Token, req.NewPassword)
+	if err != nil {
+		var policyErr *service.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrWeakPassword.Error(), "violations": policyErr.Violations})
+			return
+		}
+
+		switch err {
+		case service.ErrInvalidResetToken:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case service.ErrOrganizationSuspended:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case service.ErrOrganizationNotFound:
+			c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrInvalidResetToken.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// StepUp re-checks the caller's password or TOTP code and issues a signing token that
+// approval endpoints require in the X-Signing-Token header.
+func (h *AuthHandler) StepUp(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req StepUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	grant, err := h.authService.StepUp(c.Request.Context(), userID, req.Password, req.Code, meta)
 	if err != nil {
-		// Don't leak which org exists
 		switch err {
 		case service.ErrAccountLocked:
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "account temporarily locked"})
+		case service.ErrInvalidCredentials, service.ErrInvalidMFACode:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case service.ErrTOTPRequired, service.ErrMFANotEnrolled:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case service.ErrUserNotFound:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 		default:
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, AuthResponse{
-		Token: token,
-		Email: req.Email,
+	c.JSON(http.StatusOK, StepUpResponse{
+		SigningToken: grant.Token,
+		AuthFactor:   grant.AuthFactor,
+		ExpiresIn:    int(grant.ExpiresIn.Seconds()),
+	})
+}
+
+// writeLoginResult responds with the access token, or with an MFA challenge when the login
+// still needs a second factor.
+func writeLoginResult(c *gin.Context, status int, result *service.LoginResult, email string) {
+	if result.MFAToken != "" {
+		c.JSON(status, MFAChallengeResponse{
+			MFARequired:           result.MFARequired,
+			MFAEnrollmentRequired: result.MFAEnrollmentRequired,
+			MFAToken:              result.MFAToken,
+			Email:                 email,
+		})
+		return
+	}
+
+	c.JSON(status, AuthResponse{
+		Token: result.Token,
+		Email: email,
 	})
 }
+
+// writeRegistrationError maps errors from creating an account by registration or invitation.
+func writeRegistrationError(c *gin.Context, err error) {
+	var policyErr *service.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrWeakPassword.Error(), "violations": policyErr.Violations})
+		return
+	}
+
+	switch err {
+	case service.ErrEmailExists, service.ErrWeakPassword, service.ErrInvalidInvitation:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrRegistrationClosed, service.ErrOrganizationSuspended:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
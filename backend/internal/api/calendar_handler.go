@@ -0,0 +1,120 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type CalendarHandler struct {
+	calendarFeedService *service.CalendarFeedService
+}
+
+func NewCalendarHandler(calendarFeedService *service.CalendarFeedService) *CalendarHandler {
+	return &CalendarHandler{calendarFeedService: calendarFeedService}
+}
+
+// CreateCalendarFeedResponse includes the feed URL with its token, which is only ever
+// returned here.
+type CreateCalendarFeedResponse struct {
+	service.CalendarFeedView
+	URL string `json:"url"`
+}
+
+// GetFeed describes the caller's feed token, if they have one.
+func (h *CalendarHandler) GetFeed(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	feed, err := h.calendarFeedService.GetFeed(c.Request.Context(), userID)
+	if err != nil {
+		writeCalendarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, feed)
+}
+
+// CreateFeed issues the caller a new feed URL; a previous one stops working.
+func (h *CalendarHandler) CreateFeed(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	feed, url, err := h.calendarFeedService.CreateFeed(c.Request.Context(), organizationID, userID, meta)
+	if err != nil {
+		writeCalendarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateCalendarFeedResponse{CalendarFeedView: *feed, URL: url})
+}
+
+func (h *CalendarHandler) RevokeFeed(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	if err := h.calendarFeedService.RevokeFeed(c.Request.Context(), organizationID, userID, meta); err != nil {
+		writeCalendarError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Maintenance serves the .ics feed authenticated by the token in the path. ?location=,
+// ?maintenance_type_id= and ?assignee= (a user id or "me") filter.
+func (h *CalendarHandler) Maintenance(c *gin.Context) {
+	filter := service.CalendarFeedFilter{
+		Location:          c.Query("location"),
+		MaintenanceTypeID: c.Query("maintenance_type_id"),
+		Assignee:          c.Query("assignee"),
+	}
+
+	calendar, err := h.calendarFeedService.Render(c.Request.Context(), c.Param("token"), filter)
+	if err != nil {
+		writeCalendarError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Content-Disposition", `inline; filename="maintenance.ics"`)
+	c.Header("Cache-Control", "private, no-store")
+	c.Status(http.StatusOK)
+	if _, err := calendar.WriteTo(c.Writer); err != nil {
+		c.Error(err)
+	}
+}
+
+func writeCalendarError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidCalendarFeedFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err {
+	case service.ErrCalendarFeedNotFound, service.ErrOrganizationNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrCalendarFeedNotFound.Error()})
+	case service.ErrOrganizationSuspended:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
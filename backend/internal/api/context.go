@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// organizationIDFromContext reads the organization set by AuthMiddleware. On failure it
+// writes a 401 response and returns false.
+func organizationIDFromContext(c *gin.Context) (uuid.UUID, bool) {
+	orgIDInterface, exists := c.Get("organization_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "organization_id not in token"})
+		return uuid.Nil, false
+	}
+
+	parsedOrganizationID, ok := orgIDInterface.(uuid.UUID)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid organization_id type"})
+		return uuid.Nil, false
+	}
+
+	return parsedOrganizationID, true
+}
+
+// userIDFromContext reads the user set by AuthMiddleware. On failure it writes a 401
+// response and returns false.
+func userIDFromContext(c *gin.Context) (uuid.UUID, bool) {
+	userIDInterface, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id not in token"})
+		return uuid.Nil, false
+	}
+
+	parsedUserID, ok := userIDInterface.(uuid.UUID)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id type"})
+		return uuid.Nil, false
+	}
+
+	return parsedUserID, true
+}
+
+// roleIDFromContext reads the role set by AuthMiddleware. On failure it writes a 401
+// response and returns false.
+func roleIDFromContext(c *gin.Context) (int16, bool) {
+	roleIDInterface, exists := c.Get("role_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "role_id not in token"})
+		return 0, false
+	}
+
+	roleID, ok := roleIDInterface.(int16)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid role_id type"})
+		return 0, false
+	}
+
+	return roleID, true
+}
+
+// permissionsFromContext reads the permissions set by AuthMiddleware. On failure it writes
+// a 403 response and returns false.
+func permissionsFromContext(c *gin.Context) ([]string, bool) {
+	permissionsInterface, exists := c.Get("permissions")
+	if !exists {
+		c.JSON(http.StatusForbidden, gin.H{"error": "permissions not found"})
+		return nil, false
+	}
+
+	permissions, ok := permissionsInterface.([]string)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid permissions type"})
+		return nil, false
+	}
+
+	return permissions, true
+}
@@ -11,10 +11,11 @@ import (
 
 type EquipmentHandler struct {
 	equipmentService *service.EquipmentService
+	meterService     *service.MeterService
 }
 
-func NewEquipmentHandler(equipmentService *service.EquipmentService) *EquipmentHandler {
-	return &EquipmentHandler{equipmentService: equipmentService}
+func NewEquipmentHandler(equipmentService *service.EquipmentService, meterService *service.MeterService) *EquipmentHandler {
+	return &EquipmentHandler{equipmentService: equipmentService, meterService: meterService}
 }
 
 type CreateEquipmentRequest struct {
@@ -54,6 +55,9 @@ type EquipmentResponse struct {
 	WarrantyExpires *string    `json:"warranty_expires,omitempty"`
 	CreatedAt       string     `json:"created_at"`
 	UpdatedAt       string     `json:"updated_at"`
+
+	// Meters holds the latest reading and usage rate of each meter read so far
+	Meters []service.MeterSummary `json:"meters,omitempty"`
 }
 
 func (h *EquipmentHandler) List(c *gin.Context) {
@@ -81,9 +85,20 @@ func (h *EquipmentHandler) List(c *gin.Context) {
 		return
 	}
 
+	equipmentIDs := make([]uuid.UUID, len(equipment))
+	for i, e := range equipment {
+		equipmentIDs[i] = e.ID
+	}
+	meters, err := h.meterService.Summaries(c.Request.Context(), equipmentIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
 	responses := make([]EquipmentResponse, len(equipment))
 	for i, e := range equipment {
 		responses[i] = h.mapToResponse(e)
+		responses[i].Meters = meters[e.ID]
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -125,7 +140,15 @@ func (h *EquipmentHandler) Get(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, h.mapToResponse(equipment))
+	meters, err := h.meterService.Summaries(c.Request.Context(), []uuid.UUID{equipment.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	resp := h.mapToResponse(equipment)
+	resp.Meters = meters[equipment.ID]
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *EquipmentHandler) Create(c *gin.Context) {
@@ -306,7 +329,15 @@ func (h *EquipmentHandler) Update(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, h.mapToResponse(updated))
+	meters, err := h.meterService.Summaries(c.Request.Context(), []uuid.UUID{updated.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	resp := h.mapToResponse(updated)
+	resp.Meters = meters[updated.ID]
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *EquipmentHandler) Delete(c *gin.Context) {
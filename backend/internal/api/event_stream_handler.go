@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// eventStreamHeartbeat keeps idle connections open through proxies.
+	eventStreamHeartbeat = 25 * time.Second
+	// Streams are closed after eventStreamMaxDuration so that reconnecting clients are
+	// authenticated again; EventSource reconnects on its own, after eventStreamRetry.
+	eventStreamMaxDuration = 30 * time.Minute
+	eventStreamRetry       = 3 * time.Second
+)
+
+type EventStreamHandler struct {
+	eventStreamService *service.EventStreamService
+}
+
+func NewEventStreamHandler(eventStreamService *service.EventStreamService) *EventStreamHandler {
+	return &EventStreamHandler{eventStreamService: eventStreamService}
+}
+
+// Stream sends the organization's domain events as Server-Sent Events, filtered by
+// ?types= (comma-separated; default every type the caller may see). Each frame's id is the
+// stream's EventCursor; a Last-Event-ID header, or ?last_event_id= for the first
+// connection, replays the stored events that cursor has not seen before the live ones.
+func (h *EventStreamHandler) Stream(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	permissions, ok := permissionsFromContext(c)
+	if !ok {
+		return
+	}
+
+	var requested []string
+	if types := c.Query("types"); types != "" {
+		requested = strings.Split(types, ",")
+	}
+	eventTypes, err := h.eventStreamService.StreamTypes(permissions, requested)
+	if err != nil {
+		writeEventStreamError(c, err)
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	cursor := &service.EventCursor{}
+	if lastEventID != "" {
+		if cursor, err = service.ParseEventCursor(lastEventID); err != nil {
+			writeEventStreamError(c, err)
+			return
+		}
+	}
+
+	// Subscribe before replaying, so that nothing published meanwhile is missed
+	subscription := h.eventStreamService.Subscribe(organizationID, eventTypes)
+	defer h.eventStreamService.Unsubscribe(subscription)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", eventStreamRetry.Milliseconds())
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	if lastEventID != "" {
+		if err := h.eventStreamService.Replay(ctx, organizationID, cursor, eventTypes, func(event service.StreamEvent) error {
+			return writeStreamEvent(c, cursor, event)
+		}); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+	deadline := time.NewTimer(eventStreamMaxDuration)
+	defer deadline.Stop()
+	for {
+		select {
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// Fell behind, or the server is shutting down; the client reconnects
+				// and replays from its last id
+				return
+			}
+			// The replay may have sent it already
+			if cursor.Sent(event.Sequence) {
+				continue
+			}
+			cursor.Add(event.Sequence)
+			if err := writeStreamEvent(c, cursor, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-deadline.C:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// writeStreamEvent writes an event that was added to cursor, with the cursor as its id.
+func writeStreamEvent(c *gin.Context, cursor *service.EventCursor, event service.StreamEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", cursor, event.Event, data); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+func writeEventStreamError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidEventStreamFilter), errors.Is(err, service.ErrInvalidEventCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrEventTypeForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type InboundWebhookHandler struct {
+	inboundWebhookService *service.InboundWebhookService
+}
+
+func NewInboundWebhookHandler(inboundWebhookService *service.InboundWebhookService) *InboundWebhookHandler {
+	return &InboundWebhookHandler{inboundWebhookService: inboundWebhookService}
+}
+
+// Receive accepts a partner's signed call. It is authenticated by the signature headers
+// rather than a session, and answers 202 once the call is stored, even if its payload is
+// not understood.
+func (h *InboundWebhookHandler) Receive(c *gin.Context) {
+	integrationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrIntegrationNotFound.Error()})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, service.MaxInboundWebhookBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		return
+	}
+
+	req := service.InboundWebhookRequest{
+		Timestamp: c.GetHeader(service.InboundTimestampHeader),
+		Nonce:     c.GetHeader(service.InboundNonceHeader),
+		Signature: c.GetHeader(service.WebhookSignatureHeader),
+		Body:      body,
+	}
+	receipt, err := h.inboundWebhookService.Receive(c.Request.Context(), integrationID, req)
+	if err != nil {
+		writeInboundWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, receipt)
+}
+
+// List returns the calls an integration received, newest first, filtered by ?status= and
+// ?event_type= and paginated with ?page= and ?page_size=.
+func (h *InboundWebhookHandler) List(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	integrationID, ok := integrationIDFromParam(c)
+	if !ok {
+		return
+	}
+
+	query := service.InboundWebhookQuery{
+		Status:    c.Query("status"),
+		EventType: c.Query("event_type"),
+	}
+	for name, target := range map[string]*int{"page": &query.Page, "page_size": &query.PageSize} {
+		if value := c.Query(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	page, err := h.inboundWebhookService.ListInbound(c.Request.Context(), organizationID, integrationID, query)
+	if err != nil {
+		writeInboundWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *InboundWebhookHandler) Get(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	integrationID, ok := integrationIDFromParam(c)
+	if !ok {
+		return
+	}
+	inboundID, err := uuid.Parse(c.Param("inbound_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid inbound webhook id"})
+		return
+	}
+
+	inbound, err := h.inboundWebhookService.GetInbound(c.Request.Context(), organizationID, integrationID, inboundID)
+	if err != nil {
+		writeInboundWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, inbound)
+}
+
+// References lists the external references partners attached to a maintenance record.
+func (h *InboundWebhookHandler) References(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	recordID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid maintenance record id"})
+		return
+	}
+
+	references, err := h.inboundWebhookService.ListReferences(c.Request.Context(), organizationID, recordID)
+	if err != nil {
+		writeInboundWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"references": references})
+}
+
+func writeInboundWebhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidInboundSignature):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrInvalidIntegration):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err {
+	case service.ErrInboundWebhookReplayed:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case service.ErrIntegrationNotFound, service.ErrOrganizationNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": service.ErrIntegrationNotFound.Error()})
+	case service.ErrInboundWebhookNotFound, service.ErrMaintenanceNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrOrganizationSuspended:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
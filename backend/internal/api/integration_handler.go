@@ -0,0 +1,365 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type IntegrationHandler struct {
+	integrationService *service.IntegrationService
+}
+
+func NewIntegrationHandler(integrationService *service.IntegrationService) *IntegrationHandler {
+	return &IntegrationHandler{integrationService: integrationService}
+}
+
+type CreateIntegrationRequest struct {
+	IntegrationType string          `json:"integration_type" binding:"required"`
+	IntegrationName *string         `json:"integration_name"`
+	WebhookURL      *string         `json:"webhook_url"`
+	WebhookSecret   *string         `json:"webhook_secret"`
+	APIKey          *string         `json:"api_key"`
+	APISecret       *string         `json:"api_secret"`
+	Settings        json.RawMessage `json:"settings"`
+	EventTypes      []string        `json:"event_types"`
+	IsActive        *bool           `json:"is_active"`
+	TestMode        *bool           `json:"test_mode"`
+}
+
+type UpdateIntegrationRequest struct {
+	IntegrationName *string         `json:"integration_name"`
+	WebhookURL      *string         `json:"webhook_url"`
+	APIKey          *string         `json:"api_key"`
+	APISecret       *string         `json:"api_secret"`
+	Settings        json.RawMessage `json:"settings"`
+	EventTypes      *[]string       `json:"event_types"`
+	IsActive        *bool           `json:"is_active"`
+	TestMode        *bool           `json:"test_mode"`
+}
+
+// RotateSecretRequest sets how many hours the previous secret keeps signing deliveries;
+// 24 if omitted, 0 to drop it right away.
+type RotateSecretRequest struct {
+	GraceHours *int `json:"grace_hours"`
+}
+
+// IntegrationSecretResponse includes the webhook secret, which is only ever returned on
+// create and rotation.
+type IntegrationSecretResponse struct {
+	service.IntegrationView
+	WebhookSecret string `json:"webhook_secret,omitempty"`
+}
+
+func (h *IntegrationHandler) List(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	integrations, err := h.integrationService.ListIntegrations(c.Request.Context(), organizationID)
+	if err != nil {
+		writeIntegrationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"integrations": integrations})
+}
+
+func (h *IntegrationHandler) Get(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	integrationID, ok := integrationIDFromParam(c)
+	if !ok {
+		return
+	}
+
+	integration, err := h.integrationService.GetIntegration(c.Request.Context(), organizationID, integrationID)
+	if err != nil {
+		writeIntegrationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, integration)
+}
+
+func (h *IntegrationHandler) Create(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	var req CreateIntegrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	input := service.CreateIntegrationInput{
+		IntegrationType: req.IntegrationType,
+		IntegrationName: req.IntegrationName,
+		WebhookURL:      req.WebhookURL,
+		WebhookSecret:   req.WebhookSecret,
+		APIKey:          req.APIKey,
+		APISecret:       req.APISecret,
+		Settings:        req.Settings,
+		EventTypes:      req.EventTypes,
+		IsActive:        req.IsActive,
+		TestMode:        req.TestMode,
+	}
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+
+	view, secret, err := h.integrationService.CreateIntegration(c.Request.Context(), organizationID, userID, input, meta)
+	if err != nil {
+		writeIntegrationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, IntegrationSecretResponse{IntegrationView: *view, WebhookSecret: secret})
+}
+
+func (h *IntegrationHandler) Update(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	integrationID, ok := integrationIDFromParam(c)
+	if !ok {
+		return
+	}
+	var req UpdateIntegrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	input := service.UpdateIntegrationInput{
+		IntegrationName: req.IntegrationName,
+		WebhookURL:      req.WebhookURL,
+		APIKey:          req.APIKey,
+		APISecret:       req.APISecret,
+		Settings:        req.Settings,
+		EventTypes:      req.EventTypes,
+		IsActive:        req.IsActive,
+		TestMode:        req.TestMode,
+	}
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+
+	view, err := h.integrationService.UpdateIntegration(c.Request.Context(), organizationID, userID, integrationID, input, meta)
+	if err != nil {
+		writeIntegrationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+func (h *IntegrationHandler) Delete(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	integrationID, ok := integrationIDFromParam(c)
+	if !ok {
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	if err := h.integrationService.DeleteIntegration(c.Request.Context(), organizationID, userID, integrationID, meta); err != nil {
+		writeIntegrationError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *IntegrationHandler) RotateSecret(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	integrationID, ok := integrationIDFromParam(c)
+	if !ok {
+		return
+	}
+	var req RotateSecretRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	grace := service.DefaultSecretGrace
+	if req.GraceHours != nil {
+		grace = time.Duration(*req.GraceHours) * time.Hour
+	}
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+
+	view, secret, err := h.integrationService.RotateSecret(c.Request.Context(), organizationID, userID, integrationID, grace, meta)
+	if err != nil {
+		writeIntegrationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, IntegrationSecretResponse{IntegrationView: *view, WebhookSecret: secret})
+}
+
+// Test sends a ping event to the integration's webhook and returns the delivery with the
+// endpoint's response.
+func (h *IntegrationHandler) Test(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	integrationID, ok := integrationIDFromParam(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.integrationService.SendTest(c.Request.Context(), organizationID, integrationID)
+	if err != nil {
+		writeIntegrationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// ListDeliveries returns the integration's delivery log, newest first, filtered by
+// ?status= and ?event_type= and paginated with ?page= and ?page_size=.
+func (h *IntegrationHandler) ListDeliveries(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	integrationID, ok := integrationIDFromParam(c)
+	if !ok {
+		return
+	}
+
+	query := service.WebhookDeliveryQuery{
+		Status:    c.Query("status"),
+		EventType: c.Query("event_type"),
+	}
+	for name, target := range map[string]*int{"page": &query.Page, "page_size": &query.PageSize} {
+		if value := c.Query(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	page, err := h.integrationService.ListDeliveries(c.Request.Context(), organizationID, integrationID, query)
+	if err != nil {
+		writeIntegrationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *IntegrationHandler) GetDelivery(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	integrationID, ok := integrationIDFromParam(c)
+	if !ok {
+		return
+	}
+	deliveryID, ok := deliveryIDFromParam(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.integrationService.GetDelivery(c.Request.Context(), organizationID, integrationID, deliveryID)
+	if err != nil {
+		writeIntegrationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// Redeliver posts a past delivery's payload again and returns the new delivery.
+func (h *IntegrationHandler) Redeliver(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	integrationID, ok := integrationIDFromParam(c)
+	if !ok {
+		return
+	}
+	deliveryID, ok := deliveryIDFromParam(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.integrationService.Redeliver(c.Request.Context(), organizationID, integrationID, deliveryID)
+	if err != nil {
+		writeIntegrationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, delivery)
+}
+
+func integrationIDFromParam(c *gin.Context) (uuid.UUID, bool) {
+	integrationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid integration id"})
+		return uuid.Nil, false
+	}
+	return integrationID, true
+}
+
+func deliveryIDFromParam(c *gin.Context) (uuid.UUID, bool) {
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery id"})
+		return uuid.Nil, false
+	}
+	return deliveryID, true
+}
+
+func writeIntegrationError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidIntegration) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err {
+	case service.ErrIntegrationNotFound, service.ErrWebhookDeliveryNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
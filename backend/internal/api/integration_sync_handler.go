@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type IntegrationSyncHandler struct {
+	procoreSyncService *service.ProcoreSyncService
+}
+
+func NewIntegrationSyncHandler(procoreSyncService *service.ProcoreSyncService) *IntegrationSyncHandler {
+	return &IntegrationSyncHandler{procoreSyncService: procoreSyncService}
+}
+
+// Get returns where the integration's equipment sync stands: the phase and position of
+// the current cycle, its counters, and the last error.
+func (h *IntegrationSyncHandler) Get(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	integrationID, ok := integrationIDFromParam(c)
+	if !ok {
+		return
+	}
+
+	state, err := h.procoreSyncService.GetSyncState(c.Request.Context(), organizationID, integrationID)
+	if err != nil {
+		writeIntegrationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, state)
+}
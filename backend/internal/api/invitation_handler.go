@@ -0,0 +1,144 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type InvitationHandler struct {
+	onboardingService *service.OnboardingService
+}
+
+func NewInvitationHandler(onboardingService *service.OnboardingService) *InvitationHandler {
+	return &InvitationHandler{onboardingService: onboardingService}
+}
+
+type CreateInvitationRequest struct {
+	Email  string `json:"email" binding:"required,email"`
+	RoleID int16  `json:"role_id" binding:"required"`
+}
+
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+func (h *InvitationHandler) List(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	invitations, err := h.onboardingService.ListInvitations(c.Request.Context(), organizationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+func (h *InvitationHandler) Create(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	var req CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	invitation, err := h.onboardingService.CreateInvitation(c.Request.Context(), organizationID, userID, req.Email, req.RoleID, meta)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidRole:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case service.ErrRoleNotAssignable:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case service.ErrEmailExists:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+func (h *InvitationHandler) Revoke(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	invitationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation id"})
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	if err := h.onboardingService.RevokeInvitation(c.Request.Context(), organizationID, invitationID, userID, meta); err != nil {
+		switch err {
+		case service.ErrInvitationNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Preview lets the onboarding page show the organization and role before accepting.
+func (h *InvitationHandler) Preview(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	preview, err := h.onboardingService.PreviewInvitation(c.Request.Context(), token)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidInvitation:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+func (h *InvitationHandler) Accept(c *gin.Context) {
+	var req AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	user, result, err := h.onboardingService.AcceptInvitation(c.Request.Context(), req.Token, req.Password, meta)
+	if err != nil {
+		writeRegistrationError(c, err)
+		return
+	}
+
+	writeLoginResult(c, http.StatusCreated, result, user.Email)
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	jwtService *service.JWTService
+}
+
+func NewJWKSHandler(jwtService *service.JWTService) *JWKSHandler {
+	return &JWKSHandler{jwtService: jwtService}
+}
+
+// Get publishes the public keys that verify EquipChain tokens, so other services can
+// validate them without a shared secret.
+func (h *JWKSHandler) Get(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.jwtService.JWKS())
+}
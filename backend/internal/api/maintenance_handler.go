@@ -0,0 +1,354 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type MaintenanceHandler struct {
+	maintenanceService *service.MaintenanceService
+}
+
+func NewMaintenanceHandler(maintenanceService *service.MaintenanceService) *MaintenanceHandler {
+	return &MaintenanceHandler{maintenanceService: maintenanceService}
+}
+
+type CreateMaintenanceRequest struct {
+	EquipmentID       string                      `json:"equipment_id" binding:"required"`
+	MaintenanceTypeID int16                       `json:"maintenance_type_id" binding:"required"`
+	Notes             *string                     `json:"notes"`
+	GPSLatitude       *float64                    `json:"gps_latitude"`
+	GPSLongitude      *float64                    `json:"gps_longitude"`
+	MeterReadings     []service.MeterReadingInput `json:"meter_readings"`
+}
+
+type ApprovalDecisionRequest struct {
+	Comments *string `json:"comments"`
+}
+
+type ConfirmMaintenanceRequest struct {
+	TransactionSignature string `json:"transaction_signature" binding:"required"`
+}
+
+type MaintenanceResponse struct {
+	ID                uuid.UUID  `json:"id"`
+	EquipmentID       uuid.UUID  `json:"equipment_id"`
+	MaintenanceTypeID int16      `json:"maintenance_type_id"`
+	StatusID          int16      `json:"status_id"`
+	TechnicianID      uuid.UUID  `json:"technician_id"`
+	SupervisorID      *uuid.UUID `json:"supervisor_id,omitempty"`
+	Notes             *string    `json:"notes,omitempty"`
+	GPSLatitude       *float64   `json:"gps_latitude,omitempty"`
+	GPSLongitude      *float64   `json:"gps_longitude,omitempty"`
+	SubmittedAt       *string    `json:"submitted_at,omitempty"`
+	ApprovedAt        *string    `json:"approved_at,omitempty"`
+	RejectedAt        *string    `json:"rejected_at,omitempty"`
+	ConfirmedAt       *string    `json:"confirmed_at,omitempty"`
+	SolanaSignature   *string    `json:"solana_signature,omitempty"`
+	CreatedAt         string     `json:"created_at"`
+	UpdatedAt         string     `json:"updated_at"`
+}
+
+type ApprovalAuditResponse struct {
+	ID               uuid.UUID `json:"id"`
+	ApproverID       uuid.UUID `json:"approver_id"`
+	Action           string    `json:"action"`
+	Comments         *string   `json:"comments,omitempty"`
+	ApprovalSequence int16     `json:"approval_sequence"`
+	AuthFactor       *string   `json:"auth_factor,omitempty"`
+	CreatedAt        string    `json:"created_at"`
+}
+
+func (h *MaintenanceHandler) List(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	filters := map[string]interface{}{
+		"status_id":     c.Query("status_id"),
+		"equipment_id":  c.Query("equipment_id"),
+		"technician_id": c.Query("technician_id"),
+	}
+
+	records, err := h.maintenanceService.ListRecords(c.Request.Context(), organizationID, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	responses := make([]MaintenanceResponse, len(records))
+	for i, r := range records {
+		responses[i] = h.mapToResponse(r)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"maintenance_records": responses,
+		"total":               len(responses),
+	})
+}
+
+func (h *MaintenanceHandler) Get(c *gin.Context) {
+	recordID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid maintenance record id"})
+		return
+	}
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	record, err := h.maintenanceService.GetRecord(c.Request.Context(), organizationID, recordID)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.mapToResponse(record))
+}
+
+func (h *MaintenanceHandler) ApprovalHistory(c *gin.Context) {
+	recordID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid maintenance record id"})
+		return
+	}
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	history, err := h.maintenanceService.ApprovalHistory(c.Request.Context(), organizationID, recordID)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	responses := make([]ApprovalAuditResponse, len(history))
+	for i, a := range history {
+		responses[i] = ApprovalAuditResponse{
+			ID:               a.ID,
+			ApproverID:       a.ApproverID,
+			Action:           a.Action,
+			Comments:         a.Comments,
+			ApprovalSequence: a.ApprovalSequence,
+			AuthFactor:       a.AuthFactor,
+			CreatedAt:        a.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"approvals": responses})
+}
+
+func (h *MaintenanceHandler) Create(c *gin.Context) {
+	var req CreateMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	equipmentID, err := uuid.Parse(req.EquipmentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid equipment_id"})
+		return
+	}
+
+	record := &model.MaintenanceRecord{
+		EquipmentID:       equipmentID,
+		MaintenanceTypeID: req.MaintenanceTypeID,
+		Notes:             req.Notes,
+		GPSLatitude:       req.GPSLatitude,
+		GPSLongitude:      req.GPSLongitude,
+	}
+
+	created, err := h.maintenanceService.CreateRecord(c.Request.Context(), organizationID, userID, record, req.MeterReadings)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, h.mapToResponse(created))
+}
+
+func (h *MaintenanceHandler) Submit(c *gin.Context) {
+	recordID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid maintenance record id"})
+		return
+	}
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	record, err := h.maintenanceService.SubmitRecord(c.Request.Context(), organizationID, recordID, userID)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.mapToResponse(record))
+}
+
+// Approve requires a step-up signing token (see middleware.RequireSigningToken).
+func (h *MaintenanceHandler) Approve(c *gin.Context) {
+	h.decide(c, h.maintenanceService.ApproveRecord)
+}
+
+// Reject requires a step-up signing token (see middleware.RequireSigningToken).
+func (h *MaintenanceHandler) Reject(c *gin.Context) {
+	h.decide(c, h.maintenanceService.RejectRecord)
+}
+
+// Confirm records the blockchain transaction of an approved record, reported by the
+// confirmation worker with its API key. It rolls the matching schedule forward.
+func (h *MaintenanceHandler) Confirm(c *gin.Context) {
+	recordID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid maintenance record id"})
+		return
+	}
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req ConfirmMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	record, err := h.maintenanceService.ConfirmRecord(c.Request.Context(), organizationID, recordID, userID, req.TransactionSignature)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.mapToResponse(record))
+}
+
+type decisionFunc func(ctx context.Context, organizationID, recordID, approverID uuid.UUID, comments *string, signature service.Signature) (*model.MaintenanceRecord, error)
+
+func (h *MaintenanceHandler) decide(c *gin.Context, decide decisionFunc) {
+	recordID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid maintenance record id"})
+		return
+	}
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	// The body is optional; approvals often carry no comments
+	var req ApprovalDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	value, _ := c.Get("signing_token_id")
+	tokenID, _ := value.(uuid.UUID)
+	signature := service.Signature{
+		Factor:   c.GetString("signing_factor"),
+		TokenID:  tokenID,
+		Metadata: service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()},
+	}
+
+	record, err := decide(c.Request.Context(), organizationID, recordID, userID, req.Comments, signature)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.mapToResponse(record))
+}
+
+func (h *MaintenanceHandler) writeError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidMeterReading) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrMeterReadingConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err {
+	case service.ErrMaintenanceNotFound, service.ErrEquipmentNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrInvalidMaintenanceType, service.ErrInvalidGPSCoordinates, service.ErrNotesRequired,
+		service.ErrRejectionReasonRequired, service.ErrEquipmentNotMaintainable, service.ErrInvalidTransactionSignature:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrInvalidMaintenanceStatus:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case service.ErrSigningTokenUsed:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "step_up": "/api/auth/step-up"})
+	case service.ErrSelfApproval, service.ErrNotMaintenanceTechnician, service.ErrTechnicianLicenseExpired:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
+
+func (h *MaintenanceHandler) mapToResponse(r *model.MaintenanceRecord) MaintenanceResponse {
+	resp := MaintenanceResponse{
+		ID:                r.ID,
+		EquipmentID:       r.EquipmentID,
+		MaintenanceTypeID: r.MaintenanceTypeID,
+		StatusID:          r.StatusID,
+		TechnicianID:      r.TechnicianID,
+		SupervisorID:      r.SupervisorID,
+		Notes:             r.Notes,
+		GPSLatitude:       r.GPSLatitude,
+		GPSLongitude:      r.GPSLongitude,
+		SolanaSignature:   r.SolanaSignature,
+		CreatedAt:         r.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:         r.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+
+	resp.SubmittedAt = formatOptionalTime(r.SubmittedAt)
+	resp.ApprovedAt = formatOptionalTime(r.ApprovedAt)
+	resp.RejectedAt = formatOptionalTime(r.RejectedAt)
+	resp.ConfirmedAt = formatOptionalTime(r.ConfirmedAt)
+	return resp
+}
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format("2006-01-02T15:04:05Z")
+	return &formatted
+}
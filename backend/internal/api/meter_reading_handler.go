@@ -0,0 +1,138 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type MeterReadingHandler struct {
+	meterService *service.MeterService
+}
+
+func NewMeterReadingHandler(meterService *service.MeterService) *MeterReadingHandler {
+	return &MeterReadingHandler{meterService: meterService}
+}
+
+type MeterReadingBatchRequest struct {
+	Readings []service.MeterReadingInput `json:"readings" binding:"required"`
+}
+
+// List returns the equipment's readings, newest first; ?meter_type= filters and ?limit=
+// caps the result (default 100).
+func (h *MeterReadingHandler) List(c *gin.Context) {
+	equipmentID, ok := equipmentIDFromParam(c)
+	if !ok {
+		return
+	}
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	readings, err := h.meterService.ListReadings(c.Request.Context(), organizationID, equipmentID, c.Query("meter_type"), limit)
+	if err != nil {
+		writeMeterReadingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"readings": readings, "total": len(readings)})
+}
+
+// Record stores a single reading of the equipment in the path.
+func (h *MeterReadingHandler) Record(c *gin.Context) {
+	equipmentID, ok := equipmentIDFromParam(c)
+	if !ok {
+		return
+	}
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req service.MeterReadingInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.EquipmentID = equipmentID
+
+	readings, err := h.meterService.RecordReadings(c.Request.Context(), organizationID, userID, []service.MeterReadingInput{req})
+	if err != nil {
+		writeMeterReadingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, readings[0])
+}
+
+// RecordBatch stores readings of several equipment at once, all or none.
+func (h *MeterReadingHandler) RecordBatch(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req MeterReadingBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	readings, err := h.meterService.RecordReadings(c.Request.Context(), organizationID, userID, req.Readings)
+	if err != nil {
+		writeMeterReadingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"readings": readings, "total": len(readings)})
+}
+
+func equipmentIDFromParam(c *gin.Context) (uuid.UUID, bool) {
+	equipmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid equipment id"})
+		return uuid.Nil, false
+	}
+	return equipmentID, true
+}
+
+func writeMeterReadingError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidMeterReading) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrMeterReadingConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err {
+	case service.ErrEquipmentNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	mfaService  *service.MFAService
+	authService *service.AuthService
+}
+
+func NewMFAHandler(mfaService *service.MFAService, authService *service.AuthService) *MFAHandler {
+	return &MFAHandler{mfaService: mfaService, authService: authService}
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	Token         string   `json:"token,omitempty"`
+}
+
+func (h *MFAHandler) Status(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.mfaService.Status(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Enroll starts TOTP enrollment and returns the secret and otpauth:// provisioning URI.
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.mfaService.BeginEnrollment(c.Request.Context(), userID)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmEnrollment activates MFA and returns the recovery codes. When called with an
+// MFA-pending token from login it also completes that login and returns an access token.
+func (h *MFAHandler) ConfirmEnrollment(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+
+	var response RecoveryCodesResponse
+	var err error
+	if c.GetString("token_scope") == service.TokenScopeMFA {
+		response.RecoveryCodes, response.Token, err = h.authService.CompleteMFAEnrollment(c.Request.Context(), userID, req.Code, meta)
+	} else {
+		response.RecoveryCodes, err = h.mfaService.ConfirmEnrollment(c.Request.Context(), userID, req.Code, meta)
+	}
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes; requires a current TOTP code.
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	codes, err := h.mfaService.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code, meta)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *MFAHandler) Disable(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	if err := h.mfaService.Disable(c.Request.Context(), userID, req.Code, meta); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *MFAHandler) writeError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrInvalidMFACode:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrMFAAlreadyEnabled, service.ErrMFANotEnrolled:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case service.ErrMFARequired, service.ErrOrganizationSuspended:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case service.ErrAccountLocked:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "account temporarily locked"})
+	case service.ErrUserNotFound, service.ErrInvalidMFAToken, service.ErrOrganizationNotFound:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCHandler struct {
+	oidcService         *service.OIDCService
+	frontendCallbackURL string
+}
+
+func NewOIDCHandler(oidcService *service.OIDCService, frontendCallbackURL string) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService:         oidcService,
+		frontendCallbackURL: frontendCallbackURL,
+	}
+}
+
+// Login redirects the browser to the organization's identity provider.
+func (h *OIDCHandler) Login(c *gin.Context) {
+	authURL, err := h.oidcService.BeginLogin(c.Request.Context(), c.Param("organization_code"))
+	if err != nil {
+		switch err {
+		case service.ErrOIDCNotConfigured:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case service.ErrOrganizationSuspended:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadGateway, gin.H{"error": "identity provider unavailable"})
+		}
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback completes the login and hands the access token to the frontend in the URL
+// fragment, which is never sent to servers or written to access logs.
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	if providerError := c.Query("error"); providerError != "" {
+		h.redirectToFrontend(c, url.Values{"error": {"sso_denied"}})
+		return
+	}
+	state := c.Query("state")
+	code := c.Query("code")
+	if state == "" || code == "" {
+		h.redirectToFrontend(c, url.Values{"error": {"sso_invalid_request"}})
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	_, token, err := h.oidcService.CompleteLogin(c.Request.Context(), state, code, meta)
+	if err != nil {
+		reason := "sso_failed"
+		switch err {
+		case service.ErrOIDCInvalidState:
+			reason = "sso_expired"
+		case service.ErrOIDCEmailNotAllowed:
+			reason = "sso_email_not_allowed"
+		case service.ErrOIDCNotConfigured:
+			reason = "sso_not_configured"
+		case service.ErrAccountLocked:
+			reason = "account_locked"
+		case service.ErrAccountDisabled:
+			reason = "account_disabled"
+		case service.ErrOrganizationSuspended:
+			reason = "organization_suspended"
+		}
+		h.redirectToFrontend(c, url.Values{"error": {reason}})
+		return
+	}
+
+	h.redirectToFrontend(c, url.Values{"token": {token}})
+}
+
+func (h *OIDCHandler) GetProvider(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	provider, err := h.oidcService.GetProvider(c.Request.Context(), organizationID)
+	if err != nil {
+		switch err {
+		case service.ErrOIDCNotConfigured:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, provider)
+}
+
+func (h *OIDCHandler) UpdateProvider(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	var req service.OIDCProviderConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	provider, err := h.oidcService.ConfigureProvider(c.Request.Context(), organizationID, req, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidOIDCProvider) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, provider)
+}
+
+func (h *OIDCHandler) DeleteProvider(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	if err := h.oidcService.DeleteProvider(c.Request.Context(), organizationID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *OIDCHandler) redirectToFrontend(c *gin.Context, fragment url.Values) {
+	c.Redirect(http.StatusFound, h.frontendCallbackURL+"#"+fragment.Encode())
+}
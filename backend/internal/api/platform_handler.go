@@ -0,0 +1,213 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PlatformHandler serves /api/platform, which only platform admins may use.
+type PlatformHandler struct {
+	platformService *service.PlatformService
+}
+
+func NewPlatformHandler(platformService *service.PlatformService) *PlatformHandler {
+	return &PlatformHandler{platformService: platformService}
+}
+
+type CreateOrganizationRequest struct {
+	Code        string `json:"code" binding:"required"`
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	AdminEmail  string `json:"admin_email" binding:"required,email"`
+}
+
+type CreateOrganizationResponse struct {
+	Organization    *service.OrganizationView `json:"organization"`
+	AdminInvitation *service.InvitationView   `json:"admin_invitation"`
+}
+
+type SuspendOrganizationRequest struct {
+	Reason string `json:"reason"`
+}
+
+type InviteAdminRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ListOrganizations returns all organizations, optionally filtered by ?status=.
+func (h *PlatformHandler) ListOrganizations(c *gin.Context) {
+	organizations, err := h.platformService.ListOrganizations(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"organizations": organizations})
+}
+
+func (h *PlatformHandler) GetOrganization(c *gin.Context) {
+	organizationID, ok := organizationIDFromParam(c)
+	if !ok {
+		return
+	}
+
+	organization, err := h.platformService.GetOrganization(c.Request.Context(), organizationID)
+	if err != nil {
+		writePlatformError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, organization)
+}
+
+// CreateOrganization creates an organization and emails its first admin an invitation.
+func (h *PlatformHandler) CreateOrganization(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	organization, invitation, err := h.platformService.CreateOrganization(c.Request.Context(), userID, service.OrganizationInput{
+		Code:        req.Code,
+		Name:        req.Name,
+		Description: req.Description,
+		AdminEmail:  req.AdminEmail,
+	}, meta)
+	if err != nil {
+		writePlatformError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateOrganizationResponse{Organization: organization, AdminInvitation: invitation})
+}
+
+// InviteAdmin invites an additional admin, e.g. after the first invitation expired.
+func (h *PlatformHandler) InviteAdmin(c *gin.Context) {
+	organizationID, ok := organizationIDFromParam(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req InviteAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	invitation, err := h.platformService.InviteAdmin(c.Request.Context(), userID, organizationID, req.Email, meta)
+	if err != nil {
+		writePlatformError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// SuspendOrganization takes effect immediately: the organization's sessions and API keys
+// are rejected from their next request on.
+func (h *PlatformHandler) SuspendOrganization(c *gin.Context) {
+	organizationID, ok := organizationIDFromParam(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req SuspendOrganizationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	organization, err := h.platformService.Suspend(c.Request.Context(), userID, organizationID, req.Reason, meta)
+	if err != nil {
+		writePlatformError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, organization)
+}
+
+func (h *PlatformHandler) ReactivateOrganization(c *gin.Context) {
+	organizationID, ok := organizationIDFromParam(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	organization, err := h.platformService.Reactivate(c.Request.Context(), userID, organizationID, meta)
+	if err != nil {
+		writePlatformError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, organization)
+}
+
+// DeleteOrganization soft-deletes the organization; its data is kept.
+func (h *PlatformHandler) DeleteOrganization(c *gin.Context) {
+	organizationID, ok := organizationIDFromParam(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	if err := h.platformService.Delete(c.Request.Context(), userID, organizationID, meta); err != nil {
+		writePlatformError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func organizationIDFromParam(c *gin.Context) (uuid.UUID, bool) {
+	organizationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+		return uuid.Nil, false
+	}
+	return organizationID, true
+}
+
+func writePlatformError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrOrganizationNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrInvalidOrganization, service.ErrInvalidInvitation, service.ErrOwnOrganization:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrOrganizationCodeExists, service.ErrInvalidOrganizationState, service.ErrEmailExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case service.ErrPlatformAdminRequired:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
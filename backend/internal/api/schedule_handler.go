@@ -0,0 +1,202 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ScheduleHandler struct {
+	scheduleService *service.ScheduleService
+}
+
+func NewScheduleHandler(scheduleService *service.ScheduleService) *ScheduleHandler {
+	return &ScheduleHandler{scheduleService: scheduleService}
+}
+
+// List returns the organization's schedules; ?equipment_id= and ?maintenance_type_id= filter.
+func (h *ScheduleHandler) List(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	filters := map[string]interface{}{}
+	if value := c.Query("equipment_id"); value != "" {
+		if _, err := uuid.Parse(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid equipment_id"})
+			return
+		}
+		filters["equipment_id"] = value
+	}
+	if value := c.Query("maintenance_type_id"); value != "" {
+		if _, err := strconv.ParseInt(value, 10, 16); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid maintenance_type_id"})
+			return
+		}
+		filters["maintenance_type_id"] = value
+	}
+
+	schedules, err := h.scheduleService.ListSchedules(c.Request.Context(), organizationID, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"schedules": schedules, "total": len(schedules)})
+}
+
+func (h *ScheduleHandler) Get(c *gin.Context) {
+	scheduleID, ok := scheduleIDFromParam(c)
+	if !ok {
+		return
+	}
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.scheduleService.GetSchedule(c.Request.Context(), organizationID, scheduleID)
+	if err != nil {
+		writeScheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+func (h *ScheduleHandler) Create(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req service.CreateScheduleInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	schedule, err := h.scheduleService.CreateSchedule(c.Request.Context(), organizationID, userID, req, meta)
+	if err != nil {
+		writeScheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+func (h *ScheduleHandler) Update(c *gin.Context) {
+	scheduleID, ok := scheduleIDFromParam(c)
+	if !ok {
+		return
+	}
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req service.UpdateScheduleInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	schedule, err := h.scheduleService.UpdateSchedule(c.Request.Context(), organizationID, userID, scheduleID, req, meta)
+	if err != nil {
+		writeScheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+func (h *ScheduleHandler) Delete(c *gin.Context) {
+	scheduleID, ok := scheduleIDFromParam(c)
+	if !ok {
+		return
+	}
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	if err := h.scheduleService.DeleteSchedule(c.Request.Context(), organizationID, userID, scheduleID, meta); err != nil {
+		writeScheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// Due lists schedules that are overdue or due within ?days= (default 30).
+func (h *ScheduleHandler) Due(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	days := 0
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrInvalidDueWindow.Error()})
+			return
+		}
+		days = parsed
+	}
+
+	due, err := h.scheduleService.DueSchedules(c.Request.Context(), organizationID, days)
+	if err != nil {
+		writeScheduleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"schedules": due, "total": len(due)})
+}
+
+func scheduleIDFromParam(c *gin.Context) (uuid.UUID, bool) {
+	scheduleID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule id"})
+		return uuid.Nil, false
+	}
+	return scheduleID, true
+}
+
+func writeScheduleError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidSchedule) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err {
+	case service.ErrScheduleNotFound, service.ErrEquipmentNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrInvalidMaintenanceType, service.ErrInvalidDueWindow:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrScheduleExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
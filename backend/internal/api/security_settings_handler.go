@@ -0,0 +1,179 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type SecuritySettingsHandler struct {
+	passwordPolicyService *service.PasswordPolicyService
+	mfaService            *service.MFAService
+	onboardingService     *service.OnboardingService
+}
+
+func NewSecuritySettingsHandler(passwordPolicyService *service.PasswordPolicyService, mfaService *service.MFAService, onboardingService *service.OnboardingService) *SecuritySettingsHandler {
+	return &SecuritySettingsHandler{
+		passwordPolicyService: passwordPolicyService,
+		mfaService:            mfaService,
+		onboardingService:     onboardingService,
+	}
+}
+
+type PasswordPolicyRequest struct {
+	MinLength        *int  `json:"min_length" binding:"required"`
+	RequireUppercase *bool `json:"require_uppercase" binding:"required"`
+	RequireLowercase *bool `json:"require_lowercase" binding:"required"`
+	RequireDigit     *bool `json:"require_digit" binding:"required"`
+	RequireSymbol    *bool `json:"require_symbol" binding:"required"`
+	HistoryCount     *int  `json:"history_count" binding:"required"`
+	RejectCommon     *bool `json:"reject_common" binding:"required"`
+}
+
+type MFAPolicyRequest struct {
+	RequireForApprovers *bool `json:"require_for_approvers" binding:"required"`
+}
+
+type RegistrationPolicyRequest struct {
+	Mode           string   `json:"mode" binding:"required"`
+	AllowedDomains []string `json:"allowed_domains"`
+}
+
+func (h *SecuritySettingsHandler) GetPasswordPolicy(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	policy, err := h.passwordPolicyService.GetPolicy(c.Request.Context(), organizationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+func (h *SecuritySettingsHandler) UpdatePasswordPolicy(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	var req PasswordPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy := service.PasswordPolicy{
+		MinLength:        *req.MinLength,
+		RequireUppercase: *req.RequireUppercase,
+		RequireLowercase: *req.RequireLowercase,
+		RequireDigit:     *req.RequireDigit,
+		RequireSymbol:    *req.RequireSymbol,
+		HistoryCount:     *req.HistoryCount,
+		RejectCommon:     *req.RejectCommon,
+	}
+
+	updated, err := h.passwordPolicyService.UpdatePolicy(c.Request.Context(), organizationID, policy, userID)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidPasswordPolicy:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *SecuritySettingsHandler) GetMFAPolicy(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	policy, err := h.mfaService.GetPolicy(c.Request.Context(), organizationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+func (h *SecuritySettingsHandler) UpdateMFAPolicy(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	var req MFAPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := h.mfaService.UpdatePolicy(c.Request.Context(), organizationID, service.MFAPolicy{RequireForApprovers: *req.RequireForApprovers}, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *SecuritySettingsHandler) GetRegistrationPolicy(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	policy, err := h.onboardingService.GetRegistrationPolicy(c.Request.Context(), organizationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+func (h *SecuritySettingsHandler) UpdateRegistrationPolicy(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	var req RegistrationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy := service.RegistrationPolicy{Mode: req.Mode, AllowedDomains: req.AllowedDomains}
+	updated, err := h.onboardingService.UpdateRegistrationPolicy(c.Request.Context(), organizationID, policy, userID)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidRegistrationPolicy:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TechnicianHandler struct {
+	technicianService *service.TechnicianService
+}
+
+func NewTechnicianHandler(technicianService *service.TechnicianService) *TechnicianHandler {
+	return &TechnicianHandler{technicianService: technicianService}
+}
+
+type CreateTechnicianProfileRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+	service.TechnicianProfileInput
+}
+
+// List returns the organization's technician profiles; ?available=true|false filters.
+func (h *TechnicianHandler) List(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var available *bool
+	if value := c.Query("available"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid available"})
+			return
+		}
+		available = &parsed
+	}
+
+	profiles, err := h.technicianService.ListProfiles(c.Request.Context(), organizationID, available)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"technicians": profiles, "total": len(profiles)})
+}
+
+func (h *TechnicianHandler) Get(c *gin.Context) {
+	userID, ok := technicianUserIDFromParam(c)
+	if !ok {
+		return
+	}
+	h.get(c, userID)
+}
+
+// GetOwn returns the caller's own profile.
+func (h *TechnicianHandler) GetOwn(c *gin.Context) {
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+	h.get(c, userID)
+}
+
+func (h *TechnicianHandler) get(c *gin.Context, userID uuid.UUID) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	profile, err := h.technicianService.GetProfile(c.Request.Context(), organizationID, userID)
+	if err != nil {
+		writeTechnicianError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *TechnicianHandler) Create(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	actorID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req CreateTechnicianProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	profile, err := h.technicianService.CreateProfile(c.Request.Context(), organizationID, actorID, req.UserID, req.TechnicianProfileInput, meta)
+	if err != nil {
+		writeTechnicianError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, profile)
+}
+
+// Update applies a partial update; omitted fields are unchanged.
+func (h *TechnicianHandler) Update(c *gin.Context) {
+	userID, ok := technicianUserIDFromParam(c)
+	if !ok {
+		return
+	}
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	actorID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req service.TechnicianProfileInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	profile, err := h.technicianService.UpdateProfile(c.Request.Context(), organizationID, actorID, userID, req, meta)
+	if err != nil {
+		writeTechnicianError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *TechnicianHandler) Delete(c *gin.Context) {
+	userID, ok := technicianUserIDFromParam(c)
+	if !ok {
+		return
+	}
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+	actorID, ok := userIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	if err := h.technicianService.DeleteProfile(c.Request.Context(), organizationID, actorID, userID, meta); err != nil {
+		writeTechnicianError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// Search finds technicians with ?certification= and a valid license, available first.
+func (h *TechnicianHandler) Search(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	technicians, err := h.technicianService.SearchByCertification(c.Request.Context(), organizationID, c.Query("certification"))
+	if err != nil {
+		writeTechnicianError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"technicians": technicians, "total": len(technicians)})
+}
+
+// ExpiringLicenses lists licenses expiring within ?days= (default 30).
+func (h *TechnicianHandler) ExpiringLicenses(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	days := 0
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrInvalidExpiryWindow.Error()})
+			return
+		}
+		days = parsed
+	}
+
+	licenses, err := h.technicianService.ExpiringLicenses(c.Request.Context(), organizationID, days)
+	if err != nil {
+		writeTechnicianError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"licenses": licenses, "total": len(licenses)})
+}
+
+func technicianUserIDFromParam(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func writeTechnicianError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidTechnicianProfile) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err {
+	case service.ErrTechnicianProfileNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrInvalidTechnicianUser, service.ErrCertificationRequired, service.ErrInvalidExpiryWindow:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrTechnicianProfileExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
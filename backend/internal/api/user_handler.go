@@ -0,0 +1,222 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type UserHandler struct {
+	userManagementService *service.UserManagementService
+}
+
+func NewUserHandler(userManagementService *service.UserManagementService) *UserHandler {
+	return &UserHandler{userManagementService: userManagementService}
+}
+
+type ChangeRoleRequest struct {
+	RoleID int16 `json:"role_id" binding:"required"`
+}
+
+// userActor identifies the organization, user and role making a user management request.
+type userActor struct {
+	organizationID uuid.UUID
+	userID         uuid.UUID
+	roleID         int16
+}
+
+// List returns the organization's users, filtered by ?status=, ?role_id= and ?search=
+// (email substring) and paginated with ?page= and ?page_size=.
+func (h *UserHandler) List(c *gin.Context) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	query := service.UserListQuery{
+		Status: c.Query("status"),
+		Search: c.Query("search"),
+	}
+	for name, target := range map[string]*int{"page": &query.Page, "page_size": &query.PageSize} {
+		if value := c.Query(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+				return
+			}
+			*target = parsed
+		}
+	}
+	if value := c.Query("role_id"); value != "" {
+		roleID, err := strconv.ParseInt(value, 10, 16)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role_id"})
+			return
+		}
+		query.RoleID = int16(roleID)
+	}
+
+	page, err := h.userManagementService.ListUsers(c.Request.Context(), organizationID, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *UserHandler) Get(c *gin.Context) {
+	targetUserID, ok := targetUserIDFromParam(c)
+	if !ok {
+		return
+	}
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userManagementService.GetUser(c.Request.Context(), organizationID, targetUserID)
+	if err != nil {
+		writeUserManagementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *UserHandler) ChangeRole(c *gin.Context) {
+	targetUserID, ok := targetUserIDFromParam(c)
+	if !ok {
+		return
+	}
+	actor, ok := userActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	var req ChangeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	user, err := h.userManagementService.ChangeRole(c.Request.Context(), actor.organizationID, actor.userID, actor.roleID, targetUserID, req.RoleID, meta)
+	if err != nil {
+		writeUserManagementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *UserHandler) Deactivate(c *gin.Context) {
+	h.setActive(c, false)
+}
+
+func (h *UserHandler) Reactivate(c *gin.Context) {
+	h.setActive(c, true)
+}
+
+func (h *UserHandler) setActive(c *gin.Context, active bool) {
+	targetUserID, ok := targetUserIDFromParam(c)
+	if !ok {
+		return
+	}
+	actor, ok := userActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	user, err := h.userManagementService.SetActive(c.Request.Context(), actor.organizationID, actor.userID, actor.roleID, targetUserID, active, meta)
+	if err != nil {
+		writeUserManagementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *UserHandler) Unlock(c *gin.Context) {
+	targetUserID, ok := targetUserIDFromParam(c)
+	if !ok {
+		return
+	}
+	actor, ok := userActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	if err := h.userManagementService.Unlock(c.Request.Context(), actor.organizationID, actor.userID, actor.roleID, targetUserID, meta); err != nil {
+		writeUserManagementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// ForcePasswordReset blocks the user's password login until they set a new password with
+// the link emailed to them.
+func (h *UserHandler) ForcePasswordReset(c *gin.Context) {
+	targetUserID, ok := targetUserIDFromParam(c)
+	if !ok {
+		return
+	}
+	actor, ok := userActorFromContext(c)
+	if !ok {
+		return
+	}
+
+	meta := service.RequestMetadata{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	if err := h.userManagementService.ForcePasswordReset(c.Request.Context(), actor.organizationID, actor.userID, actor.roleID, targetUserID, meta); err != nil {
+		writeUserManagementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "password reset email queued"})
+}
+
+func targetUserIDFromParam(c *gin.Context) (uuid.UUID, bool) {
+	targetUserID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return uuid.Nil, false
+	}
+	return targetUserID, true
+}
+
+func userActorFromContext(c *gin.Context) (userActor, bool) {
+	organizationID, ok := organizationIDFromContext(c)
+	if !ok {
+		return userActor{}, false
+	}
+	userID, ok := userIDFromContext(c)
+	if !ok {
+		return userActor{}, false
+	}
+	roleID, ok := roleIDFromContext(c)
+	if !ok {
+		return userActor{}, false
+	}
+	return userActor{organizationID: organizationID, userID: userID, roleID: roleID}, true
+}
+
+func writeUserManagementError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrInvalidRole, service.ErrCannotDeactivateSelf:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrUserNotManageable, service.ErrRoleNotAssignable:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case service.ErrLastAdmin, service.ErrUserNotInactive:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
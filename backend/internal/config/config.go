@@ -7,6 +7,8 @@ import (
 	"github.com/spf13/viper"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,6 +18,75 @@ type Config struct {
 	Port        string
 	Environment string
 	LogLevel    string
+
+	// Path of the asymmetric JWT keyring managed by cmd/keyctl. Required in production;
+	// without it tokens are signed with HS256 JWT_SECRET.
+	JWTKeysFile string
+
+	// Account lockout: LockoutDurations[n] applies to the (n+1)th consecutive lockout,
+	// the last entry repeats.
+	LockoutThreshold         int
+	LockoutDurations         []time.Duration
+	LockoutObservationWindow time.Duration
+
+	// How long role_lookup permissions are cached in memory before being reloaded.
+	PermissionCacheTTL time.Duration
+
+	// Path of the master keyring managed by cmd/enckeyctl that encrypts secrets stored in
+	// the database (TOTP seeds, OIDC client secrets, integration credentials).
+	EncryptionKeysFile string
+	// Base64-encoded 32-byte AES master key, used as key version 1 when there is no
+	// keyring file.
+	EncryptionKey string
+	// Issuer label shown in authenticator apps.
+	MFAIssuer string
+
+	// Callback registered with organizations' OIDC providers.
+	OIDCRedirectURL string
+	// Frontend page that receives the session token after SSO (as #token=...).
+	OIDCFrontendCallbackURL string
+
+	// Frontend page that accepts invitations; the signed token is appended as ?token=...
+	InvitationAcceptURL string
+	// Frontend page for forced password resets; the reset token is appended as ?token=...
+	PasswordResetURL string
+
+	// License expiration alerts fire this many days before expiry, once per threshold.
+	LicenseAlertThresholds []int
+	// How often the license expiration alert job runs.
+	LicenseAlertInterval time.Duration
+
+	// Preventive maintenance due within this many days gets a due soon alert.
+	MaintenanceDueSoonDays int
+	// Overdue maintenance is escalated to admins after this many days (0 disables).
+	MaintenanceEscalationDays int
+	// How often the maintenance alert job runs.
+	MaintenanceAlertInterval time.Duration
+
+	// Public URL of the calendar feed endpoint; /<token>/maintenance.ics is appended.
+	CalendarFeedURL string
+	// Frontend base URL that calendar events link to (/equipment/<id>, /maintenance/<id>).
+	CalendarLinkBaseURL string
+
+	// How often the webhook delivery job posts due deliveries from the outbox.
+	WebhookDeliveryInterval time.Duration
+	// Attempts per webhook delivery before it is marked failed.
+	WebhookMaxAttempts int
+
+	// How often the Procore sync job continues the sync of procore integrations.
+	ProcoreSyncInterval time.Duration
+
+	// How long domain events are kept for event stream clients to resume.
+	EventStreamRetention time.Duration
+
+	// Lets webhooks and the Procore sync call loopback and private addresses, e.g. a local
+	// mock server. Refused in production.
+	OutboundAllowPrivateNetworks bool
+}
+
+// IsProduction reports whether the server runs with production safeguards.
+func (c *Config) IsProduction() bool {
+	return c.Environment == "production" || c.Environment == "prod"
 }
 
 func LoadConfig() (*Config, error) {
@@ -36,13 +107,67 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("ENVIRONMENT", "development")
 	viper.SetDefault("LOG_LEVEL", "debug")
 	viper.SetDefault("JWT_SECRET", "dev-secret-key")
+	viper.SetDefault("LOCKOUT_THRESHOLD", 5)
+	viper.SetDefault("LOCKOUT_DURATIONS", "5m,15m,1h,24h")
+	viper.SetDefault("LOCKOUT_OBSERVATION_WINDOW", "15m")
+	viper.SetDefault("PERMISSION_CACHE_TTL", "5m")
+	viper.SetDefault("MFA_ISSUER", "EquipChain")
+	viper.SetDefault("OIDC_REDIRECT_URL", "http://localhost:8080/api/auth/oidc/callback")
+	viper.SetDefault("OIDC_FRONTEND_CALLBACK_URL", "http://localhost:5173/auth/sso-callback")
+	viper.SetDefault("INVITATION_ACCEPT_URL", "http://localhost:5173/accept-invitation")
+	viper.SetDefault("PASSWORD_RESET_URL", "http://localhost:5173/reset-password")
+	viper.SetDefault("LICENSE_ALERT_THRESHOLDS", "60,30,7")
+	viper.SetDefault("LICENSE_ALERT_INTERVAL", "1h")
+	viper.SetDefault("MAINTENANCE_DUE_SOON_DAYS", 30)
+	viper.SetDefault("MAINTENANCE_ESCALATION_DAYS", 7)
+	viper.SetDefault("MAINTENANCE_ALERT_INTERVAL", "1h")
+	viper.SetDefault("CALENDAR_FEED_URL", "http://localhost:8080/api/calendar")
+	viper.SetDefault("CALENDAR_LINK_BASE_URL", "http://localhost:5173")
+	viper.SetDefault("WEBHOOK_DELIVERY_INTERVAL", "15s")
+	viper.SetDefault("WEBHOOK_MAX_ATTEMPTS", 8)
+	viper.SetDefault("PROCORE_SYNC_INTERVAL", "15m")
+	viper.SetDefault("EVENT_STREAM_RETENTION", "24h")
+	viper.SetDefault("OUTBOUND_ALLOW_PRIVATE_NETWORKS", false)
 
 	// Bind environment variables to Viper keys
 	viper.BindEnv("DATABASE_URL")
 	viper.BindEnv("JWT_SECRET")
+	viper.BindEnv("JWT_KEYS_FILE")
 	viper.BindEnv("PORT")
 	viper.BindEnv("ENVIRONMENT")
 	viper.BindEnv("LOG_LEVEL")
+	viper.BindEnv("LOCKOUT_THRESHOLD")
+	viper.BindEnv("LOCKOUT_DURATIONS")
+	viper.BindEnv("LOCKOUT_OBSERVATION_WINDOW")
+	viper.BindEnv("PERMISSION_CACHE_TTL")
+	viper.BindEnv("ENCRYPTION_KEYS_FILE")
+	viper.BindEnv("ENCRYPTION_KEY")
+	viper.BindEnv("MFA_ISSUER")
+	viper.BindEnv("OIDC_REDIRECT_URL")
+	viper.BindEnv("OIDC_FRONTEND_CALLBACK_URL")
+	viper.BindEnv("INVITATION_ACCEPT_URL")
+	viper.BindEnv("PASSWORD_RESET_URL")
+	viper.BindEnv("LICENSE_ALERT_THRESHOLDS")
+	viper.BindEnv("LICENSE_ALERT_INTERVAL")
+	viper.BindEnv("MAINTENANCE_DUE_SOON_DAYS")
+	viper.BindEnv("MAINTENANCE_ESCALATION_DAYS")
+	viper.BindEnv("MAINTENANCE_ALERT_INTERVAL")
+	viper.BindEnv("CALENDAR_FEED_URL")
+	viper.BindEnv("CALENDAR_LINK_BASE_URL")
+	viper.BindEnv("WEBHOOK_DELIVERY_INTERVAL")
+	viper.BindEnv("WEBHOOK_MAX_ATTEMPTS")
+	viper.BindEnv("PROCORE_SYNC_INTERVAL")
+	viper.BindEnv("EVENT_STREAM_RETENTION")
+	viper.BindEnv("OUTBOUND_ALLOW_PRIVATE_NETWORKS")
+
+	lockoutDurations, err := parseDurationList(viper.GetString("LOCKOUT_DURATIONS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOCKOUT_DURATIONS: %w", err)
+	}
+	licenseAlertThresholds, err := parseDayList(viper.GetString("LICENSE_ALERT_THRESHOLDS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LICENSE_ALERT_THRESHOLDS: %w", err)
+	}
 
 	// Create config struct
 	cfg := &Config{
@@ -51,6 +176,40 @@ func LoadConfig() (*Config, error) {
 		Port:        viper.GetString("PORT"),
 		Environment: viper.GetString("ENVIRONMENT"),
 		LogLevel:    viper.GetString("LOG_LEVEL"),
+		JWTKeysFile: viper.GetString("JWT_KEYS_FILE"),
+
+		LockoutThreshold:         viper.GetInt("LOCKOUT_THRESHOLD"),
+		LockoutDurations:         lockoutDurations,
+		LockoutObservationWindow: viper.GetDuration("LOCKOUT_OBSERVATION_WINDOW"),
+
+		PermissionCacheTTL: viper.GetDuration("PERMISSION_CACHE_TTL"),
+
+		EncryptionKeysFile: viper.GetString("ENCRYPTION_KEYS_FILE"),
+		EncryptionKey:      viper.GetString("ENCRYPTION_KEY"),
+		MFAIssuer:          viper.GetString("MFA_ISSUER"),
+
+		OIDCRedirectURL:         viper.GetString("OIDC_REDIRECT_URL"),
+		OIDCFrontendCallbackURL: viper.GetString("OIDC_FRONTEND_CALLBACK_URL"),
+
+		InvitationAcceptURL: viper.GetString("INVITATION_ACCEPT_URL"),
+		PasswordResetURL:    viper.GetString("PASSWORD_RESET_URL"),
+
+		LicenseAlertThresholds: licenseAlertThresholds,
+		LicenseAlertInterval:   viper.GetDuration("LICENSE_ALERT_INTERVAL"),
+
+		MaintenanceDueSoonDays:    viper.GetInt("MAINTENANCE_DUE_SOON_DAYS"),
+		MaintenanceEscalationDays: viper.GetInt("MAINTENANCE_ESCALATION_DAYS"),
+		MaintenanceAlertInterval:  viper.GetDuration("MAINTENANCE_ALERT_INTERVAL"),
+
+		CalendarFeedURL:     viper.GetString("CALENDAR_FEED_URL"),
+		CalendarLinkBaseURL: viper.GetString("CALENDAR_LINK_BASE_URL"),
+
+		WebhookDeliveryInterval: viper.GetDuration("WEBHOOK_DELIVERY_INTERVAL"),
+		WebhookMaxAttempts:      viper.GetInt("WEBHOOK_MAX_ATTEMPTS"),
+		ProcoreSyncInterval:     viper.GetDuration("PROCORE_SYNC_INTERVAL"),
+		EventStreamRetention:    viper.GetDuration("EVENT_STREAM_RETENTION"),
+
+		OutboundAllowPrivateNetworks: viper.GetBool("OUTBOUND_ALLOW_PRIVATE_NETWORKS"),
 	}
 
 	// Validate required config
@@ -60,10 +219,63 @@ func LoadConfig() (*Config, error) {
 	if cfg.JWTSecret == "" {
 		return nil, fmt.Errorf("JWT_SECRET is required")
 	}
+	if cfg.LockoutThreshold < 1 {
+		return nil, fmt.Errorf("LOCKOUT_THRESHOLD must be at least 1")
+	}
+	if cfg.LicenseAlertInterval < time.Minute {
+		return nil, fmt.Errorf("LICENSE_ALERT_INTERVAL must be at least 1m")
+	}
+	if cfg.MaintenanceDueSoonDays < 1 || cfg.MaintenanceDueSoonDays > 365 {
+		return nil, fmt.Errorf("MAINTENANCE_DUE_SOON_DAYS must be between 1 and 365")
+	}
+	if cfg.MaintenanceEscalationDays < 0 {
+		return nil, fmt.Errorf("MAINTENANCE_ESCALATION_DAYS must not be negative")
+	}
+	if cfg.MaintenanceAlertInterval < time.Minute {
+		return nil, fmt.Errorf("MAINTENANCE_ALERT_INTERVAL must be at least 1m")
+	}
+	if cfg.WebhookDeliveryInterval < time.Second {
+		return nil, fmt.Errorf("WEBHOOK_DELIVERY_INTERVAL must be at least 1s")
+	}
+	if cfg.WebhookMaxAttempts < 1 || cfg.WebhookMaxAttempts > 20 {
+		return nil, fmt.Errorf("WEBHOOK_MAX_ATTEMPTS must be between 1 and 20")
+	}
+	if cfg.ProcoreSyncInterval < time.Minute {
+		return nil, fmt.Errorf("PROCORE_SYNC_INTERVAL must be at least 1m")
+	}
+	if cfg.EventStreamRetention < time.Hour {
+		return nil, fmt.Errorf("EVENT_STREAM_RETENTION must be at least 1h")
+	}
+	if cfg.OutboundAllowPrivateNetworks && cfg.IsProduction() {
+		return nil, fmt.Errorf("OUTBOUND_ALLOW_PRIVATE_NETWORKS must not be set in production")
+	}
 
 	return cfg, nil
 }
 
+// parseDurationList parses a comma-separated list such as "5m,15m,1h".
+func parseDurationList(value string) ([]time.Duration, error) {
+	var durations []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil {
+			return nil, err
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("duration %q must be positive", part)
+		}
+		durations = append(durations, d)
+	}
+	if len(durations) == 0 {
+		return nil, fmt.Errorf("at least one duration is required")
+	}
+	return durations, nil
+}
+
 func InitDB(ctx context.Context, cfg *Config) (*gorm.DB, error) {
 	databaseURL := cfg.DatabaseURL
 
@@ -98,3 +310,26 @@ func InitDB(ctx context.Context, cfg *Config) (*gorm.DB, error) {
 
 	return gormDB, nil
 }
+
+// parseDayList parses a comma-separated list of day counts such as "60,30,7".
+func parseDayList(value string) ([]int, error) {
+	var days []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, err
+		}
+		if n < 1 || n > 365 {
+			return nil, fmt.Errorf("day count %q must be between 1 and 365", part)
+		}
+		days = append(days, n)
+	}
+	if len(days) == 0 {
+		return nil, fmt.Errorf("at least one day count is required")
+	}
+	return days, nil
+}
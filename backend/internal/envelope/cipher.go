@@ -0,0 +1,210 @@
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Ciphertext formats. Both store base64(nonce || sealed) parts.
+//
+//	v1:<sealed>                            sealed directly with master key version 1 (legacy)
+//	v2:<version>:<wrapped key>:<sealed>    data key wrapped by master key <version>
+const (
+	legacyPrefix   = "v1:"
+	envelopePrefix = "v2:"
+)
+
+// Cipher encrypts and decrypts secrets with the master keys of a keyring.
+type Cipher struct {
+	activeVersion int
+	masters       map[int]cipher.AEAD
+}
+
+// New builds a cipher from master keys by version; active wraps new data keys.
+func New(keys map[int][]byte, active int) (*Cipher, error) {
+	c := &Cipher{activeVersion: active, masters: make(map[int]cipher.AEAD, len(keys))}
+	for version, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key version %d: %w", version, err)
+		}
+		c.masters[version] = aead
+	}
+	if _, ok := c.masters[active]; !ok {
+		return nil, fmt.Errorf("no encryption key version %d", active)
+	}
+	return c, nil
+}
+
+// FromKeyring builds a cipher from every key of the ring.
+func FromKeyring(ring *Keyring) (*Cipher, error) {
+	keys, err := ring.decode()
+	if err != nil {
+		return nil, err
+	}
+	return New(keys, ring.ActiveVersion)
+}
+
+// ActiveVersion is the master key version new values are wrapped with.
+func (c *Cipher) ActiveVersion() int {
+	return c.activeVersion
+}
+
+// Encrypt seals plaintext with a fresh data key wrapped by the active master key.
+func (c *Cipher) Encrypt(plaintext []byte) (string, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := seal(data, plaintext, nil)
+	if err != nil {
+		return "", err
+	}
+	return c.wrap(dataKey, sealed)
+}
+
+// Decrypt opens a value produced by Encrypt, or a legacy v1 value.
+func (c *Cipher) Decrypt(ciphertext string) ([]byte, error) {
+	if strings.HasPrefix(ciphertext, legacyPrefix) {
+		return c.decryptLegacy(ciphertext)
+	}
+
+	version, dataKey, sealed, err := c.unwrap(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := open(data, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt with data key of encryption key version %d: %w", version, err)
+	}
+	return plaintext, nil
+}
+
+// Rewrap returns ciphertext with its data key wrapped by the active master key, and
+// whether it changed. The sealed value itself is kept; legacy v1 values are re-encrypted.
+func (c *Cipher) Rewrap(ciphertext string) (string, bool, error) {
+	if strings.HasPrefix(ciphertext, legacyPrefix) {
+		plaintext, err := c.decryptLegacy(ciphertext)
+		if err != nil {
+			return "", false, err
+		}
+		rewrapped, err := c.Encrypt(plaintext)
+		return rewrapped, err == nil, err
+	}
+
+	version, dataKey, sealed, err := c.unwrap(ciphertext)
+	if err != nil {
+		return "", false, err
+	}
+	if version == c.activeVersion {
+		return ciphertext, false, nil
+	}
+	rewrapped, err := c.wrap(dataKey, sealed)
+	return rewrapped, err == nil, err
+}
+
+// IsCiphertext reports whether value looks like the output of Encrypt (or a legacy v1
+// value) rather than plaintext.
+func IsCiphertext(value string) bool {
+	return strings.HasPrefix(value, envelopePrefix) || strings.HasPrefix(value, legacyPrefix)
+}
+
+// wrap seals the data key with the active master key, bound to its version, and formats
+// the ciphertext.
+func (c *Cipher) wrap(dataKey, sealed []byte) (string, error) {
+	wrapped, err := seal(c.masters[c.activeVersion], dataKey, wrapAssociatedData(c.activeVersion))
+	if err != nil {
+		return "", err
+	}
+	return envelopePrefix + strconv.Itoa(c.activeVersion) + ":" +
+		base64.StdEncoding.EncodeToString(wrapped) + ":" +
+		base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// unwrap parses a v2 ciphertext and returns its master key version, the unwrapped data key
+// and the sealed value.
+func (c *Cipher) unwrap(ciphertext string) (int, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(ciphertext, envelopePrefix), ":")
+	if !strings.HasPrefix(ciphertext, envelopePrefix) || len(parts) != 3 {
+		return 0, nil, nil, fmt.Errorf("unsupported ciphertext format")
+	}
+	version, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("invalid ciphertext key version %q", parts[0])
+	}
+	master, ok := c.masters[version]
+	if !ok {
+		return 0, nil, nil, fmt.Errorf("encryption key version %d is not in the keyring", version)
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	dataKey, err := open(master, wrapped, wrapAssociatedData(version))
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("unwrap data key with encryption key version %d: %w", version, err)
+	}
+	return version, dataKey, sealed, nil
+}
+
+// decryptLegacy opens a v1 value, sealed directly with the former ENCRYPTION_KEY, which
+// is master key version 1.
+func (c *Cipher) decryptLegacy(ciphertext string) ([]byte, error) {
+	master, ok := c.masters[1]
+	if !ok {
+		return nil, fmt.Errorf("v1 ciphertext needs encryption key version 1, which is not in the keyring")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ciphertext, legacyPrefix))
+	if err != nil {
+		return nil, err
+	}
+	return open(master, sealed, nil)
+}
+
+func wrapAssociatedData(version int) []byte {
+	return []byte("equipchain data key v" + strconv.Itoa(version))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns nonce || sealed.
+func seal(aead cipher.AEAD, plaintext, associatedData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+func open(aead cipher.AEAD, sealed, associatedData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, associatedData)
+}
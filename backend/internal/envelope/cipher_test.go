@@ -0,0 +1,180 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func testKey(fill byte) []byte {
+	return bytes.Repeat([]byte{fill}, KeySize)
+}
+
+func newTestCipher(t *testing.T, keys map[int][]byte, active int) *Cipher {
+	t.Helper()
+	c, err := New(keys, active)
+	if err != nil {
+		t.Fatalf("new cipher: %v", err)
+	}
+	return c
+}
+
+// legacyCiphertext seals plaintext directly with key, as ENCRYPTION_KEY did before the
+// keyring existed.
+func legacyCiphertext(t *testing.T, key, plaintext []byte) string {
+	t.Helper()
+	aead, err := newAEAD(key)
+	if err != nil {
+		t.Fatalf("aead: %v", err)
+	}
+	sealed, err := seal(aead, plaintext, nil)
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	return legacyPrefix + base64.StdEncoding.EncodeToString(sealed)
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	c := newTestCipher(t, map[int][]byte{1: testKey(1), 2: testKey(2)}, 2)
+	plaintext := []byte("JBSWY3DPEHPK3PXP")
+
+	ciphertext, err := c.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if !strings.HasPrefix(ciphertext, "v2:2:") || !IsCiphertext(ciphertext) {
+		t.Fatalf("ciphertext %q, want it wrapped by the active key version 2", ciphertext)
+	}
+	if again, _ := c.Encrypt(plaintext); again == ciphertext {
+		t.Fatal("encrypting twice gave the same ciphertext; data keys and nonces must be fresh")
+	}
+
+	decrypted, err := c.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("decrypted %q, want %q", decrypted, plaintext)
+	}
+}
+
+func TestCipherDecryptsLegacyValues(t *testing.T) {
+	legacy := legacyCiphertext(t, testKey(1), []byte("client-secret"))
+
+	c := newTestCipher(t, map[int][]byte{1: testKey(1), 2: testKey(2)}, 2)
+	decrypted, err := c.Decrypt(legacy)
+	if err != nil || string(decrypted) != "client-secret" {
+		t.Fatalf("decrypt v1 = %q, %v", decrypted, err)
+	}
+
+	// v1 values always belong to key version 1
+	withoutV1 := newTestCipher(t, map[int][]byte{2: testKey(2)}, 2)
+	if _, err := withoutV1.Decrypt(legacy); err == nil {
+		t.Fatal("decrypted a v1 value without key version 1")
+	}
+	wrongV1 := newTestCipher(t, map[int][]byte{1: testKey(9)}, 1)
+	if _, err := wrongV1.Decrypt(legacy); err == nil {
+		t.Fatal("decrypted a v1 value with the wrong key version 1")
+	}
+}
+
+func TestCipherDecryptsWithTheKeyVersionOfTheValue(t *testing.T) {
+	before := newTestCipher(t, map[int][]byte{1: testKey(1)}, 1)
+	ciphertext, err := before.Encrypt([]byte("webhook-secret"))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	// After a rotation the old key only decrypts
+	after := newTestCipher(t, map[int][]byte{1: testKey(1), 2: testKey(2)}, 2)
+	if decrypted, err := after.Decrypt(ciphertext); err != nil || string(decrypted) != "webhook-secret" {
+		t.Fatalf("decrypt with the previous key = %q, %v", decrypted, err)
+	}
+
+	removed := newTestCipher(t, map[int][]byte{2: testKey(2)}, 2)
+	if _, err := removed.Decrypt(ciphertext); err == nil || !strings.Contains(err.Error(), "version 1 is not in the keyring") {
+		t.Fatalf("decrypt after removing its key: %v, want key version 1 missing", err)
+	}
+
+	wrong := newTestCipher(t, map[int][]byte{1: testKey(9)}, 1)
+	if _, err := wrong.Decrypt(ciphertext); err == nil {
+		t.Fatal("decrypted with a different key under the same version")
+	}
+}
+
+func TestCipherRejectsTamperedValues(t *testing.T) {
+	c := newTestCipher(t, map[int][]byte{1: testKey(1), 2: testKey(2)}, 1)
+	ciphertext, err := c.Encrypt([]byte("api-key"))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	parts := strings.Split(ciphertext, ":")
+
+	flip := func(encoded string) string {
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		raw[len(raw)-1] ^= 0x01
+		return base64.StdEncoding.EncodeToString(raw)
+	}
+
+	tests := map[string]string{
+		"sealed value":       strings.Join([]string{parts[0], parts[1], parts[2], flip(parts[3])}, ":"),
+		"wrapped data key":   strings.Join([]string{parts[0], parts[1], flip(parts[2]), parts[3]}, ":"),
+		"key version":        strings.Join([]string{parts[0], "2", parts[2], parts[3]}, ":"),
+		"truncated":          strings.Join(parts[:3], ":"),
+		"unknown format":     "v3:" + strings.Join(parts[1:], ":"),
+		"not base64":         strings.Join([]string{parts[0], parts[1], "!!", parts[3]}, ":"),
+		"short sealed value": strings.Join([]string{parts[0], parts[1], parts[2], "AAAA"}, ":"),
+	}
+	for name, tampered := range tests {
+		if _, err := c.Decrypt(tampered); err == nil {
+			t.Errorf("%s: tampered ciphertext decrypted", name)
+		}
+	}
+}
+
+func TestCipherRewrap(t *testing.T) {
+	old := newTestCipher(t, map[int][]byte{1: testKey(1)}, 1)
+	ciphertext, err := old.Encrypt([]byte("totp-seed"))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	legacy := legacyCiphertext(t, testKey(1), []byte("legacy-seed"))
+
+	c := newTestCipher(t, map[int][]byte{1: testKey(1), 2: testKey(2)}, 2)
+	rewrapped, changed, err := c.Rewrap(ciphertext)
+	if err != nil || !changed || !strings.HasPrefix(rewrapped, "v2:2:") {
+		t.Fatalf("rewrap = %q, %v, %v; want it wrapped by version 2", rewrapped, changed, err)
+	}
+	// Only the data key is rewrapped; the sealed value is kept
+	if sealed := rewrapped[strings.LastIndex(rewrapped, ":"):]; !strings.HasSuffix(ciphertext, sealed) {
+		t.Fatal("rewrap re-sealed the value instead of rewrapping its data key")
+	}
+	if decrypted, err := newTestCipher(t, map[int][]byte{2: testKey(2)}, 2).Decrypt(rewrapped); err != nil || string(decrypted) != "totp-seed" {
+		t.Fatalf("decrypt the rewrapped value with version 2 only = %q, %v", decrypted, err)
+	}
+
+	if again, changed, err := c.Rewrap(rewrapped); err != nil || changed || again != rewrapped {
+		t.Fatalf("rewrap of a value of the active version = %q, %v, %v; want it unchanged", again, changed, err)
+	}
+
+	fromLegacy, changed, err := c.Rewrap(legacy)
+	if err != nil || !changed || !strings.HasPrefix(fromLegacy, "v2:2:") {
+		t.Fatalf("rewrap of a v1 value = %q, %v, %v; want it encrypted with version 2", fromLegacy, changed, err)
+	}
+	if decrypted, err := c.Decrypt(fromLegacy); err != nil || string(decrypted) != "legacy-seed" {
+		t.Fatalf("decrypt the re-encrypted v1 value = %q, %v", decrypted, err)
+	}
+}
+
+func TestNewRejectsBadKeys(t *testing.T) {
+	if _, err := New(map[int][]byte{1: testKey(1)}, 2); err == nil {
+		t.Fatal("accepted an active version that is not in the keys")
+	}
+	if _, err := New(map[int][]byte{1: []byte("short")}, 1); err == nil {
+		t.Fatal("accepted a key that is not an AES key")
+	}
+}
@@ -0,0 +1,169 @@
+// Package envelope encrypts secrets stored in the database (integration credentials,
+// webhook secrets, OIDC client secrets, TOTP seeds) with envelope encryption: every value
+// is sealed with its own AES-256-GCM data key, and the data key is wrapped by a versioned
+// master key from the keyring. Rotating the master key only rewraps data keys.
+package envelope
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// KeySize is the length of master and data keys in bytes (AES-256).
+const KeySize = 32
+
+// Keyring is the set of master keys stored in ENCRYPTION_KEYS_FILE. The active key wraps
+// new data keys; every key in the ring unwraps, so values encrypted before a rotation stay
+// readable until they are re-encrypted and the old key is removed.
+type Keyring struct {
+	ActiveVersion int       `json:"active_version"`
+	Keys          []KeyFile `json:"keys"`
+}
+
+// KeyFile is one master key of the ring. Key is the base64 encoded 32-byte key.
+type KeyFile struct {
+	Version   int       `json:"version"`
+	Key       string    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LoadKeyring reads a keyring file. A missing file yields an empty ring.
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Keyring{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ring Keyring
+	if err := json.Unmarshal(data, &ring); err != nil {
+		return nil, fmt.Errorf("parse encryption keyring %s: %w", path, err)
+	}
+	return &ring, nil
+}
+
+// Save writes the ring atomically with owner-only permissions.
+func (r *Keyring) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".encryption-keys-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Generate adds a new random key and returns its version. The first key becomes active.
+func (r *Keyring) Generate() (int, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return 0, err
+	}
+	return r.Add(key)
+}
+
+// Add adds an existing key, e.g. the former ENCRYPTION_KEY, under the next version. The
+// first key becomes active.
+func (r *Keyring) Add(key []byte) (int, error) {
+	if len(key) != KeySize {
+		return 0, fmt.Errorf("encryption key must be %d bytes (got %d)", KeySize, len(key))
+	}
+
+	version := 1
+	for _, existing := range r.Keys {
+		if existing.Version >= version {
+			version = existing.Version + 1
+		}
+	}
+	r.Keys = append(r.Keys, KeyFile{
+		Version:   version,
+		Key:       base64.StdEncoding.EncodeToString(key),
+		CreatedAt: time.Now().UTC(),
+	})
+	if r.ActiveVersion == 0 {
+		r.ActiveVersion = version
+	}
+	return version, nil
+}
+
+// Activate makes version the key that wraps new data keys.
+func (r *Keyring) Activate(version int) error {
+	if r.find(version) == nil {
+		return fmt.Errorf("encryption key version %d not found", version)
+	}
+	r.ActiveVersion = version
+	return nil
+}
+
+// Remove drops a key that is no longer active. Values still wrapped by it can no longer
+// be decrypted, so it must only be removed after re-encryption.
+func (r *Keyring) Remove(version int) error {
+	if r.find(version) == nil {
+		return fmt.Errorf("encryption key version %d not found", version)
+	}
+	if version == r.ActiveVersion {
+		return fmt.Errorf("encryption key version %d is active; activate another key first", version)
+	}
+
+	kept := r.Keys[:0]
+	for _, key := range r.Keys {
+		if key.Version != version {
+			kept = append(kept, key)
+		}
+	}
+	r.Keys = kept
+	return nil
+}
+
+func (r *Keyring) find(version int) *KeyFile {
+	for i := range r.Keys {
+		if r.Keys[i].Version == version {
+			return &r.Keys[i]
+		}
+	}
+	return nil
+}
+
+// decode returns the raw keys by version and checks that the active key exists.
+func (r *Keyring) decode() (map[int][]byte, error) {
+	keys := make(map[int][]byte, len(r.Keys))
+	for _, file := range r.Keys {
+		key, err := base64.StdEncoding.DecodeString(file.Key)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key version %d is not base64: %w", file.Version, err)
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("encryption key version %d must be %d bytes (got %d)", file.Version, KeySize, len(key))
+		}
+		keys[file.Version] = key
+	}
+
+	if _, ok := keys[r.ActiveVersion]; !ok {
+		return nil, fmt.Errorf("encryption keyring has no active key version %d", r.ActiveVersion)
+	}
+	return keys, nil
+}
@@ -0,0 +1,98 @@
+package envelope
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "encryption-keys.json")
+	ring, err := LoadKeyring(path)
+	if err != nil || len(ring.Keys) != 0 {
+		t.Fatalf("load a missing keyring = %+v, %v; want an empty ring", ring, err)
+	}
+
+	first, err := ring.Generate()
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	// A later key is added decrypt-only until it is activated
+	second, err := ring.Generate()
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if first != 1 || second != 2 || ring.ActiveVersion != 1 {
+		t.Fatalf("versions %d, %d, active %d; want 1, 2 and the first active", first, second, ring.ActiveVersion)
+	}
+	if err := ring.Remove(first); err == nil {
+		t.Fatal("removed the active key")
+	}
+
+	if err := ring.Save(path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("keyring mode %v, want 0600", perm)
+	}
+
+	loaded, err := LoadKeyring(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	before, err := FromKeyring(loaded)
+	if err != nil {
+		t.Fatalf("cipher: %v", err)
+	}
+	ciphertext, err := before.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	if err := loaded.Activate(3); err == nil {
+		t.Fatal("activated a missing key")
+	}
+	if err := loaded.Activate(second); err != nil {
+		t.Fatalf("activate: %v", err)
+	}
+	after, err := FromKeyring(loaded)
+	if err != nil {
+		t.Fatalf("cipher: %v", err)
+	}
+	if after.ActiveVersion() != second {
+		t.Fatalf("active version %d, want %d", after.ActiveVersion(), second)
+	}
+	rewrapped, _, err := after.Rewrap(ciphertext)
+	if err != nil {
+		t.Fatalf("rewrap: %v", err)
+	}
+
+	if err := loaded.Remove(first); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	pruned, err := FromKeyring(loaded)
+	if err != nil {
+		t.Fatalf("cipher: %v", err)
+	}
+	if decrypted, err := pruned.Decrypt(rewrapped); err != nil || string(decrypted) != "secret" {
+		t.Fatalf("decrypt after removing the old key = %q, %v", decrypted, err)
+	}
+}
+
+func TestFromKeyringRejectsInvalidKeys(t *testing.T) {
+	tests := map[string]*Keyring{
+		"no active key": {ActiveVersion: 2, Keys: []KeyFile{{Version: 1, Key: "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}}},
+		"not base64":    {ActiveVersion: 1, Keys: []KeyFile{{Version: 1, Key: "not base64!"}}},
+		"short key":     {ActiveVersion: 1, Keys: []KeyFile{{Version: 1, Key: "c2hvcnQ="}}},
+		"empty ring":    {},
+	}
+	for name, ring := range tests {
+		if _, err := FromKeyring(ring); err == nil {
+			t.Errorf("%s: keyring accepted", name)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Package events is an in-process bus for domain events such as equipment.created or
+// maintenance.approved. Services publish an event inside the transaction of the change it
+// describes; subscribers (the webhook outbox, the event stream log) write whatever they
+// need to act on it through that transaction, so both commit or roll back together.
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Domain event types. They are also the webhook event names.
+const (
+	EquipmentCreated = "equipment.created"
+	EquipmentUpdated = "equipment.updated"
+	EquipmentDeleted = "equipment.deleted"
+
+	MaintenanceCreated   = "maintenance.created"
+	MaintenanceSubmitted = "maintenance.submitted"
+	MaintenanceApproved  = "maintenance.approved"
+	MaintenanceRejected  = "maintenance.rejected"
+	MaintenanceConfirmed = "maintenance.confirmed"
+	MaintenanceAssigned  = "maintenance.assigned"
+
+	ScheduleDueSoon          = "schedule.due_soon"
+	ScheduleOverdue          = "schedule.overdue"
+	ScheduleOverdueEscalated = "schedule.overdue_escalated"
+)
+
+// Types lists every event type, in documentation order.
+var Types = []string{
+	EquipmentCreated, EquipmentUpdated, EquipmentDeleted,
+	MaintenanceCreated, MaintenanceSubmitted, MaintenanceApproved, MaintenanceRejected, MaintenanceConfirmed, MaintenanceAssigned,
+	ScheduleDueSoon, ScheduleOverdue, ScheduleOverdueEscalated,
+}
+
+// Event is something that happened in an organization. Data must marshal to a JSON object.
+type Event struct {
+	ID             uuid.UUID
+	Type           string
+	OrganizationID uuid.UUID
+	OccurredAt     time.Time
+	Data           interface{}
+}
+
+// Handler receives published events. Handlers run synchronously in the publisher's
+// transaction and must write through tx; they should only do quick work such as writing
+// to an outbox.
+type Handler func(ctx context.Context, tx *gorm.DB, event Event) error
+
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish hands the event to every subscriber within tx, the transaction of the change it
+// describes. A subscriber error is returned so that the caller rolls the change back
+// rather than commit it without its outbox rows.
+func (b *Bus) Publish(ctx context.Context, tx *gorm.DB, organizationID uuid.UUID, eventType string, data interface{}) error {
+	event := Event{
+		ID:             uuid.New(),
+		Type:           eventType,
+		OrganizationID: organizationID,
+		OccurredAt:     time.Now().UTC(),
+		Data:           data,
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+	for _, handler := range handlers {
+		if err := handler(ctx, tx, event); err != nil {
+			return fmt.Errorf("handle %s event: %w", event.Type, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+// Package ical writes iCalendar (RFC 5545) feeds of all-day events for calendar clients
+// such as Outlook and Google Calendar to subscribe to.
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+
+	// maxLineOctets is the longest content line allowed before folding, excluding CRLF.
+	maxLineOctets = 75
+)
+
+// Calendar is a VCALENDAR published to subscribers (METHOD:PUBLISH).
+type Calendar struct {
+	ProdID string
+	Name   string
+	// RefreshInterval suggests how often clients poll the feed; zero omits it.
+	RefreshInterval time.Duration
+	Events          []Event
+}
+
+// Event is an all-day VEVENT on Date. UID must be stable across feed generations so that
+// clients update the event rather than adding a duplicate.
+type Event struct {
+	UID          string
+	Date         time.Time
+	Stamp        time.Time
+	LastModified time.Time
+	Summary      string
+	Description  string
+	Location     string
+	URL          string
+	Categories   []string
+	// Properties are extra TEXT properties, typically X- extensions.
+	Properties []Property
+}
+
+// Property is a TEXT property; Value is escaped when written.
+type Property struct {
+	Name  string
+	Value string
+}
+
+// WriteTo writes the calendar with CRLF line endings, folding long lines.
+func (c *Calendar) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	line := func(name, value string) {
+		writeLine(&buf, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", c.ProdID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escapeText(c.Name))
+	}
+	if c.RefreshInterval > 0 {
+		duration := formatDuration(c.RefreshInterval)
+		line("REFRESH-INTERVAL;VALUE=DURATION", duration)
+		line("X-PUBLISHED-TTL", duration)
+	}
+
+	for _, event := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("DTSTAMP", event.Stamp.UTC().Format(dateTimeLayout))
+		if !event.LastModified.IsZero() {
+			line("LAST-MODIFIED", event.LastModified.UTC().Format(dateTimeLayout))
+		}
+		line("DTSTART;VALUE=DATE", event.Date.Format(dateLayout))
+		line("DTEND;VALUE=DATE", event.Date.AddDate(0, 0, 1).Format(dateLayout))
+		line("SUMMARY", escapeText(event.Summary))
+		if event.Location != "" {
+			line("LOCATION", escapeText(event.Location))
+		}
+		if event.Description != "" {
+			line("DESCRIPTION", escapeText(event.Description))
+		}
+		if event.URL != "" {
+			line("URL", event.URL)
+		}
+		if len(event.Categories) > 0 {
+			categories := make([]string, len(event.Categories))
+			for i, category := range event.Categories {
+				categories[i] = escapeText(category)
+			}
+			line("CATEGORIES", strings.Join(categories, ","))
+		}
+		for _, property := range event.Properties {
+			line(property.Name, escapeText(property.Value))
+		}
+		// All-day maintenance does not block the subscriber's free/busy time
+		line("TRANSP", "TRANSPARENT")
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// escapeText escapes a TEXT value (RFC 5545 section 3.3.11).
+func escapeText(value string) string {
+	var b strings.Builder
+	for _, r := range strings.ReplaceAll(value, "\r\n", "\n") {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case ';':
+			b.WriteString(`\;`)
+		case ',':
+			b.WriteString(`\,`)
+		case '\n', '\r':
+			b.WriteString(`\n`)
+		default:
+			// Other control characters are not allowed in TEXT
+			if r < 0x20 && r != '\t' {
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// writeLine folds the content line into lines of at most 75 octets, each continuation
+// starting with a space, without splitting UTF-8 sequences (RFC 5545 section 3.1).
+func writeLine(buf *bytes.Buffer, content string) {
+	limit := maxLineOctets
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		buf.WriteString(content[:cut])
+		buf.WriteString("\r\n ")
+		content = content[cut:]
+		// The leading space counts towards the continuation line's length
+		limit = maxLineOctets - 1
+	}
+	buf.WriteString(content)
+	buf.WriteString("\r\n")
+}
+
+// formatDuration formats d as a DURATION value in whole minutes, e.g. PT1H or PT90M.
+func formatDuration(d time.Duration) string {
+	minutes := int(d / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("PT%dH", minutes/60)
+	}
+	return fmt.Sprintf("PT%dM", minutes)
+}
@@ -0,0 +1,174 @@
+package ical
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// testCalendar has one plain event and one whose texts need escaping and folding.
+func testCalendar() *Calendar {
+	stamp := time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC)
+	return &Calendar{
+		ProdID:          "-//EquipChain//Maintenance Calendar//EN",
+		Name:            "EquipChain maintenance",
+		RefreshInterval: time.Hour,
+		Events: []Event{
+			{
+				UID:          "schedule-0b7c4f1e-2d55-4a8e-9a43-6f0d2c1e8b90@equipchain",
+				Date:         time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC),
+				Stamp:        stamp,
+				LastModified: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+				Summary:      "Oil change: Excavator 7 (EX-0007)",
+				Location:     "Yard B",
+				URL:          "https://app.equipchain.example/equipment/0b7c4f1e-2d55-4a8e-9a43-6f0d2c1e8b90",
+				Categories:   []string{"Maintenance", "Oil change", "Preventive schedule"},
+				Properties:   []Property{{Name: "X-EQUIPCHAIN-OVERDUE", Value: "FALSE"}},
+			},
+			{
+				UID:         "assignment-5e2a9c3b-81f4-4d07-b6e2-0c9f7a4d1e36@equipchain",
+				Date:        time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+				Stamp:       stamp,
+				Summary:     `[OVERDUE] Inspection; annual, Kran "Süd" \ Halle 3`,
+				Location:    "Lager Nord, Tor 2; Rampe",
+				Description: "Equipment: Kran Süd\r\nSerial number: KR-0042\nStandort: Müllerstraße 12, 80469 München – Halle 3, Rückseite\n\x07Status: overdue",
+				Categories:  []string{"Maintenance", "Inspection, annual", "Overdue"},
+				Properties:  []Property{{Name: "X-EQUIPCHAIN-OVERDUE", Value: "TRUE"}},
+			},
+		},
+	}
+}
+
+func TestCalendarGolden(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := testCalendar().WriteTo(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	golden := filepath.Join("testdata", "calendar.ics")
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatalf("update golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("calendar differs from %s (run go test -update to accept):\n%s", golden, buf.Bytes())
+	}
+
+	// Feeds regenerate with the same UIDs, so clients update events instead of duplicating them
+	var again bytes.Buffer
+	if _, err := testCalendar().WriteTo(&again); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), again.Bytes()) {
+		t.Fatal("writing the same calendar twice gave different output")
+	}
+}
+
+func TestCalendarLines(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := testCalendar().WriteTo(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	output := buf.String()
+
+	if !strings.HasSuffix(output, "\r\n") || strings.Count(output, "\n") != strings.Count(output, "\r\n") {
+		t.Fatal("lines do not all end with CRLF")
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line of %d octets: %q", len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line splits a UTF-8 sequence: %q", line)
+		}
+		if strings.ContainsAny(line, "\r\n\x07") {
+			t.Errorf("line contains a control character: %q", line)
+		}
+	}
+
+	unfolded := strings.ReplaceAll(output, "\r\n ", "")
+	want := `DESCRIPTION:Equipment: Kran Süd\nSerial number: KR-0042\nStandort: Müllerstraße 12\, 80469 München – Halle 3\, Rückseite\nStatus: overdue` + "\r\n"
+	if !strings.Contains(unfolded, want) {
+		t.Fatalf("unfolded calendar lacks %q:\n%s", want, unfolded)
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"plain text", "plain text"},
+		{`back\slash`, `back\\slash`},
+		{"semi;colon", `semi\;colon`},
+		{"com,ma", `com\,ma`},
+		{"line\nbreak", `line\nbreak`},
+		{"crlf\r\nbreak", `crlf\nbreak`},
+		{"bare\rcr", `bare\ncr`},
+		{"tab\tkept", "tab\tkept"},
+		{"bell\x07dropped", "belldropped"},
+		{"colon: kept", "colon: kept"},
+		{`all \;,` + "\n", `all \\\;\,\n`},
+		{"Müller – Süd", "Müller – Süd"},
+	}
+	for _, tt := range tests {
+		if got := escapeText(tt.value); got != tt.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestWriteLineFolding(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"short", "SUMMARY:Oil change", "SUMMARY:Oil change\r\n"},
+		{"exactly 75 octets", strings.Repeat("a", 75), strings.Repeat("a", 75) + "\r\n"},
+		{"76 octets", strings.Repeat("a", 76), strings.Repeat("a", 75) + "\r\n a\r\n"},
+		{"continuations hold 74 octets", strings.Repeat("a", 75+74+1), strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 74) + "\r\n a\r\n"},
+		// "ü" is two octets; the first line would end in the middle of it
+		{"multibyte at the fold", strings.Repeat("a", 74) + "üb", strings.Repeat("a", 74) + "\r\n üb\r\n"},
+		{"multibyte before the fold", strings.Repeat("a", 73) + "üb", strings.Repeat("a", 73) + "ü\r\n b\r\n"},
+		// "–" is three octets
+		{"three octets at the fold", strings.Repeat("a", 74) + "–", strings.Repeat("a", 74) + "\r\n –\r\n"},
+		{"four octets at the fold", strings.Repeat("a", 73) + "🔧x", strings.Repeat("a", 73) + "\r\n 🔧x\r\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		writeLine(&buf, tt.content)
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s: writeLine = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     string
+	}{
+		{time.Hour, "PT1H"},
+		{90 * time.Minute, "PT90M"},
+		{24 * time.Hour, "PT24H"},
+		{time.Second, "PT1M"},
+	}
+	for _, tt := range tests {
+		if got := formatDuration(tt.duration); got != tt.want {
+			t.Errorf("formatDuration(%s) = %s, want %s", tt.duration, got, tt.want)
+		}
+	}
+}
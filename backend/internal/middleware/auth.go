@@ -1,56 +1,313 @@
 package middleware
 
 import (
+	"github.com/NWhite12/EquipChain/internal/model"
 	"github.com/NWhite12/EquipChain/internal/service"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"net/http"
 	"strings"
 )
 
-func AuthMiddleware(jwtService *service.JWTService) gin.HandlerFunc {
+// AuthMiddleware authenticates "Authorization: Bearer <jwt>" or "Authorization: ApiKey <key>"
+// and sets the same context values for both, so handlers need not know which was used.
+// Requests for suspended or deleted organizations are rejected, and so are tokens of users
+// who have since been deactivated or must reset their password.
+func AuthMiddleware(jwtService *service.JWTService, authService *service.AuthService, permissionService *service.PermissionService, apiKeyService *service.APIKeyService, organizationService *service.OrganizationService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing authorization handler"})
+		scheme, tokenString, ok := authorizationCredentials(c)
+		if !ok {
+			return
+		}
+
+		if scheme == apiKeyScheme {
+			principal, err := apiKeyService.Authenticate(c.Request.Context(), tokenString, c.ClientIP())
+			if err != nil {
+				if err == service.ErrInvalidAPIKey {
+					c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+				} else {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+				}
+				c.Abort()
+				return
+			}
+			if !requireActiveOrganization(c, organizationService, principal.Key.OrganizationID) {
+				return
+			}
+
+			c.Set("user_id", principal.User.ID)
+			c.Set("organization_id", principal.Key.OrganizationID)
+			c.Set("email", principal.User.Email)
+			c.Set("role_id", principal.User.RoleID)
+			c.Set("permissions", principal.Permissions)
+			c.Set("token_scope", service.TokenScopeAPIKey)
+			c.Set("api_key_id", principal.Key.ID)
+
+			c.Next()
+			return
+		}
+
+		claims, err := jwtService.ValidateToken(tokenString)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			c.Abort()
 			return
 		}
 
-		parts := strings.SplitN(authHeader, " ", 2)
+		if !requireActiveOrganization(c, organizationService, claims.OrganizationID) {
+			return
+		}
 
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
+		// The user is read on every request so that deactivation and role changes are immediate
+		user, ok := sessionUser(c, authService, claims)
+		if !ok {
+			return
+		}
+
+		permissions, err := permissionService.PermissionsForRole(c.Request.Context(), user.RoleID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
 			c.Abort()
 			return
 		}
 
-		claims, err := jwtService.ValidateToken(parts[1])
+		c.Set("user_id", user.ID)
+		c.Set("organization_id", user.OrganizationID)
+		c.Set("email", user.Email)
+		c.Set("role_id", user.RoleID)
+		c.Set("permissions", permissions)
+		c.Set("token_scope", claims.Scope)
+
+		c.Next()
+	}
+}
+
+// MFAEnrollmentMiddleware accepts an access token or an MFA-pending token, so that users
+// whose organization requires MFA can enroll before they are allowed a full login. Like
+// AuthMiddleware it reloads the user, so deactivated users are refused. It does not load
+// permissions; routes behind it must only act on the caller's own account.
+func MFAEnrollmentMiddleware(jwtService *service.JWTService, authService *service.AuthService, organizationService *service.OrganizationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := bearerToken(c)
+		if !ok {
+			return
+		}
+
+		claims, err := jwtService.ValidateToken(tokenString)
+		if err != nil {
+			claims, err = jwtService.ValidateScopedToken(tokenString, service.TokenScopeMFA)
+		}
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			c.Abort()
 			return
 		}
+		if !requireActiveOrganization(c, organizationService, claims.OrganizationID) {
+			return
+		}
+		user, ok := sessionUser(c, authService, claims)
+		if !ok {
+			return
+		}
+
+		c.Set("user_id", user.ID)
+		c.Set("organization_id", user.OrganizationID)
+		c.Set("email", user.Email)
+		c.Set("role_id", user.RoleID)
+		c.Set("token_scope", claims.Scope)
+
+		c.Next()
+	}
+}
+
+// sessionUser loads the user behind the token claims, aborting with 401 if they no longer
+// exist, were deactivated or must reset their password.
+func sessionUser(c *gin.Context, authService *service.AuthService, claims *service.Claims) (*model.User, bool) {
+	user, err := authService.SessionUser(c.Request.Context(), claims)
+	if err != nil {
+		switch err {
+		case service.ErrUserNotFound:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		case service.ErrAccountDisabled, service.ErrPasswordResetRequired:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		}
+		c.Abort()
+		return nil, false
+	}
+	return user, true
+}
+
+// requireActiveOrganization aborts with 403 for a suspended and 401 for a deleted
+// organization. The status is read on every request so that suspension is immediate.
+func requireActiveOrganization(c *gin.Context, organizationService *service.OrganizationService, organizationID uuid.UUID) bool {
+	err := organizationService.CheckActive(c.Request.Context(), organizationID)
+	switch err {
+	case nil:
+		return true
+	case service.ErrOrganizationSuspended:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case service.ErrOrganizationNotFound:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+	c.Abort()
+	return false
+}
+
+// RequirePlatformAdmin admits only interactive sessions of platform admins. It must run
+// after AuthMiddleware; the flag is looked up on every request.
+func RequirePlatformAdmin(platformService *service.PlatformService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if scope, _ := c.Get("token_scope"); scope == service.TokenScopeAPIKey {
+			c.JSON(http.StatusForbidden, gin.H{"error": service.ErrPlatformAdminRequired.Error()})
+			c.Abort()
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		sessionUserID, ok := userID.(uuid.UUID)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id type"})
+			c.Abort()
+			return
+		}
+
+		isPlatformAdmin, err := platformService.IsPlatformAdmin(c.Request.Context(), sessionUserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			c.Abort()
+			return
+		}
+		if !isPlatformAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": service.ErrPlatformAdminRequired.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// SigningTokenHeader carries the step-up token issued by /api/auth/step-up.
+const SigningTokenHeader = "X-Signing-Token"
+
+// RequireSigningToken demands a fresh step-up signing token for the same user as the
+// session. It must run after AuthMiddleware. The factor used is stored as "signing_factor"
+// and the token's jti as "signing_token_id"; the decision records the jti, which makes
+// the token single-use.
+func RequireSigningToken(jwtService *service.JWTService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := c.GetHeader(SigningTokenHeader)
+		if tokenString == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "signing token required", "step_up": "/api/auth/step-up"})
+			c.Abort()
+			return
+		}
+
+		claims, err := jwtService.ValidateScopedToken(tokenString, service.TokenScopeSigning)
+		var tokenID uuid.UUID
+		if err == nil {
+			tokenID, err = uuid.Parse(claims.ID)
+		}
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "invalid or expired signing token", "step_up": "/api/auth/step-up"})
+			c.Abort()
+			return
+		}
+
+		if scope, _ := c.Get("token_scope"); scope == service.TokenScopeAPIKey {
+			c.JSON(http.StatusForbidden, gin.H{"error": "signing requires an interactive user session"})
+			c.Abort()
+			return
+		}
 
-		c.Set("user_id", claims.UserID)
-		c.Set("organization_id", claims.OrganizationID)
-		c.Set("email", claims.Email)
-		c.Set("role_id", claims.RoleID)
+		userID, _ := c.Get("user_id")
+		if sessionUserID, ok := userID.(uuid.UUID); !ok || sessionUserID != claims.UserID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "signing token does not belong to this session"})
+			c.Abort()
+			return
+		}
+
+		c.Set("signing_factor", claims.AuthFactor)
+		c.Set("signing_token_id", tokenID)
+
+		c.Next()
+	}
+}
+
+// RequireAPIKey admits only requests authenticated with an API key, for endpoints called
+// by machines such as the blockchain confirmation worker. It must run after
+// AuthMiddleware.
+func RequireAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if scope, _ := c.Get("token_scope"); scope != service.TokenScopeAPIKey {
+			c.JSON(http.StatusForbidden, gin.H{"error": "this endpoint requires an API key"})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
 }
 
-func RequireRole(requiredRole int) gin.HandlerFunc {
+const (
+	bearerScheme = "Bearer"
+	apiKeyScheme = "ApiKey"
+)
+
+// bearerToken extracts the token from the Authorization header, aborting with 401 if absent.
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, token, ok := authorizationCredentials(c)
+	if !ok {
+		return "", false
+	}
+	if scheme != bearerScheme {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
+		c.Abort()
+		return "", false
+	}
+
+	return token, true
+}
+
+// authorizationCredentials splits a Bearer or ApiKey Authorization header, aborting with
+// 401 if it is absent or uses another scheme.
+func authorizationCredentials(c *gin.Context) (string, string, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing authorization handler"})
+		c.Abort()
+		return "", "", false
+	}
+
+	parts := strings.SplitN(authHeader, " ", 2)
+
+	if len(parts) != 2 || (parts[0] != bearerScheme && parts[0] != apiKeyScheme) || parts[1] == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
+		c.Abort()
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+// RequirePermission rejects the request unless the permissions loaded by AuthMiddleware
+// grant the given permission (wildcards such as "*" and "create:*" are honoured).
+func RequirePermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		roleID, exists := c.Get("role_id")
+		permissionsInterface, exists := c.Get("permissions")
 		if !exists {
-			c.JSON(http.StatusForbidden, gin.H{"error": "role not found"})
+			c.JSON(http.StatusForbidden, gin.H{"error": "permissions not found"})
 			c.Abort()
 			return
 		}
 
-		if roleID.(int) > requiredRole {
-			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+		permissions, ok := permissionsInterface.([]string)
+		if !ok || !service.HasPermission(permissions, permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions", "required_permission": permission})
 			c.Abort()
 			return
 		}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NWhite12/EquipChain/internal/config"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/NWhite12/EquipChain/internal/service"
+	"github.com/NWhite12/EquipChain/internal/testdb"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// newSessionRouter serves GET /probe behind AuthMiddleware, answering with the role the
+// middleware put in the context.
+func newSessionRouter(t *testing.T, db *gorm.DB) (*gin.Engine, *service.JWTService) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	jwtService, err := service.NewJWTService(&config.Config{Environment: "test", JWTSecret: "middleware-test-secret"})
+	if err != nil {
+		t.Fatalf("jwt service: %v", err)
+	}
+	userRepo := repository.NewUserRepository(db)
+	organizationService := service.NewOrganizationService(repository.NewOrganizationRepository(db))
+	authService := service.NewAuthService(userRepo, jwtService, nil, nil, nil, organizationService)
+	permissionService := service.NewPermissionService(repository.NewRoleRepository(db), 0)
+
+	router := gin.New()
+	router.GET("/probe", AuthMiddleware(jwtService, authService, permissionService, nil, organizationService), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"role_id": c.GetInt16("role_id")})
+	})
+	return router, jwtService
+}
+
+func probe(router *gin.Engine, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/probe", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthMiddlewareRejectsDeactivatedUser(t *testing.T) {
+	db := testdb.Open(t)
+	router, jwtService := newSessionRouter(t, db)
+	organization := testdb.CreateOrganization(t, db)
+	user := testdb.CreateUser(t, db, organization.ID, model.RoleTechnician)
+
+	token, err := jwtService.GenerateToken(user.ID, organization.ID, user.Email, user.RoleID)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	if rec := probe(router, token); rec.Code != http.StatusOK {
+		t.Fatalf("active user: status %d, body %s", rec.Code, rec.Body)
+	}
+
+	if err := db.Model(&model.User{}).Where("id = ?", user.ID).Update("status", "inactive").Error; err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+	if rec := probe(router, token); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("deactivated user: status %d, want 401", rec.Code)
+	}
+}
+
+func TestAuthMiddlewareAppliesCurrentRoleAndForcedReset(t *testing.T) {
+	db := testdb.Open(t)
+	router, jwtService := newSessionRouter(t, db)
+	organization := testdb.CreateOrganization(t, db)
+	user := testdb.CreateUser(t, db, organization.ID, model.RoleSupervisor)
+
+	token, err := jwtService.GenerateToken(user.ID, organization.ID, user.Email, user.RoleID)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+
+	// A demotion applies to the token issued before it
+	if err := db.Model(&model.User{}).Where("id = ?", user.ID).Update("role_id", model.RoleViewer).Error; err != nil {
+		t.Fatalf("demote: %v", err)
+	}
+	rec := probe(router, token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("demoted user: status %d, body %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		RoleID int16 `json:"role_id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.RoleID != model.RoleViewer {
+		t.Fatalf("demoted user: role %d, want the viewer role %d", body.RoleID, model.RoleViewer)
+	}
+
+	if err := db.Model(&model.User{}).Where("id = ?", user.ID).Update("password_reset_required", true).Error; err != nil {
+		t.Fatalf("force reset: %v", err)
+	}
+	if rec := probe(router, token); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("user with a forced reset: status %d, want 401", rec.Code)
+	}
+
+	// A token for another organization's user is not honoured either
+	other := testdb.CreateOrganization(t, db)
+	mismatched, err := jwtService.GenerateToken(user.ID, other.ID, user.Email, model.RoleAdmin)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	if rec := probe(router, mismatched); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("token with another organization: status %d, want 401", rec.Code)
+	}
+}
+
+func TestMFAEnrollmentMiddlewareRejectsDeactivatedUser(t *testing.T) {
+	db := testdb.Open(t)
+	gin.SetMode(gin.TestMode)
+	jwtService, err := service.NewJWTService(&config.Config{Environment: "test", JWTSecret: "middleware-test-secret"})
+	if err != nil {
+		t.Fatalf("jwt service: %v", err)
+	}
+	organizationService := service.NewOrganizationService(repository.NewOrganizationRepository(db))
+	authService := service.NewAuthService(repository.NewUserRepository(db), jwtService, nil, nil, nil, organizationService)
+
+	router := gin.New()
+	router.GET("/probe", MFAEnrollmentMiddleware(jwtService, authService, organizationService), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	organization := testdb.CreateOrganization(t, db)
+	user := testdb.CreateUser(t, db, organization.ID, model.RoleSupervisor)
+	token, err := jwtService.GenerateScopedToken(user.ID, organization.ID, user.Email, user.RoleID, service.TokenScopeMFA)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	if rec := probe(router, token); rec.Code != http.StatusNoContent {
+		t.Fatalf("active user: status %d, body %s", rec.Code, rec.Body)
+	}
+
+	if err := db.Model(&model.User{}).Where("id = ?", user.ID).Update("status", "inactive").Error; err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+	if rec := probe(router, token); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("deactivated user with an MFA-pending token: status %d, want 401", rec.Code)
+	}
+}
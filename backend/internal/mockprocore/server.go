@@ -0,0 +1,302 @@
+// Package mockprocore is an in-memory stand-in for the parts of the Procore API the
+// equipment sync uses: the client credentials token endpoint, a project's managed
+// equipment and its equipment logs. It is served by cmd/mockprocore and by the sync
+// tests. Never expose it outside a development machine.
+package mockprocore
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	tokenLifetime  = 2 * time.Hour
+	maxPerPage     = 100
+	defaultPerPage = 20
+)
+
+// Server serves one company's project. Its fields must be set before it starts serving;
+// the methods may be used at any time.
+type Server struct {
+	ClientID     string
+	ClientSecret string
+	CompanyID    int64
+	ProjectID    int64
+
+	mu        sync.Mutex
+	tokens    map[string]time.Time
+	equipment map[int64]map[string]interface{}
+	logs      []map[string]interface{}
+	nextID    int64
+}
+
+// New returns a server with no equipment.
+func New(clientID, clientSecret string, companyID, projectID int64) *Server {
+	return &Server{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		CompanyID:    companyID,
+		ProjectID:    projectID,
+		tokens:       make(map[string]time.Time),
+		equipment:    make(map[int64]map[string]interface{}),
+		nextID:       1000,
+	}
+}
+
+// Handler routes the token endpoint and the project's REST endpoints.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /oauth/token", s.token)
+	mux.HandleFunc("GET /rest/v1.0/projects/{project_id}/managed_equipment", s.authorized(s.listEquipment))
+	mux.HandleFunc("POST /rest/v1.0/projects/{project_id}/managed_equipment", s.authorized(s.createEquipment))
+	mux.HandleFunc("GET /rest/v1.0/projects/{project_id}/managed_equipment/{id}", s.authorized(s.getEquipment))
+	mux.HandleFunc("PATCH /rest/v1.0/projects/{project_id}/managed_equipment/{id}", s.authorized(s.updateEquipment))
+	mux.HandleFunc("DELETE /rest/v1.0/projects/{project_id}/managed_equipment/{id}", s.authorized(s.deleteEquipment))
+	mux.HandleFunc("GET /rest/v1.0/projects/{project_id}/equipment_logs", s.authorized(s.listLogs))
+	mux.HandleFunc("POST /rest/v1.0/projects/{project_id}/equipment_logs", s.authorized(s.createLog))
+	return mux
+}
+
+// AddEquipment stores new managed equipment, as if created in Procore, and returns its id.
+func (s *Server) AddEquipment(fields map[string]interface{}) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.create(fields)["id"].(int64)
+}
+
+// SetEquipment changes fields of managed equipment, as if edited in Procore. It reports
+// false if there is no such equipment.
+func (s *Server) SetEquipment(id int64, fields map[string]interface{}) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	equipment, found := s.equipment[id]
+	if found {
+		s.update(equipment, fields)
+	}
+	return found
+}
+
+// Equipment returns a copy of managed equipment, or nil if there is no such equipment.
+func (s *Server) Equipment(id int64) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	equipment, found := s.equipment[id]
+	if !found {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(equipment))
+	for field, value := range equipment {
+		copied[field] = value
+	}
+	return copied
+}
+
+// EquipmentLogs returns the equipment log entries created so far, oldest first.
+func (s *Server) EquipmentLogs() []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]interface{}(nil), s.logs...)
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if r.ParseForm() != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+	if r.PostForm.Get("client_id") != s.ClientID || subtle.ConstantTimeCompare([]byte(r.PostForm.Get("client_secret")), []byte(s.ClientSecret)) != 1 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	token := randomToken()
+	s.mu.Lock()
+	s.tokens[token] = time.Now().Add(tokenLifetime)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int(tokenLifetime.Seconds()),
+		"created_at":   time.Now().Unix(),
+	})
+}
+
+// authorized checks the bearer token, the company header and the project like Procore
+// does, and holds the lock for the handler.
+func (s *Server) authorized(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if expiresAt, ok := s.tokens[token]; !ok || time.Now().After(expiresAt) {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"errors": "invalid or expired access token"})
+			return
+		}
+		if r.Header.Get("Procore-Company-Id") != strconv.FormatInt(s.CompanyID, 10) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"errors": "unknown company"})
+			return
+		}
+		if r.PathValue("project_id") != strconv.FormatInt(s.ProjectID, 10) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"errors": "project not found"})
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (s *Server) listEquipment(w http.ResponseWriter, r *http.Request) {
+	page, perPage := pageParam(r, "page", 1), pageParam(r, "per_page", defaultPerPage)
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+
+	ids := make([]int64, 0, len(s.equipment))
+	for id := range s.equipment {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	items := make([]map[string]interface{}, 0, perPage)
+	for i := (page - 1) * perPage; i < len(ids) && len(items) < perPage; i++ {
+		items = append(items, s.equipment[ids[i]])
+	}
+	w.Header().Set("Total", strconv.Itoa(len(ids)))
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) createEquipment(w http.ResponseWriter, r *http.Request) {
+	fields, ok := decodeObject(w, r, "managed_equipment")
+	if !ok {
+		return
+	}
+	if name, _ := fields["name"].(string); strings.TrimSpace(name) == "" {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"errors": "name can't be blank"})
+		return
+	}
+	writeJSON(w, http.StatusCreated, s.create(fields))
+}
+
+func (s *Server) getEquipment(w http.ResponseWriter, r *http.Request) {
+	equipment, ok := s.find(w, r)
+	if ok {
+		writeJSON(w, http.StatusOK, equipment)
+	}
+}
+
+func (s *Server) updateEquipment(w http.ResponseWriter, r *http.Request) {
+	equipment, ok := s.find(w, r)
+	if !ok {
+		return
+	}
+	fields, ok := decodeObject(w, r, "managed_equipment")
+	if !ok {
+		return
+	}
+	s.update(equipment, fields)
+	writeJSON(w, http.StatusOK, equipment)
+}
+
+func (s *Server) deleteEquipment(w http.ResponseWriter, r *http.Request) {
+	equipment, ok := s.find(w, r)
+	if !ok {
+		return
+	}
+	delete(s.equipment, equipment["id"].(int64))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listLogs(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.logs)
+}
+
+func (s *Server) createLog(w http.ResponseWriter, r *http.Request) {
+	fields, ok := decodeObject(w, r, "equipment_log")
+	if !ok {
+		return
+	}
+	equipmentID, _ := fields["managed_equipment_id"].(float64)
+	if _, found := s.equipment[int64(equipmentID)]; !found {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"errors": "managed equipment not found"})
+		return
+	}
+
+	s.nextID++
+	fields["id"] = s.nextID
+	fields["created_at"] = time.Now().UTC().Format(time.RFC3339)
+	s.logs = append(s.logs, fields)
+	log.Printf("equipment log %d for managed equipment %d: %v", s.nextID, int64(equipmentID), fields["notes"])
+	writeJSON(w, http.StatusCreated, fields)
+}
+
+// create stores new managed equipment; the caller holds the lock.
+func (s *Server) create(fields map[string]interface{}) map[string]interface{} {
+	s.nextID++
+	now := time.Now().UTC().Format(time.RFC3339)
+	fields["id"] = s.nextID
+	fields["created_at"] = now
+	fields["updated_at"] = now
+	s.equipment[s.nextID] = fields
+	return fields
+}
+
+// update applies changed fields; the caller holds the lock.
+func (s *Server) update(equipment, fields map[string]interface{}) {
+	for field, value := range fields {
+		if field != "id" && field != "created_at" && field != "updated_at" {
+			equipment[field] = value
+		}
+	}
+	equipment["updated_at"] = time.Now().UTC().Format(time.RFC3339)
+}
+
+func (s *Server) find(w http.ResponseWriter, r *http.Request) (map[string]interface{}, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	equipment, found := s.equipment[id]
+	if err != nil || !found {
+		writeJSON(w, http.StatusNotFound, map[string]string{"errors": "managed equipment not found"})
+		return nil, false
+	}
+	return equipment, true
+}
+
+// decodeObject reads a body of the form {"<root>": {...}}.
+func decodeObject(w http.ResponseWriter, r *http.Request, root string) (map[string]interface{}, bool) {
+	var body map[string]map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body[root] == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"errors": "body must be a JSON object with a " + root + " object"})
+		return nil, false
+	}
+	return body[root], true
+}
+
+func pageParam(r *http.Request, name string, fallback int) int {
+	value, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil || value < 1 {
+		return fallback
+	}
+	return value
+}
+
+func randomToken() string {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
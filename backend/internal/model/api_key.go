@@ -0,0 +1,45 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+type APIKey struct {
+	ID             uuid.UUID `gorm:"primaryKey"`
+	OrganizationID uuid.UUID
+	Name           string
+	KeyPrefix      string
+	KeyHash        string
+	Permissions    json.RawMessage `gorm:"type:jsonb"`
+	ExpiresAt      *time.Time
+	LastUsedAt     *time.Time
+	LastUsedIP     *string `gorm:"column:last_used_ip;type:inet"`
+	RevokedAt      *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	CreatedBy      uuid.UUID
+	UpdatedBy      *uuid.UUID
+}
+
+func (APIKey) TableName() string {
+	return "equipchain.api_keys"
+}
+
+// PermissionList decodes the JSONB permissions array.
+func (k *APIKey) PermissionList() ([]string, error) {
+	var permissions []string
+	if len(k.Permissions) == 0 {
+		return permissions, nil
+	}
+	if err := json.Unmarshal(k.Permissions, &permissions); err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// Usable reports whether the key is neither revoked nor expired at t.
+func (k *APIKey) Usable(t time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || t.Before(*k.ExpiresAt))
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+type AuditLog struct {
+	ID             uuid.UUID `gorm:"primaryKey"`
+	OrganizationID uuid.UUID
+	UserID         *uuid.UUID
+
+	EntityType string
+	EntityID   uuid.UUID
+	Action     string
+
+	ChangesBefore json.RawMessage `gorm:"type:jsonb"`
+	ChangesAfter  json.RawMessage `gorm:"type:jsonb"`
+
+	IPAddress *string `gorm:"column:ip_address"`
+	UserAgent *string
+
+	CreatedAt time.Time
+}
+
+func (AuditLog) TableName() string {
+	return "equipchain.audit_log"
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type CalendarFeedToken struct {
+	ID             uuid.UUID `gorm:"primaryKey"`
+	OrganizationID uuid.UUID
+	UserID         uuid.UUID
+	TokenPrefix    string
+	TokenHash      string
+	LastUsedAt     *time.Time
+	CreatedAt      time.Time
+}
+
+func (CalendarFeedToken) TableName() string {
+	return "equipchain.calendar_feed_tokens"
+}
+
+// Kinds of calendar feed events.
+const (
+	CalendarEventSchedule   = "schedule"
+	CalendarEventAssignment = "assignment"
+)
+
+// CalendarEvent is a schedule's next due date or an open assignment's due date, with the
+// equipment it concerns. ScheduleID is set for schedules; RecordID, AssigneeID and
+// AssigneeEmail for assignments.
+type CalendarEvent struct {
+	Kind              string
+	ID                uuid.UUID
+	ScheduleID        *uuid.UUID
+	RecordID          *uuid.UUID
+	AssigneeID        *uuid.UUID
+	AssigneeEmail     *string
+	EquipmentID       uuid.UUID
+	EquipmentName     string
+	SerialNumber      string
+	Location          *string
+	MaintenanceTypeID int16
+	MaintenanceType   string
+	DueDate           time.Time
+	NextDueTrigger    *string
+	IsOverdue         bool
+	UpdatedAt         time.Time
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DomainEvent is a published domain event as kept for the event stream. ID orders the
+// events and is their SSE event id.
+type DomainEvent struct {
+	ID             int64 `gorm:"primaryKey"`
+	EventID        uuid.UUID
+	OrganizationID uuid.UUID
+	EventType      string
+	Data           json.RawMessage `gorm:"type:jsonb"`
+	OccurredAt     time.Time
+	CreatedAt      time.Time
+}
+
+func (DomainEvent) TableName() string {
+	return "equipchain.domain_events"
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Email types accepted by email_queue.email_type.
+const (
+	EmailTypeOrganizationInvitation = "organization_invitation"
+	EmailTypePasswordReset          = "password_reset"
+	EmailTypeLicenseExpirationAlert = "license_expiration_alert"
+	EmailTypeTechnicianAssigned     = "technician_assigned"
+	EmailTypeOverdueMaintenance     = "overdue_maintenance_alert"
+)
+
+type EmailQueueEntry struct {
+	ID             uuid.UUID `gorm:"primaryKey"`
+	OrganizationID uuid.UUID
+	RecipientEmail string
+	EmailType      string
+	TemplateData   json.RawMessage `gorm:"type:jsonb"`
+	Status         string
+	RetryCount     int16
+	LastAttemptAt  *time.Time
+	ErrorMessage   *string
+	CreatedAt      time.Time
+	SentAt         *time.Time
+}
+
+func (EmailQueueEntry) TableName() string {
+	return "equipchain.email_queue"
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of inbound_webhooks.
+const (
+	InboundWebhookReceived  = "received"
+	InboundWebhookProcessed = "processed"
+	InboundWebhookUnhandled = "unhandled"
+	InboundWebhookFailed    = "failed"
+)
+
+// Inbound event types mapped onto maintenance records.
+const (
+	InboundEventMaintenanceAcknowledged = "maintenance.acknowledged"
+	InboundEventClaimUpdated            = "claim.updated"
+)
+
+// Types accepted by maintenance_external_references.reference_type.
+const (
+	ExternalReferenceAcknowledgement = "acknowledgement"
+	ExternalReferenceClaim           = "claim"
+)
+
+type InboundWebhook struct {
+	ID                  uuid.UUID `gorm:"primaryKey"`
+	OrganizationID      uuid.UUID
+	IntegrationID       uuid.UUID
+	Nonce               string
+	SignedAt            time.Time
+	EventType           *string
+	ExternalID          *string
+	Body                string
+	Status              string
+	Error               *string
+	MaintenanceRecordID *uuid.UUID
+	ReceivedAt          time.Time
+	ProcessedAt         *time.Time
+}
+
+func (InboundWebhook) TableName() string {
+	return "equipchain.inbound_webhooks"
+}
+
+type MaintenanceExternalReference struct {
+	ID                  uuid.UUID `gorm:"primaryKey"`
+	OrganizationID      uuid.UUID
+	MaintenanceRecordID uuid.UUID
+	IntegrationID       uuid.UUID
+	ReferenceType       string
+	Reference           string
+	Status              *string
+	InboundWebhookID    *uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+func (MaintenanceExternalReference) TableName() string {
+	return "equipchain.maintenance_external_references"
+}
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+// OrganizationIntegrationErrorLimit is the number of consecutive failed calls after which
+// an integration is deactivated.
+const OrganizationIntegrationErrorLimit = 10
+
+// Integration types accepted by organizations_integrations.integration_type.
+const (
+	IntegrationTypeWebhook      = "webhook"
+	IntegrationTypeInsuranceAPI = "insurance_api"
+	IntegrationTypeProcore      = "procore"
+	IntegrationTypeAutodesk     = "autodesk"
+	IntegrationTypeSalesforce   = "crm_salesforce"
+	IntegrationTypeSlack        = "slack"
+)
+
+type OrganizationIntegration struct {
+	ID              uuid.UUID `gorm:"primaryKey"`
+	OrganizationID  uuid.UUID
+	IntegrationType string
+	IntegrationName *string
+	// APIKey, APISecret and the webhook secrets are plaintext here; IntegrationRepository
+	// encrypts them in the database.
+	APIKey        *string `gorm:"column:api_key_encrypted"`
+	APISecret     *string `gorm:"column:api_secret_encrypted"`
+	WebhookURL    *string
+	WebhookSecret *string
+	// EventTypes is a JSONB array of subscribed event types; empty subscribes to all.
+	EventTypes json.RawMessage `gorm:"type:jsonb"`
+	// Settings is a JSONB object of type-specific, non-secret configuration.
+	Settings                json.RawMessage `gorm:"type:jsonb"`
+	PreviousWebhookSecret   *string
+	PreviousSecretExpiresAt *time.Time
+	IsActive                bool
+	TestMode                bool
+	LastWebhookCall         *time.Time
+	WebhookCallCount        int
+	LastError               *string
+	ErrorCount              int
+	CreatedAt               time.Time
+	UpdatedAt               time.Time
+	CreatedBy               *uuid.UUID
+	UpdatedBy               *uuid.UUID
+}
+
+func (OrganizationIntegration) TableName() string {
+	return "equipchain.organizations_integrations"
+}
+
+// EventTypeList decodes the JSONB event_types array.
+func (i *OrganizationIntegration) EventTypeList() ([]string, error) {
+	var eventTypes []string
+	if len(i.EventTypes) == 0 {
+		return eventTypes, nil
+	}
+	if err := json.Unmarshal(i.EventTypes, &eventTypes); err != nil {
+		return nil, err
+	}
+	return eventTypes, nil
+}
+
+// WebhookSecrets returns the secrets deliveries are signed with at t: the current one and,
+// during a rotation grace window, the previous one.
+func (i *OrganizationIntegration) WebhookSecrets(t time.Time) []string {
+	var secrets []string
+	if i.WebhookSecret != nil && *i.WebhookSecret != "" {
+		secrets = append(secrets, *i.WebhookSecret)
+	}
+	if i.PreviousWebhookSecret != nil && *i.PreviousWebhookSecret != "" && i.PreviousSecretExpiresAt != nil && t.Before(*i.PreviousSecretExpiresAt) {
+		secrets = append(secrets, *i.PreviousWebhookSecret)
+	}
+	return secrets
+}
+
+// WebhookEventPing is the event type of test deliveries.
+const WebhookEventPing = "ping"
+
+// Statuses of webhook_deliveries.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is a domain event queued for an integration's webhook_url.
+type WebhookDelivery struct {
+	ID             uuid.UUID `gorm:"primaryKey"`
+	OrganizationID uuid.UUID
+	IntegrationID  uuid.UUID
+	EventID        uuid.UUID
+	EventType      string
+	Payload        json.RawMessage `gorm:"type:jsonb"`
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastAttemptAt  *time.Time
+	ResponseStatus *int
+	LastError      *string
+	DeliveredAt    *time.Time
+	RequestURL     *string
+	ResponseBody   *string
+	LatencyMS      *int `gorm:"column:latency_ms"`
+	RedeliveryOf   *uuid.UUID
+	CreatedAt      time.Time
+}
+
+func (WebhookDelivery) TableName() string {
+	return "equipchain.webhook_deliveries"
+}
+
+// WebhookAttempt is the outcome of one delivery attempt. Error is nil on success.
+type WebhookAttempt struct {
+	RequestURL     *string
+	ResponseStatus *int
+	ResponseBody   *string
+	LatencyMS      *int
+	Error          *string
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Phases of integration_sync_states, in the order a sync cycle runs them.
+const (
+	SyncPhasePullEquipment   = "pull_equipment"
+	SyncPhasePushEquipment   = "push_equipment"
+	SyncPhasePushMaintenance = "push_maintenance"
+)
+
+// IntegrationSyncState is the checkpoint of an integration's sync job.
+type IntegrationSyncState struct {
+	IntegrationID  uuid.UUID `gorm:"primaryKey"`
+	OrganizationID uuid.UUID
+	RemoteScope    string
+
+	Phase      string
+	RemotePage int
+
+	EquipmentCursorAt   *time.Time
+	EquipmentCursorID   *uuid.UUID
+	MaintenanceCursorAt time.Time
+	MaintenanceCursorID *uuid.UUID
+
+	CycleStartedAt  *time.Time
+	CycleCounts     json.RawMessage `gorm:"type:jsonb"`
+	LastCompletedAt *time.Time
+	LastError       *string
+	LockedUntil     *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (IntegrationSyncState) TableName() string {
+	return "equipchain.integration_sync_states"
+}
+
+// IntegrationEquipmentLink is local equipment matched to a remote record.
+type IntegrationEquipmentLink struct {
+	ID             uuid.UUID `gorm:"primaryKey"`
+	OrganizationID uuid.UUID
+	IntegrationID  uuid.UUID
+	EquipmentID    uuid.UUID
+
+	ExternalID int64
+	// RemoteValues holds the last seen remote values of the pulled fields, by local field.
+	RemoteValues json.RawMessage `gorm:"type:jsonb"`
+
+	PulledAt  *time.Time
+	PushedAt  *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (IntegrationEquipmentLink) TableName() string {
+	return "equipchain.integration_equipment_links"
+}
+
+// ConfirmedMaintenance is a confirmed maintenance record of linked equipment, as pushed to
+// the remote system.
+type ConfirmedMaintenance struct {
+	ID              uuid.UUID
+	EquipmentID     uuid.UUID
+	ExternalID      int64
+	MaintenanceType string
+	Notes           *string
+	ConfirmedAt     time.Time
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type Invitation struct {
+	ID             uuid.UUID `gorm:"primaryKey"`
+	OrganizationID uuid.UUID
+	Email          string
+	RoleID         int16
+	ExpiresAt      time.Time
+	AcceptedAt     *time.Time
+	AcceptedUserID *uuid.UUID
+	RevokedAt      *time.Time
+	InvitedBy      uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	UpdatedBy      *uuid.UUID
+}
+
+func (Invitation) TableName() string {
+	return "equipchain.organization_invitations"
+}
+
+// Pending reports whether the invitation can still be accepted at t.
+func (i *Invitation) Pending(t time.Time) bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && t.Before(i.ExpiresAt)
+}
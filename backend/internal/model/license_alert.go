@@ -0,0 +1,23 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// LicenseAlertExpired is the threshold recorded when a license has actually expired.
+const LicenseAlertExpired = 0
+
+type LicenseExpirationAlert struct {
+	ID                    uuid.UUID `gorm:"primaryKey"`
+	OrganizationID        uuid.UUID
+	TechnicianUserID      uuid.UUID
+	LicenseExpirationDate time.Time
+	ThresholdDays         int
+	RecipientCount        int
+	CreatedAt             time.Time
+}
+
+func (LicenseExpirationAlert) TableName() string {
+	return "equipchain.license_expiration_alerts"
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Maintenance status IDs seeded into maintenance_status_lookup.
+const (
+	MaintenanceStatusDraft     int16 = 1
+	MaintenanceStatusSubmitted int16 = 2
+	MaintenanceStatusApproved  int16 = 3
+	MaintenanceStatusConfirmed int16 = 4
+	MaintenanceStatusRejected  int16 = 5
+)
+
+// Actions accepted by maintenance_approval_audit.action.
+const (
+	ApprovalActionApproved = "approved"
+	ApprovalActionRejected = "rejected"
+)
+
+type MaintenanceRecord struct {
+	ID                uuid.UUID `gorm:"primaryKey"`
+	OrganizationID    uuid.UUID
+	EquipmentID       uuid.UUID
+	MaintenanceTypeID int16
+	StatusID          int16
+
+	TechnicianID uuid.UUID
+	SupervisorID *uuid.UUID
+	InspectorID  *uuid.UUID
+
+	Notes *string
+
+	GPSLatitude  *float64 `gorm:"column:gps_latitude"`
+	GPSLongitude *float64 `gorm:"column:gps_longitude"`
+
+	SolanaSignature *string
+
+	SubmittedAt *time.Time
+	ApprovedAt  *time.Time
+	ConfirmedAt *time.Time
+	RejectedAt  *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	CreatedBy *uuid.UUID
+	UpdatedBy *uuid.UUID
+}
+
+func (MaintenanceRecord) TableName() string {
+	return "equipchain.maintenance_records"
+}
+
+type MaintenanceApprovalAudit struct {
+	ID                  uuid.UUID `gorm:"primaryKey"`
+	MaintenanceRecordID uuid.UUID
+	OrganizationID      uuid.UUID
+	ApproverID          uuid.UUID
+	Action              string
+	Comments            *string
+	ApprovalSequence    int16
+	AuthFactor          *string
+	SigningTokenID      *uuid.UUID
+	CreatedAt           time.Time
+	IPAddress           *string `gorm:"column:ip_address"`
+	UserAgent           *string
+}
+
+func (MaintenanceApprovalAudit) TableName() string {
+	return "equipchain.maintenance_approval_audit"
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Kinds of maintenance schedule alerts. Each is sent once per due date and stamped on the
+// schedule (due_soon_alert_sent_at, overdue_alert_sent_at, overdue_escalated_at).
+const (
+	MaintenanceAlertDueSoon    = "due_soon"
+	MaintenanceAlertOverdue    = "overdue"
+	MaintenanceAlertEscalation = "escalation"
+)
+
+// MaintenanceAlert is a schedule that needs an alert, with its equipment.
+type MaintenanceAlert struct {
+	ScheduleID        uuid.UUID
+	EquipmentID       uuid.UUID
+	EquipmentName     string
+	SerialNumber      string
+	OwnerID           *uuid.UUID
+	MaintenanceTypeID int16
+	MaintenanceType   string
+	NextDueDate       time.Time
+	NextDueTrigger    *string
+	DaysUntilDue      int
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+type MaintenanceAssignment struct {
+	ID                  uuid.UUID `gorm:"primaryKey"`
+	OrganizationID      uuid.UUID
+	MaintenanceRecordID uuid.UUID
+	TechnicianID        uuid.UUID
+	AssignedBy          *uuid.UUID
+	DueDate             *time.Time
+	Notes               *string
+	CreatedAt           time.Time
+	UnassignedAt        *time.Time
+	UnassignedBy        *uuid.UUID
+}
+
+func (MaintenanceAssignment) TableName() string {
+	return "equipchain.maintenance_assignments"
+}
+
+// AssignmentCandidate is an available technician with a valid license, with their open
+// assignment load and last GPS fix (nil if they never recorded one).
+type AssignmentCandidate struct {
+	TechnicianID    uuid.UUID
+	Email           string
+	Certifications  json.RawMessage
+	HourlyRate      *float64
+	OpenAssignments int
+	LastLatitude    *float64
+	LastLongitude   *float64
+	LastFixAt       *time.Time
+}
+
+// GPSFix is a position recorded on a maintenance record.
+type GPSFix struct {
+	Latitude   float64
+	Longitude  float64
+	RecordedAt time.Time
+}
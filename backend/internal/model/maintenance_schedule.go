@@ -0,0 +1,58 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Meter types accepted by equipment_maintenance_schedule.meter_type and
+// equipment_meter_readings.meter_type.
+const (
+	MeterTypeHours  = "hours"
+	MeterTypeMiles  = "miles"
+	MeterTypeCycles = "cycles"
+)
+
+type MaintenanceSchedule struct {
+	ID                     uuid.UUID `gorm:"primaryKey"`
+	EquipmentID            uuid.UUID
+	OrganizationID         uuid.UUID
+	MaintenanceTypeID      int16
+	ScheduledFrequencyDays *int
+	CalendarRule           *string
+	CalendarRuleStart      *time.Time
+	MeterType              *string
+	MeterInterval          *float64
+	LastMaintenanceDate    *time.Time
+	LastServiceMeterValue  *float64
+	NextDueDate            *time.Time
+	NextDueMeterValue      *float64
+	NextDueTrigger         *string
+	OverdueAlertSentAt     *time.Time
+	DueSoonAlertSentAt     *time.Time
+	OverdueEscalatedAt     *time.Time
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+	CreatedBy              *uuid.UUID
+	UpdatedBy              *uuid.UUID
+
+	// IsOverdue is read through is_maintenance_overdue() and never written. A schedule
+	// without a due date is not overdue.
+	IsOverdue bool `gorm:"->"`
+}
+
+func (MaintenanceSchedule) TableName() string {
+	return "equipchain.equipment_maintenance_schedule"
+}
+
+// DueMaintenance is a row of get_equipment_due_for_maintenance().
+type DueMaintenance struct {
+	ScheduleID        uuid.UUID
+	EquipmentID       uuid.UUID
+	EquipmentName     string
+	MaintenanceTypeID int16
+	MaintenanceType   string
+	NextDueDate       time.Time
+	DaysUntilDue      int
+	IsOverdue         bool
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Sources accepted by equipment_meter_readings.source.
+const (
+	MeterReadingSourceManual      = "manual"
+	MeterReadingSourceMaintenance = "maintenance"
+)
+
+type MeterReading struct {
+	ID                  uuid.UUID `gorm:"primaryKey"`
+	OrganizationID      uuid.UUID
+	EquipmentID         uuid.UUID
+	MeterType           string
+	Value               float64
+	ReadAt              time.Time
+	IsReset             bool
+	Source              string
+	MaintenanceRecordID *uuid.UUID
+	Notes               *string
+	RecordedBy          *uuid.UUID
+	CreatedAt           time.Time
+}
+
+func (MeterReading) TableName() string {
+	return "equipchain.equipment_meter_readings"
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type UserTOTP struct {
+	UserID          uuid.UUID `gorm:"primaryKey"`
+	SecretEncrypted string
+	ConfirmedAt     *time.Time
+	LastUsedStep    int64
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (UserTOTP) TableName() string {
+	return "equipchain.user_mfa_totp"
+}
+
+// Confirmed reports whether enrollment finished and MFA is active for the user.
+func (t *UserTOTP) Confirmed() bool {
+	return t.ConfirmedAt != nil
+}
+
+type UserRecoveryCode struct {
+	ID        uuid.UUID `gorm:"primaryKey"`
+	UserID    uuid.UUID
+	CodeHash  string
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (UserRecoveryCode) TableName() string {
+	return "equipchain.user_mfa_recovery_codes"
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+type OIDCProvider struct {
+	OrganizationID        uuid.UUID `gorm:"primaryKey"`
+	IssuerURL             string    `gorm:"column:issuer_url"`
+	ClientID              string
+	ClientSecretEncrypted string
+	Scopes                string
+	AllowedEmailDomains   json.RawMessage `gorm:"type:jsonb"`
+	DefaultRoleID         int16
+	RoleClaim             *string
+	RoleMapping           json.RawMessage `gorm:"type:jsonb"`
+	Enabled               bool
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	CreatedBy             *uuid.UUID
+	UpdatedBy             *uuid.UUID
+}
+
+func (OIDCProvider) TableName() string {
+	return "equipchain.organization_oidc_providers"
+}
+
+// DomainList decodes the allowed_email_domains JSON array.
+func (p *OIDCProvider) DomainList() ([]string, error) {
+	var domains []string
+	if len(p.AllowedEmailDomains) == 0 {
+		return domains, nil
+	}
+	if err := json.Unmarshal(p.AllowedEmailDomains, &domains); err != nil {
+		return nil, err
+	}
+	return domains, nil
+}
+
+// RoleMap decodes the role_mapping JSON object of claim value to role ID.
+func (p *OIDCProvider) RoleMap() (map[string]int16, error) {
+	mapping := map[string]int16{}
+	if len(p.RoleMapping) == 0 {
+		return mapping, nil
+	}
+	if err := json.Unmarshal(p.RoleMapping, &mapping); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+type OIDCLoginState struct {
+	State          string `gorm:"primaryKey"`
+	OrganizationID uuid.UUID
+	Nonce          string
+	CodeVerifier   string
+	ExpiresAt      time.Time
+	CreatedAt      time.Time
+}
+
+func (OIDCLoginState) TableName() string {
+	return "equipchain.oidc_login_states"
+}
+
+type UserIdentity struct {
+	ID             uuid.UUID `gorm:"primaryKey"`
+	UserID         uuid.UUID
+	OrganizationID uuid.UUID
+	Issuer         string
+	Subject        string
+	Email          *string
+	LastLoginAt    *time.Time
+	CreatedAt      time.Time
+}
+
+func (UserIdentity) TableName() string {
+	return "equipchain.user_identities"
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type Organization struct {
+	ID               uuid.UUID `gorm:"primaryKey"`
+	Code             string
+	Name             string
+	Description      *string
+	Status           string
+	SuspendedAt      *time.Time
+	SuspensionReason *string
+	DeletedAt        *time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	CreatedBy        *uuid.UUID
+	UpdatedBy        *uuid.UUID
+}
+
+func (Organization) TableName() string {
+	return "equipchain.organizations"
+}
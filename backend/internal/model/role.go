@@ -0,0 +1,63 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Role IDs seeded into role_lookup. Kept in sync with migrations/003_seed_data.sql.
+const (
+	RoleAdmin      int16 = 1
+	RoleSupervisor int16 = 2
+	RoleTechnician int16 = 3
+	RoleViewer     int16 = 4
+)
+
+// Permission strings stored in role_lookup.permissions. A granted "*" matches everything
+// and a "*" segment matches any value in that position, e.g. "create:*".
+const (
+	PermissionViewEquipment      = "view:equipment"
+	PermissionCreateEquipment    = "create:equipment"
+	PermissionUpdateEquipment    = "update:equipment"
+	PermissionDeleteEquipment    = "delete:equipment"
+	PermissionCreateMaintenance  = "create:maintenance"
+	PermissionApproveMaintenance = "approve:maintenance"
+	PermissionManageUsers        = "manage:users"
+	PermissionManageOrganization = "manage:organization"
+	PermissionViewReports        = "view:reports"
+	PermissionRecordMeters       = "record:meters"
+	// PermissionConfirmMaintenance lets the blockchain confirmation worker report on-chain
+	// transactions. No role is granted it; an admin gives it to the worker's API key.
+	PermissionConfirmMaintenance = "confirm:maintenance"
+)
+
+type Role struct {
+	ID                   int16 `gorm:"primaryKey"`
+	Code                 string
+	Label                string
+	Description          *string
+	Permissions          json.RawMessage `gorm:"type:jsonb"`
+	PermissionPrecedence int16
+	Status               string
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	CreatedBy            *uuid.UUID
+	UpdatedBy            *uuid.UUID
+}
+
+func (Role) TableName() string {
+	return "equipchain.role_lookup"
+}
+
+// PermissionList decodes the JSONB permissions array. NULL means no permissions.
+func (r *Role) PermissionList() ([]string, error) {
+	if len(r.Permissions) == 0 {
+		return nil, nil
+	}
+	var permissions []string
+	if err := json.Unmarshal(r.Permissions, &permissions); err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
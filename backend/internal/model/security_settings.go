@@ -0,0 +1,51 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Registration modes stored in organization_security_settings.registration_mode.
+const (
+	RegistrationModeInviteOnly = "invite_only"
+	RegistrationModeDomain     = "domain"
+)
+
+type OrganizationSecuritySettings struct {
+	OrganizationID uuid.UUID `gorm:"primaryKey"`
+
+	PasswordMinLength        int16
+	PasswordRequireUppercase bool
+	PasswordRequireLowercase bool
+	PasswordRequireDigit     bool
+	PasswordRequireSymbol    bool
+	PasswordHistoryCount     int16
+	PasswordRejectCommon     bool
+
+	RequireMFAForApprovers bool `gorm:"column:require_mfa_for_approvers"`
+
+	RegistrationMode   string
+	SignupEmailDomains json.RawMessage `gorm:"type:jsonb"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	CreatedBy *uuid.UUID
+	UpdatedBy *uuid.UUID
+}
+
+func (OrganizationSecuritySettings) TableName() string {
+	return "equipchain.organization_security_settings"
+}
+
+// SignupDomainList decodes the signup_email_domains JSON array.
+func (s *OrganizationSecuritySettings) SignupDomainList() ([]string, error) {
+	var domains []string
+	if len(s.SignupEmailDomains) == 0 {
+		return domains, nil
+	}
+	if err := json.Unmarshal(s.SignupEmailDomains, &domains); err != nil {
+		return nil, err
+	}
+	return domains, nil
+}
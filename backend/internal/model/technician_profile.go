@@ -0,0 +1,69 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+type TechnicianProfile struct {
+	ID                    uuid.UUID `gorm:"primaryKey"`
+	UserID                uuid.UUID
+	OrganizationID        uuid.UUID
+	LicenseNumber         *string
+	LicenseType           *string
+	LicenseState          *string
+	LicenseIssuedDate     *time.Time
+	LicenseExpirationDate *time.Time
+	Certifications        json.RawMessage `gorm:"type:jsonb"`
+	IsAvailable           bool
+	HourlyRate            *float64
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	CreatedBy             *uuid.UUID
+	UpdatedBy             *uuid.UUID
+}
+
+func (TechnicianProfile) TableName() string {
+	return "equipchain.technician_profiles"
+}
+
+// CertificationList decodes the JSONB certifications array. NULL means none.
+func (p *TechnicianProfile) CertificationList() ([]string, error) {
+	if len(p.Certifications) == 0 {
+		return []string{}, nil
+	}
+	certifications := []string{}
+	if err := json.Unmarshal(p.Certifications, &certifications); err != nil {
+		return nil, err
+	}
+	return certifications, nil
+}
+
+// LicenseExpired reports whether the license expired before the given day. A license
+// is valid through its expiration date; profiles without an expiration never expire.
+func (p *TechnicianProfile) LicenseExpired(today time.Time) bool {
+	if p.LicenseExpirationDate == nil {
+		return false
+	}
+	y, m, d := today.Date()
+	return p.LicenseExpirationDate.Before(time.Date(y, m, d, 0, 0, 0, 0, time.UTC))
+}
+
+// CertifiedTechnician is a row of get_technicians_by_certification().
+type CertifiedTechnician struct {
+	TechnicianID  uuid.UUID
+	LicenseNumber *string
+	LicenseType   *string
+	IsAvailable   bool
+	HourlyRate    *float64
+}
+
+// ExpiringLicense is a row of get_expiring_licenses().
+type ExpiringLicense struct {
+	TechnicianID          uuid.UUID
+	LicenseNumber         *string
+	LicenseType           *string
+	LicenseExpirationDate time.Time
+	DaysUntilExpiry       int
+}
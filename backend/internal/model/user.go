@@ -2,7 +2,6 @@ package model
 
 import (
 	"github.com/google/uuid"
-	"net"
 	"time"
 )
 
@@ -19,9 +18,14 @@ type User struct {
 	FailedLoginAttempts             int16
 	LastFailedLoginAt               *time.Time
 	LockedUntil                     *time.Time
+	LockoutCount                    int16
 	LastLoginAt                     *time.Time
-	LastLoginIP                     *net.IP
+	LastLoginIP                     *string `gorm:"column:last_login_ip"`
 	PasswordChangedAt               time.Time
+	PasswordResetRequired           bool
+	PasswordResetTokenHash          *string
+	PasswordResetExpiresAt          *time.Time
+	IsPlatformAdmin                 bool
 	Status                          string
 	CreatedAt                       time.Time
 	UpdatedAt                       time.Time
@@ -32,3 +36,14 @@ type User struct {
 func (User) TableName() string {
 	return "equipchain.users"
 }
+
+type UserPasswordHistory struct {
+	ID           uuid.UUID `gorm:"primaryKey"`
+	UserID       uuid.UUID
+	PasswordHash string
+	SetAt        time.Time
+}
+
+func (UserPasswordHistory) TableName() string {
+	return "equipchain.user_password_history"
+}
@@ -0,0 +1,248 @@
+// Package procore is a minimal client of the Procore REST API: OAuth client credentials,
+// a project's managed equipment and its equipment logs.
+package procore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBaseURL is Procore's production API.
+const DefaultBaseURL = "https://api.procore.com"
+
+const (
+	// CompanyHeader selects the company of every REST call.
+	CompanyHeader = "Procore-Company-Id"
+
+	// Tokens are refreshed this long before they expire.
+	tokenExpiryMargin = time.Minute
+	// maxErrorBodyBytes of an error response are kept in APIError.
+	maxErrorBodyBytes = 512
+)
+
+// Equipment is a managed equipment record. It is kept as a map so that any of its fields
+// can be mapped onto local equipment.
+type Equipment map[string]interface{}
+
+// ID returns the record's id, or 0 if it has none.
+func (e Equipment) ID() int64 {
+	switch id := e["id"].(type) {
+	case float64:
+		return int64(id)
+	case json.Number:
+		parsed, _ := id.Int64()
+		return parsed
+	}
+	return 0
+}
+
+// Value returns a field as text: strings as they are, numbers and booleans formatted,
+// objects and arrays as JSON. It reports false if the field is missing or null.
+func (e Equipment) Value(field string) (string, bool) {
+	switch value := e[field].(type) {
+	case nil:
+		return "", false
+	case string:
+		return value, true
+	case json.Number:
+		return value.String(), true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(value), true
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", false
+		}
+		return string(encoded), true
+	}
+}
+
+// EquipmentLog is an entry of a project's equipment log.
+type EquipmentLog struct {
+	ManagedEquipmentID int64  `json:"managed_equipment_id"`
+	Date               string `json:"date"`
+	Notes              string `json:"notes"`
+}
+
+// APIError is a non-2xx response.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("procore returned %d: %s", e.StatusCode, e.Body)
+}
+
+// Client calls the API of one company with one OAuth application's credentials. It is
+// safe for concurrent use.
+type Client struct {
+	baseURL      string
+	companyID    int64
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func NewClient(httpClient *http.Client, baseURL string, companyID int64, clientID, clientSecret string) *Client {
+	return &Client{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		companyID:    companyID,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		httpClient:   httpClient,
+	}
+}
+
+// ListEquipment returns a page (from 1) of the project's managed equipment. A page shorter
+// than perPage is the last.
+func (c *Client) ListEquipment(ctx context.Context, projectID int64, page, perPage int) ([]Equipment, error) {
+	query := url.Values{"page": {strconv.Itoa(page)}, "per_page": {strconv.Itoa(perPage)}}
+	var equipment []Equipment
+	err := c.do(ctx, http.MethodGet, c.equipmentPath(projectID)+"?"+query.Encode(), nil, &equipment)
+	return equipment, err
+}
+
+// CreateEquipment adds managed equipment with the given fields and returns it.
+func (c *Client) CreateEquipment(ctx context.Context, projectID int64, fields map[string]interface{}) (Equipment, error) {
+	var created Equipment
+	err := c.do(ctx, http.MethodPost, c.equipmentPath(projectID), map[string]interface{}{"managed_equipment": fields}, &created)
+	return created, err
+}
+
+// UpdateEquipment changes the given fields of managed equipment.
+func (c *Client) UpdateEquipment(ctx context.Context, projectID, equipmentID int64, fields map[string]interface{}) (Equipment, error) {
+	var updated Equipment
+	path := c.equipmentPath(projectID) + "/" + strconv.FormatInt(equipmentID, 10)
+	err := c.do(ctx, http.MethodPatch, path, map[string]interface{}{"managed_equipment": fields}, &updated)
+	return updated, err
+}
+
+// CreateEquipmentLog adds an entry to the project's equipment log and returns its id.
+func (c *Client) CreateEquipmentLog(ctx context.Context, projectID int64, entry EquipmentLog) (int64, error) {
+	var created Equipment
+	path := fmt.Sprintf("/rest/v1.0/projects/%d/equipment_logs", projectID)
+	if err := c.do(ctx, http.MethodPost, path, map[string]interface{}{"equipment_log": entry}, &created); err != nil {
+		return 0, err
+	}
+	return created.ID(), nil
+}
+
+func (c *Client) equipmentPath(projectID int64) string {
+	return fmt.Sprintf("/rest/v1.0/projects/%d/managed_equipment", projectID)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(CompanyHeader, strconv.FormatInt(c.companyID, 10))
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		// Revoked or expired early; fetch a new token on the next call
+		c.mu.Lock()
+		c.token = ""
+		c.mu.Unlock()
+	}
+	return decodeResponse(resp, out)
+}
+
+// accessToken returns a cached token, fetching a new one with the client credentials
+// grant when it is about to expire.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.clientID},
+		"client_secret": {c.clientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := decodeResponse(resp, &token); err != nil {
+		return "", fmt.Errorf("procore token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("procore token: response has no access_token")
+	}
+
+	c.token = token.AccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - tokenExpiryMargin)
+	return c.token, nil
+}
+
+func decodeResponse(resp *http.Response, out interface{}) error {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		return &APIError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+	if out == nil {
+		return nil
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("decode procore response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) FindByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ?", organizationID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+func (r *APIKeyRepository) FindByID(ctx context.Context, organizationID, keyID uuid.UUID) (*model.APIKey, error) {
+	var key model.APIKey
+	if err := r.db.WithContext(ctx).Where("id = ? AND organization_id = ?", keyID, organizationID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *APIKeyRepository) FindByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	var key model.APIKey
+	if err := r.db.WithContext(ctx).Where("key_prefix = ?", prefix).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// Revoke marks an active key revoked. It reports false if the key was not found or was
+// already revoked.
+func (r *APIKeyRepository) Revoke(ctx context.Context, organizationID, keyID, revokedBy uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.APIKey{}).
+		Where("id = ? AND organization_id = ? AND revoked_at IS NULL", keyID, organizationID).
+		Updates(map[string]interface{}{
+			"revoked_at": gorm.Expr("NOW()"),
+			"updated_by": revokedBy,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// TouchLastUsed records a use of the key, writing at most once per minute per key.
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, keyID uuid.UUID, ipAddress string) error {
+	updates := map[string]interface{}{
+		"last_used_at": gorm.Expr("NOW()"),
+	}
+	if ipAddress != "" {
+		updates["last_used_ip"] = ipAddress
+	}
+
+	return r.db.WithContext(ctx).
+		Model(&model.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')", keyID).
+		UpdateColumns(updates).Error
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// openAssignmentStatuses are the record statuses in which an assignment still counts as
+// work to do for the technician.
+var openAssignmentStatuses = []int16{model.MaintenanceStatusDraft, model.MaintenanceStatusRejected}
+
+type AssignmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAssignmentRepository(db *gorm.DB) *AssignmentRepository {
+	return &AssignmentRepository{db: db}
+}
+
+// FindCurrent returns the record's current assignment, or nil if it was never assigned.
+func (r *AssignmentRepository) FindCurrent(ctx context.Context, recordID uuid.UUID) (*model.MaintenanceAssignment, error) {
+	var assignment model.MaintenanceAssignment
+	if err := r.db.WithContext(ctx).
+		Where("maintenance_record_id = ? AND unassigned_at IS NULL", recordID).
+		First(&assignment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// FindOpenByTechnician returns the technician's current assignments whose record is still
+// draft or rejected, earliest due date first.
+func (r *AssignmentRepository) FindOpenByTechnician(ctx context.Context, organizationID, technicianID uuid.UUID) ([]*model.MaintenanceAssignment, error) {
+	var assignments []*model.MaintenanceAssignment
+	err := r.db.WithContext(ctx).
+		Joins("JOIN equipchain.maintenance_records m ON m.id = maintenance_assignments.maintenance_record_id").
+		Where("maintenance_assignments.organization_id = ? AND maintenance_assignments.technician_id = ?", organizationID, technicianID).
+		Where("maintenance_assignments.unassigned_at IS NULL AND m.status_id IN ?", openAssignmentStatuses).
+		Order("maintenance_assignments.due_date NULLS LAST, maintenance_assignments.created_at").
+		Find(&assignments).Error
+	return assignments, err
+}
+
+// Assign closes the record's current assignment (if any), creates the new one, makes the
+// assignee the record's technician and queues the notification, all in one transaction.
+// It fails with ErrStaleMaintenanceStatus if the record is no longer draft or rejected.
+func (r *AssignmentRepository) Assign(ctx context.Context, assignment *model.MaintenanceAssignment, email *model.EmailQueueEntry, publish PublishFunc) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.MaintenanceAssignment{}).
+			Where("maintenance_record_id = ? AND unassigned_at IS NULL", assignment.MaintenanceRecordID).
+			Updates(map[string]interface{}{
+				"unassigned_at": time.Now(),
+				"unassigned_by": assignment.AssignedBy,
+			}).Error; err != nil {
+			return err
+		}
+
+		if err := updateMaintenanceStatus(tx, assignment.MaintenanceRecordID, openAssignmentStatuses,
+			map[string]interface{}{"technician_id": assignment.TechnicianID}, *assignment.AssignedBy); err != nil {
+			return err
+		}
+
+		if err := tx.Create(assignment).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(email).Error; err != nil {
+			return err
+		}
+		return publish(tx)
+	})
+}
+
+// FindCandidates returns the organization's enabled technicians who are available and
+// hold a valid license, with their open load and last GPS fix.
+func (r *AssignmentRepository) FindCandidates(ctx context.Context, organizationID uuid.UUID) ([]model.AssignmentCandidate, error) {
+	var candidates []model.AssignmentCandidate
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT tp.user_id AS technician_id, u.email, tp.certifications, tp.hourly_rate,
+		       (SELECT COUNT(*)
+		        FROM equipchain.maintenance_assignments a
+		        JOIN equipchain.maintenance_records m ON m.id = a.maintenance_record_id
+		        WHERE a.technician_id = tp.user_id AND a.unassigned_at IS NULL AND m.status_id IN ?) AS open_assignments,
+		       fix.gps_latitude AS last_latitude, fix.gps_longitude AS last_longitude, fix.created_at AS last_fix_at
+		FROM equipchain.technician_profiles tp
+		JOIN equipchain.users u ON u.id = tp.user_id
+		LEFT JOIN LATERAL (
+		    SELECT gps_latitude, gps_longitude, created_at
+		    FROM equipchain.maintenance_records
+		    WHERE technician_id = tp.user_id AND gps_latitude IS NOT NULL AND gps_longitude IS NOT NULL
+		    ORDER BY created_at DESC
+		    LIMIT 1
+		) fix ON true
+		WHERE tp.organization_id = ?
+		  AND tp.is_available
+		  AND (tp.license_expiration_date IS NULL OR tp.license_expiration_date >= CURRENT_DATE)
+		  AND u.status NOT IN ('inactive', 'deleted')`,
+		openAssignmentStatuses, organizationID).
+		Scan(&candidates).Error
+	return candidates, err
+}
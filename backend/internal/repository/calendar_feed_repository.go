@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CalendarFeedRepository struct {
+	db *gorm.DB
+}
+
+func NewCalendarFeedRepository(db *gorm.DB) *CalendarFeedRepository {
+	return &CalendarFeedRepository{db: db}
+}
+
+func (r *CalendarFeedRepository) FindByUser(ctx context.Context, userID uuid.UUID) (*model.CalendarFeedToken, error) {
+	var token model.CalendarFeedToken
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *CalendarFeedRepository) FindByPrefix(ctx context.Context, prefix string) (*model.CalendarFeedToken, error) {
+	var token model.CalendarFeedToken
+	if err := r.db.WithContext(ctx).Where("token_prefix = ?", prefix).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// Replace stores the user's token in place of the previous one, if any.
+func (r *CalendarFeedRepository) Replace(ctx context.Context, token *model.CalendarFeedToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", token.UserID).Delete(&model.CalendarFeedToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// Delete removes the user's token. It reports false if the user had none.
+func (r *CalendarFeedRepository) Delete(ctx context.Context, userID uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.CalendarFeedToken{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// TouchLastUsed records a fetch of the feed, writing at most once per minute per token.
+func (r *CalendarFeedRepository) TouchLastUsed(ctx context.Context, tokenID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Model(&model.CalendarFeedToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')", tokenID).
+		UpdateColumn("last_used_at", gorm.Expr("NOW()")).Error
+}
+
+// FindEvents returns the organization's calendar events on non-deleted equipment, earliest
+// first: schedules due within days (or overdue) and current assignments of draft or
+// rejected records that have a due date. Filters are "location" (substring, any case),
+// "maintenance_type_id" and "assignee_id"; an assignee filter leaves out schedules, which
+// are not assigned to anyone.
+func (r *CalendarFeedRepository) FindEvents(ctx context.Context, organizationID uuid.UUID, filters map[string]interface{}, days int) ([]model.CalendarEvent, error) {
+	var events []model.CalendarEvent
+
+	if _, ok := filters["assignee_id"]; !ok {
+		query := r.db.WithContext(ctx).
+			Table("equipchain.equipment_maintenance_schedule ems").
+			Select(`'`+model.CalendarEventSchedule+`' AS kind, ems.id, ems.id AS schedule_id,
+				ems.equipment_id, CONCAT(e.make, ' ', e.model) AS equipment_name, e.serial_number, e.location,
+				ems.maintenance_type_id, mtl.label AS maintenance_type, ems.next_due_date AS due_date,
+				ems.next_due_trigger, ems.next_due_date < CURRENT_DATE AS is_overdue, ems.updated_at`).
+			Joins("JOIN equipchain.equipment e ON e.id = ems.equipment_id").
+			Joins("JOIN equipchain.maintenance_type_lookup mtl ON mtl.id = ems.maintenance_type_id").
+			Where("ems.organization_id = ? AND e.deleted_at IS NULL", organizationID).
+			Where("ems.next_due_date IS NOT NULL AND ems.next_due_date <= CURRENT_DATE + ?", days)
+		query = applyCalendarFilters(query, filters, "ems")
+
+		var schedules []model.CalendarEvent
+		if err := query.Scan(&schedules).Error; err != nil {
+			return nil, err
+		}
+		events = append(events, schedules...)
+	}
+
+	query := r.db.WithContext(ctx).
+		Table("equipchain.maintenance_assignments a").
+		Select(`'`+model.CalendarEventAssignment+`' AS kind, a.id, m.id AS record_id,
+			a.technician_id AS assignee_id, u.email AS assignee_email,
+			m.equipment_id, CONCAT(e.make, ' ', e.model) AS equipment_name, e.serial_number, e.location,
+			m.maintenance_type_id, mtl.label AS maintenance_type, a.due_date,
+			a.due_date < CURRENT_DATE AS is_overdue, GREATEST(a.created_at, m.updated_at) AS updated_at`).
+		Joins("JOIN equipchain.maintenance_records m ON m.id = a.maintenance_record_id").
+		Joins("JOIN equipchain.equipment e ON e.id = m.equipment_id").
+		Joins("JOIN equipchain.maintenance_type_lookup mtl ON mtl.id = m.maintenance_type_id").
+		Joins("JOIN equipchain.users u ON u.id = a.technician_id").
+		Where("a.organization_id = ? AND e.deleted_at IS NULL", organizationID).
+		Where("a.unassigned_at IS NULL AND m.status_id IN ?", openAssignmentStatuses).
+		Where("a.due_date IS NOT NULL AND a.due_date <= CURRENT_DATE + ?", days)
+	if assigneeID, ok := filters["assignee_id"]; ok {
+		query = query.Where("a.technician_id = ?", assigneeID)
+	}
+	query = applyCalendarFilters(query, filters, "m")
+
+	var assignments []model.CalendarEvent
+	if err := query.Scan(&assignments).Error; err != nil {
+		return nil, err
+	}
+	events = append(events, assignments...)
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].DueDate.Before(events[j].DueDate)
+	})
+	return events, nil
+}
+
+// applyCalendarFilters adds the location and maintenance type filters; alias is the table
+// carrying maintenance_type_id.
+func applyCalendarFilters(query *gorm.DB, filters map[string]interface{}, alias string) *gorm.DB {
+	if location, ok := filters["location"]; ok && location != "" {
+		query = query.Where("e.location ILIKE ?", "%"+location.(string)+"%")
+	}
+	if maintenanceTypeID, ok := filters["maintenance_type_id"]; ok {
+		query = query.Where(alias+".maintenance_type_id = ?", maintenanceTypeID)
+	}
+	return query
+}
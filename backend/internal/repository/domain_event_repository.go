@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DomainEventChannel is the NOTIFY channel on which inserted domain_events ids are
+// announced (see migration 026).
+const DomainEventChannel = "equipchain_domain_events"
+
+type DomainEventRepository struct {
+	db *gorm.DB
+}
+
+func NewDomainEventRepository(db *gorm.DB) *DomainEventRepository {
+	return &DomainEventRepository{db: db}
+}
+
+// WithTx returns a repository that works in tx, for event subscribers.
+func (r *DomainEventRepository) WithTx(tx *gorm.DB) *DomainEventRepository {
+	return &DomainEventRepository{db: tx}
+}
+
+// Create stores the event, which announces it to every listener once committed. An event
+// already stored is skipped.
+func (r *DomainEventRepository) Create(ctx context.Context, event *model.DomainEvent) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "event_id"}}, DoNothing: true}).
+		Create(event).Error
+}
+
+func (r *DomainEventRepository) FindByID(ctx context.Context, id int64) (*model.DomainEvent, error) {
+	var event model.DomainEvent
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&event).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// FindAfter returns up to limit events after afterID, in id order. A nil organizationID
+// returns the events of every organization; empty eventTypes returns every type.
+func (r *DomainEventRepository) FindAfter(ctx context.Context, organizationID *uuid.UUID, afterID int64, eventTypes []string, limit int) ([]*model.DomainEvent, error) {
+	query := r.db.WithContext(ctx).Where("id > ?", afterID)
+	if organizationID != nil {
+		query = query.Where("organization_id = ?", *organizationID)
+	}
+	if len(eventTypes) > 0 {
+		query = query.Where("event_type IN ?", eventTypes)
+	}
+
+	var events []*model.DomainEvent
+	err := query.Order("id").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// LatestID returns the id of the newest event, or 0 if there are none.
+func (r *DomainEventRepository) LatestID(ctx context.Context) (int64, error) {
+	var latest int64
+	err := r.db.WithContext(ctx).Model(&model.DomainEvent{}).Select("COALESCE(MAX(id), 0)").Scan(&latest).Error
+	return latest, err
+}
+
+// DeleteCreatedBefore removes events created before cutoff and returns how many.
+func (r *DomainEventRepository) DeleteCreatedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", cutoff).Delete(&model.DomainEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// Listen takes a connection out of the pool, LISTENs on DomainEventChannel and calls
+// notify with the id of every event announced, until ctx is cancelled or the connection
+// fails. The connection is discarded afterwards rather than returned to the pool.
+// listening is called once the LISTEN is in place.
+func (r *DomainEventRepository) Listen(ctx context.Context, listening func(), notify func(id int64)) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var listenErr error
+	conn.Raw(func(driverConn interface{}) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			listenErr = fmt.Errorf("unexpected database driver connection %T", driverConn)
+			return driver.ErrBadConn
+		}
+		pgxConn := stdlibConn.Conn()
+		if _, listenErr = pgxConn.Exec(ctx, "LISTEN "+DomainEventChannel); listenErr != nil {
+			return driver.ErrBadConn
+		}
+		listening()
+
+		for {
+			notification, err := pgxConn.WaitForNotification(ctx)
+			if err != nil {
+				listenErr = err
+				return driver.ErrBadConn
+			}
+			id, err := strconv.ParseInt(notification.Payload, 10, 64)
+			if err != nil {
+				continue
+			}
+			notify(id)
+		}
+	})
+	return listenErr
+}
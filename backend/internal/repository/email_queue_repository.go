@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"gorm.io/gorm"
+)
+
+type EmailQueueRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailQueueRepository(db *gorm.DB) *EmailQueueRepository {
+	return &EmailQueueRepository{db: db}
+}
+
+func (r *EmailQueueRepository) Enqueue(ctx context.Context, entry *model.EmailQueueEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
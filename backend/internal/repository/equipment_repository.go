@@ -69,24 +69,48 @@ func (r *EquipmentRepository) FindBySerialNumber(ctx context.Context, organizati
 	return &equipment, nil
 }
 
-func (r *EquipmentRepository) Create(ctx context.Context, equipment *model.Equipment) error {
-	return r.db.WithContext(ctx).Create(equipment).Error
+func (r *EquipmentRepository) Create(ctx context.Context, equipment *model.Equipment, publish PublishFunc) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(equipment).Error; err != nil {
+			return err
+		}
+		return publish(tx)
+	})
 }
 
-func (r *EquipmentRepository) UpdateEquipment(ctx context.Context, equipmentID uuid.UUID, updates map[string]interface{}, updatedBy uuid.UUID) error {
+// UpdateEquipment applies the updates and returns the updated equipment, which is also
+// handed to publish inside the transaction. It returns nil if the equipment is gone.
+func (r *EquipmentRepository) UpdateEquipment(ctx context.Context, equipmentID uuid.UUID, updates map[string]interface{}, updatedBy uuid.UUID, publish func(tx *gorm.DB, updated *model.Equipment) error) (*model.Equipment, error) {
 	updates["updated_by"] = updatedBy
 
-	return r.db.WithContext(ctx).
-		Model(&model.Equipment{}).
-		Where("id = ? AND deleted_at IS NULL", equipmentID).
-		Updates(updates).Error
+	var updated model.Equipment
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Equipment{}).
+			Where("id = ? AND deleted_at IS NULL", equipmentID).
+			Updates(updates)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := tx.Where("id = ?", equipmentID).First(&updated).Error; err != nil {
+			return err
+		}
+		return publish(tx, &updated)
+	})
+	if err != nil || updated.ID == uuid.Nil {
+		return nil, err
+	}
+	return &updated, nil
 }
 
-func (r *EquipmentRepository) Delete(ctx context.Context, equipmentID uuid.UUID) error {
-	return r.db.WithContext(ctx).
-		Model(&model.Equipment{}).
-		Where("id = ?", equipmentID).
-		Update("deleted_at", gorm.Expr("NOW()")).Error
+func (r *EquipmentRepository) Delete(ctx context.Context, equipmentID uuid.UUID, publish PublishFunc) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Equipment{}).
+			Where("id = ?", equipmentID).
+			Update("deleted_at", gorm.Expr("NOW()")).Error; err != nil {
+			return err
+		}
+		return publish(tx)
+	})
 }
 
 func (r *EquipmentRepository) CountByOrganization(ctx context.Context, organizationID uuid.UUID) (int64, error) {
@@ -110,3 +134,16 @@ func (r *EquipmentRepository) IsValidStatusID(ctx context.Context, statusID int1
 	}
 	return count > 0, nil
 }
+
+// StatusAllowsMaintenance reports whether equipment in the given status may receive new
+// maintenance records.
+func (r *EquipmentRepository) StatusAllowsMaintenance(ctx context.Context, statusID int16) (bool, error) {
+	var status model.EquipmentStatusLookup
+	if err := r.db.WithContext(ctx).Where("id = ?", statusID).First(&status).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return status.AllowsMaintenance, nil
+}
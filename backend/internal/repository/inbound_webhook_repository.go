@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InboundWebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewInboundWebhookRepository(db *gorm.DB) *InboundWebhookRepository {
+	return &InboundWebhookRepository{db: db}
+}
+
+// Create stores a received call. It reports false, storing nothing, if the integration
+// already received a call with the same nonce.
+func (r *InboundWebhookRepository) Create(ctx context.Context, inbound *model.InboundWebhook) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "integration_id"}, {Name: "nonce"}},
+			DoNothing: true,
+		}).
+		Create(inbound)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Finish records the outcome of processing a call.
+func (r *InboundWebhookRepository) Finish(ctx context.Context, inboundID uuid.UUID, status string, processErr *string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.InboundWebhook{}).
+		Where("id = ?", inboundID).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"error":        processErr,
+			"processed_at": time.Now(),
+		}).Error
+}
+
+// ApplyReference creates or updates the record's reference of its integration and type,
+// and marks the call that carried it processed, in one transaction.
+func (r *InboundWebhookRepository) ApplyReference(ctx context.Context, reference *model.MaintenanceExternalReference) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "maintenance_record_id"}, {Name: "integration_id"}, {Name: "reference_type"}},
+			DoUpdates: clause.AssignmentColumns([]string{"reference", "status", "inbound_webhook_id", "updated_at"}),
+		}).Create(reference).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.InboundWebhook{}).
+			Where("id = ?", reference.InboundWebhookID).
+			Updates(map[string]interface{}{
+				"status":                model.InboundWebhookProcessed,
+				"error":                 nil,
+				"maintenance_record_id": reference.MaintenanceRecordID,
+				"processed_at":          time.Now(),
+			}).Error
+	})
+}
+
+// InboundWebhookFilter selects a page of an integration's inbound calls, newest first.
+type InboundWebhookFilter struct {
+	Status    string
+	EventType string
+	Limit     int
+	Offset    int
+}
+
+func (r *InboundWebhookRepository) FindPage(ctx context.Context, integrationID uuid.UUID, filter InboundWebhookFilter) ([]*model.InboundWebhook, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.InboundWebhook{}).Where("integration_id = ?", integrationID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var inbound []*model.InboundWebhook
+	if err := query.Order("received_at DESC, id").Limit(filter.Limit).Offset(filter.Offset).Find(&inbound).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return inbound, total, nil
+}
+
+func (r *InboundWebhookRepository) FindByID(ctx context.Context, inboundID uuid.UUID) (*model.InboundWebhook, error) {
+	var inbound model.InboundWebhook
+	if err := r.db.WithContext(ctx).Where("id = ?", inboundID).First(&inbound).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &inbound, nil
+}
+
+func (r *InboundWebhookRepository) FindReferences(ctx context.Context, recordID uuid.UUID) ([]*model.MaintenanceExternalReference, error) {
+	var references []*model.MaintenanceExternalReference
+	err := r.db.WithContext(ctx).
+		Where("maintenance_record_id = ?", recordID).
+		Order("created_at").
+		Find(&references).Error
+	return references, err
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/envelope"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// integrationSecretColumns are encrypted with the envelope cipher on write and decrypted
+// on read, so that services only ever see plaintext.
+var integrationSecretColumns = []string{"api_key_encrypted", "api_secret_encrypted", "webhook_secret", "previous_webhook_secret"}
+
+type IntegrationRepository struct {
+	db     *gorm.DB
+	cipher *envelope.Cipher
+}
+
+func NewIntegrationRepository(db *gorm.DB, cipher *envelope.Cipher) *IntegrationRepository {
+	return &IntegrationRepository{db: db, cipher: cipher}
+}
+
+// WithTx returns a repository that works in tx, for event subscribers.
+func (r *IntegrationRepository) WithTx(tx *gorm.DB) *IntegrationRepository {
+	return &IntegrationRepository{db: tx, cipher: r.cipher}
+}
+
+func (r *IntegrationRepository) FindByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]*model.OrganizationIntegration, error) {
+	var integrations []*model.OrganizationIntegration
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ?", organizationID).
+		Order("created_at").
+		Find(&integrations).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := r.decryptAll(integrations); err != nil {
+		return nil, err
+	}
+	return integrations, nil
+}
+
+// FindActiveWebhooks returns the organization's active integrations with a webhook URL
+// that subscribe to the event type.
+func (r *IntegrationRepository) FindActiveWebhooks(ctx context.Context, organizationID uuid.UUID, eventType string) ([]*model.OrganizationIntegration, error) {
+	var integrations []*model.OrganizationIntegration
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ? AND is_active AND webhook_url IS NOT NULL AND webhook_url <> ''", organizationID).
+		Where("(event_types = '[]'::jsonb OR event_types @> jsonb_build_array(?::text))", eventType).
+		Order("created_at").
+		Find(&integrations).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := r.decryptAll(integrations); err != nil {
+		return nil, err
+	}
+	return integrations, nil
+}
+
+// FindActiveByType returns the active integrations of a type across all active
+// organizations.
+func (r *IntegrationRepository) FindActiveByType(ctx context.Context, integrationType string) ([]*model.OrganizationIntegration, error) {
+	var integrations []*model.OrganizationIntegration
+	err := r.db.WithContext(ctx).
+		Joins("JOIN equipchain.organizations o ON o.id = organizations_integrations.organization_id AND o.status = 'active'").
+		Where("organizations_integrations.integration_type = ? AND organizations_integrations.is_active", integrationType).
+		Order("organizations_integrations.created_at").
+		Find(&integrations).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := r.decryptAll(integrations); err != nil {
+		return nil, err
+	}
+	return integrations, nil
+}
+
+// Create stores the integration with its secrets encrypted; integration itself keeps the
+// plaintext.
+func (r *IntegrationRepository) Create(ctx context.Context, integration *model.OrganizationIntegration) error {
+	stored := *integration
+	for _, field := range integrationSecretFields(&stored) {
+		if *field == nil {
+			continue
+		}
+		ciphertext, err := r.cipher.Encrypt([]byte(**field))
+		if err != nil {
+			return err
+		}
+		*field = &ciphertext
+	}
+	return r.db.WithContext(ctx).Create(&stored).Error
+}
+
+// Update applies updates; plaintext values (string or *string) for secret columns are
+// encrypted.
+func (r *IntegrationRepository) Update(ctx context.Context, integrationID uuid.UUID, updates map[string]interface{}) error {
+	for _, column := range integrationSecretColumns {
+		var plaintext *string
+		switch value := updates[column].(type) {
+		case string:
+			plaintext = &value
+		case *string:
+			plaintext = value
+		}
+		if plaintext == nil {
+			continue
+		}
+		ciphertext, err := r.cipher.Encrypt([]byte(*plaintext))
+		if err != nil {
+			return err
+		}
+		updates[column] = ciphertext
+	}
+	updates["updated_at"] = time.Now()
+	return r.db.WithContext(ctx).
+		Model(&model.OrganizationIntegration{}).
+		Where("id = ?", integrationID).
+		Updates(updates).Error
+}
+
+// Delete removes the integration and, by cascade, its deliveries. It reports false if the
+// integration was not found.
+func (r *IntegrationRepository) Delete(ctx context.Context, organizationID, integrationID uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND organization_id = ?", integrationID, organizationID).
+		Delete(&model.OrganizationIntegration{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// RecordWebhookCall counts a webhook call. A failure (callErr set) increments the
+// consecutive error count and deactivates the integration once it reaches
+// model.OrganizationIntegrationErrorLimit; a success resets it. Neither reactivates an
+// integration, e.g. one an admin deactivated and sent a test ping.
+func (r *IntegrationRepository) RecordWebhookCall(ctx context.Context, integrationID uuid.UUID, callErr error) error {
+	updates := map[string]interface{}{
+		"last_webhook_call":  time.Now(),
+		"webhook_call_count": gorm.Expr("webhook_call_count + 1"),
+	}
+	if callErr != nil {
+		updates["last_error"] = callErr.Error()
+		updates["error_count"] = gorm.Expr("error_count + 1")
+		updates["is_active"] = gorm.Expr("is_active AND error_count + 1 < ?", model.OrganizationIntegrationErrorLimit)
+	} else {
+		updates["error_count"] = 0
+	}
+
+	return r.db.WithContext(ctx).
+		Model(&model.OrganizationIntegration{}).
+		Where("id = ?", integrationID).
+		Updates(updates).Error
+}
+
+func (r *IntegrationRepository) FindByID(ctx context.Context, integrationID uuid.UUID) (*model.OrganizationIntegration, error) {
+	var integration model.OrganizationIntegration
+	if err := r.db.WithContext(ctx).Where("id = ?", integrationID).First(&integration).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := r.decrypt(&integration); err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+func (r *IntegrationRepository) decryptAll(integrations []*model.OrganizationIntegration) error {
+	for _, integration := range integrations {
+		if err := r.decrypt(integration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decrypt replaces the integration's encrypted secrets with their plaintext. Webhook
+// secrets stored before encryption was introduced are still plaintext until enckeyctl
+// reencrypt runs, and are passed through.
+func (r *IntegrationRepository) decrypt(integration *model.OrganizationIntegration) error {
+	for _, field := range integrationSecretFields(integration) {
+		if *field == nil || !envelope.IsCiphertext(**field) {
+			continue
+		}
+		plaintext, err := r.cipher.Decrypt(**field)
+		if err != nil {
+			return fmt.Errorf("decrypt secrets of integration %s: %w", integration.ID, err)
+		}
+		value := string(plaintext)
+		*field = &value
+	}
+	return nil
+}
+
+// integrationSecretFields are the fields of integrationSecretColumns.
+func integrationSecretFields(integration *model.OrganizationIntegration) []**string {
+	return []**string{&integration.APIKey, &integration.APISecret, &integration.WebhookSecret, &integration.PreviousWebhookSecret}
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IntegrationSyncRepository struct {
+	db *gorm.DB
+}
+
+func NewIntegrationSyncRepository(db *gorm.DB) *IntegrationSyncRepository {
+	return &IntegrationSyncRepository{db: db}
+}
+
+// Claim leases the integration's sync for lease and returns its checkpoint, creating it
+// on the first run. It returns nil if another server instance holds the lease.
+func (r *IntegrationSyncRepository) Claim(ctx context.Context, integration *model.OrganizationIntegration, lease time.Duration) (*model.IntegrationSyncState, error) {
+	now := time.Now()
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.IntegrationSyncState{
+			IntegrationID:       integration.ID,
+			OrganizationID:      integration.OrganizationID,
+			Phase:               model.SyncPhasePullEquipment,
+			RemotePage:          1,
+			MaintenanceCursorAt: now,
+			CycleCounts:         []byte("{}"),
+			CreatedAt:           now,
+			UpdatedAt:           now,
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var states []*model.IntegrationSyncState
+	err = r.db.WithContext(ctx).Raw(`
+		UPDATE equipchain.integration_sync_states
+		SET locked_until = NOW() + make_interval(secs => ?)
+		WHERE integration_id = ? AND (locked_until IS NULL OR locked_until < NOW())
+		RETURNING *`, lease.Seconds(), integration.ID).
+		Scan(&states).Error
+	if err != nil || len(states) == 0 {
+		return nil, err
+	}
+	return states[0], nil
+}
+
+// SaveCheckpoint stores where the sync stopped. A nil state.LockedUntil releases the
+// lease.
+func (r *IntegrationSyncRepository) SaveCheckpoint(ctx context.Context, state *model.IntegrationSyncState) error {
+	return r.db.WithContext(ctx).
+		Model(&model.IntegrationSyncState{}).
+		Where("integration_id = ?", state.IntegrationID).
+		Updates(map[string]interface{}{
+			"remote_scope":          state.RemoteScope,
+			"phase":                 state.Phase,
+			"remote_page":           state.RemotePage,
+			"equipment_cursor_at":   state.EquipmentCursorAt,
+			"equipment_cursor_id":   state.EquipmentCursorID,
+			"maintenance_cursor_at": state.MaintenanceCursorAt,
+			"maintenance_cursor_id": state.MaintenanceCursorID,
+			"cycle_started_at":      state.CycleStartedAt,
+			"cycle_counts":          state.CycleCounts,
+			"last_completed_at":     state.LastCompletedAt,
+			"last_error":            state.LastError,
+			"locked_until":          state.LockedUntil,
+			"updated_at":            time.Now(),
+		}).Error
+}
+
+// DeleteLinks drops every link of the integration, e.g. when it is pointed at another
+// remote account.
+func (r *IntegrationSyncRepository) DeleteLinks(ctx context.Context, integrationID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Where("integration_id = ?", integrationID).
+		Delete(&model.IntegrationEquipmentLink{}).Error
+}
+
+// DeleteLink drops a link whose remote record is gone.
+func (r *IntegrationSyncRepository) DeleteLink(ctx context.Context, linkID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Where("id = ?", linkID).
+		Delete(&model.IntegrationEquipmentLink{}).Error
+}
+
+func (r *IntegrationSyncRepository) FindState(ctx context.Context, integrationID uuid.UUID) (*model.IntegrationSyncState, error) {
+	var state model.IntegrationSyncState
+	if err := r.db.WithContext(ctx).Where("integration_id = ?", integrationID).First(&state).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func (r *IntegrationSyncRepository) FindLinkByExternalID(ctx context.Context, integrationID uuid.UUID, externalID int64) (*model.IntegrationEquipmentLink, error) {
+	return r.findLink(ctx, "integration_id = ? AND external_id = ?", integrationID, externalID)
+}
+
+func (r *IntegrationSyncRepository) FindLinkByEquipmentID(ctx context.Context, integrationID, equipmentID uuid.UUID) (*model.IntegrationEquipmentLink, error) {
+	return r.findLink(ctx, "integration_id = ? AND equipment_id = ?", integrationID, equipmentID)
+}
+
+func (r *IntegrationSyncRepository) findLink(ctx context.Context, query string, args ...interface{}) (*model.IntegrationEquipmentLink, error) {
+	var link model.IntegrationEquipmentLink
+	if err := r.db.WithContext(ctx).Where(query, args...).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+// CreateLink stores a new link. It reports false, storing nothing, if the equipment or the
+// remote record is already linked.
+func (r *IntegrationSyncRepository) CreateLink(ctx context.Context, link *model.IntegrationEquipmentLink) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(link)
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkPushed records that the link's equipment was pushed.
+func (r *IntegrationSyncRepository) MarkPushed(ctx context.Context, linkID uuid.UUID) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).
+		Model(&model.IntegrationEquipmentLink{}).
+		Where("id = ?", linkID).
+		Updates(map[string]interface{}{"pushed_at": now, "updated_at": now}).Error
+}
+
+// ApplyPull writes pulled values to the link's equipment, if there are any, and the remote
+// values they came from to the link, in one transaction. The equipment's updated_by is
+// cleared: the change was made by the remote system, not a user. publish is called only
+// when the equipment changed.
+func (r *IntegrationSyncRepository) ApplyPull(ctx context.Context, link *model.IntegrationEquipmentLink, equipmentUpdates map[string]interface{}, publish PublishFunc) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if len(equipmentUpdates) > 0 {
+			updates := map[string]interface{}{"updated_by": nil, "updated_at": now}
+			for column, value := range equipmentUpdates {
+				updates[column] = value
+			}
+			if err := tx.Model(&model.Equipment{}).
+				Where("id = ? AND deleted_at IS NULL", link.EquipmentID).
+				Updates(updates).Error; err != nil {
+				return err
+			}
+			if err := publish(tx); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&model.IntegrationEquipmentLink{}).
+			Where("id = ?", link.ID).
+			Updates(map[string]interface{}{
+				"remote_values": link.RemoteValues,
+				"pulled_at":     now,
+				"updated_at":    now,
+			}).Error
+	})
+}
+
+// FindEquipmentAfter returns up to limit of the organization's equipment updated after
+// the (updatedAt, id) cursor, in that order. A nil cursor starts from the beginning.
+func (r *IntegrationSyncRepository) FindEquipmentAfter(ctx context.Context, organizationID uuid.UUID, updatedAt *time.Time, id *uuid.UUID, limit int) ([]*model.Equipment, error) {
+	query := r.db.WithContext(ctx).Where("organization_id = ? AND deleted_at IS NULL", organizationID)
+	if updatedAt != nil && id != nil {
+		query = query.Where("(updated_at, id) > (?, ?)", *updatedAt, *id)
+	}
+
+	var equipment []*model.Equipment
+	err := query.Order("updated_at, id").Limit(limit).Find(&equipment).Error
+	return equipment, err
+}
+
+// FindConfirmedMaintenanceAfter returns up to limit maintenance records confirmed after
+// the (confirmedAt, id) cursor, of equipment linked to the integration, in that order.
+func (r *IntegrationSyncRepository) FindConfirmedMaintenanceAfter(ctx context.Context, integrationID uuid.UUID, confirmedAt time.Time, id *uuid.UUID, limit int) ([]*model.ConfirmedMaintenance, error) {
+	cursorID := uuid.Nil
+	if id != nil {
+		cursorID = *id
+	}
+
+	var records []*model.ConfirmedMaintenance
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT m.id, m.equipment_id, l.external_id, t.label AS maintenance_type, m.notes, m.confirmed_at
+		FROM equipchain.maintenance_records m
+		JOIN equipchain.integration_equipment_links l ON l.equipment_id = m.equipment_id AND l.integration_id = ?
+		JOIN equipchain.maintenance_type_lookup t ON t.id = m.maintenance_type_id
+		WHERE m.status_id = ? AND m.confirmed_at IS NOT NULL AND (m.confirmed_at, m.id) > (?, ?)
+		ORDER BY m.confirmed_at, m.id
+		LIMIT ?`, integrationID, model.MaintenanceStatusConfirmed, confirmedAt, cursorID, limit).
+		Scan(&records).Error
+	return records, err
+}
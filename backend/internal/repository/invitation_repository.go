@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvitationNotPending is returned when an invitation was accepted, revoked or expired
+// concurrently.
+var ErrInvitationNotPending = errors.New("invitation is no longer pending")
+
+type InvitationRepository struct {
+	db *gorm.DB
+}
+
+func NewInvitationRepository(db *gorm.DB) *InvitationRepository {
+	return &InvitationRepository{db: db}
+}
+
+func (r *InvitationRepository) FindByID(ctx context.Context, invitationID uuid.UUID) (*model.Invitation, error) {
+	var invitation model.Invitation
+	if err := r.db.WithContext(ctx).Where("id = ?", invitationID).First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// FindPendingByOrganizationID lists open invitations, including expired ones so that
+// admins can see and resend them.
+func (r *InvitationRepository) FindPendingByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]model.Invitation, error) {
+	var invitations []model.Invitation
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ? AND accepted_at IS NULL AND revoked_at IS NULL", organizationID).
+		Order("created_at DESC").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+// CreateWithEmail revokes any open invitation for the same email, stores the new one and
+// queues its email in one transaction.
+func (r *InvitationRepository) CreateWithEmail(ctx context.Context, invitation *model.Invitation, email *model.EmailQueueEntry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Invitation{}).
+			Where("organization_id = ? AND email = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitation.OrganizationID, invitation.Email).
+			Updates(map[string]interface{}{
+				"revoked_at": gorm.Expr("NOW()"),
+				"updated_by": invitation.InvitedBy,
+			}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(invitation).Error; err != nil {
+			return err
+		}
+		return tx.Create(email).Error
+	})
+}
+
+// Revoke closes an open invitation. It reports false if none was found.
+func (r *InvitationRepository) Revoke(ctx context.Context, organizationID, invitationID, revokedBy uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.Invitation{}).
+		Where("id = ? AND organization_id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitationID, organizationID).
+		Updates(map[string]interface{}{
+			"revoked_at": gorm.Expr("NOW()"),
+			"updated_by": revokedBy,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// AcceptWithUser creates the invited user and marks the invitation accepted in one
+// transaction. It returns ErrInvitationNotPending if the invitation was used or revoked
+// in the meantime.
+func (r *InvitationRepository) AcceptWithUser(ctx context.Context, invitationID uuid.UUID, user *model.User) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Invitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()", invitationID).
+			Updates(map[string]interface{}{
+				"accepted_at": gorm.Expr("NOW()"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvitationNotPending
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Invitation{}).
+			Where("id = ?", invitationID).
+			Update("accepted_user_id", user.ID).Error
+	})
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LicenseAlertRepository struct {
+	db *gorm.DB
+}
+
+func NewLicenseAlertRepository(db *gorm.DB) *LicenseAlertRepository {
+	return &LicenseAlertRepository{db: db}
+}
+
+// FindUnreportedExpired returns profiles whose license expired before today and whose
+// expiry has not been alerted yet.
+func (r *LicenseAlertRepository) FindUnreportedExpired(ctx context.Context, organizationID uuid.UUID) ([]*model.TechnicianProfile, error) {
+	var profiles []*model.TechnicianProfile
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ? AND license_expiration_date < CURRENT_DATE", organizationID).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM equipchain.license_expiration_alerts a
+			WHERE a.technician_user_id = technician_profiles.user_id
+			  AND a.license_expiration_date = technician_profiles.license_expiration_date
+			  AND a.threshold_days = ?)`, model.LicenseAlertExpired).
+		Order("license_expiration_date").
+		Find(&profiles).Error
+	return profiles, err
+}
+
+// MarkExpiredUnavailable makes technicians whose license expired before today unavailable
+// again, if they were made available since their expiry alert, and returns their profiles.
+func (r *LicenseAlertRepository) MarkExpiredUnavailable(ctx context.Context, organizationID uuid.UUID) ([]*model.TechnicianProfile, error) {
+	var profiles []*model.TechnicianProfile
+	err := r.db.WithContext(ctx).
+		Model(&profiles).
+		Clauses(clause.Returning{}).
+		Where("organization_id = ? AND is_available AND license_expiration_date < CURRENT_DATE", organizationID).
+		Update("is_available", false).Error
+	return profiles, err
+}
+
+// Record stores the alert and queues its emails in one transaction. It reports false,
+// queuing nothing, if the same alert was already recorded. For expiry alerts
+// (threshold 0) the technician is also made unavailable.
+func (r *LicenseAlertRepository) Record(ctx context.Context, alert *model.LicenseExpirationAlert, emails []*model.EmailQueueEntry) (bool, error) {
+	recorded := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(alert)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		recorded = true
+
+		if alert.ThresholdDays == model.LicenseAlertExpired {
+			if err := tx.Model(&model.TechnicianProfile{}).
+				Where("user_id = ?", alert.TechnicianUserID).
+				Update("is_available", false).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, email := range emails {
+			if err := tx.Create(email).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return recorded, err
+}
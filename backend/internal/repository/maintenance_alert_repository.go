@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maintenanceAlertColumns maps alert kinds to the schedule column stamped when sent.
+var maintenanceAlertColumns = map[string]string{
+	model.MaintenanceAlertDueSoon:    "due_soon_alert_sent_at",
+	model.MaintenanceAlertOverdue:    "overdue_alert_sent_at",
+	model.MaintenanceAlertEscalation: "overdue_escalated_at",
+}
+
+type MaintenanceAlertRepository struct {
+	db *gorm.DB
+}
+
+func NewMaintenanceAlertRepository(db *gorm.DB) *MaintenanceAlertRepository {
+	return &MaintenanceAlertRepository{db: db}
+}
+
+// FindPending returns the organization's schedules of non-deleted equipment that need an
+// alert of the kind, most urgent first:
+//   - due_soon: due today or within days, not alerted yet;
+//   - overdue: past due, not alerted yet;
+//   - escalation: overdue by at least days after the overdue alert, not escalated yet.
+func (r *MaintenanceAlertRepository) FindPending(ctx context.Context, organizationID uuid.UUID, kind string, days int) ([]model.MaintenanceAlert, error) {
+	var condition string
+	args := []interface{}{organizationID}
+	switch kind {
+	case model.MaintenanceAlertDueSoon:
+		condition = "ems.next_due_date BETWEEN CURRENT_DATE AND CURRENT_DATE + ? AND ems.due_soon_alert_sent_at IS NULL"
+		args = append(args, days)
+	case model.MaintenanceAlertOverdue:
+		condition = "ems.next_due_date < CURRENT_DATE AND ems.overdue_alert_sent_at IS NULL"
+	case model.MaintenanceAlertEscalation:
+		condition = "ems.next_due_date <= CURRENT_DATE - ? AND ems.overdue_alert_sent_at IS NOT NULL AND ems.overdue_escalated_at IS NULL"
+		args = append(args, days)
+	default:
+		return nil, fmt.Errorf("unknown maintenance alert kind %q", kind)
+	}
+
+	var alerts []model.MaintenanceAlert
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT ems.id AS schedule_id, ems.equipment_id, CONCAT(e.make, ' ', e.model) AS equipment_name,
+		       e.serial_number, e.owner_id, ems.maintenance_type_id, mtl.label AS maintenance_type,
+		       ems.next_due_date, ems.next_due_trigger, (ems.next_due_date - CURRENT_DATE)::INT AS days_until_due
+		FROM equipchain.equipment_maintenance_schedule ems
+		JOIN equipchain.equipment e ON e.id = ems.equipment_id
+		JOIN equipchain.maintenance_type_lookup mtl ON mtl.id = ems.maintenance_type_id
+		WHERE ems.organization_id = ? AND e.deleted_at IS NULL AND `+condition+`
+		ORDER BY ems.next_due_date, ems.id`, args...).
+		Scan(&alerts).Error
+	return alerts, err
+}
+
+// Record stamps the alert on the schedule, queues its emails and publishes it in one
+// transaction. It reports false, queuing and publishing nothing, if the alert was stamped
+// already (by another instance) or the schedule moved to another due date meanwhile.
+func (r *MaintenanceAlertRepository) Record(ctx context.Context, alert model.MaintenanceAlert, kind string, emails []*model.EmailQueueEntry, publish PublishFunc) (bool, error) {
+	column, ok := maintenanceAlertColumns[kind]
+	if !ok {
+		return false, fmt.Errorf("unknown maintenance alert kind %q", kind)
+	}
+
+	recorded := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.MaintenanceSchedule{}).
+			Where("id = ? AND next_due_date = ? AND "+column+" IS NULL", alert.ScheduleID, alert.NextDueDate.Format("2006-01-02")).
+			Update(column, time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		recorded = true
+
+		for _, email := range emails {
+			if err := tx.Create(email).Error; err != nil {
+				return err
+			}
+		}
+		return publish(tx)
+	})
+	return recorded, err
+}
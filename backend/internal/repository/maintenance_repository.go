@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrStaleMaintenanceStatus is returned when a record left the expected status between
+// being read and being updated.
+var ErrStaleMaintenanceStatus = errors.New("maintenance record status changed concurrently")
+
+// ErrSigningTokenUsed is returned when an approval's signing token already signed another.
+var ErrSigningTokenUsed = errors.New("signing token already used")
+
+type MaintenanceRepository struct {
+	db *gorm.DB
+}
+
+func NewMaintenanceRepository(db *gorm.DB) *MaintenanceRepository {
+	return &MaintenanceRepository{db: db}
+}
+
+func (r *MaintenanceRepository) FindByOrganizationID(ctx context.Context, organizationID uuid.UUID, filters map[string]interface{}) ([]*model.MaintenanceRecord, error) {
+	var records []*model.MaintenanceRecord
+	query := r.db.WithContext(ctx).Where("organization_id = ?", organizationID)
+
+	if statusID, ok := filters["status_id"]; ok && statusID != "" {
+		query = query.Where("status_id = ?", statusID)
+	}
+
+	if equipmentID, ok := filters["equipment_id"]; ok && equipmentID != "" {
+		query = query.Where("equipment_id = ?", equipmentID)
+	}
+
+	if technicianID, ok := filters["technician_id"]; ok && technicianID != "" {
+		query = query.Where("technician_id = ?", technicianID)
+	}
+
+	if err := query.Order("created_at DESC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func (r *MaintenanceRepository) FindByID(ctx context.Context, recordID uuid.UUID) (*model.MaintenanceRecord, error) {
+	var record model.MaintenanceRecord
+	if err := r.db.WithContext(ctx).Where("id = ?", recordID).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (r *MaintenanceRepository) FindByIDs(ctx context.Context, recordIDs []uuid.UUID) ([]*model.MaintenanceRecord, error) {
+	var records []*model.MaintenanceRecord
+	if len(recordIDs) == 0 {
+		return records, nil
+	}
+	if err := r.db.WithContext(ctx).Where("id IN ?", recordIDs).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// RecordPublishFunc publishes the domain event of a status change from inside its
+// transaction, given the record as changed. An error rolls the change back.
+type RecordPublishFunc func(tx *gorm.DB, record *model.MaintenanceRecord) error
+
+// Create stores a record with the meter readings taken on-site, atomically (see
+// insertMeterReadings).
+func (r *MaintenanceRepository) Create(ctx context.Context, record *model.MaintenanceRecord, readings []*model.MeterReading, publish PublishFunc) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+		if err := insertMeterReadings(tx, readings); err != nil {
+			return err
+		}
+		return publish(tx)
+	})
+}
+
+// UpdateStatus moves a record from one of fromStatuses to a new status, applying the extra
+// column updates, and returns the updated record. It fails with ErrStaleMaintenanceStatus
+// if the record is no longer in one of fromStatuses.
+func (r *MaintenanceRepository) UpdateStatus(ctx context.Context, recordID uuid.UUID, fromStatuses []int16, updates map[string]interface{}, updatedBy uuid.UUID, publish RecordPublishFunc) (*model.MaintenanceRecord, error) {
+	var updated *model.MaintenanceRecord
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := updateMaintenanceStatus(tx, recordID, fromStatuses, updates, updatedBy); err != nil {
+			return err
+		}
+		var err error
+		updated, err = publishRecord(tx, recordID, publish)
+		return err
+	})
+	return updated, err
+}
+
+// RecordApproval applies an approval or rejection and writes its maintenance_approval_audit
+// row in the same transaction, returning the updated record.
+func (r *MaintenanceRepository) RecordApproval(ctx context.Context, recordID uuid.UUID, updates map[string]interface{}, audit *model.MaintenanceApprovalAudit, publish RecordPublishFunc) (*model.MaintenanceRecord, error) {
+	var updated *model.MaintenanceRecord
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var sequence int64
+		if err := tx.Model(&model.MaintenanceApprovalAudit{}).
+			Where("maintenance_record_id = ? AND action = ?", recordID, model.ApprovalActionApproved).
+			Count(&sequence).Error; err != nil {
+			return err
+		}
+		audit.ApprovalSequence = int16(sequence) + 1
+
+		if err := updateMaintenanceStatus(tx, recordID, []int16{model.MaintenanceStatusSubmitted}, updates, audit.ApproverID); err != nil {
+			return err
+		}
+		// A second use of the signing token conflicts on its unique index; a concurrent one
+		// waits for the first to commit
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(audit)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrSigningTokenUsed
+		}
+
+		var err error
+		updated, err = publishRecord(tx, recordID, publish)
+		return err
+	})
+	return updated, err
+}
+
+// Confirm marks an approved record as confirmed on chain and rolls the matching preventive
+// maintenance schedule forward, atomically, returning the updated record. The schedule is
+// read and locked inside the transaction and handed to rollForward, which returns the
+// columns to update.
+func (r *MaintenanceRepository) Confirm(ctx context.Context, record *model.MaintenanceRecord, transactionSignature string, confirmedBy uuid.UUID, rollForward func(*model.MaintenanceSchedule) (map[string]interface{}, error), publish RecordPublishFunc) (*model.MaintenanceRecord, error) {
+	var updated *model.MaintenanceRecord
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := updateMaintenanceStatus(tx, record.ID, []int16{model.MaintenanceStatusApproved}, map[string]interface{}{
+			"status_id":        model.MaintenanceStatusConfirmed,
+			"confirmed_at":     gorm.Expr("NOW()"),
+			"solana_signature": transactionSignature,
+		}, confirmedBy)
+		if err != nil {
+			return err
+		}
+
+		var schedules []model.MaintenanceSchedule
+		if err := tx.Select(scheduleColumns).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("equipment_id = ? AND maintenance_type_id = ?", record.EquipmentID, record.MaintenanceTypeID).
+			Limit(1).
+			Find(&schedules).Error; err != nil {
+			return err
+		}
+		if len(schedules) > 0 {
+			updates, err := rollForward(&schedules[0])
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&model.MaintenanceSchedule{}).
+				Where("id = ?", schedules[0].ID).
+				Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		updated, err = publishRecord(tx, record.ID, publish)
+		return err
+	})
+	return updated, err
+}
+
+func (r *MaintenanceRepository) FindApprovalHistory(ctx context.Context, recordID uuid.UUID) ([]*model.MaintenanceApprovalAudit, error) {
+	var history []*model.MaintenanceApprovalAudit
+	if err := r.db.WithContext(ctx).
+		Where("maintenance_record_id = ?", recordID).
+		Order("created_at ASC").
+		Find(&history).Error; err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// publishRecord reloads the record as changed in tx and hands it to publish.
+func publishRecord(tx *gorm.DB, recordID uuid.UUID, publish RecordPublishFunc) (*model.MaintenanceRecord, error) {
+	var record model.MaintenanceRecord
+	if err := tx.Where("id = ?", recordID).First(&record).Error; err != nil {
+		return nil, err
+	}
+	return &record, publish(tx, &record)
+}
+
+func updateMaintenanceStatus(db *gorm.DB, recordID uuid.UUID, fromStatuses []int16, updates map[string]interface{}, updatedBy uuid.UUID) error {
+	updates["updated_by"] = updatedBy
+
+	result := db.Model(&model.MaintenanceRecord{}).
+		Where("id = ? AND status_id IN ?", recordID, fromStatuses).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStaleMaintenanceStatus
+	}
+	return nil
+}
+
+func (r *MaintenanceRepository) IsValidTypeID(ctx context.Context, typeID int16) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).
+		Table("equipchain.maintenance_type_lookup").
+		Where("id = ? AND status = ?", typeID, "active").
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// LastEquipmentFix returns the most recent GPS position recorded on the equipment's
+// maintenance records, or nil if none has one.
+func (r *MaintenanceRepository) LastEquipmentFix(ctx context.Context, equipmentID uuid.UUID) (*model.GPSFix, error) {
+	var fixes []model.GPSFix
+	if err := r.db.WithContext(ctx).
+		Model(&model.MaintenanceRecord{}).
+		Select("gps_latitude AS latitude, gps_longitude AS longitude, created_at AS recorded_at").
+		Where("equipment_id = ? AND gps_latitude IS NOT NULL AND gps_longitude IS NOT NULL", equipmentID).
+		Order("created_at DESC").
+		Limit(1).
+		Scan(&fixes).Error; err != nil {
+		return nil, err
+	}
+	if len(fixes) == 0 {
+		return nil, nil
+	}
+	return &fixes[0], nil
+}
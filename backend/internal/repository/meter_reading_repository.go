@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrMeterReadingOutOfOrder is returned when a reading would make a meter decrease over
+// time without a reset.
+var ErrMeterReadingOutOfOrder = errors.New("meter reading out of order")
+
+// ErrMeterResetNotLatest is returned for a backdated reset.
+var ErrMeterResetNotLatest = errors.New("meter reset must be the latest reading")
+
+type MeterReadingRepository struct {
+	db *gorm.DB
+}
+
+func NewMeterReadingRepository(db *gorm.DB) *MeterReadingRepository {
+	return &MeterReadingRepository{db: db}
+}
+
+// FindByEquipment lists the equipment's readings, newest first. An empty meterType
+// returns every meter.
+func (r *MeterReadingRepository) FindByEquipment(ctx context.Context, equipmentID uuid.UUID, meterType string, limit int) ([]*model.MeterReading, error) {
+	query := r.db.WithContext(ctx).Where("equipment_id = ?", equipmentID)
+	if meterType != "" {
+		query = query.Where("meter_type = ?", meterType)
+	}
+
+	var readings []*model.MeterReading
+	if err := query.Order("read_at DESC, created_at DESC").Limit(limit).Find(&readings).Error; err != nil {
+		return nil, err
+	}
+	return readings, nil
+}
+
+// FindByMaintenanceRecord returns the readings taken with a maintenance record.
+func (r *MeterReadingRepository) FindByMaintenanceRecord(ctx context.Context, recordID uuid.UUID) ([]*model.MeterReading, error) {
+	var readings []*model.MeterReading
+	if err := r.db.WithContext(ctx).
+		Where("maintenance_record_id = ?", recordID).
+		Order("read_at").
+		Find(&readings).Error; err != nil {
+		return nil, err
+	}
+	return readings, nil
+}
+
+// FindUsageWindow returns, per equipment and meter, the readings within window of that
+// meter's latest reading, ordered by equipment, meter type and read_at. An empty
+// meterType returns every meter.
+func (r *MeterReadingRepository) FindUsageWindow(ctx context.Context, equipmentIDs []uuid.UUID, meterType string, window time.Duration) ([]*model.MeterReading, error) {
+	if len(equipmentIDs) == 0 {
+		return nil, nil
+	}
+
+	var readings []*model.MeterReading
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT r.*
+		FROM equipchain.equipment_meter_readings r
+		JOIN (
+		    SELECT equipment_id, meter_type, MAX(read_at) AS latest
+		    FROM equipchain.equipment_meter_readings
+		    WHERE equipment_id IN ? AND (? = '' OR meter_type = ?)
+		    GROUP BY equipment_id, meter_type
+		) l ON l.equipment_id = r.equipment_id AND l.meter_type = r.meter_type
+		WHERE r.read_at >= l.latest - make_interval(secs => ?)
+		ORDER BY r.equipment_id, r.meter_type, r.read_at, r.created_at`,
+		equipmentIDs, meterType, meterType, window.Seconds()).
+		Scan(&readings).Error
+	return readings, err
+}
+
+// Create stores readings atomically, see insertMeterReadings.
+func (r *MeterReadingRepository) Create(ctx context.Context, readings []*model.MeterReading) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return insertMeterReadings(tx, readings)
+	})
+}
+
+// insertMeterReadings stores readings in read_at order after checking that each meter
+// keeps increasing around them. A reset must be the latest reading of its meter; the
+// meter-based schedules of the equipment are shifted onto the new meter so they keep
+// their remaining interval. The equipment rows are locked so concurrent submissions
+// are checked against each other.
+func insertMeterReadings(tx *gorm.DB, readings []*model.MeterReading) error {
+	if len(readings) == 0 {
+		return nil
+	}
+	sort.SliceStable(readings, func(i, j int) bool {
+		return readings[i].ReadAt.Before(readings[j].ReadAt)
+	})
+
+	equipmentIDs := make([]uuid.UUID, 0, len(readings))
+	for _, reading := range readings {
+		equipmentIDs = append(equipmentIDs, reading.EquipmentID)
+	}
+	if err := tx.Exec("SELECT id FROM equipchain.equipment WHERE id IN ? ORDER BY id FOR UPDATE", equipmentIDs).Error; err != nil {
+		return err
+	}
+
+	for _, reading := range readings {
+		series := tx.Model(&model.MeterReading{}).
+			Where("equipment_id = ? AND meter_type = ?", reading.EquipmentID, reading.MeterType)
+
+		var previous model.MeterReading
+		hasPrevious := true
+		if err := series.Session(&gorm.Session{}).
+			Where("read_at <= ?", reading.ReadAt).
+			Order("read_at DESC, created_at DESC").
+			First(&previous).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			hasPrevious = false
+		}
+
+		var next model.MeterReading
+		hasNext := true
+		if err := series.Session(&gorm.Session{}).
+			Where("read_at > ?", reading.ReadAt).
+			Order("read_at, created_at").
+			First(&next).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			hasNext = false
+		}
+
+		if reading.IsReset && hasNext {
+			return fmt.Errorf("%w: %s reset of equipment %s at %s precedes a reading at %s",
+				ErrMeterResetNotLatest, reading.MeterType, reading.EquipmentID, reading.ReadAt.Format(time.RFC3339), next.ReadAt.Format(time.RFC3339))
+		}
+		if !reading.IsReset && hasPrevious && reading.Value < previous.Value {
+			return fmt.Errorf("%w: %s reading %.1f of equipment %s at %s is below %.1f read at %s",
+				ErrMeterReadingOutOfOrder, reading.MeterType, reading.Value, reading.EquipmentID, reading.ReadAt.Format(time.RFC3339), previous.Value, previous.ReadAt.Format(time.RFC3339))
+		}
+		if hasNext && !next.IsReset && next.Value < reading.Value {
+			return fmt.Errorf("%w: %s reading %.1f of equipment %s at %s is above %.1f read at %s",
+				ErrMeterReadingOutOfOrder, reading.MeterType, reading.Value, reading.EquipmentID, reading.ReadAt.Format(time.RFC3339), next.Value, next.ReadAt.Format(time.RFC3339))
+		}
+
+		if err := tx.Create(reading).Error; err != nil {
+			return err
+		}
+
+		if reading.IsReset && hasPrevious {
+			shift := reading.Value - previous.Value
+			if err := tx.Model(&model.MaintenanceSchedule{}).
+				Where("equipment_id = ? AND meter_type = ?", reading.EquipmentID, reading.MeterType).
+				Updates(map[string]interface{}{
+					"last_service_meter_value": gorm.Expr("COALESCE(last_service_meter_value, 0) + ?", shift),
+					"next_due_meter_value":     gorm.Expr("next_due_meter_value + ?", shift),
+				}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OIDCRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCRepository(db *gorm.DB) *OIDCRepository {
+	return &OIDCRepository{db: db}
+}
+
+func (r *OIDCRepository) FindProvider(ctx context.Context, organizationID uuid.UUID) (*model.OIDCProvider, error) {
+	var provider model.OIDCProvider
+	if err := r.db.WithContext(ctx).Where("organization_id = ?", organizationID).First(&provider).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &provider, nil
+}
+
+func (r *OIDCRepository) UpsertProvider(ctx context.Context, provider *model.OIDCProvider) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "organization_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"issuer_url",
+				"client_id",
+				"client_secret_encrypted",
+				"scopes",
+				"allowed_email_domains",
+				"default_role_id",
+				"role_claim",
+				"role_mapping",
+				"enabled",
+				"updated_by",
+			}),
+		}).
+		Create(provider).Error
+}
+
+func (r *OIDCRepository) DeleteProvider(ctx context.Context, organizationID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("organization_id = ?", organizationID).Delete(&model.OIDCProvider{}).Error
+}
+
+// CreateLoginState stores a pending login and drops expired ones.
+func (r *OIDCRepository) CreateLoginState(ctx context.Context, state *model.OIDCLoginState) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at < NOW()").Delete(&model.OIDCLoginState{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(state).Error
+	})
+}
+
+// ConsumeLoginState deletes and returns an unexpired login state, so each state can be
+// used for exactly one callback.
+func (r *OIDCRepository) ConsumeLoginState(ctx context.Context, state string) (*model.OIDCLoginState, error) {
+	var consumed []model.OIDCLoginState
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("state = ? AND expires_at > ?", state, time.Now()).
+		Delete(&consumed).Error; err != nil {
+		return nil, err
+	}
+	if len(consumed) == 0 {
+		return nil, nil
+	}
+
+	return &consumed[0], nil
+}
+
+func (r *OIDCRepository) FindIdentity(ctx context.Context, organizationID uuid.UUID, issuer, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	if err := r.db.WithContext(ctx).
+		Where("organization_id = ? AND issuer = ? AND subject = ?", organizationID, issuer, subject).
+		First(&identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+func (r *OIDCRepository) CreateIdentity(ctx context.Context, identity *model.UserIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+func (r *OIDCRepository) TouchIdentity(ctx context.Context, identityID uuid.UUID, email string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.UserIdentity{}).
+		Where("id = ?", identityID).
+		Updates(map[string]interface{}{
+			"email":         email,
+			"last_login_at": gorm.Expr("NOW()"),
+		}).Error
+}
+
+// CreateUserWithIdentity provisions a new user and links the external identity atomically.
+func (r *OIDCRepository) CreateUserWithIdentity(ctx context.Context, user *model.User, identity *model.UserIdentity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return tx.Create(identity).Error
+	})
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OrganizationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+func (r *OrganizationRepository) FindByID(ctx context.Context, organizationID uuid.UUID) (*model.Organization, error) {
+	var organization model.Organization
+	if err := r.db.WithContext(ctx).Where("id = ?", organizationID).First(&organization).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &organization, nil
+}
+
+func (r *OrganizationRepository) FindByCode(ctx context.Context, code string) (*model.Organization, error) {
+	var organization model.Organization
+	if err := r.db.WithContext(ctx).Where("code = ?", code).First(&organization).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &organization, nil
+}
+
+// FindAll lists organizations by code, optionally restricted to one status.
+func (r *OrganizationRepository) FindAll(ctx context.Context, status string) ([]*model.Organization, error) {
+	query := r.db.WithContext(ctx).Order("code")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var organizations []*model.Organization
+	if err := query.Find(&organizations).Error; err != nil {
+		return nil, err
+	}
+	return organizations, nil
+}
+
+// CreateWithInvitation creates an organization together with the invitation of its first
+// admin and the invitation email, so an organization never exists without a way in.
+func (r *OrganizationRepository) CreateWithInvitation(ctx context.Context, organization *model.Organization, invitation *model.Invitation, email *model.EmailQueueEntry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(organization).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(invitation).Error; err != nil {
+			return err
+		}
+		return tx.Create(email).Error
+	})
+}
+
+// UpdateStatus applies a lifecycle change if the organization is currently in one of the
+// given statuses. It reports false if no such organization was found.
+func (r *OrganizationRepository) UpdateStatus(ctx context.Context, organizationID uuid.UUID, fromStatuses []string, updates map[string]interface{}, updatedBy uuid.UUID) (bool, error) {
+	updates["updated_by"] = updatedBy
+	result := r.db.WithContext(ctx).
+		Model(&model.Organization{}).
+		Where("id = ? AND status IN ?", organizationID, fromStatuses).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,8 @@
+package repository
+
+import "gorm.io/gorm"
+
+// PublishFunc publishes the domain event of a change from inside the change's transaction
+// (see events.Bus.Publish), so the event's outbox rows commit with it. An error rolls the
+// change back.
+type PublishFunc func(tx *gorm.DB) error
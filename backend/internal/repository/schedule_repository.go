@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// scheduleColumns selects a schedule with its overdue flag.
+const scheduleColumns = "equipment_maintenance_schedule.*, COALESCE(equipchain.is_maintenance_overdue(equipment_maintenance_schedule.id), false) AS is_overdue"
+
+type ScheduleRepository struct {
+	db *gorm.DB
+}
+
+func NewScheduleRepository(db *gorm.DB) *ScheduleRepository {
+	return &ScheduleRepository{db: db}
+}
+
+// FindByOrganizationID lists schedules, soonest due first. Filters: "equipment_id" and
+// "maintenance_type_id".
+func (r *ScheduleRepository) FindByOrganizationID(ctx context.Context, organizationID uuid.UUID, filters map[string]interface{}) ([]*model.MaintenanceSchedule, error) {
+	query := r.db.WithContext(ctx).Select(scheduleColumns).Where("organization_id = ?", organizationID)
+
+	if equipmentID, ok := filters["equipment_id"]; ok && equipmentID != "" {
+		query = query.Where("equipment_id = ?", equipmentID)
+	}
+
+	if typeID, ok := filters["maintenance_type_id"]; ok && typeID != "" {
+		query = query.Where("maintenance_type_id = ?", typeID)
+	}
+
+	var schedules []*model.MaintenanceSchedule
+	if err := query.Order("next_due_date, created_at").Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (r *ScheduleRepository) FindByID(ctx context.Context, scheduleID uuid.UUID) (*model.MaintenanceSchedule, error) {
+	var schedule model.MaintenanceSchedule
+	if err := r.db.WithContext(ctx).Select(scheduleColumns).Where("id = ?", scheduleID).First(&schedule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// FindByEquipmentAndType returns the equipment's schedule for the maintenance type, or nil.
+func (r *ScheduleRepository) FindByEquipmentAndType(ctx context.Context, equipmentID uuid.UUID, typeID int16) (*model.MaintenanceSchedule, error) {
+	var schedule model.MaintenanceSchedule
+	if err := r.db.WithContext(ctx).
+		Select(scheduleColumns).
+		Where("equipment_id = ? AND maintenance_type_id = ?", equipmentID, typeID).
+		First(&schedule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// FindByEquipmentAndMeter returns the equipment's schedules with a trigger on the meter type.
+func (r *ScheduleRepository) FindByEquipmentAndMeter(ctx context.Context, equipmentID uuid.UUID, meterType string) ([]*model.MaintenanceSchedule, error) {
+	var schedules []*model.MaintenanceSchedule
+	if err := r.db.WithContext(ctx).
+		Select(scheduleColumns).
+		Where("equipment_id = ? AND meter_type = ?", equipmentID, meterType).
+		Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (r *ScheduleRepository) Create(ctx context.Context, schedule *model.MaintenanceSchedule) error {
+	return r.db.WithContext(ctx).Create(schedule).Error
+}
+
+func (r *ScheduleRepository) Update(ctx context.Context, scheduleID uuid.UUID, updates map[string]interface{}, updatedBy uuid.UUID) error {
+	updates["updated_by"] = updatedBy
+	return r.db.WithContext(ctx).
+		Model(&model.MaintenanceSchedule{}).
+		Where("id = ?", scheduleID).
+		Updates(updates).Error
+}
+
+func (r *ScheduleRepository) Delete(ctx context.Context, scheduleID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", scheduleID).Delete(&model.MaintenanceSchedule{}).Error
+}
+
+// FindDue wraps get_equipment_due_for_maintenance(): schedules that are overdue or due
+// within the given number of days, most urgent first.
+func (r *ScheduleRepository) FindDue(ctx context.Context, organizationID uuid.UUID, days int) ([]model.DueMaintenance, error) {
+	var due []model.DueMaintenance
+	err := r.db.WithContext(ctx).
+		Raw(`SELECT schedule_id, equipment_id, equipment_name, maintenance_type_id, maintenance_type,
+		            next_due_date, days_until_due, is_overdue
+		     FROM equipchain.get_equipment_due_for_maintenance(?, ?)`, organizationID, days).
+		Scan(&due).Error
+	return due, err
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const secretColumnBatchSize = 500
+
+// SecretColumn is a database column holding encrypted secrets, keyed by a UUID column.
+type SecretColumn struct {
+	Table     string
+	KeyColumn string
+	Column    string
+}
+
+func (c SecretColumn) String() string {
+	return c.Table + "." + c.Column
+}
+
+// SecretColumnRepository rewrites encrypted columns in place, e.g. to rewrap their values
+// after a master key rotation.
+type SecretColumnRepository struct {
+	db *gorm.DB
+}
+
+func NewSecretColumnRepository(db *gorm.DB) *SecretColumnRepository {
+	return &SecretColumnRepository{db: db}
+}
+
+type secretColumnRow struct {
+	Key   uuid.UUID
+	Value string
+}
+
+// Rewrite passes every non-null value of the column through rewrite, batch by batch, and
+// stores the values it reports as changed. A row changed concurrently is left alone. It
+// returns the number of rows rewritten.
+func (r *SecretColumnRepository) Rewrite(ctx context.Context, column SecretColumn, rewrite func(string) (string, bool, error)) (int, error) {
+	// Identifiers come from the fixed list of secret columns, never from input
+	selectSQL := fmt.Sprintf(`SELECT %[2]s AS key, %[3]s AS value FROM equipchain.%[1]s
+		WHERE %[3]s IS NOT NULL AND %[2]s > ? ORDER BY %[2]s LIMIT ?`, column.Table, column.KeyColumn, column.Column)
+	updateSQL := fmt.Sprintf(`UPDATE equipchain.%[1]s SET %[3]s = ? WHERE %[2]s = ? AND %[3]s = ?`,
+		column.Table, column.KeyColumn, column.Column)
+
+	rewritten := 0
+	after := uuid.Nil
+	for {
+		var rows []secretColumnRow
+		if err := r.db.WithContext(ctx).Raw(selectSQL, after, secretColumnBatchSize).Scan(&rows).Error; err != nil {
+			return rewritten, err
+		}
+
+		for _, row := range rows {
+			value, changed, err := rewrite(row.Value)
+			if err != nil {
+				return rewritten, fmt.Errorf("%s of %s: %w", column, row.Key, err)
+			}
+			if !changed {
+				continue
+			}
+			result := r.db.WithContext(ctx).Exec(updateSQL, value, row.Key, row.Value)
+			if result.Error != nil {
+				return rewritten, result.Error
+			}
+			rewritten += int(result.RowsAffected)
+		}
+
+		if len(rows) < secretColumnBatchSize {
+			return rewritten, nil
+		}
+		after = rows[len(rows)-1].Key
+	}
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SecuritySettingsRepository struct {
+	db *gorm.DB
+}
+
+func NewSecuritySettingsRepository(db *gorm.DB) *SecuritySettingsRepository {
+	return &SecuritySettingsRepository{db: db}
+}
+
+func (r *SecuritySettingsRepository) FindByOrganizationID(ctx context.Context, organizationID uuid.UUID) (*model.OrganizationSecuritySettings, error) {
+	var settings model.OrganizationSecuritySettings
+	if err := r.db.WithContext(ctx).Where("organization_id = ?", organizationID).First(&settings).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+// Upsert inserts the settings row, or overwrites only the given columns (plus updated_by)
+// of an existing one so that password and MFA settings can be changed independently.
+func (r *SecuritySettingsRepository) Upsert(ctx context.Context, settings *model.OrganizationSecuritySettings, columns []string) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "organization_id"}},
+			DoUpdates: clause.AssignmentColumns(append(columns, "updated_by")),
+		}).
+		Create(settings).Error
+}
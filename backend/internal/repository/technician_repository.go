@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TechnicianRepository struct {
+	db *gorm.DB
+}
+
+func NewTechnicianRepository(db *gorm.DB) *TechnicianRepository {
+	return &TechnicianRepository{db: db}
+}
+
+// FindByOrganizationID lists profiles, available technicians first. The "is_available"
+// filter takes a bool.
+func (r *TechnicianRepository) FindByOrganizationID(ctx context.Context, organizationID uuid.UUID, filters map[string]interface{}) ([]*model.TechnicianProfile, error) {
+	query := r.db.WithContext(ctx).Where("organization_id = ?", organizationID)
+
+	if available, ok := filters["is_available"].(bool); ok {
+		query = query.Where("is_available = ?", available)
+	}
+
+	var profiles []*model.TechnicianProfile
+	if err := query.Order("is_available DESC, created_at").Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+func (r *TechnicianRepository) FindByUserID(ctx context.Context, userID uuid.UUID) (*model.TechnicianProfile, error) {
+	var profile model.TechnicianProfile
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *TechnicianRepository) Create(ctx context.Context, profile *model.TechnicianProfile) error {
+	return r.db.WithContext(ctx).Create(profile).Error
+}
+
+func (r *TechnicianRepository) Update(ctx context.Context, profileID uuid.UUID, updates map[string]interface{}, updatedBy uuid.UUID) error {
+	updates["updated_by"] = updatedBy
+	return r.db.WithContext(ctx).
+		Model(&model.TechnicianProfile{}).
+		Where("id = ?", profileID).
+		Updates(updates).Error
+}
+
+func (r *TechnicianRepository) Delete(ctx context.Context, profileID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", profileID).Delete(&model.TechnicianProfile{}).Error
+}
+
+// FindByCertification wraps get_technicians_by_certification(): technicians holding the
+// certification with a license that has not expired, available ones first.
+func (r *TechnicianRepository) FindByCertification(ctx context.Context, organizationID uuid.UUID, certification string) ([]model.CertifiedTechnician, error) {
+	var technicians []model.CertifiedTechnician
+	err := r.db.WithContext(ctx).
+		Raw(`SELECT technician_id, license_number, license_type, is_available, hourly_rate
+		     FROM equipchain.get_technicians_by_certification(?, ?)`, organizationID, certification).
+		Scan(&technicians).Error
+	return technicians, err
+}
+
+// FindExpiringLicenses wraps get_expiring_licenses(): licenses expiring between today and
+// the given number of days from now, soonest first.
+func (r *TechnicianRepository) FindExpiringLicenses(ctx context.Context, organizationID uuid.UUID, days int) ([]model.ExpiringLicense, error) {
+	var licenses []model.ExpiringLicense
+	err := r.db.WithContext(ctx).
+		Raw(`SELECT technician_id, license_number, license_type, license_expiration_date, days_until_expiry
+		     FROM equipchain.get_expiring_licenses(?, ?)`, organizationID, days).
+		Scan(&licenses).Error
+	return licenses, err
+}
+
+// HasExpiredLicense reports whether the user's technician profile carries a license that
+// expired before today (database date). Users without a profile have no expired license.
+func (r *TechnicianRepository) HasExpiredLicense(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.TechnicianProfile{}).
+		Where("user_id = ? AND license_expiration_date < CURRENT_DATE", userID).
+		Count(&count).Error
+	return count > 0, err
+}
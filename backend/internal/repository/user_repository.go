@@ -6,8 +6,15 @@ import (
 	"github.com/NWhite12/EquipChain/internal/model"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// ErrLastAdmin is returned when a change would leave an organization without an admin.
+var ErrLastAdmin = errors.New("organization must keep at least one admin")
+
 type UserRepository struct {
 	db *gorm.DB
 }
@@ -87,18 +94,34 @@ func (r *UserRepository) UpdateProfile(ctx context.Context, userID uuid.UUID, em
 		}).Error
 }
 
-func (r *UserRepository) CheckAndUpdateLockout(ctx context.Context, userID uuid.UUID) (isLocked bool, remainingSeconds int, err error) {
-	var locked bool
-	var remaining int
-	err = r.db.WithContext(ctx).Raw("SELECT is_locked, remaining_lockout_seconds FROM check_and_update_lockout(?)", userID).Row().Scan(locked, remaining)
-	return locked, remaining, err
+// CheckAndUpdateLockout records a failed login via the check_and_update_lockout database
+// function. justLocked is true only for the attempt that triggered a new lockout.
+func (r *UserRepository) CheckAndUpdateLockout(ctx context.Context, userID uuid.UUID, maxAttempts int, lockoutDurations []time.Duration, observationWindow time.Duration) (isLocked bool, remainingSeconds int, justLocked bool, err error) {
+	seconds := make([]string, len(lockoutDurations))
+	for i, d := range lockoutDurations {
+		seconds[i] = strconv.Itoa(int(d.Seconds()))
+	}
+
+	// The array is passed as a literal because gorm expands slice arguments into value lists.
+	err = r.db.WithContext(ctx).
+		Raw("SELECT is_locked, remaining_lockout_seconds, just_locked FROM equipchain.check_and_update_lockout(?, ?, ?::int[], ?)",
+			userID, maxAttempts, "{"+strings.Join(seconds, ",")+"}", int(observationWindow.Seconds())).
+		Row().
+		Scan(&isLocked, &remainingSeconds, &justLocked)
+	return isLocked, remainingSeconds, justLocked, err
 }
 
-func (r *UserRepository) ResetFailedAttempts(ctx context.Context, userID uuid.UUID) error {
-	updates := model.User{
-		FailedLoginAttempts: 0,
-		LockedUntil:         nil,
-		Status:              "active",
+// RecordSuccessfulLogin clears lockout state and stores the time and IP of the login.
+func (r *UserRepository) RecordSuccessfulLogin(ctx context.Context, userID uuid.UUID, ipAddress string) error {
+	updates := map[string]interface{}{
+		"failed_login_attempts": 0,
+		"lockout_count":         0,
+		"locked_until":          nil,
+		"last_login_at":         gorm.Expr("NOW()"),
+		"status":                gorm.Expr("CASE WHEN status = 'locked' THEN 'active' ELSE status END"),
+	}
+	if ipAddress != "" {
+		updates["last_login_ip"] = ipAddress
 	}
 
 	return r.db.WithContext(ctx).
@@ -106,3 +129,208 @@ func (r *UserRepository) ResetFailedAttempts(ctx context.Context, userID uuid.UU
 		Where("id = ?", userID).
 		Updates(updates).Error
 }
+
+// ResetLockout clears failed attempts and any active lock, e.g. when an admin unlocks an account.
+func (r *UserRepository) ResetLockout(ctx context.Context, userID uuid.UUID, updatedBy uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"failed_login_attempts": 0,
+			"lockout_count":         0,
+			"locked_until":          nil,
+			"status":                gorm.Expr("CASE WHEN status = 'locked' THEN 'active' ELSE status END"),
+			"updated_by":            updatedBy,
+		}).Error
+}
+
+func (r *UserRepository) FindRecentPasswordHashes(ctx context.Context, userID uuid.UUID, limit int) ([]string, error) {
+	var hashes []string
+	if limit <= 0 {
+		return hashes, nil
+	}
+
+	if err := r.db.WithContext(ctx).
+		Model(&model.UserPasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("set_at DESC").
+		Limit(limit).
+		Pluck("password_hash", &hashes).Error; err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+// UpdatePassword replaces the user's password hash, archives the previous hash in
+// user_password_history and prunes history beyond keepHistory entries, all in one transaction.
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID uuid.UUID, previousHash, newHash string, keepHistory int, updatedBy uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(
+			`INSERT INTO equipchain.user_password_history (user_id, password_hash, set_at)
+			 VALUES (?, ?, NOW())
+			 ON CONFLICT (user_id, password_hash) DO UPDATE SET set_at = EXCLUDED.set_at`,
+			userID, previousHash,
+		).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.User{}).
+			Where("id = ?", userID).
+			Updates(map[string]interface{}{
+				"password_hash":             newHash,
+				"password_changed_at":       gorm.Expr("NOW()"),
+				"password_reset_required":   false,
+				"password_reset_token_hash": nil,
+				"password_reset_expires_at": nil,
+				"updated_by":                updatedBy,
+			}).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec(
+			`DELETE FROM equipchain.user_password_history
+			 WHERE user_id = ? AND id NOT IN (
+			   SELECT id FROM equipchain.user_password_history
+			   WHERE user_id = ?
+			   ORDER BY set_at DESC
+			   LIMIT ?
+			 )`,
+			userID, userID, keepHistory,
+		).Error
+	})
+}
+
+// UserListFilter narrows FindPage. Zero values are ignored.
+type UserListFilter struct {
+	Status string
+	RoleID int16
+	Search string
+	Limit  int
+	Offset int
+}
+
+// likeEscaper escapes LIKE wildcards so that a search term matches literally; backslash is
+// the default escape character in Postgres.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// FindPage lists an organization's users (excluding deleted ones unless Status asks for
+// them) and returns the total number of matches.
+func (r *UserRepository) FindPage(ctx context.Context, organizationID uuid.UUID, filter UserListFilter) ([]*model.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.User{}).Where("organization_id = ?", organizationID)
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	} else {
+		query = query.Where("status != 'deleted'")
+	}
+	if filter.RoleID != 0 {
+		query = query.Where("role_id = ?", filter.RoleID)
+	}
+	if filter.Search != "" {
+		query = query.Where("email ILIKE ?", "%"+likeEscaper.Replace(filter.Search)+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []*model.User
+	if err := query.Order("email ASC").Limit(filter.Limit).Offset(filter.Offset).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// UpdateRoleOrStatus applies role and/or status updates to a user and stores the audit
+// log entry of the change in the same transaction. It locks the organization's admins
+// first and returns ErrLastAdmin if the change would remove the last admin that is not
+// deactivated.
+func (r *UserRepository) UpdateRoleOrStatus(ctx context.Context, organizationID, userID uuid.UUID, updates map[string]interface{}, updatedBy uuid.UUID, audit *model.AuditLog) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var adminIDs []uuid.UUID
+		if err := tx.Model(&model.User{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("organization_id = ? AND role_id = ? AND status IN ('active', 'locked')", organizationID, model.RoleAdmin).
+			Pluck("id", &adminIDs).Error; err != nil {
+			return err
+		}
+
+		removesAdmin := false
+		if roleID, ok := updates["role_id"]; ok && roleID != model.RoleAdmin {
+			removesAdmin = true
+		}
+		if status, ok := updates["status"]; ok && status != "active" && status != "locked" {
+			removesAdmin = true
+		}
+		if removesAdmin && len(adminIDs) == 1 && adminIDs[0] == userID {
+			return ErrLastAdmin
+		}
+
+		updates["updated_by"] = updatedBy
+		if err := tx.Model(&model.User{}).
+			Where("id = ? AND organization_id = ?", userID, organizationID).
+			Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Create(audit).Error
+	})
+}
+
+// SetPasswordReset forces a password reset with the given token hash, and queues the
+// reset email and stores the audit log entry in one transaction.
+func (r *UserRepository) SetPasswordReset(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time, updatedBy uuid.UUID, email *model.EmailQueueEntry, audit *model.AuditLog) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).
+			Where("id = ?", userID).
+			Updates(map[string]interface{}{
+				"password_reset_required":   true,
+				"password_reset_token_hash": tokenHash,
+				"password_reset_expires_at": expiresAt,
+				"updated_by":                updatedBy,
+			}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(email).Error; err != nil {
+			return err
+		}
+		return tx.Create(audit).Error
+	})
+}
+
+func (r *UserRepository) FindByPasswordResetToken(ctx context.Context, tokenHash string) (*model.User, error) {
+	var user model.User
+	if err := r.db.WithContext(ctx).
+		Where("password_reset_token_hash = ? AND password_reset_expires_at > NOW()", tokenHash).
+		First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// IsPlatformAdmin reports whether an enabled user holds the platform super-admin flag.
+func (r *UserRepository) IsPlatformAdmin(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Where("id = ? AND is_platform_admin = true AND status NOT IN ('inactive', 'deleted')", userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindEnabledByRole lists the organization's users with the role that are not
+// deactivated or deleted.
+func (r *UserRepository) FindEnabledByRole(ctx context.Context, organizationID uuid.UUID, roleID int16) ([]*model.User, error) {
+	var users []*model.User
+	err := r.db.WithContext(ctx).
+		Where("organization_id = ? AND role_id = ? AND status NOT IN ('inactive', 'deleted')", organizationID, roleID).
+		Order("email").
+		Find(&users).Error
+	return users, err
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookDeliveryRepository(db *gorm.DB) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{db: db}
+}
+
+// WithTx returns a repository that works in tx, for event subscribers.
+func (r *WebhookDeliveryRepository) WithTx(tx *gorm.DB) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{db: tx}
+}
+
+// Enqueue stores the deliveries. An event already queued for an integration is skipped.
+func (r *WebhookDeliveryRepository) Enqueue(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "event_id"}, {Name: "integration_id"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "redelivery_of IS NULL"}}},
+			DoNothing:   true,
+		}).
+		Create(&deliveries).Error
+}
+
+// ClaimDue returns up to limit pending deliveries that are due, oldest first, for active
+// integrations. Their next_attempt_at is pushed lease into the future so that other
+// server instances skip them while they are attempted. Deliveries of deactivated
+// integrations stay pending until the integration is reactivated.
+func (r *WebhookDeliveryRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE equipchain.webhook_deliveries
+		SET next_attempt_at = NOW() + make_interval(secs => ?)
+		WHERE id IN (
+			SELECT d.id
+			FROM equipchain.webhook_deliveries d
+			JOIN equipchain.organizations_integrations i ON i.id = d.integration_id
+			WHERE d.status = ? AND d.next_attempt_at <= NOW() AND i.is_active
+			ORDER BY d.next_attempt_at
+			LIMIT ?
+			FOR UPDATE OF d SKIP LOCKED)
+		RETURNING *`, lease.Seconds(), model.WebhookDeliveryPending, limit).
+		Scan(&deliveries).Error
+	return deliveries, err
+}
+
+// WebhookDeliveryFilter selects a page of an integration's deliveries, newest first.
+type WebhookDeliveryFilter struct {
+	Status    string
+	EventType string
+	Limit     int
+	Offset    int
+}
+
+func (r *WebhookDeliveryRepository) FindPage(ctx context.Context, integrationID uuid.UUID, filter WebhookDeliveryFilter) ([]*model.WebhookDelivery, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.WebhookDelivery{}).Where("integration_id = ?", integrationID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []*model.WebhookDelivery
+	if err := query.Order("created_at DESC, id").Limit(filter.Limit).Offset(filter.Offset).Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+func (r *WebhookDeliveryRepository) FindByID(ctx context.Context, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	if err := r.db.WithContext(ctx).Where("id = ?", deliveryID).First(&delivery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+func (r *WebhookDeliveryRepository) Create(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
+}
+
+// RecordAttempt stores the outcome of an attempt. A successful delivery is marked
+// delivered; a failed one is retried at retryAt, or marked failed if retryAt is nil.
+func (r *WebhookDeliveryRepository) RecordAttempt(ctx context.Context, deliveryID uuid.UUID, attempt model.WebhookAttempt, retryAt *time.Time) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"last_attempt_at": now,
+		"request_url":     attempt.RequestURL,
+		"response_status": attempt.ResponseStatus,
+		"response_body":   attempt.ResponseBody,
+		"latency_ms":      attempt.LatencyMS,
+		"last_error":      attempt.Error,
+	}
+	switch {
+	case attempt.Error == nil:
+		updates["status"] = model.WebhookDeliveryDelivered
+		updates["delivered_at"] = now
+	case retryAt != nil:
+		updates["next_attempt_at"] = *retryAt
+	default:
+		updates["status"] = model.WebhookDeliveryFailed
+	}
+
+	return r.db.WithContext(ctx).
+		Model(&model.WebhookDelivery{}).
+		Where("id = ?", deliveryID).
+		Updates(updates).Error
+}
@@ -0,0 +1,120 @@
+// Package scheduling computes when preventive maintenance is next due from fixed day
+// intervals, calendar rules and meter (hours, miles, cycles) triggers. It is pure Go:
+// callers load schedules and meter readings and pass them in.
+package scheduling
+
+import (
+	"math"
+	"time"
+)
+
+// Trigger names the schedule trigger that determined a due date.
+type Trigger string
+
+const (
+	TriggerInterval Trigger = "interval"
+	TriggerCalendar Trigger = "calendar"
+	TriggerMeter    Trigger = "meter"
+)
+
+// maxForecastDays caps meter forecasts for nearly idle equipment.
+const maxForecastDays = 36500
+
+// Plan is a schedule's triggers and service history. Zero FrequencyDays, nil Rule and
+// zero MeterInterval disable the respective trigger.
+type Plan struct {
+	FrequencyDays int
+
+	Rule      *Rule
+	RuleStart time.Time
+
+	MeterInterval float64
+
+	// Start is when the schedule began; a never-serviced interval schedule is due then.
+	Start            time.Time
+	LastServiceDate  *time.Time
+	LastServiceMeter *float64
+}
+
+// MeterState is the equipment's latest reading of the schedule's meter and its average
+// usage per day (0 if unknown).
+type MeterState struct {
+	Value      float64
+	ReadAt     time.Time
+	RatePerDay float64
+}
+
+// Due is the outcome of NextDue. Date is nil when no trigger yields a date, e.g. a
+// meter-only schedule without readings. Meter is the reading at which the meter trigger
+// fires.
+type Due struct {
+	Date         *time.Time
+	Trigger      Trigger
+	Meter        *float64
+	MeterReached bool
+}
+
+// NextDue returns when the plan is next due: whichever trigger comes first. Meter
+// triggers are forecast from the usage rate when not reached yet. A due date never falls
+// on or before the last service date.
+func (p Plan) NextDue(meter *MeterState) Due {
+	var due Due
+	consider := func(date time.Time, trigger Trigger) {
+		date = DateOf(date)
+		if p.LastServiceDate != nil {
+			if floor := DateOf(*p.LastServiceDate).AddDate(0, 0, 1); date.Before(floor) {
+				date = floor
+			}
+		}
+		if due.Date == nil || date.Before(*due.Date) {
+			due.Date = &date
+			due.Trigger = trigger
+		}
+	}
+
+	if p.FrequencyDays > 0 {
+		if p.LastServiceDate != nil {
+			consider(DateOf(*p.LastServiceDate).AddDate(0, 0, p.FrequencyDays), TriggerInterval)
+		} else {
+			consider(p.Start, TriggerInterval)
+		}
+	}
+
+	if p.Rule != nil {
+		after := DateOf(p.RuleStart).AddDate(0, 0, -1)
+		if p.LastServiceDate != nil {
+			after = *p.LastServiceDate
+		}
+		if next, ok := p.Rule.Next(p.RuleStart, after); ok {
+			consider(next, TriggerCalendar)
+		}
+	}
+
+	if p.MeterInterval > 0 {
+		baseline := 0.0
+		if p.LastServiceMeter != nil {
+			baseline = *p.LastServiceMeter
+		}
+		dueMeter := baseline + p.MeterInterval
+		due.Meter = &dueMeter
+
+		if meter != nil {
+			if meter.Value >= dueMeter {
+				due.MeterReached = true
+				consider(meter.ReadAt, TriggerMeter)
+			} else if meter.RatePerDay > 0 {
+				days := math.Ceil((dueMeter - meter.Value) / meter.RatePerDay)
+				if days <= maxForecastDays {
+					consider(DateOf(meter.ReadAt).AddDate(0, 0, int(days)), TriggerMeter)
+				}
+			}
+		}
+	}
+	return due
+}
+
+// DateOf truncates a time to its calendar day at midnight UTC, matching DATE columns.
+func DateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,221 @@
+package scheduling
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParseRule(t *testing.T, value string) *Rule {
+	t.Helper()
+	rule, err := ParseRule(value)
+	if err != nil {
+		t.Fatalf("ParseRule(%q): %v", value, err)
+	}
+	return rule
+}
+
+func float(v float64) *float64 { return &v }
+
+func day(v time.Time) *time.Time { return &v }
+
+func TestPlanNextDue(t *testing.T) {
+	serviced := date(2026, 3, 2)
+
+	tests := []struct {
+		name        string
+		plan        Plan
+		meter       *MeterState
+		wantDate    time.Time // zero: no due date
+		wantTrigger Trigger
+		wantMeter   *float64
+		wantReached bool
+	}{
+		{
+			name:        "never serviced interval is due at the start",
+			plan:        Plan{FrequencyDays: 30, Start: date(2026, 1, 10)},
+			wantDate:    date(2026, 1, 10),
+			wantTrigger: TriggerInterval,
+		},
+		{
+			name:        "interval counts from the last service",
+			plan:        Plan{FrequencyDays: 90, Start: date(2026, 1, 10), LastServiceDate: &serviced},
+			wantDate:    date(2026, 5, 31),
+			wantTrigger: TriggerInterval,
+		},
+		{
+			name: "calendar rule before the interval",
+			plan: Plan{FrequencyDays: 90, Rule: mustParseRule(t, "FREQ=MONTHLY;INTERVAL=3;BYDAY=1MO"), RuleStart: date(2026, 1, 1),
+				Start: date(2026, 1, 1), LastServiceDate: &serviced},
+			wantDate:    date(2026, 4, 6),
+			wantTrigger: TriggerCalendar,
+		},
+		{
+			name: "interval before the calendar rule",
+			plan: Plan{FrequencyDays: 14, Rule: mustParseRule(t, "FREQ=MONTHLY;INTERVAL=3;BYDAY=1MO"), RuleStart: date(2026, 1, 1),
+				Start: date(2026, 1, 1), LastServiceDate: &serviced},
+			wantDate:    date(2026, 3, 16),
+			wantTrigger: TriggerInterval,
+		},
+		{
+			name:        "never serviced calendar rule is due at its first occurrence",
+			plan:        Plan{Rule: mustParseRule(t, "FREQ=MONTHLY;BYMONTHDAY=-1"), RuleStart: date(2026, 2, 1), Start: date(2026, 2, 1)},
+			wantDate:    date(2026, 2, 28),
+			wantTrigger: TriggerCalendar,
+		},
+		{
+			name:     "impossible calendar rule yields nothing",
+			plan:     Plan{Rule: mustParseRule(t, "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30"), RuleStart: date(2026, 1, 1), Start: date(2026, 1, 1)},
+			wantDate: time.Time{},
+		},
+		{
+			name:        "meter forecast before the interval",
+			plan:        Plan{FrequencyDays: 180, MeterInterval: 250, Start: date(2026, 1, 1), LastServiceDate: &serviced, LastServiceMeter: float(1000)},
+			meter:       &MeterState{Value: 1150, ReadAt: date(2026, 4, 1), RatePerDay: 8},
+			wantDate:    date(2026, 4, 14), // 100 hours left at 8 a day: 12.5, rounded up to 13 days
+			wantTrigger: TriggerMeter,
+			wantMeter:   float(1250),
+		},
+		{
+			name:        "interval before a slow meter",
+			plan:        Plan{FrequencyDays: 30, MeterInterval: 250, Start: date(2026, 1, 1), LastServiceDate: &serviced, LastServiceMeter: float(1000)},
+			meter:       &MeterState{Value: 1010, ReadAt: date(2026, 3, 10), RatePerDay: 1},
+			wantDate:    date(2026, 4, 1),
+			wantTrigger: TriggerInterval,
+			wantMeter:   float(1250),
+		},
+		{
+			name:        "reached meter is due when read",
+			plan:        Plan{FrequencyDays: 180, MeterInterval: 250, Start: date(2026, 1, 1), LastServiceDate: &serviced, LastServiceMeter: float(1000)},
+			meter:       &MeterState{Value: 1260, ReadAt: date(2026, 3, 20)},
+			wantDate:    date(2026, 3, 20),
+			wantTrigger: TriggerMeter,
+			wantMeter:   float(1250),
+			wantReached: true,
+		},
+		{
+			name:        "due date never falls on the last service day",
+			plan:        Plan{MeterInterval: 250, Start: date(2026, 1, 1), LastServiceDate: &serviced, LastServiceMeter: float(1000)},
+			meter:       &MeterState{Value: 1300, ReadAt: date(2026, 3, 1)},
+			wantDate:    date(2026, 3, 3),
+			wantTrigger: TriggerMeter,
+			wantMeter:   float(1250),
+			wantReached: true,
+		},
+		{
+			name:      "meter without readings",
+			plan:      Plan{MeterInterval: 500, Start: date(2026, 1, 1)},
+			wantDate:  time.Time{},
+			wantMeter: float(500),
+		},
+		{
+			name:      "idle meter is not forecast",
+			plan:      Plan{MeterInterval: 500, Start: date(2026, 1, 1)},
+			meter:     &MeterState{Value: 100, ReadAt: date(2026, 3, 1), RatePerDay: 0.001},
+			wantDate:  time.Time{},
+			wantMeter: float(500),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			due := tt.plan.NextDue(tt.meter)
+			if tt.wantDate.IsZero() {
+				if due.Date != nil {
+					t.Fatalf("due %s by %s, want no due date", due.Date.Format("2006-01-02"), due.Trigger)
+				}
+			} else {
+				if due.Date == nil || !due.Date.Equal(tt.wantDate) {
+					t.Fatalf("due %v, want %s", due.Date, tt.wantDate.Format("2006-01-02"))
+				}
+				if due.Trigger != tt.wantTrigger {
+					t.Fatalf("trigger %s, want %s", due.Trigger, tt.wantTrigger)
+				}
+			}
+			if (due.Meter == nil) != (tt.wantMeter == nil) || (due.Meter != nil && *due.Meter != *tt.wantMeter) {
+				t.Fatalf("due meter %v, want %v", due.Meter, tt.wantMeter)
+			}
+			if due.MeterReached != tt.wantReached {
+				t.Fatalf("meter reached %v, want %v", due.MeterReached, tt.wantReached)
+			}
+		})
+	}
+}
+
+func TestMeter(t *testing.T) {
+	base := date(2026, 1, 1)
+	at := func(days int) time.Time { return base.AddDate(0, 0, days) }
+
+	tests := []struct {
+		name      string
+		readings  []Reading
+		wantValue float64
+		wantRate  float64
+	}{
+		{
+			name:      "steady usage",
+			readings:  []Reading{{Value: 100, ReadAt: at(0)}, {Value: 150, ReadAt: at(5)}, {Value: 200, ReadAt: at(10)}},
+			wantValue: 200,
+			wantRate:  10,
+		},
+		{
+			name: "usage across a reset",
+			// 100 hours before the meter was replaced and 40 after, over 20 days
+			readings:  []Reading{{Value: 0, ReadAt: at(0)}, {Value: 100, ReadAt: at(10)}, {Value: 5, ReadAt: at(12), Reset: true}, {Value: 45, ReadAt: at(20)}},
+			wantValue: 45,
+			wantRate:  7,
+		},
+		{
+			name:      "reset as the latest reading",
+			readings:  []Reading{{Value: 900, ReadAt: at(0)}, {Value: 990, ReadAt: at(9)}, {Value: 0, ReadAt: at(10), Reset: true}},
+			wantValue: 0,
+			wantRate:  9,
+		},
+		{
+			name:      "readings outside the usage window are ignored",
+			readings:  []Reading{{Value: 0, ReadAt: at(0)}, {Value: 1000, ReadAt: at(100)}, {Value: 1100, ReadAt: at(110)}},
+			wantValue: 1100,
+			wantRate:  10,
+		},
+		{
+			name:      "single reading",
+			readings:  []Reading{{Value: 42, ReadAt: at(0)}},
+			wantValue: 42,
+		},
+		{
+			name:      "readings within a day",
+			readings:  []Reading{{Value: 10, ReadAt: at(0)}, {Value: 20, ReadAt: at(0).Add(6 * time.Hour)}},
+			wantValue: 20,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := Meter(tt.readings)
+			if state == nil {
+				t.Fatal("Meter returned nil")
+			}
+			if state.Value != tt.wantValue || state.RatePerDay != tt.wantRate {
+				t.Fatalf("value %v at %v a day, want %v at %v a day", state.Value, state.RatePerDay, tt.wantValue, tt.wantRate)
+			}
+			if latest := tt.readings[len(tt.readings)-1].ReadAt; !state.ReadAt.Equal(latest) {
+				t.Fatalf("read at %s, want %s", state.ReadAt, latest)
+			}
+		})
+	}
+
+	if Meter(nil) != nil {
+		t.Fatal("Meter without readings is not nil")
+	}
+}
+
+func TestPlanNextDueAfterMeterReset(t *testing.T) {
+	// The meter was replaced after the last service; the baseline is the reset reading,
+	// and the forecast uses the rate measured across the reset.
+	readings := []Reading{{Value: 0, ReadAt: date(2026, 1, 1)}, {Value: 100, ReadAt: date(2026, 1, 11)}, {Value: 5, ReadAt: date(2026, 1, 13), Reset: true}, {Value: 45, ReadAt: date(2026, 1, 21)}}
+	meter := Meter(readings)
+	plan := Plan{MeterInterval: 250, Start: date(2026, 1, 1), LastServiceDate: day(date(2026, 1, 13)), LastServiceMeter: float(5)}
+
+	due := plan.NextDue(meter)
+	// 210 hours left at 7 a day
+	if due.Date == nil || !due.Date.Equal(date(2026, 2, 20)) || due.Trigger != TriggerMeter || *due.Meter != 255 {
+		t.Fatalf("due %v by %s at meter %v, want 2026-02-20 by meter at 255", due.Date, due.Trigger, due.Meter)
+	}
+}
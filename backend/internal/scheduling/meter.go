@@ -0,0 +1,48 @@
+package scheduling
+
+import "time"
+
+// UsageWindow is how far back from the latest reading usage rates are averaged.
+const UsageWindow = 90 * 24 * time.Hour
+
+// Reading is one meter reading. Reset marks a rolled over or replaced meter that
+// restarts at Value.
+type Reading struct {
+	Value  float64
+	ReadAt time.Time
+	Reset  bool
+}
+
+// Meter summarizes a meter's readings, oldest first, into its latest value and average
+// usage per day. Usage across a reset is unknown and skipped. Readings older than
+// UsageWindow before the latest are ignored; the rate is 0 unless the readings span at
+// least a day. Meter returns nil without readings.
+func Meter(readings []Reading) *MeterState {
+	if len(readings) == 0 {
+		return nil
+	}
+	latest := readings[len(readings)-1]
+	state := &MeterState{Value: latest.Value, ReadAt: latest.ReadAt}
+
+	var first *Reading
+	var usage float64
+	for i := range readings {
+		reading := &readings[i]
+		if latest.ReadAt.Sub(reading.ReadAt) > UsageWindow {
+			continue
+		}
+		if first == nil {
+			first = reading
+			continue
+		}
+		if !reading.Reset {
+			usage += reading.Value - readings[i-1].Value
+		}
+	}
+
+	days := latest.ReadAt.Sub(first.ReadAt).Hours() / 24
+	if days >= 1 && usage > 0 {
+		state.RatePerDay = usage / days
+	}
+	return state
+}
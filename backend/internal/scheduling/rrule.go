@@ -0,0 +1,440 @@
+package scheduling
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the RRULE FREQ part.
+type Frequency int
+
+const (
+	Daily Frequency = iota
+	Weekly
+	Monthly
+	Yearly
+)
+
+var frequencyNames = map[string]Frequency{
+	"DAILY":   Daily,
+	"WEEKLY":  Weekly,
+	"MONTHLY": Monthly,
+	"YEARLY":  Yearly,
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+const (
+	maxInterval = 1000
+	// maxPeriods bounds the search for rules that can never match, e.g. BYMONTH=2;BYMONTHDAY=30.
+	maxPeriods = 5000
+)
+
+// ErrInvalidRule is wrapped by every ParseRule error.
+var ErrInvalidRule = errors.New("invalid calendar rule")
+
+// WeekdayNum is a BYDAY entry. N is the ordinal within the month (1 = first, -1 = last);
+// 0 means every such weekday.
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// Rule is a date-only subset of an RFC 5545 RRULE: FREQ, INTERVAL, BYMONTH, BYMONTHDAY,
+// BYDAY and UNTIL. Occurrences are anchored at a start date, which plays the role of
+// DTSTART. "First Monday of each quarter" is FREQ=MONTHLY;INTERVAL=3;BYDAY=1MO anchored
+// in January, or FREQ=YEARLY;BYMONTH=1,4,7,10;BYDAY=1MO.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	ByMonth    []time.Month
+	ByMonthDay []int
+	ByDay      []WeekdayNum
+	Until      *time.Time
+}
+
+// ParseRule parses an RRULE value such as "FREQ=MONTHLY;BYDAY=1MO". A leading "RRULE:"
+// is accepted.
+func ParseRule(value string) (*Rule, error) {
+	value = strings.TrimSpace(value)
+	value = strings.TrimPrefix(strings.ToUpper(value), "RRULE:")
+	if value == "" {
+		return nil, fmt.Errorf("%w: rule is empty", ErrInvalidRule)
+	}
+
+	rule := &Rule{Interval: 1}
+	seen := map[string]bool{}
+	hasFreq := false
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%w: %s given twice", ErrInvalidRule, key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "FREQ":
+			freq, ok := frequencyNames[val]
+			if !ok {
+				return nil, fmt.Errorf("%w: FREQ must be DAILY, WEEKLY, MONTHLY or YEARLY", ErrInvalidRule)
+			}
+			rule.Freq = freq
+			hasFreq = true
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 || interval > maxInterval {
+				return nil, fmt.Errorf("%w: INTERVAL must be between 1 and %d", ErrInvalidRule, maxInterval)
+			}
+			rule.Interval = interval
+		case "BYMONTH":
+			for _, item := range strings.Split(val, ",") {
+				month, err := strconv.Atoi(item)
+				if err != nil || month < 1 || month > 12 {
+					return nil, fmt.Errorf("%w: BYMONTH values must be 1-12", ErrInvalidRule)
+				}
+				rule.ByMonth = append(rule.ByMonth, time.Month(month))
+			}
+		case "BYMONTHDAY":
+			for _, item := range strings.Split(val, ",") {
+				day, err := strconv.Atoi(item)
+				if err != nil || day == 0 || day < -31 || day > 31 {
+					return nil, fmt.Errorf("%w: BYMONTHDAY values must be 1-31 or -31 to -1", ErrInvalidRule)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, day)
+			}
+		case "BYDAY":
+			for _, item := range strings.Split(val, ",") {
+				day, err := parseWeekdayNum(item)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "UNTIL":
+			until, err := parseUntil(val)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		default:
+			return nil, fmt.Errorf("%w: %s is not supported", ErrInvalidRule, key)
+		}
+	}
+	if !hasFreq {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+
+	for _, day := range rule.ByDay {
+		if day.N == 0 {
+			continue
+		}
+		switch {
+		case rule.Freq == Daily || rule.Freq == Weekly:
+			return nil, fmt.Errorf("%w: BYDAY ordinals need FREQ=MONTHLY or YEARLY", ErrInvalidRule)
+		case rule.Freq == Yearly && len(rule.ByMonth) == 0:
+			return nil, fmt.Errorf("%w: BYDAY ordinals with FREQ=YEARLY need BYMONTH", ErrInvalidRule)
+		}
+	}
+	if rule.Freq == Weekly && len(rule.ByMonthDay) > 0 {
+		return nil, fmt.Errorf("%w: BYMONTHDAY cannot be used with FREQ=WEEKLY", ErrInvalidRule)
+	}
+	return rule, nil
+}
+
+func parseWeekdayNum(value string) (WeekdayNum, error) {
+	if len(value) < 2 {
+		return WeekdayNum{}, fmt.Errorf("%w: BYDAY value %q", ErrInvalidRule, value)
+	}
+	weekday, ok := weekdayNames[value[len(value)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("%w: BYDAY value %q", ErrInvalidRule, value)
+	}
+	day := WeekdayNum{Weekday: weekday}
+	if ordinal := value[:len(value)-2]; ordinal != "" {
+		n, err := strconv.Atoi(ordinal)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, fmt.Errorf("%w: BYDAY ordinal in %q must be 1-5 or -5 to -1", ErrInvalidRule, value)
+		}
+		day.N = n
+	}
+	return day, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if len(value) >= 8 {
+		if until, err := time.Parse("20060102", value[:8]); err == nil {
+			return until, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: UNTIL must be YYYYMMDD", ErrInvalidRule)
+}
+
+// String renders the rule in canonical RRULE form (without the "RRULE:" prefix).
+func (r *Rule) String() string {
+	var parts []string
+	for name, freq := range frequencyNames {
+		if freq == r.Freq {
+			parts = append(parts, "FREQ="+name)
+		}
+	}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]string, len(r.ByMonth))
+		for i, month := range r.ByMonth {
+			months[i] = strconv.Itoa(int(month))
+		}
+		parts = append(parts, "BYMONTH="+strings.Join(months, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = weekdayCode(day.Weekday)
+			if day.N != 0 {
+				days[i] = strconv.Itoa(day.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	return strings.Join(parts, ";")
+}
+
+func weekdayCode(weekday time.Weekday) string {
+	for code, day := range weekdayNames {
+		if day == weekday {
+			return code
+		}
+	}
+	return ""
+}
+
+// Next returns the first occurrence that is on or after start and strictly after the
+// given day, or false if the rule has no further occurrence.
+func (r *Rule) Next(start, after time.Time) (time.Time, bool) {
+	start, after = DateOf(start), DateOf(after)
+	from := after.AddDate(0, 0, 1)
+	if from.Before(start) {
+		from = start
+	}
+	if r.Until != nil && from.After(*r.Until) {
+		return time.Time{}, false
+	}
+
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	first := r.periodsBetween(start, from) / interval * interval
+	for k := first; k < first+maxPeriods*interval; k += interval {
+		for _, candidate := range r.expand(start, k) {
+			if candidate.Before(from) {
+				continue
+			}
+			if r.Until != nil && candidate.After(*r.Until) {
+				return time.Time{}, false
+			}
+			return candidate, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// periodsBetween counts whole FREQ periods from the start's period to the day's period.
+func (r *Rule) periodsBetween(start, day time.Time) int {
+	switch r.Freq {
+	case Daily:
+		return daysBetween(start, day)
+	case Weekly:
+		return daysBetween(weekStart(start), weekStart(day)) / 7
+	case Monthly:
+		return (day.Year()-start.Year())*12 + int(day.Month()) - int(start.Month())
+	default:
+		return day.Year() - start.Year()
+	}
+}
+
+// expand lists the occurrences in the k-th period after the start, in order.
+func (r *Rule) expand(start time.Time, k int) []time.Time {
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		day := start.AddDate(0, 0, k)
+		if r.matchesMonth(day.Month()) && r.matchesMonthDay(day) && r.matchesWeekday(day.Weekday()) {
+			days = append(days, day)
+		}
+	case Weekly:
+		monday := weekStart(start).AddDate(0, 0, 7*k)
+		weekdays := []time.Weekday{start.Weekday()}
+		if len(r.ByDay) > 0 {
+			weekdays = weekdays[:0]
+			for _, day := range r.ByDay {
+				weekdays = append(weekdays, day.Weekday)
+			}
+		}
+		for _, weekday := range weekdays {
+			day := monday.AddDate(0, 0, (int(weekday)+6)%7)
+			if r.matchesMonth(day.Month()) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		month := time.Date(start.Year(), start.Month()+time.Month(k), 1, 0, 0, 0, 0, time.UTC)
+		if r.matchesMonth(month.Month()) {
+			days = r.monthDays(start, month.Year(), month.Month())
+		}
+	case Yearly:
+		year := start.Year() + k
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{start.Month()}
+		}
+		for _, month := range months {
+			days = append(days, r.monthDays(start, year, month)...)
+		}
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
+
+// monthDays lists the days of a month selected by BYMONTHDAY and BYDAY (their
+// intersection when both are given), or the start's day of month.
+func (r *Rule) monthDays(start time.Time, year int, month time.Month) []time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	selected := map[int]bool{}
+	switch {
+	case len(r.ByMonthDay) == 0 && len(r.ByDay) == 0:
+		selected[start.Day()] = true
+	case len(r.ByDay) == 0:
+		for _, day := range r.monthDayNumbers(last) {
+			selected[day] = true
+		}
+	default:
+		byMonthDay := map[int]bool{}
+		for _, day := range r.monthDayNumbers(last) {
+			byMonthDay[day] = true
+		}
+		for _, day := range r.ByDay {
+			for _, d := range weekdayOccurrences(year, month, last, day) {
+				if len(r.ByMonthDay) == 0 || byMonthDay[d] {
+					selected[d] = true
+				}
+			}
+		}
+	}
+
+	days := make([]time.Time, 0, len(selected))
+	for day := range selected {
+		if day >= 1 && day <= last {
+			days = append(days, time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+		}
+	}
+	return days
+}
+
+func (r *Rule) monthDayNumbers(last int) []int {
+	days := make([]int, 0, len(r.ByMonthDay))
+	for _, day := range r.ByMonthDay {
+		if day < 0 {
+			day = last + day + 1
+		}
+		if day >= 1 && day <= last {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// weekdayOccurrences returns the days of the month falling on the weekday, narrowed to
+// the ordinal if one is set.
+func weekdayOccurrences(year int, month time.Month, last int, day WeekdayNum) []int {
+	firstWeekday := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC).Weekday()
+	var occurrences []int
+	for d := 1 + (int(day.Weekday)-int(firstWeekday)+7)%7; d <= last; d += 7 {
+		occurrences = append(occurrences, d)
+	}
+
+	switch {
+	case day.N == 0:
+		return occurrences
+	case day.N > 0 && day.N <= len(occurrences):
+		return occurrences[day.N-1 : day.N]
+	case day.N < 0 && -day.N <= len(occurrences):
+		i := len(occurrences) + day.N
+		return occurrences[i : i+1]
+	}
+	return nil
+}
+
+func (r *Rule) matchesMonth(month time.Month) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	last := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, d := range r.monthDayNumbers(last) {
+		if d == day.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesWeekday(weekday time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, day := range r.ByDay {
+		if day.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// weekStart is the Monday of the day's week (RRULE's default WKST=MO).
+func weekStart(day time.Time) time.Time {
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
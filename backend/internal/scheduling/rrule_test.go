@@ -0,0 +1,122 @@
+package scheduling
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"FREQ=MONTHLY;INTERVAL=3;BYDAY=1MO", "FREQ=MONTHLY;INTERVAL=3;BYDAY=1MO"},
+		{"rrule:freq=yearly;bymonth=1,4,7,10;byday=1mo", "FREQ=YEARLY;BYMONTH=1,4,7,10;BYDAY=1MO"},
+		{" FREQ=MONTHLY;BYMONTHDAY=31 ", "FREQ=MONTHLY;BYMONTHDAY=31"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "FREQ=MONTHLY;BYMONTHDAY=-1"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH"},
+		{"FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20271231T000000Z", "FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20271231"},
+		{"FREQ=DAILY;INTERVAL=1", "FREQ=DAILY"},
+		// Never matches, but is well formed; Next reports no occurrence
+		{"FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			rule, err := ParseRule(tt.value)
+			if err != nil {
+				t.Fatalf("ParseRule: %v", err)
+			}
+			if got := rule.String(); got != tt.want {
+				t.Fatalf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRuleRejects(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"RRULE:",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=MONTHLY;FREQ=YEARLY",
+		"FREQ=MONTHLY;INTERVAL=0",
+		"FREQ=MONTHLY;INTERVAL=1001",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYMONTHDAY=-32",
+		"FREQ=YEARLY;BYMONTH=13",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=YEARLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;UNTIL=2027",
+		"FREQ=MONTHLY;COUNT=3",
+		"FREQ=MONTHLY;BYDAY",
+	} {
+		if _, err := ParseRule(value); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("ParseRule(%q) = %v, want ErrInvalidRule", value, err)
+		}
+	}
+}
+
+func TestRuleNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		after time.Time
+		want  time.Time // zero: no further occurrence
+	}{
+		{"first monday of the quarter, monthly", "FREQ=MONTHLY;INTERVAL=3;BYDAY=1MO", date(2026, 1, 1), date(2025, 12, 31), date(2026, 1, 5)},
+		{"first monday of the quarter, monthly, next quarter", "FREQ=MONTHLY;INTERVAL=3;BYDAY=1MO", date(2026, 1, 1), date(2026, 1, 5), date(2026, 4, 6)},
+		{"first monday of the quarter, monthly, across the year", "FREQ=MONTHLY;INTERVAL=3;BYDAY=1MO", date(2026, 1, 1), date(2026, 10, 5), date(2027, 1, 4)},
+		{"first monday of the quarter, yearly", "FREQ=YEARLY;BYMONTH=1,4,7,10;BYDAY=1MO", date(2026, 1, 1), date(2026, 4, 6), date(2026, 7, 6)},
+		{"first monday of the quarter, yearly, across the year", "FREQ=YEARLY;BYMONTH=1,4,7,10;BYDAY=1MO", date(2026, 1, 1), date(2026, 10, 5), date(2027, 1, 4)},
+
+		{"31st skips short months", "FREQ=MONTHLY;BYMONTHDAY=31", date(2026, 1, 31), date(2026, 1, 31), date(2026, 3, 31)},
+		{"31st skips april", "FREQ=MONTHLY;BYMONTHDAY=31", date(2026, 1, 31), date(2026, 3, 31), date(2026, 5, 31)},
+		{"last day of february", "FREQ=MONTHLY;BYMONTHDAY=-1", date(2026, 1, 31), date(2026, 1, 31), date(2026, 2, 28)},
+		{"last day of a leap february", "FREQ=MONTHLY;BYMONTHDAY=-1", date(2028, 1, 1), date(2028, 1, 31), date(2028, 2, 29)},
+		{"last day of a 30-day month", "FREQ=MONTHLY;BYMONTHDAY=-1", date(2026, 1, 1), date(2026, 4, 1), date(2026, 4, 30)},
+		{"last friday", "FREQ=MONTHLY;BYDAY=-1FR", date(2026, 1, 1), date(2026, 1, 30), date(2026, 2, 27)},
+
+		{"every other week on the start's weekday", "FREQ=WEEKLY;INTERVAL=2", date(2026, 1, 5), date(2026, 1, 5), date(2026, 1, 19)},
+		{"every other week, second weekday", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH", date(2026, 1, 5), date(2026, 1, 6), date(2026, 1, 8)},
+		{"every other week skips the odd week", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH", date(2026, 1, 5), date(2026, 1, 8), date(2026, 1, 20)},
+		{"every other month", "FREQ=MONTHLY;INTERVAL=2", date(2026, 1, 15), date(2026, 1, 15), date(2026, 3, 15)},
+		{"every other month from between occurrences", "FREQ=MONTHLY;INTERVAL=2", date(2026, 1, 15), date(2026, 2, 20), date(2026, 3, 15)},
+		{"every other month across the year", "FREQ=MONTHLY;INTERVAL=2", date(2026, 1, 15), date(2026, 11, 15), date(2027, 1, 15)},
+
+		{"nothing before the start", "FREQ=MONTHLY;BYMONTHDAY=1", date(2026, 6, 1), date(2026, 1, 1), date(2026, 6, 1)},
+		{"february 30th never occurs", "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", date(2026, 1, 1), date(2026, 1, 1), time.Time{}},
+		{"april 31st never occurs", "FREQ=MONTHLY;BYMONTH=4;BYMONTHDAY=31", date(2026, 1, 1), date(2026, 1, 1), time.Time{}},
+		{"feb 29th waits for a leap year", "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29", date(2026, 1, 1), date(2026, 1, 1), date(2028, 2, 29)},
+		{"until is inclusive", "FREQ=MONTHLY;BYMONTHDAY=10;UNTIL=20260310", date(2026, 1, 10), date(2026, 2, 10), date(2026, 3, 10)},
+		{"until has passed", "FREQ=MONTHLY;BYMONTHDAY=10;UNTIL=20260309", date(2026, 1, 10), date(2026, 2, 10), time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRule(%q): %v", tt.rule, err)
+			}
+			got, ok := rule.Next(tt.start, tt.after)
+			if tt.want.IsZero() {
+				if ok {
+					t.Fatalf("Next = %s, want no occurrence", got.Format("2006-01-02"))
+				}
+				return
+			}
+			if !ok || !got.Equal(tt.want) {
+				t.Fatalf("Next = %s (%v), want %s", got.Format("2006-01-02"), ok, tt.want.Format("2006-01-02"))
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+)
+
+// TokenScopeAPIKey is stored as "token_scope" for requests authenticated with an API key.
+const TokenScopeAPIKey = "api_key"
+
+// API keys look like eck_<12 hex prefix>_<secret>. The prefix is the lookup id; the
+// whole key is hashed.
+const (
+	apiKeyMarker       = "eck_"
+	apiKeyPrefixBytes  = 6
+	apiKeySecretBytes  = 32
+	maxAPIKeyNameChars = 100
+)
+
+// APIKeyInput is a request to create an API key.
+type APIKeyInput struct {
+	Name        string
+	Permissions []string
+	ExpiresAt   *time.Time
+}
+
+// APIKeyView is the API representation of a key. It never contains the secret.
+type APIKeyView struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	KeyPrefix   string     `json:"key_prefix"`
+	Permissions []string   `json:"permissions"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	LastUsedIP  *string    `json:"last_used_ip"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CreatedBy   uuid.UUID  `json:"created_by"`
+}
+
+// APIKeyPrincipal is the identity a valid API key authenticates as: the creating user,
+// restricted to the key permissions that user's role still grants.
+type APIKeyPrincipal struct {
+	Key         *model.APIKey
+	User        *model.User
+	Permissions []string
+}
+
+type APIKeyService struct {
+	apiKeyRepo        *repository.APIKeyRepository
+	userRepo          *repository.UserRepository
+	permissionService *PermissionService
+	auditService      *AuditService
+}
+
+func NewAPIKeyService(apiKeyRepo *repository.APIKeyRepository, userRepo *repository.UserRepository, permissionService *PermissionService, auditService *AuditService) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo:        apiKeyRepo,
+		userRepo:          userRepo,
+		permissionService: permissionService,
+		auditService:      auditService,
+	}
+}
+
+func (s *APIKeyService) ListKeys(ctx context.Context, organizationID uuid.UUID) ([]APIKeyView, error) {
+	keys, err := s.apiKeyRepo.FindByOrganizationID(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]APIKeyView, 0, len(keys))
+	for i := range keys {
+		view, err := newAPIKeyView(&keys[i])
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, *view)
+	}
+	return views, nil
+}
+
+// CreateKey issues a key for the creator's organization. Every requested permission must be
+// granted by the creator's own role. The plaintext key is returned once and never stored.
+func (s *APIKeyService) CreateKey(ctx context.Context, organizationID, creatorID uuid.UUID, creatorRoleID int16, input APIKeyInput, meta RequestMetadata) (*APIKeyView, string, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len([]rune(name)) > maxAPIKeyNameChars {
+		return nil, "", ErrAPIKeyNameRequired
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, "", ErrInvalidAPIKeyExpiry
+	}
+
+	creatorPermissions, err := s.permissionService.PermissionsForRole(ctx, creatorRoleID)
+	if err != nil {
+		return nil, "", err
+	}
+	permissions := make([]string, 0, len(input.Permissions))
+	seen := make(map[string]bool)
+	for _, permission := range input.Permissions {
+		permission = strings.TrimSpace(permission)
+		if permission == "" || !HasPermission(creatorPermissions, permission) {
+			return nil, "", ErrInvalidAPIKeyPermissions
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+	if len(permissions) == 0 {
+		return nil, "", ErrInvalidAPIKeyPermissions
+	}
+	permissionsJSON, err := json.Marshal(permissions)
+	if err != nil {
+		return nil, "", err
+	}
+
+	prefixBytes := make([]byte, apiKeyPrefixBytes)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return nil, "", err
+	}
+	secret, err := randomURLToken(apiKeySecretBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	prefix := hex.EncodeToString(prefixBytes)
+	plaintext := apiKeyMarker + prefix + "_" + secret
+
+	now := time.Now()
+	key := &model.APIKey{
+		ID:             uuid.New(),
+		OrganizationID: organizationID,
+		Name:           name,
+		KeyPrefix:      prefix,
+		KeyHash:        hashAPIKey(plaintext),
+		Permissions:    permissionsJSON,
+		ExpiresAt:      input.ExpiresAt,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		CreatedBy:      creatorID,
+	}
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	if err := s.auditService.Record(ctx, AuditEntry{
+		OrganizationID: organizationID,
+		ActorID:        &creatorID,
+		EntityType:     "api_key",
+		EntityID:       key.ID,
+		Action:         AuditActionCreate,
+		After: map[string]interface{}{
+			"name":        name,
+			"key_prefix":  prefix,
+			"permissions": permissions,
+			"expires_at":  input.ExpiresAt,
+		},
+		Metadata: meta,
+	}); err != nil {
+		log.Printf("failed to audit creation of api key %s: %v", key.ID, err)
+	}
+
+	view, err := newAPIKeyView(key)
+	if err != nil {
+		return nil, "", err
+	}
+	return view, plaintext, nil
+}
+
+func (s *APIKeyService) RevokeKey(ctx context.Context, organizationID, keyID, revokedBy uuid.UUID, meta RequestMetadata) error {
+	revoked, err := s.apiKeyRepo.Revoke(ctx, organizationID, keyID, revokedBy)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPIKeyNotFound
+	}
+
+	if err := s.auditService.Record(ctx, AuditEntry{
+		OrganizationID: organizationID,
+		ActorID:        &revokedBy,
+		EntityType:     "api_key",
+		EntityID:       keyID,
+		Action:         AuditActionDelete,
+		After:          map[string]interface{}{"event": "api_key_revoked"},
+		Metadata:       meta,
+	}); err != nil {
+		log.Printf("failed to audit revocation of api key %s: %v", keyID, err)
+	}
+
+	return nil
+}
+
+// Authenticate resolves a plaintext key to its principal. Revoked or expired keys, keys of
+// disabled users and malformed keys all yield ErrInvalidAPIKey.
+func (s *APIKeyService) Authenticate(ctx context.Context, plaintext, ipAddress string) (*APIKeyPrincipal, error) {
+	rest, ok := strings.CutPrefix(plaintext, apiKeyMarker)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	prefix, _, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != apiKeyPrefixBytes*2 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.FindByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil || subtle.ConstantTimeCompare([]byte(hashAPIKey(plaintext)), []byte(key.KeyHash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	if !key.Usable(time.Now()) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	user, err := s.userRepo.FindByID(ctx, key.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.OrganizationID != key.OrganizationID || user.Status == "inactive" || user.Status == "deleted" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	keyPermissions, err := key.PermissionList()
+	if err != nil {
+		return nil, err
+	}
+	rolePermissions, err := s.permissionService.PermissionsForRole(ctx, user.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	// A key never outlives a downgrade of its creator's role
+	permissions := make([]string, 0, len(keyPermissions))
+	for _, permission := range keyPermissions {
+		if HasPermission(rolePermissions, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+
+	if err := s.apiKeyRepo.TouchLastUsed(ctx, key.ID, ipAddress); err != nil {
+		log.Printf("failed to record use of api key %s: %v", key.ID, err)
+	}
+
+	return &APIKeyPrincipal{Key: key, User: user, Permissions: permissions}, nil
+}
+
+func newAPIKeyView(key *model.APIKey) (*APIKeyView, error) {
+	permissions, err := key.PermissionList()
+	if err != nil {
+		return nil, err
+	}
+	return &APIKeyView{
+		ID:          key.ID,
+		Name:        key.Name,
+		KeyPrefix:   key.KeyPrefix,
+		Permissions: permissions,
+		ExpiresAt:   key.ExpiresAt,
+		LastUsedAt:  key.LastUsedAt,
+		LastUsedIP:  key.LastUsedIP,
+		RevokedAt:   key.RevokedAt,
+		CreatedAt:   key.CreatedAt,
+		CreatedBy:   key.CreatedBy,
+	}, nil
+}
+
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
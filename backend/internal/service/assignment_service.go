@@ -0,0 +1,406 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/events"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	maxAssignmentNotesLength = 2000
+	earthRadiusKm            = 6371.0
+)
+
+// AssignmentInput dispatches a maintenance record. DueDate uses YYYY-MM-DD.
+type AssignmentInput struct {
+	TechnicianID uuid.UUID `json:"technician_id"`
+	DueDate      *string   `json:"due_date"`
+	Notes        *string   `json:"notes"`
+}
+
+type AssignmentView struct {
+	ID                  uuid.UUID  `json:"id"`
+	MaintenanceRecordID uuid.UUID  `json:"maintenance_record_id"`
+	TechnicianID        uuid.UUID  `json:"technician_id"`
+	AssignedBy          *uuid.UUID `json:"assigned_by"`
+	DueDate             *string    `json:"due_date"`
+	Notes               *string    `json:"notes"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+// AssignedWorkView is an entry of a technician's assignment list.
+type AssignedWorkView struct {
+	AssignmentView
+	EquipmentID           uuid.UUID `json:"equipment_id"`
+	EquipmentSerialNumber string    `json:"equipment_serial_number"`
+	EquipmentMake         string    `json:"equipment_make"`
+	EquipmentModel        string    `json:"equipment_model"`
+	EquipmentLocation     *string   `json:"equipment_location"`
+	MaintenanceTypeID     int16     `json:"maintenance_type_id"`
+	StatusID              int16     `json:"status_id"`
+}
+
+// AssignmentCandidateView is a suggested technician. DistanceKm is nil when either the
+// technician or the equipment has no GPS fix.
+type AssignmentCandidateView struct {
+	TechnicianID       uuid.UUID  `json:"technician_id"`
+	Email              string     `json:"email"`
+	Certifications     []string   `json:"certifications"`
+	CertificationMatch bool       `json:"certification_match"`
+	OpenAssignments    int        `json:"open_assignments"`
+	DistanceKm         *float64   `json:"distance_km"`
+	LastFixAt          *time.Time `json:"last_fix_at"`
+	HourlyRate         *float64   `json:"hourly_rate"`
+}
+
+// AssignmentService dispatches draft and rejected maintenance records to technicians and
+// suggests who to send.
+type AssignmentService struct {
+	assignmentRepo  *repository.AssignmentRepository
+	maintenanceRepo *repository.MaintenanceRepository
+	equipmentRepo   *repository.EquipmentRepository
+	technicianRepo  *repository.TechnicianRepository
+	userRepo        *repository.UserRepository
+	auditService    *AuditService
+	bus             *events.Bus
+}
+
+func NewAssignmentService(assignmentRepo *repository.AssignmentRepository, maintenanceRepo *repository.MaintenanceRepository, equipmentRepo *repository.EquipmentRepository,
+	technicianRepo *repository.TechnicianRepository, userRepo *repository.UserRepository, auditService *AuditService, bus *events.Bus) *AssignmentService {
+	return &AssignmentService{
+		assignmentRepo:  assignmentRepo,
+		maintenanceRepo: maintenanceRepo,
+		equipmentRepo:   equipmentRepo,
+		technicianRepo:  technicianRepo,
+		userRepo:        userRepo,
+		auditService:    auditService,
+		bus:             bus,
+	}
+}
+
+// Candidates ranks the technicians who could take the record: holders of the optional
+// certification first, then by open assignment load, then by distance from their last
+// GPS fix to the equipment's last known position.
+func (s *AssignmentService) Candidates(ctx context.Context, organizationID, recordID uuid.UUID, certification string) ([]AssignmentCandidateView, error) {
+	record, err := s.findRecord(ctx, organizationID, recordID)
+	if err != nil {
+		return nil, err
+	}
+
+	certification = normalizeCertification(certification)
+	if len(certification) > maxCertificationLength {
+		return nil, ErrCertificationRequired
+	}
+
+	candidates, err := s.assignmentRepo.FindCandidates(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	equipmentFix, err := s.maintenanceRepo.LastEquipmentFix(ctx, record.EquipmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]AssignmentCandidateView, 0, len(candidates))
+	for _, candidate := range candidates {
+		profile := model.TechnicianProfile{Certifications: candidate.Certifications}
+		certifications, err := profile.CertificationList()
+		if err != nil {
+			return nil, err
+		}
+
+		view := AssignmentCandidateView{
+			TechnicianID:    candidate.TechnicianID,
+			Email:           candidate.Email,
+			Certifications:  certifications,
+			OpenAssignments: candidate.OpenAssignments,
+			LastFixAt:       candidate.LastFixAt,
+			HourlyRate:      candidate.HourlyRate,
+		}
+		if certification != "" {
+			for _, held := range certifications {
+				if normalizeCertification(held) == certification {
+					view.CertificationMatch = true
+					break
+				}
+			}
+		}
+		if equipmentFix != nil && candidate.LastLatitude != nil && candidate.LastLongitude != nil {
+			distance := distanceKm(*candidate.LastLatitude, *candidate.LastLongitude, equipmentFix.Latitude, equipmentFix.Longitude)
+			view.DistanceKm = &distance
+		}
+		views = append(views, view)
+	}
+
+	sort.SliceStable(views, func(i, j int) bool {
+		a, b := views[i], views[j]
+		if a.CertificationMatch != b.CertificationMatch {
+			return a.CertificationMatch
+		}
+		if a.OpenAssignments != b.OpenAssignments {
+			return a.OpenAssignments < b.OpenAssignments
+		}
+		if (a.DistanceKm == nil) != (b.DistanceKm == nil) {
+			return a.DistanceKm != nil
+		}
+		if a.DistanceKm != nil && *a.DistanceKm != *b.DistanceKm {
+			return *a.DistanceKm < *b.DistanceKm
+		}
+		return a.Email < b.Email
+	})
+	return views, nil
+}
+
+// GetAssignment returns the record's current assignment.
+func (s *AssignmentService) GetAssignment(ctx context.Context, organizationID, recordID uuid.UUID) (*AssignmentView, error) {
+	if _, err := s.findRecord(ctx, organizationID, recordID); err != nil {
+		return nil, err
+	}
+	assignment, err := s.assignmentRepo.FindCurrent(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment == nil {
+		return nil, ErrAssignmentNotFound
+	}
+	view := newAssignmentView(assignment)
+	return &view, nil
+}
+
+// Assign dispatches a draft or rejected record to a technician, replacing any current
+// assignment. The technician becomes the record's technician and is emailed.
+func (s *AssignmentService) Assign(ctx context.Context, organizationID, actorID, recordID uuid.UUID, input AssignmentInput, meta RequestMetadata) (*AssignmentView, error) {
+	record, err := s.findRecord(ctx, organizationID, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if record.StatusID != model.MaintenanceStatusDraft && record.StatusID != model.MaintenanceStatusRejected {
+		return nil, ErrInvalidMaintenanceStatus
+	}
+
+	if input.TechnicianID == uuid.Nil {
+		return nil, fmt.Errorf("%w: technician_id is required", ErrInvalidAssignment)
+	}
+	var dueDate *time.Time
+	if input.DueDate != nil {
+		if dueDate, err = optionalDate(*input.DueDate); err != nil {
+			return nil, fmt.Errorf("%w: due_date must be YYYY-MM-DD", ErrInvalidAssignment)
+		}
+		if dueDate != nil && dueDate.Before(today()) {
+			return nil, fmt.Errorf("%w: due_date is in the past", ErrInvalidAssignment)
+		}
+	}
+	var notes *string
+	if input.Notes != nil {
+		notes = optionalText(*input.Notes)
+		if notes != nil && len(*notes) > maxAssignmentNotesLength {
+			return nil, fmt.Errorf("%w: notes are too long", ErrInvalidAssignment)
+		}
+	}
+
+	technician, err := s.userRepo.FindByID(ctx, input.TechnicianID)
+	if err != nil {
+		return nil, err
+	}
+	if technician == nil || technician.OrganizationID != organizationID || technician.Status == "inactive" || technician.Status == "deleted" {
+		return nil, ErrTechnicianNotAssignable
+	}
+	profile, err := s.technicianRepo.FindByUserID(ctx, technician.ID)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil || !profile.IsAvailable || profile.LicenseExpired(time.Now()) {
+		return nil, ErrTechnicianNotAssignable
+	}
+
+	current, err := s.assignmentRepo.FindCurrent(ctx, record.ID)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && current.TechnicianID == technician.ID {
+		return nil, ErrAlreadyAssigned
+	}
+
+	equipment, err := s.equipmentRepo.FindByID(ctx, record.EquipmentID)
+	if err != nil {
+		return nil, err
+	}
+	if equipment == nil {
+		return nil, ErrEquipmentNotFound
+	}
+	actor, err := s.userRepo.FindByID(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	assignedByEmail := ""
+	if actor != nil {
+		assignedByEmail = actor.Email
+	}
+
+	templateData, err := json.Marshal(map[string]interface{}{
+		"maintenance_record_id":   record.ID,
+		"maintenance_type_id":     record.MaintenanceTypeID,
+		"equipment_serial_number": equipment.SerialNumber,
+		"equipment_make":          equipment.Make,
+		"equipment_model":         equipment.Model,
+		"equipment_location":      equipment.Location,
+		"due_date":                formatDate(dueDate),
+		"notes":                   notes,
+		"assigned_by_email":       assignedByEmail,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	assignment := &model.MaintenanceAssignment{
+		ID:                  uuid.New(),
+		OrganizationID:      organizationID,
+		MaintenanceRecordID: record.ID,
+		TechnicianID:        technician.ID,
+		AssignedBy:          &actorID,
+		DueDate:             dueDate,
+		Notes:               notes,
+		CreatedAt:           now,
+	}
+	email := &model.EmailQueueEntry{
+		ID:             uuid.New(),
+		OrganizationID: organizationID,
+		RecipientEmail: technician.Email,
+		EmailType:      model.EmailTypeTechnicianAssigned,
+		TemplateData:   templateData,
+		Status:         "pending",
+		CreatedAt:      now,
+	}
+	err = s.assignmentRepo.Assign(ctx, assignment, email, func(tx *gorm.DB) error {
+		return s.bus.Publish(ctx, tx, organizationID, events.MaintenanceAssigned, map[string]interface{}{
+			"maintenance_record_id": record.ID,
+			"equipment_id":          record.EquipmentID,
+			"maintenance_type_id":   record.MaintenanceTypeID,
+			"assignment_id":         assignment.ID,
+			"technician_id":         technician.ID,
+			"due_date":              formatDate(dueDate),
+		})
+	})
+	if err != nil {
+		return nil, mapStaleStatus(err)
+	}
+
+	if err := s.auditService.Record(ctx, AuditEntry{
+		OrganizationID: organizationID,
+		ActorID:        &actorID,
+		EntityType:     "maintenance_record",
+		EntityID:       record.ID,
+		Action:         AuditActionUpdate,
+		Before:         map[string]interface{}{"technician_id": record.TechnicianID},
+		After: map[string]interface{}{
+			"event":         "assigned",
+			"assignment_id": assignment.ID,
+			"technician_id": technician.ID,
+			"due_date":      formatDate(dueDate),
+		},
+		Metadata: meta,
+	}); err != nil {
+		log.Printf("failed to audit assignment of maintenance record %s: %v", record.ID, err)
+	}
+
+	view := newAssignmentView(assignment)
+	return &view, nil
+}
+
+// MyAssignments lists the technician's open work: current assignments whose record is
+// still draft or rejected, earliest due date first.
+func (s *AssignmentService) MyAssignments(ctx context.Context, organizationID, technicianID uuid.UUID) ([]AssignedWorkView, error) {
+	assignments, err := s.assignmentRepo.FindOpenByTechnician(ctx, organizationID, technicianID)
+	if err != nil {
+		return nil, err
+	}
+
+	recordIDs := make([]uuid.UUID, 0, len(assignments))
+	for _, assignment := range assignments {
+		recordIDs = append(recordIDs, assignment.MaintenanceRecordID)
+	}
+	records, err := s.maintenanceRepo.FindByIDs(ctx, recordIDs)
+	if err != nil {
+		return nil, err
+	}
+	recordsByID := make(map[uuid.UUID]*model.MaintenanceRecord, len(records))
+	for _, record := range records {
+		recordsByID[record.ID] = record
+	}
+
+	equipmentByID := map[uuid.UUID]*model.Equipment{}
+	views := make([]AssignedWorkView, 0, len(assignments))
+	for _, assignment := range assignments {
+		record, ok := recordsByID[assignment.MaintenanceRecordID]
+		if !ok {
+			continue
+		}
+		equipment, ok := equipmentByID[record.EquipmentID]
+		if !ok {
+			if equipment, err = s.equipmentRepo.FindByID(ctx, record.EquipmentID); err != nil {
+				return nil, err
+			}
+			equipmentByID[record.EquipmentID] = equipment
+		}
+
+		view := AssignedWorkView{
+			AssignmentView:    newAssignmentView(assignment),
+			EquipmentID:       record.EquipmentID,
+			MaintenanceTypeID: record.MaintenanceTypeID,
+			StatusID:          record.StatusID,
+		}
+		if equipment != nil {
+			view.EquipmentSerialNumber = equipment.SerialNumber
+			view.EquipmentMake = equipment.Make
+			view.EquipmentModel = equipment.Model
+			view.EquipmentLocation = equipment.Location
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+func (s *AssignmentService) findRecord(ctx context.Context, organizationID, recordID uuid.UUID) (*model.MaintenanceRecord, error) {
+	record, err := s.maintenanceRepo.FindByID(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil || record.OrganizationID != organizationID {
+		return nil, ErrMaintenanceNotFound
+	}
+	return record, nil
+}
+
+func newAssignmentView(assignment *model.MaintenanceAssignment) AssignmentView {
+	return AssignmentView{
+		ID:                  assignment.ID,
+		MaintenanceRecordID: assignment.MaintenanceRecordID,
+		TechnicianID:        assignment.TechnicianID,
+		AssignedBy:          assignment.AssignedBy,
+		DueDate:             formatDate(assignment.DueDate),
+		Notes:               assignment.Notes,
+		CreatedAt:           assignment.CreatedAt,
+	}
+}
+
+// distanceKm is the great-circle (haversine) distance, rounded to 100 m.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	distance := 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+	return math.Round(distance*10) / 10
+}
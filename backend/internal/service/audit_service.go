@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Audit actions accepted by audit_log.action.
+const (
+	AuditActionCreate = "create"
+	AuditActionRead   = "read"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// RequestMetadata carries the caller's network details into audit records.
+type RequestMetadata struct {
+	IPAddress string
+	UserAgent string
+}
+
+// AuditEntry describes one audit_log row. Before and After are marshalled to JSON.
+type AuditEntry struct {
+	OrganizationID uuid.UUID
+	ActorID        *uuid.UUID
+	EntityType     string
+	EntityID       uuid.UUID
+	Action         string
+	Before         interface{}
+	After          interface{}
+	Metadata       RequestMetadata
+}
+
+type AuditService struct {
+	auditRepo *repository.AuditRepository
+}
+
+func NewAuditService(auditRepo *repository.AuditRepository) *AuditService {
+	return &AuditService{auditRepo: auditRepo}
+}
+
+func (s *AuditService) Record(ctx context.Context, entry AuditEntry) error {
+	log, err := NewAuditLog(entry)
+	if err != nil {
+		return err
+	}
+	return s.auditRepo.Create(ctx, log)
+}
+
+// NewAuditLog builds the audit_log row of an entry, for repositories that write it in the
+// transaction of the change it records.
+func NewAuditLog(entry AuditEntry) (*model.AuditLog, error) {
+	log := &model.AuditLog{
+		ID:             uuid.New(),
+		OrganizationID: entry.OrganizationID,
+		UserID:         entry.ActorID,
+		EntityType:     entry.EntityType,
+		EntityID:       entry.EntityID,
+		Action:         entry.Action,
+		CreatedAt:      time.Now(),
+	}
+
+	var err error
+	if log.ChangesBefore, err = marshalAuditChanges(entry.Before); err != nil {
+		return nil, err
+	}
+	if log.ChangesAfter, err = marshalAuditChanges(entry.After); err != nil {
+		return nil, err
+	}
+
+	if entry.Metadata.IPAddress != "" {
+		ip := entry.Metadata.IPAddress
+		log.IPAddress = &ip
+	}
+	if entry.Metadata.UserAgent != "" {
+		ua := entry.Metadata.UserAgent
+		log.UserAgent = &ua
+	}
+	return log, nil
+}
+
+func marshalAuditChanges(changes interface{}) (json.RawMessage, error) {
+	if changes == nil {
+		return nil, nil
+	}
+	return json.Marshal(changes)
+}
@@ -10,19 +10,41 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+const bcryptCost = 12
+
 type AuthService struct {
-	userRepo   *repository.UserRepository
-	jwtService *JWTService
+	userRepo       *repository.UserRepository
+	jwtService     *JWTService
+	passwordPolicy *PasswordPolicyService
+	lockout        *LockoutService
+	mfa            *MFAService
+	organizations  *OrganizationService
+}
+
+// LoginResult is either a finished login (Token), or a short-lived MFAToken when a second
+// factor is still needed: MFARequired means the user must verify a code, while
+// MFAEnrollmentRequired means organization policy requires MFA the user has not set up.
+type LoginResult struct {
+	Token                 string
+	MFAToken              string
+	MFARequired           bool
+	MFAEnrollmentRequired bool
 }
 
-func NewAuthService(userRepo *repository.UserRepository, jwtService *JWTService) *AuthService {
+func NewAuthService(userRepo *repository.UserRepository, jwtService *JWTService, passwordPolicy *PasswordPolicyService, lockout *LockoutService, mfa *MFAService, organizations *OrganizationService) *AuthService {
 	return &AuthService{
-		userRepo:   userRepo,
-		jwtService: jwtService,
+		userRepo:       userRepo,
+		jwtService:     jwtService,
+		passwordPolicy: passwordPolicy,
+		lockout:        lockout,
+		mfa:            mfa,
+		organizations:  organizations,
 	}
 }
 
-func (s *AuthService) RegisterUser(ctx context.Context, organizationID uuid.UUID, email, password string) (*model.User, error) {
+// NewUser validates the email and password against the organization and returns an unsaved
+// active user with the given role, for the caller to persist.
+func (s *AuthService) NewUser(ctx context.Context, organizationID uuid.UUID, email, password string, roleID int16) (*model.User, error) {
 	existing, err := s.userRepo.FindByEmail(ctx, organizationID, email)
 	if err != nil {
 		return nil, err
@@ -31,76 +53,318 @@ func (s *AuthService) RegisterUser(ctx context.Context, organizationID uuid.UUID
 		return nil, ErrEmailExists
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 12)
-	if err != nil {
+	if err := s.passwordPolicy.ValidatePassword(ctx, organizationID, password); err != nil {
 		return nil, err
 	}
 
-	user := &model.User{
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	if err != nil {
+		return nil, err
+	}
 
+	return &model.User{
 		ID:                              uuid.New(),
 		OrganizationID:                  organizationID,
 		Email:                           email,
 		PasswordHash:                    string(hashedPassword),
-		RoleID:                          4,
+		RoleID:                          roleID,
 		Status:                          "active",
 		EmailVerified:                   false,
 		EmailVerificationToken:          uuid.New().String(),
 		EmailVerificationTokenExpiresAt: nil,
+		PasswordChangedAt:               time.Now(),
 		CreatedAt:                       time.Now(),
 		UpdatedAt:                       time.Now(),
+	}, nil
+}
+
+// LoginUser checks the password. Users with MFA enabled, or required by their organization,
+// receive an MFA-pending token instead of an access token.
+func (s *AuthService) LoginUser(ctx context.Context, orgID uuid.UUID, email, password string, meta RequestMetadata) (*LoginResult, error) {
+	user, err := s.userRepo.FindByEmail(ctx, orgID, email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if s.lockout.IsLocked(user) {
+		return nil, ErrAccountLocked
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, s.registerFailure(ctx, user, meta, ErrInvalidCredentials)
+	}
+
+	if user.Status == "inactive" || user.Status == "deleted" {
+		return nil, ErrAccountDisabled
+	}
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
 	}
-	if err := s.userRepo.Create(ctx, user); err != nil {
+	if err := s.organizations.CheckActive(ctx, user.OrganizationID); err != nil {
 		return nil, err
 	}
-	return user, nil
+
+	mfaEnabled, err := s.mfa.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	mfaRequired := false
+	if !mfaEnabled {
+		if mfaRequired, err = s.mfa.IsRequired(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	if mfaEnabled || mfaRequired {
+		mfaToken, err := s.jwtService.GenerateScopedToken(user.ID, user.OrganizationID, user.Email, user.RoleID, TokenScopeMFA)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFAToken: mfaToken, MFARequired: mfaEnabled, MFAEnrollmentRequired: mfaRequired}, nil
+	}
+
+	token, err := s.finishLogin(ctx, user, meta)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Token: token}, nil
 }
 
-func (s *AuthService) LoginUser(ctx context.Context, orgID uuid.UUID, email, password string) (string, error) {
-	user, err := s.userRepo.FindByEmail(ctx, orgID, email)
+// CompleteMFALogin exchanges an MFA-pending token and a TOTP or recovery code for an access
+// token. Wrong codes count towards account lockout like wrong passwords.
+func (s *AuthService) CompleteMFALogin(ctx context.Context, mfaToken, code string, meta RequestMetadata) (*model.User, string, error) {
+	user, err := s.userFromMFAToken(ctx, mfaToken)
 	if err != nil {
-		return "", err
+		return nil, "", err
+	}
+
+	if err := s.mfa.Verify(ctx, user.ID, code); err != nil {
+		if err == ErrInvalidMFACode {
+			return nil, "", s.registerFailure(ctx, user, meta, ErrInvalidMFACode)
+		}
+		return nil, "", err
+	}
+
+	token, err := s.finishLogin(ctx, user, meta)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, token, nil
+}
+
+// CompleteMFAEnrollment confirms the authenticator of a user who was required to enroll
+// during login, and finishes that login. It returns the recovery codes and an access token.
+// Wrong codes count towards account lockout, as at the MFA step of a login.
+func (s *AuthService) CompleteMFAEnrollment(ctx context.Context, userID uuid.UUID, code string, meta RequestMetadata) ([]string, string, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, "", err
 	}
 	if user == nil {
-		return "", ErrInvalidCredentials
+		return nil, "", ErrInvalidMFAToken
+	}
+	if s.lockout.IsLocked(user) {
+		return nil, "", ErrAccountLocked
 	}
 
-	if user.Status == "locked" && user.LockedUntil != nil {
-		if time.Now().Before(*user.LockedUntil) {
-			return "", ErrAccountLocked
+	codes, err := s.mfa.ConfirmEnrollment(ctx, user.ID, code, meta)
+	if err != nil {
+		if err == ErrInvalidMFACode {
+			return nil, "", s.registerFailure(ctx, user, meta, ErrInvalidMFACode)
 		}
+		return nil, "", err
+	}
 
-		s.userRepo.ResetFailedAttempts(ctx, user.ID)
+	token, err := s.finishLogin(ctx, user, meta)
+	if err != nil {
+		return nil, "", err
 	}
+	return codes, token, nil
+}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		s.userRepo.CheckAndUpdateLockout(ctx, user.ID)
-		return "", ErrInvalidCredentials
+// SigningGrant is a step-up signing token and the factor that was used to obtain it.
+type SigningGrant struct {
+	Token      string
+	AuthFactor string
+	ExpiresIn  time.Duration
+}
+
+// StepUp re-authenticates a signed-in user and returns a short-lived signing token for
+// approval signatures. Accounts with MFA enabled must use a TOTP code; others re-enter
+// their password. Failures count towards account lockout.
+func (s *AuthService) StepUp(ctx context.Context, userID uuid.UUID, password, code string, meta RequestMetadata) (*SigningGrant, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if s.lockout.IsLocked(user) {
+		return nil, ErrAccountLocked
+	}
+
+	mfaEnabled, err := s.mfa.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var factor string
+	switch {
+	case code != "":
+		if err := s.mfa.VerifyTOTP(ctx, user.ID, code); err != nil {
+			if err == ErrInvalidMFACode {
+				return nil, s.registerFailure(ctx, user, meta, ErrInvalidMFACode)
+			}
+			return nil, err
+		}
+		factor = AuthFactorTOTP
+	case mfaEnabled:
+		return nil, ErrTOTPRequired
+	case password != "":
+		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+			return nil, s.registerFailure(ctx, user, meta, ErrInvalidCredentials)
+		}
+		factor = AuthFactorPassword
+	default:
+		return nil, ErrInvalidCredentials
 	}
 
-	s.userRepo.ResetFailedAttempts(ctx, user.ID)
+	token, ttl, err := s.jwtService.GenerateSigningToken(user.ID, user.OrganizationID, user.Email, user.RoleID, factor)
+	if err != nil {
+		return nil, err
+	}
+	return &SigningGrant{Token: token, AuthFactor: factor, ExpiresIn: ttl}, nil
+}
+
+// SessionUser loads the current state of the user behind validated token claims, so that
+// deactivation, role changes and forced password resets apply to tokens already issued.
+// Callers must use the returned user's role rather than the one in the claims.
+func (s *AuthService) SessionUser(ctx context.Context, claims *Claims) (*model.User, error) {
+	user, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.OrganizationID != claims.OrganizationID {
+		return nil, ErrUserNotFound
+	}
+	if user.Status == "inactive" || user.Status == "deleted" {
+		return nil, ErrAccountDisabled
+	}
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
+	return user, nil
+}
+
+func (s *AuthService) userFromMFAToken(ctx context.Context, mfaToken string) (*model.User, error) {
+	claims, err := s.jwtService.ValidateScopedToken(mfaToken, TokenScopeMFA)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	user, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidMFAToken
+	}
+
+	if s.lockout.IsLocked(user) {
+		return nil, ErrAccountLocked
+	}
+	if user.Status == "inactive" || user.Status == "deleted" {
+		return nil, ErrAccountDisabled
+	}
+	if err := s.organizations.CheckActive(ctx, user.OrganizationID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
 
-	token, err := s.jwtService.GenerateToken(user.ID, user.OrganizationID, user.Email, user.RoleID)
+// registerFailure counts a failed credential check and returns ErrAccountLocked if this
+// attempt locked the account, otherwise failure.
+func (s *AuthService) registerFailure(ctx context.Context, user *model.User, meta RequestMetadata, failure error) error {
+	locked, err := s.lockout.RegisterFailure(ctx, user, meta)
 	if err != nil {
+		return err
+	}
+	if locked {
+		return ErrAccountLocked
+	}
+	return failure
+}
+
+func (s *AuthService) finishLogin(ctx context.Context, user *model.User, meta RequestMetadata) (string, error) {
+	if err := s.lockout.RecordSuccess(ctx, user, meta); err != nil {
 		return "", err
 	}
 
-	return token, nil
+	return s.jwtService.GenerateToken(user.ID, user.OrganizationID, user.Email, user.RoleID)
+}
+
+// ChangePassword verifies the current password, enforces the organization's password policy
+// (including reuse of recent passwords) and stores the new hash. A wrong current password
+// counts towards account lockout, as at login.
+func (s *AuthService) ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword string, meta RequestMetadata) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if s.lockout.IsLocked(user) {
+		return ErrAccountLocked
+	}
 
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword)); err != nil {
+		return s.registerFailure(ctx, user, meta, ErrInvalidCredentials)
+	}
+
+	policy, err := s.passwordPolicy.ValidatePasswordChange(ctx, user, newPassword)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcryptCost)
+	if err != nil {
+		return err
+	}
+
+	return s.userRepo.UpdatePassword(ctx, user.ID, user.PasswordHash, string(hashedPassword), policy.HistoryCount, user.ID)
 }
 
-func (s *AuthService) RegisterUserAndGenerateToken(ctx context.Context, organizationID uuid.UUID, email, password string) (*model.User, string, error) {
-	// Register user
-	user, err := s.RegisterUser(ctx, organizationID, email, password)
+// ResetPassword sets a new password using a reset token from a forced password reset email.
+// The token is single-use: storing the new password clears it along with the reset flag.
+func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if token == "" {
+		return ErrInvalidResetToken
+	}
+	user, err := s.userRepo.FindByPasswordResetToken(ctx, hashPasswordResetToken(token))
+	if err != nil {
+		return err
+	}
+	if user == nil || user.Status == "inactive" || user.Status == "deleted" {
+		return ErrInvalidResetToken
+	}
+	if err := s.organizations.CheckActive(ctx, user.OrganizationID); err != nil {
+		return err
+	}
+
+	policy, err := s.passwordPolicy.ValidatePasswordChange(ctx, user, newPassword)
 	if err != nil {
-		return nil, "", err
+		return err
 	}
 
-	// Generate token
-	token, err := s.jwtService.GenerateToken(user.ID, user.OrganizationID, user.Email, user.RoleID)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcryptCost)
 	if err != nil {
-		return nil, "", err
+		return err
 	}
 
-	return user, token, nil
+	return s.userRepo.UpdatePassword(ctx, user.ID, user.PasswordHash, string(hashedPassword), policy.HistoryCount, user.ID)
 }
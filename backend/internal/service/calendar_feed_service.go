@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/ical"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Calendar feed tokens look like ecf_<12 hex prefix>_<secret>, like API keys. The prefix
+// is the lookup id; the whole token is hashed.
+const (
+	calendarFeedMarker      = "ecf_"
+	calendarFeedPrefixBytes = 6
+	calendarFeedSecretBytes = 32
+
+	// calendarFeedDays is how far ahead the feed lists due maintenance.
+	calendarFeedDays = 365
+	// calendarFeedRefresh is the polling interval suggested to calendar clients.
+	calendarFeedRefresh = time.Hour
+
+	calendarProdID = "-//EquipChain//Maintenance Calendar//EN"
+)
+
+// CalendarFeedView describes a user's feed token. It never contains the token itself.
+type CalendarFeedView struct {
+	TokenPrefix string     `json:"token_prefix"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+}
+
+// CalendarFeedFilter narrows the feed. MaintenanceTypeID is a maintenance_type_lookup id;
+// Assignee is a user id or "me" and limits the feed to that user's assignments.
+type CalendarFeedFilter struct {
+	Location          string
+	MaintenanceTypeID string
+	Assignee          string
+}
+
+// CalendarFeedService issues per-user calendar feed tokens and renders the .ics feed of
+// upcoming preventive maintenance and open assignments they authenticate. A feed is only
+// served while its user is enabled, their organization active and their role still grants
+// view:reports.
+type CalendarFeedService struct {
+	feedRepo            *repository.CalendarFeedRepository
+	userRepo            *repository.UserRepository
+	permissionService   *PermissionService
+	organizationService *OrganizationService
+	auditService        *AuditService
+	feedURL             string
+	linkBaseURL         string
+}
+
+// NewCalendarFeedService takes the public URL of the feed endpoint, to which the token and
+// "/maintenance.ics" are appended, and the frontend URL that event links point into.
+func NewCalendarFeedService(feedRepo *repository.CalendarFeedRepository, userRepo *repository.UserRepository, permissionService *PermissionService,
+	organizationService *OrganizationService, auditService *AuditService, feedURL, linkBaseURL string) *CalendarFeedService {
+	return &CalendarFeedService{
+		feedRepo:            feedRepo,
+		userRepo:            userRepo,
+		permissionService:   permissionService,
+		organizationService: organizationService,
+		auditService:        auditService,
+		feedURL:             strings.TrimRight(feedURL, "/"),
+		linkBaseURL:         strings.TrimRight(linkBaseURL, "/"),
+	}
+}
+
+func (s *CalendarFeedService) GetFeed(ctx context.Context, userID uuid.UUID) (*CalendarFeedView, error) {
+	token, err := s.feedRepo.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, ErrCalendarFeedNotFound
+	}
+	return newCalendarFeedView(token), nil
+}
+
+// CreateFeed issues a feed token for the user, replacing any previous one so that its URL
+// stops working. The feed URL embeds the plaintext token; it is returned once and never
+// stored.
+func (s *CalendarFeedService) CreateFeed(ctx context.Context, organizationID, userID uuid.UUID, meta RequestMetadata) (*CalendarFeedView, string, error) {
+	prefixBytes := make([]byte, calendarFeedPrefixBytes)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return nil, "", err
+	}
+	secret, err := randomURLToken(calendarFeedSecretBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	prefix := hex.EncodeToString(prefixBytes)
+	plaintext := calendarFeedMarker + prefix + "_" + secret
+
+	token := &model.CalendarFeedToken{
+		ID:             uuid.New(),
+		OrganizationID: organizationID,
+		UserID:         userID,
+		TokenPrefix:    prefix,
+		TokenHash:      hashAPIKey(plaintext),
+		CreatedAt:      time.Now(),
+	}
+	if err := s.feedRepo.Replace(ctx, token); err != nil {
+		return nil, "", err
+	}
+
+	if err := s.auditService.Record(ctx, AuditEntry{
+		OrganizationID: organizationID,
+		ActorID:        &userID,
+		EntityType:     "calendar_feed",
+		EntityID:       token.ID,
+		Action:         AuditActionCreate,
+		After:          map[string]interface{}{"token_prefix": prefix},
+		Metadata:       meta,
+	}); err != nil {
+		log.Printf("failed to audit creation of calendar feed %s: %v", token.ID, err)
+	}
+
+	return newCalendarFeedView(token), s.feedURL + "/" + plaintext + "/maintenance.ics", nil
+}
+
+func (s *CalendarFeedService) RevokeFeed(ctx context.Context, organizationID, userID uuid.UUID, meta RequestMetadata) error {
+	token, err := s.feedRepo.FindByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return ErrCalendarFeedNotFound
+	}
+	deleted, err := s.feedRepo.Delete(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrCalendarFeedNotFound
+	}
+
+	if err := s.auditService.Record(ctx, AuditEntry{
+		OrganizationID: organizationID,
+		ActorID:        &userID,
+		EntityType:     "calendar_feed",
+		EntityID:       token.ID,
+		Action:         AuditActionDelete,
+		After:          map[string]interface{}{"event": "calendar_feed_revoked"},
+		Metadata:       meta,
+	}); err != nil {
+		log.Printf("failed to audit revocation of calendar feed %s: %v", token.ID, err)
+	}
+
+	return nil
+}
+
+// Render authenticates the plaintext token and builds the user's feed. Unknown tokens and
+// tokens of disabled users or users without view:reports yield ErrCalendarFeedNotFound.
+func (s *CalendarFeedService) Render(ctx context.Context, plaintext string, filter CalendarFeedFilter) (*ical.Calendar, error) {
+	rest, ok := strings.CutPrefix(plaintext, calendarFeedMarker)
+	if !ok {
+		return nil, ErrCalendarFeedNotFound
+	}
+	prefix, _, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != calendarFeedPrefixBytes*2 {
+		return nil, ErrCalendarFeedNotFound
+	}
+
+	token, err := s.feedRepo.FindByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || subtle.ConstantTimeCompare([]byte(hashAPIKey(plaintext)), []byte(token.TokenHash)) != 1 {
+		return nil, ErrCalendarFeedNotFound
+	}
+
+	user, err := s.userRepo.FindByID(ctx, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.OrganizationID != token.OrganizationID || user.Status == "inactive" || user.Status == "deleted" {
+		return nil, ErrCalendarFeedNotFound
+	}
+	if err := s.organizationService.CheckActive(ctx, token.OrganizationID); err != nil {
+		return nil, err
+	}
+	allowed, err := s.permissionService.RoleHasPermission(ctx, user.RoleID, model.PermissionViewReports)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrCalendarFeedNotFound
+	}
+
+	filters, err := calendarFeedFilters(filter, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.feedRepo.FindEvents(ctx, token.OrganizationID, filters, calendarFeedDays)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.feedRepo.TouchLastUsed(ctx, token.ID); err != nil {
+		log.Printf("failed to record use of calendar feed %s: %v", token.ID, err)
+	}
+
+	calendar := &ical.Calendar{
+		ProdID:          calendarProdID,
+		Name:            "EquipChain maintenance",
+		RefreshInterval: calendarFeedRefresh,
+		Events:          make([]ical.Event, 0, len(events)),
+	}
+	now := time.Now()
+	for _, event := range events {
+		calendar.Events = append(calendar.Events, s.newCalendarEvent(event, now))
+	}
+	return calendar, nil
+}
+
+// newCalendarEvent describes a schedule or assignment. UIDs derive from the row id, so
+// rescheduling moves the client's existing event.
+func (s *CalendarFeedService) newCalendarEvent(event model.CalendarEvent, now time.Time) ical.Event {
+	summary := fmt.Sprintf("%s: %s (%s)", event.MaintenanceType, event.EquipmentName, event.SerialNumber)
+	if event.IsOverdue {
+		summary = "[OVERDUE] " + summary
+	}
+
+	description := []string{
+		"Equipment: " + event.EquipmentName,
+		"Serial number: " + event.SerialNumber,
+	}
+	location := ""
+	if event.Location != nil {
+		location = *event.Location
+		description = append(description, "Location: "+location)
+	}
+	description = append(description, "Maintenance type: "+event.MaintenanceType)
+
+	var uid, link string
+	categories := []string{"Maintenance", event.MaintenanceType}
+	if event.Kind == model.CalendarEventAssignment {
+		uid = "assignment-" + event.ID.String() + "@equipchain"
+		link = s.linkBaseURL + "/maintenance/" + event.RecordID.String()
+		description = append(description, "Assigned to: "+*event.AssigneeEmail)
+		categories = append(categories, "Assignment")
+	} else {
+		uid = "schedule-" + event.ID.String() + "@equipchain"
+		link = s.linkBaseURL + "/equipment/" + event.EquipmentID.String()
+		if event.NextDueTrigger != nil {
+			description = append(description, "Due by: "+*event.NextDueTrigger+" trigger")
+		}
+		categories = append(categories, "Preventive schedule")
+	}
+	if event.IsOverdue {
+		description = append(description, "Status: overdue since "+event.DueDate.Format(dateLayout))
+		categories = append(categories, "Overdue")
+	}
+	description = append(description, link)
+
+	return ical.Event{
+		UID:          uid,
+		Date:         event.DueDate,
+		Stamp:        now,
+		LastModified: event.UpdatedAt,
+		Summary:      summary,
+		Description:  strings.Join(description, "\n"),
+		Location:     location,
+		URL:          link,
+		Categories:   categories,
+		Properties: []ical.Property{
+			{Name: "X-EQUIPCHAIN-OVERDUE", Value: strings.ToUpper(strconv.FormatBool(event.IsOverdue))},
+		},
+	}
+}
+
+// calendarFeedFilters validates the filter; an assignee of "me" is the feed's user.
+func calendarFeedFilters(filter CalendarFeedFilter, userID uuid.UUID) (map[string]interface{}, error) {
+	filters := map[string]interface{}{}
+	if location := strings.TrimSpace(filter.Location); location != "" {
+		filters["location"] = location
+	}
+	if filter.MaintenanceTypeID != "" {
+		maintenanceTypeID, err := strconv.ParseInt(filter.MaintenanceTypeID, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid maintenance_type_id", ErrInvalidCalendarFeedFilter)
+		}
+		filters["maintenance_type_id"] = maintenanceTypeID
+	}
+	switch filter.Assignee {
+	case "":
+	case "me":
+		filters["assignee_id"] = userID
+	default:
+		assigneeID, err := uuid.Parse(filter.Assignee)
+		if err != nil {
+			return nil, fmt.Errorf("%w: assignee must be a user id or \"me\"", ErrInvalidCalendarFeedFilter)
+		}
+		filters["assignee_id"] = assigneeID
+	}
+	return filters, nil
+}
+
+func newCalendarFeedView(token *model.CalendarFeedToken) *CalendarFeedView {
+	return &CalendarFeedView{
+		TokenPrefix: token.TokenPrefix,
+		CreatedAt:   token.CreatedAt,
+		LastUsedAt:  token.LastUsedAt,
+	}
+}
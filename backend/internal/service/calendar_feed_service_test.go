@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/google/uuid"
+)
+
+func TestCalendarEventUIDsAreStable(t *testing.T) {
+	s := &CalendarFeedService{linkBaseURL: "https://app.equipchain.example"}
+	email := "ana@example.com"
+	recordID := uuid.MustParse("5e2a9c3b-81f4-4d07-b6e2-0c9f7a4d1e36")
+	schedule := model.CalendarEvent{
+		Kind:            model.CalendarEventSchedule,
+		ID:              uuid.MustParse("0b7c4f1e-2d55-4a8e-9a43-6f0d2c1e8b90"),
+		EquipmentID:     uuid.New(),
+		EquipmentName:   "Excavator 7",
+		SerialNumber:    "EX-0007",
+		MaintenanceType: "Oil change",
+		DueDate:         time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC),
+	}
+	assignment := schedule
+	assignment.Kind = model.CalendarEventAssignment
+	assignment.RecordID = &recordID
+	assignment.AssigneeEmail = &email
+
+	tests := []struct {
+		name  string
+		event model.CalendarEvent
+		uid   string
+	}{
+		{"schedule", schedule, "schedule-0b7c4f1e-2d55-4a8e-9a43-6f0d2c1e8b90@equipchain"},
+		// A schedule and an assignment of the same id stay distinct events
+		{"assignment", assignment, "assignment-0b7c4f1e-2d55-4a8e-9a43-6f0d2c1e8b90@equipchain"},
+	}
+	for _, tt := range tests {
+		first := s.newCalendarEvent(tt.event, time.Now())
+
+		// Rescheduling, overdue status and a later render keep the UID
+		changed := tt.event
+		changed.DueDate = changed.DueDate.AddDate(0, 1, 0)
+		changed.IsOverdue = true
+		changed.UpdatedAt = time.Now()
+		later := s.newCalendarEvent(changed, time.Now().Add(time.Hour))
+
+		if first.UID != tt.uid || later.UID != tt.uid {
+			t.Errorf("%s: UIDs %q and %q, want %q", tt.name, first.UID, later.UID, tt.uid)
+		}
+	}
+}
@@ -2,19 +2,23 @@ package service
 
 import (
 	"context"
+	"github.com/NWhite12/EquipChain/internal/events"
 	"github.com/NWhite12/EquipChain/internal/model"
 	"github.com/NWhite12/EquipChain/internal/repository"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 	"time"
 )
 
 type EquipmentService struct {
 	equipmentRepo *repository.EquipmentRepository
+	bus           *events.Bus
 }
 
-func NewEquipmentService(equipmentRepo *repository.EquipmentRepository) *EquipmentService {
+func NewEquipmentService(equipmentRepo *repository.EquipmentRepository, bus *events.Bus) *EquipmentService {
 	return &EquipmentService{
 		equipmentRepo: equipmentRepo,
+		bus:           bus,
 	}
 }
 func (s *EquipmentService) ValidateEquipment(ctx context.Context, organizationID uuid.UUID, equipment *model.Equipment) error {
@@ -84,7 +88,10 @@ func (s *EquipmentService) CreateEquipment(ctx context.Context, organizationID u
 	}
 
 	// Insert
-	if err := s.equipmentRepo.Create(ctx, equipment); err != nil {
+	err := s.equipmentRepo.Create(ctx, equipment, func(tx *gorm.DB) error {
+		return s.bus.Publish(ctx, tx, organizationID, events.EquipmentCreated, equipmentEventData(equipment))
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -162,13 +169,10 @@ func (s *EquipmentService) UpdateEquipment(ctx context.Context, organizationID u
 		return nil, err
 	}
 
-	// Update
-	if err := s.equipmentRepo.UpdateEquipment(ctx, equipmentID, safeUpdates, updatedBy); err != nil {
-		return nil, err
-	}
-
-	// Reload and return updated equipment
-	return s.equipmentRepo.FindByID(ctx, equipmentID)
+	// Update, returning the updated equipment
+	return s.equipmentRepo.UpdateEquipment(ctx, equipmentID, safeUpdates, updatedBy, func(tx *gorm.DB, updated *model.Equipment) error {
+		return s.bus.Publish(ctx, tx, organizationID, events.EquipmentUpdated, equipmentEventData(updated))
+	})
 }
 
 func (s *EquipmentService) DeleteEquipment(ctx context.Context, organizationID uuid.UUID, equipmentID uuid.UUID) error {
@@ -180,7 +184,9 @@ func (s *EquipmentService) DeleteEquipment(ctx context.Context, organizationID u
 		return ErrEquipmentNotFound
 	}
 
-	return s.equipmentRepo.Delete(ctx, equipmentID)
+	return s.equipmentRepo.Delete(ctx, equipmentID, func(tx *gorm.DB) error {
+		return s.bus.Publish(ctx, tx, organizationID, events.EquipmentDeleted, equipmentEventData(existing))
+	})
 }
 
 func (s *EquipmentService) GetEquipmentByID(ctx context.Context, organizationID uuid.UUID, equipmentID uuid.UUID) (*model.Equipment, error) {
@@ -201,3 +207,16 @@ func (s *EquipmentService) GetEquipmentByID(ctx context.Context, organizationID
 func (s *EquipmentService) ListEquipment(ctx context.Context, organizationID uuid.UUID, filters map[string]interface{}) ([]*model.Equipment, error) {
 	return s.equipmentRepo.FindByOrganizationID(ctx, organizationID, filters)
 }
+
+// equipmentEventData is the data of equipment events.
+func equipmentEventData(equipment *model.Equipment) map[string]interface{} {
+	return map[string]interface{}{
+		"equipment_id":  equipment.ID,
+		"serial_number": equipment.SerialNumber,
+		"make":          equipment.Make,
+		"model":         equipment.Model,
+		"location":      equipment.Location,
+		"status_id":     equipment.StatusID,
+		"owner_id":      equipment.OwnerID,
+	}
+}
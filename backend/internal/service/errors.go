@@ -7,6 +7,7 @@ var (
 	ErrInvalidCredentials     = errors.New("invalid credentials")
 	ErrEmailExists            = errors.New("email already registered in organization")
 	ErrAccountLocked          = errors.New("account temporarily locked")
+	ErrAccountDisabled        = errors.New("account disabled")
 	ErrWeakPassword           = errors.New("password does not meet requirements")
 	ErrSerialNumberRequired   = errors.New("serial_number is required")
 	ErrMakeRequired           = errors.New("make is required")
@@ -19,4 +20,95 @@ var (
 	ErrStatusIDRequired       = errors.New("status_id is required")
 	ErrInvalidStatusID        = errors.New("status_id is invalid")
 	ErrUnauthorized           = errors.New("unauthorized")
+	ErrInvalidPasswordPolicy  = errors.New("min_length must be between 8 and 72 and history_count between 0 and 24")
+	ErrMFAAlreadyEnabled      = errors.New("mfa already enabled")
+	ErrMFANotEnrolled         = errors.New("mfa enrollment not started")
+	ErrMFARequired            = errors.New("mfa is required for this role by organization policy")
+	ErrInvalidMFACode         = errors.New("invalid mfa code")
+	ErrInvalidMFAToken        = errors.New("invalid or expired mfa token")
+	ErrTOTPRequired           = errors.New("a totp code is required for accounts with mfa enabled")
+
+	ErrMaintenanceNotFound         = errors.New("maintenance record not found")
+	ErrInvalidMaintenanceType      = errors.New("maintenance_type_id is invalid")
+	ErrEquipmentNotMaintainable    = errors.New("equipment status does not allow maintenance")
+	ErrInvalidGPSCoordinates       = errors.New("gps coordinates are out of range")
+	ErrNotesRequired               = errors.New("notes are required before submission")
+	ErrInvalidMaintenanceStatus    = errors.New("maintenance record is not in a valid status for this action")
+	ErrSelfApproval                = errors.New("technicians cannot approve or reject their own maintenance records")
+	ErrSigningTokenUsed            = errors.New("signing token has already been used; step up again")
+	ErrRejectionReasonRequired     = errors.New("comments are required when rejecting")
+	ErrNotMaintenanceTechnician    = errors.New("only the assigned technician can modify this maintenance record")
+	ErrInvalidTransactionSignature = errors.New("transaction_signature must be a base58 encoded Solana transaction signature")
+
+	ErrOIDCNotConfigured   = errors.New("single sign-on is not configured for this organization")
+	ErrInvalidOIDCProvider = errors.New("invalid oidc provider configuration")
+	ErrOIDCInvalidState    = errors.New("sso login expired or was already used")
+	ErrOIDCLoginFailed     = errors.New("sso login failed")
+	ErrOIDCEmailNotAllowed = errors.New("email domain is not allowed for this organization")
+
+	ErrInvalidAPIKey            = errors.New("invalid api key")
+	ErrAPIKeyNotFound           = errors.New("api key not found")
+	ErrAPIKeyNameRequired       = errors.New("name is required and must be at most 100 characters")
+	ErrInvalidAPIKeyExpiry      = errors.New("expires_at must be in the future")
+	ErrInvalidAPIKeyPermissions = errors.New("permissions must be a non-empty subset of your own role's permissions")
+
+	ErrRegistrationClosed        = errors.New("registration for this organization is by invitation only")
+	ErrInvalidRegistrationPolicy = errors.New("mode must be invite_only or domain, and domain mode needs at least one valid domain")
+	ErrInvitationNotFound        = errors.New("invitation not found")
+	ErrInvalidInvitation         = errors.New("invitation is invalid, expired or already used")
+	ErrInvalidRole               = errors.New("role_id is invalid")
+	ErrRoleNotAssignable         = errors.New("cannot assign a role more privileged than your own")
+
+	ErrUserNotManageable     = errors.New("cannot manage a user with a more privileged role than your own")
+	ErrLastAdmin             = errors.New("organization must keep at least one admin")
+	ErrCannotDeactivateSelf  = errors.New("you cannot deactivate your own account")
+	ErrUserNotInactive       = errors.New("user is not deactivated")
+	ErrPasswordResetRequired = errors.New("password reset required")
+	ErrInvalidResetToken     = errors.New("password reset token is invalid or expired")
+
+	ErrOrganizationNotFound     = errors.New("organization not found")
+	ErrOrganizationSuspended    = errors.New("organization is suspended")
+	ErrOrganizationCodeExists   = errors.New("organization code already in use")
+	ErrInvalidOrganization      = errors.New("code must be 2-100 lowercase letters, digits, '_' or '-' and name is required")
+	ErrInvalidOrganizationState = errors.New("organization status does not allow this change")
+	ErrOwnOrganization          = errors.New("cannot suspend or delete your own organization")
+	ErrPlatformAdminRequired    = errors.New("platform admin required")
+
+	ErrTechnicianProfileNotFound = errors.New("technician profile not found")
+	ErrTechnicianProfileExists   = errors.New("user already has a technician profile")
+	ErrInvalidTechnicianUser     = errors.New("technician profiles can only be created for enabled, non-viewer users of the organization")
+	ErrInvalidTechnicianProfile  = errors.New("invalid technician profile")
+	ErrCertificationRequired     = errors.New("certification is required")
+	ErrInvalidExpiryWindow       = errors.New("days must be between 1 and 365")
+	ErrTechnicianLicenseExpired  = errors.New("your technician license has expired; renew it before submitting maintenance")
+
+	ErrInvalidAssignment       = errors.New("invalid assignment")
+	ErrTechnicianNotAssignable = errors.New("technician must be an enabled user of the organization with an available profile and a valid license")
+	ErrAlreadyAssigned         = errors.New("maintenance record is already assigned to this technician")
+	ErrAssignmentNotFound      = errors.New("maintenance record is not assigned")
+
+	ErrScheduleNotFound = errors.New("maintenance schedule not found")
+	ErrScheduleExists   = errors.New("equipment already has a schedule for this maintenance type")
+	ErrInvalidSchedule  = errors.New("invalid maintenance schedule")
+	ErrInvalidDueWindow = errors.New("days must be between 1 and 365")
+
+	ErrInvalidMeterReading  = errors.New("invalid meter reading")
+	ErrMeterReadingConflict = errors.New("meter reading conflicts with recorded readings")
+
+	ErrCalendarFeedNotFound      = errors.New("calendar feed not found")
+	ErrInvalidCalendarFeedFilter = errors.New("invalid calendar feed filter")
+
+	ErrIntegrationNotFound     = errors.New("integration not found")
+	ErrInvalidIntegration      = errors.New("invalid integration")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+
+	ErrInvalidInboundSignature = errors.New("invalid inbound webhook signature")
+	ErrInboundWebhookReplayed  = errors.New("inbound webhook nonce already used")
+	ErrInboundWebhookNotFound  = errors.New("inbound webhook not found")
+
+	ErrIntegrationSyncInProgress = errors.New("integration sync already in progress")
+
+	ErrInvalidEventStreamFilter = errors.New("invalid event stream filter")
+	ErrEventTypeForbidden       = errors.New("not permitted to stream event type")
+	ErrInvalidEventCursor       = errors.New("invalid Last-Event-ID")
 )
@@ -0,0 +1,388 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/events"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// A subscriber more than eventStreamBuffer events behind is dropped; its client
+	// reconnects with Last-Event-ID and catches up from the database.
+	eventStreamBuffer = 64
+	eventReplayBatch  = 500
+	// eventDispatchMemory ids of dispatched events are remembered, so that an event seen
+	// both while catching up and as a notification is dispatched once.
+	eventDispatchMemory = 1024
+	// domain_events ids are taken from a sequence on insert but become visible on commit,
+	// so an event may commit after one with a higher id was already seen. Catching up
+	// and replays look back eventCommitWindow ids for such events, so the window must not
+	// exceed eventDispatchMemory. Events committed later than that are not replayed.
+	eventCommitWindow = 256
+
+	// Failed LISTEN connections are retried after listenRetryBase, doubling up to
+	// listenRetryMax.
+	listenRetryBase = time.Second
+	listenRetryMax  = 30 * time.Second
+)
+
+// StreamEvent is an event as sent on the stream. Sequence is its SSE id; the JSON body has
+// the shape of a webhook payload.
+type StreamEvent struct {
+	Sequence       int64           `json:"-"`
+	ID             uuid.UUID       `json:"id"`
+	Event          string          `json:"event"`
+	OrganizationID uuid.UUID       `json:"organization_id"`
+	OccurredAt     time.Time       `json:"occurred_at"`
+	Data           json.RawMessage `json:"data"`
+}
+
+// EventSubscription receives an organization's live events of some types. Events is
+// closed if the subscriber falls too far behind, and when the service stops listening.
+type EventSubscription struct {
+	Events <-chan StreamEvent
+
+	organizationID uuid.UUID
+	eventTypes     []string
+	events         chan StreamEvent
+}
+
+// EventStreamService streams domain events to clients of every server replica. Record
+// subscribes to the event bus and stores each event in domain_events, whose insert
+// trigger NOTIFYs all replicas; Listen receives those notifications and fans the events
+// out to this replica's subscribers. Stored events are kept for the retention period so
+// that clients can replay what they missed.
+type EventStreamService struct {
+	eventRepo *repository.DomainEventRepository
+	retention time.Duration
+
+	mu          sync.Mutex
+	subscribers map[*EventSubscription]struct{}
+	stopped     bool
+
+	// Only used by the Listen goroutine. firstID is the newest event when first
+	// listening; older ones were never this replica's to dispatch.
+	firstID    int64
+	lastID     int64
+	dispatched []int64
+}
+
+func NewEventStreamService(eventRepo *repository.DomainEventRepository, retention time.Duration) *EventStreamService {
+	return &EventStreamService{
+		eventRepo:   eventRepo,
+		retention:   retention,
+		subscribers: make(map[*EventSubscription]struct{}),
+	}
+}
+
+// Record stores a published event for the stream in the transaction it was published in.
+func (s *EventStreamService) Record(ctx context.Context, tx *gorm.DB, event events.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	return s.eventRepo.WithTx(tx).Create(ctx, &model.DomainEvent{
+		EventID:        event.ID,
+		OrganizationID: event.OrganizationID,
+		EventType:      event.Type,
+		Data:           data,
+		OccurredAt:     event.OccurredAt,
+		CreatedAt:      time.Now(),
+	})
+}
+
+// Listen dispatches the events announced by every replica to this replica's subscribers
+// until ctx is cancelled, then closes every subscription so that streams end on shutdown.
+// A lost database connection is re-established, and the events stored meanwhile are
+// dispatched once it is.
+func (s *EventStreamService) Listen(ctx context.Context) {
+	defer s.stop()
+	delay := listenRetryBase
+	for ctx.Err() == nil {
+		connected := false
+		err := s.eventRepo.Listen(ctx, func() {
+			connected = true
+			delay = listenRetryBase
+			if err := s.catchUp(ctx); err != nil {
+				log.Printf("failed to catch up on domain events: %v", err)
+			}
+		}, func(id int64) {
+			event, err := s.eventRepo.FindByID(ctx, id)
+			if err != nil {
+				log.Printf("failed to load domain event %d: %v", id, err)
+				return
+			}
+			if event != nil {
+				s.dispatch(event)
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("domain event listener stopped (connected: %t), retrying in %s: %v", connected, delay, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > listenRetryMax {
+			delay = listenRetryMax
+		}
+	}
+}
+
+// catchUp dispatches the events stored while not listening: those after the last one
+// dispatched, and those within eventCommitWindow before it that committed late. On the
+// first connection there is nothing to catch up on: subscribers replay on their own.
+func (s *EventStreamService) catchUp(ctx context.Context) error {
+	if s.lastID == 0 {
+		latest, err := s.eventRepo.LatestID(ctx)
+		s.firstID, s.lastID = latest, latest
+		return err
+	}
+
+	afterID := max(s.lastID-eventCommitWindow, s.firstID)
+	for {
+		batch, err := s.eventRepo.FindAfter(ctx, nil, afterID, nil, eventReplayBatch)
+		if err != nil {
+			return err
+		}
+		for _, event := range batch {
+			s.dispatch(event)
+			afterID = event.ID
+		}
+		if len(batch) < eventReplayBatch {
+			return nil
+		}
+	}
+}
+
+// dispatch hands an event to the matching subscribers. Subscribers whose buffer is full
+// are dropped.
+func (s *EventStreamService) dispatch(event *model.DomainEvent) {
+	if slices.Contains(s.dispatched, event.ID) {
+		return
+	}
+	if len(s.dispatched) == eventDispatchMemory {
+		s.dispatched = s.dispatched[1:]
+	}
+	s.dispatched = append(s.dispatched, event.ID)
+	s.lastID = max(s.lastID, event.ID)
+
+	streamEvent := newStreamEvent(event)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for subscription := range s.subscribers {
+		if subscription.organizationID != event.OrganizationID || !slices.Contains(subscription.eventTypes, event.EventType) {
+			continue
+		}
+		select {
+		case subscription.events <- streamEvent:
+		default:
+			delete(s.subscribers, subscription)
+			close(subscription.events)
+		}
+	}
+}
+
+// Subscribe starts receiving the organization's live events of the given types.
+// Unsubscribe must be called when the subscriber is done.
+func (s *EventStreamService) Subscribe(organizationID uuid.UUID, eventTypes []string) *EventSubscription {
+	channel := make(chan StreamEvent, eventStreamBuffer)
+	subscription := &EventSubscription{
+		Events:         channel,
+		organizationID: organizationID,
+		eventTypes:     eventTypes,
+		events:         channel,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		close(channel)
+		return subscription
+	}
+	s.subscribers[subscription] = struct{}{}
+	return subscription
+}
+
+func (s *EventStreamService) Unsubscribe(subscription *EventSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subscribers[subscription]; ok {
+		delete(s.subscribers, subscription)
+		close(subscription.events)
+	}
+}
+
+// stop closes every subscription, and those made later, once Listen returns.
+func (s *EventStreamService) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	for subscription := range s.subscribers {
+		delete(s.subscribers, subscription)
+		close(subscription.events)
+	}
+}
+
+// EventCursor is a stream's position: the highest sequence sent and the sequences sent
+// within eventCommitWindow below it. It is the SSE id of every frame, written as the
+// highest sequence followed by ".<distance>" for each of the others, e.g. "1042.3.17", so
+// that a reconnecting client names the events it has, including those that committed out
+// of order.
+type EventCursor struct {
+	lastID int64
+	recent []int64 // ascending, within eventCommitWindow of lastID
+}
+
+// ParseEventCursor parses a Last-Event-ID. A plain sequence number is a cursor that has
+// sent only that event within the window.
+func ParseEventCursor(value string) (*EventCursor, error) {
+	parts := strings.Split(value, ".")
+	if len(parts) > eventCommitWindow {
+		return nil, fmt.Errorf("%w: cursor lists too many events", ErrInvalidEventCursor)
+	}
+	lastID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || lastID < 0 {
+		return nil, ErrInvalidEventCursor
+	}
+
+	cursor := &EventCursor{}
+	if lastID > 0 {
+		cursor.Add(lastID)
+	}
+	for _, part := range parts[1:] {
+		distance, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || distance < 1 || distance >= eventCommitWindow || distance >= lastID {
+			return nil, ErrInvalidEventCursor
+		}
+		cursor.Add(lastID - distance)
+	}
+	return cursor, nil
+}
+
+// Add records that the event with the given sequence was sent.
+func (c *EventCursor) Add(sequence int64) {
+	c.lastID = max(c.lastID, sequence)
+	if i, found := slices.BinarySearch(c.recent, sequence); !found {
+		c.recent = slices.Insert(c.recent, i, sequence)
+	}
+	for len(c.recent) > 0 && c.recent[0] <= c.lastID-eventCommitWindow {
+		c.recent = c.recent[1:]
+	}
+}
+
+// Sent reports whether the event with the given sequence was sent. Only events within
+// eventCommitWindow of the highest are remembered.
+func (c *EventCursor) Sent(sequence int64) bool {
+	_, found := slices.BinarySearch(c.recent, sequence)
+	return found
+}
+
+func (c *EventCursor) String() string {
+	var b strings.Builder
+	b.WriteString(strconv.FormatInt(c.lastID, 10))
+	for i := len(c.recent) - 1; i >= 0; i-- {
+		if c.recent[i] != c.lastID {
+			b.WriteString("." + strconv.FormatInt(c.lastID-c.recent[i], 10))
+		}
+	}
+	return b.String()
+}
+
+// Replay sends the organization's stored events of the given types that the cursor has
+// not sent, in order, and adds them to it. It looks back eventCommitWindow before the
+// cursor for events that committed after the client saw a higher one.
+func (s *EventStreamService) Replay(ctx context.Context, organizationID uuid.UUID, cursor *EventCursor, eventTypes []string, send func(StreamEvent) error) error {
+	afterID := max(cursor.lastID-eventCommitWindow, 0)
+	for {
+		batch, err := s.eventRepo.FindAfter(ctx, &organizationID, afterID, eventTypes, eventReplayBatch)
+		if err != nil {
+			return err
+		}
+		for _, event := range batch {
+			afterID = event.ID
+			if cursor.Sent(event.ID) {
+				continue
+			}
+			cursor.Add(event.ID)
+			if err := send(newStreamEvent(event)); err != nil {
+				return err
+			}
+		}
+		if len(batch) < eventReplayBatch {
+			return nil
+		}
+	}
+}
+
+// StreamTypes resolves the event types a caller with the given permissions streams:
+// the requested ones, or every type the caller may see if none are requested. Equipment
+// events need view:equipment, the others view:reports.
+func (s *EventStreamService) StreamTypes(permissions []string, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		var visible []string
+		for _, eventType := range events.Types {
+			if HasPermission(permissions, eventTypePermission(eventType)) {
+				visible = append(visible, eventType)
+			}
+		}
+		if len(visible) == 0 {
+			return nil, ErrEventTypeForbidden
+		}
+		return visible, nil
+	}
+
+	var eventTypes []string
+	for _, eventType := range requested {
+		eventType = strings.TrimSpace(eventType)
+		if !slices.Contains(events.Types, eventType) {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidEventStreamFilter, eventType)
+		}
+		if !HasPermission(permissions, eventTypePermission(eventType)) {
+			return nil, fmt.Errorf("%w: %s needs %s", ErrEventTypeForbidden, eventType, eventTypePermission(eventType))
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	return eventTypes, nil
+}
+
+// Prune deletes stored events older than the retention period.
+func (s *EventStreamService) Prune(ctx context.Context) error {
+	_, err := s.eventRepo.DeleteCreatedBefore(ctx, time.Now().Add(-s.retention))
+	return err
+}
+
+func eventTypePermission(eventType string) string {
+	if strings.HasPrefix(eventType, "equipment.") {
+		return model.PermissionViewEquipment
+	}
+	return model.PermissionViewReports
+}
+
+func newStreamEvent(event *model.DomainEvent) StreamEvent {
+	return StreamEvent{
+		Sequence:       event.ID,
+		ID:             event.EventID,
+		Event:          event.EventType,
+		OrganizationID: event.OrganizationID,
+		OccurredAt:     event.OccurredAt,
+		Data:           event.Data,
+	}
+}
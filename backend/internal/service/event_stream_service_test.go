@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/events"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/NWhite12/EquipChain/internal/testdb"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func createDomainEvent(t *testing.T, db *gorm.DB, organizationID uuid.UUID) *model.DomainEvent {
+	t.Helper()
+	event := &model.DomainEvent{
+		EventID:        uuid.New(),
+		OrganizationID: organizationID,
+		EventType:      events.EquipmentUpdated,
+		Data:           []byte(`{}`),
+		OccurredAt:     time.Now(),
+		CreatedAt:      time.Now(),
+	}
+	if err := db.Create(event).Error; err != nil {
+		t.Fatalf("create domain event: %v", err)
+	}
+	return event
+}
+
+func TestCatchUpDispatchesEventsCommittedOutOfOrder(t *testing.T) {
+	db := testdb.Open(t)
+	organization := testdb.CreateOrganization(t, db)
+	ctx := context.Background()
+
+	s := NewEventStreamService(repository.NewDomainEventRepository(db), time.Hour)
+	subscription := s.Subscribe(organization.ID, []string{events.EquipmentUpdated})
+	defer s.Unsubscribe(subscription)
+	if err := s.catchUp(ctx); err != nil {
+		t.Fatalf("first catch up: %v", err)
+	}
+
+	// The event with the lower id commits last: the other one was already dispatched
+	// when the listener lost its connection
+	late := createDomainEvent(t, db, organization.ID)
+	early := createDomainEvent(t, db, organization.ID)
+	s.dispatch(early)
+	if err := s.catchUp(ctx); err != nil {
+		t.Fatalf("catch up: %v", err)
+	}
+
+	var received []int64
+	for len(subscription.Events) > 0 {
+		received = append(received, (<-subscription.Events).Sequence)
+	}
+	if len(received) != 2 || received[0] != early.ID || received[1] != late.ID {
+		t.Fatalf("received events %v, want %d then the late %d once each", received, early.ID, late.ID)
+	}
+}
+
+func TestEventCursor(t *testing.T) {
+	cursor := &EventCursor{}
+	// Events arrive in commit order, so a lower sequence may follow a higher one
+	for _, sequence := range []int64{1000, 1003, 1001, 1042, 1003} {
+		cursor.Add(sequence)
+	}
+	if got := cursor.String(); got != "1042.39.41.42" {
+		t.Fatalf("cursor %q, want 1042.39.41.42", got)
+	}
+	for sequence, want := range map[int64]bool{1042: true, 1003: true, 1001: true, 1000: true, 1002: false, 1043: false} {
+		if cursor.Sent(sequence) != want {
+			t.Errorf("Sent(%d) = %v, want %v", sequence, !want, want)
+		}
+	}
+
+	parsed, err := ParseEventCursor(cursor.String())
+	if err != nil || parsed.String() != cursor.String() {
+		t.Fatalf("parse %q = %v, %v", cursor, parsed, err)
+	}
+
+	// Only the events within the commit window are remembered
+	cursor.Add(1000 + eventCommitWindow + 1)
+	if cursor.Sent(1000) || !cursor.Sent(1042) {
+		t.Fatalf("cursor %q after moving past the window of 1000", cursor)
+	}
+
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{"0", "0", true},
+		{"17", "17", true},
+		{"17.16", "17.16", true},
+		{"17.1.1", "17.1", true},
+		{"", "", false},
+		{"-1", "", false},
+		{"abc", "", false},
+		{"17.", "", false},
+		{"17.0", "", false},
+		{"17.17", "", false},
+		{"1000.256", "", false},
+		{"17.-1", "", false},
+		{"17.x", "", false},
+	}
+	for _, tt := range tests {
+		parsed, err := ParseEventCursor(tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseEventCursor(%q) = %v, want ok %v", tt.value, err, tt.ok)
+			continue
+		}
+		if tt.ok && parsed.String() != tt.want {
+			t.Errorf("ParseEventCursor(%q) = %q, want %q", tt.value, parsed, tt.want)
+		}
+	}
+	if _, err := ParseEventCursor("1000" + strings.Repeat(".1", eventCommitWindow)); err == nil {
+		t.Error("parsed a cursor listing more events than the window holds")
+	}
+}
+
+func TestReplaySendsEventsCommittedOutOfOrder(t *testing.T) {
+	db := testdb.Open(t)
+	organization := testdb.CreateOrganization(t, db)
+	ctx := context.Background()
+	s := NewEventStreamService(repository.NewDomainEventRepository(db), time.Hour)
+
+	// The client saw first and third; second committed after third was sent
+	first := createDomainEvent(t, db, organization.ID)
+	second := createDomainEvent(t, db, organization.ID)
+	third := createDomainEvent(t, db, organization.ID)
+	fourth := createDomainEvent(t, db, organization.ID)
+	sent := &EventCursor{}
+	sent.Add(first.ID)
+	sent.Add(third.ID)
+
+	cursor, err := ParseEventCursor(sent.String())
+	if err != nil {
+		t.Fatalf("parse cursor %q: %v", sent, err)
+	}
+	var replayed []int64
+	if err := s.Replay(ctx, organization.ID, cursor, []string{events.EquipmentUpdated}, func(event StreamEvent) error {
+		replayed = append(replayed, event.Sequence)
+		return nil
+	}); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if len(replayed) != 2 || replayed[0] != second.ID || replayed[1] != fourth.ID {
+		t.Fatalf("replayed %v, want the late %d and the new %d", replayed, second.ID, fourth.ID)
+	}
+	for _, event := range []*model.DomainEvent{first, second, third, fourth} {
+		if !cursor.Sent(event.ID) {
+			t.Fatalf("cursor %q does not list %d after the replay", cursor, event.ID)
+		}
+	}
+}
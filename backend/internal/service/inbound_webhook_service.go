@@ -0,0 +1,435 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// Inbound calls carry the Unix time and a unique nonce in these headers, and in
+	// WebhookSignatureHeader "sha256=" and the hex HMAC-SHA256 of "<timestamp>.<nonce>.<body>"
+	// keyed with the integration's webhook secret.
+	InboundTimestampHeader = "X-EquipChain-Timestamp"
+	InboundNonceHeader     = "X-EquipChain-Nonce"
+
+	// MaxInboundWebhookBytes is the largest body accepted.
+	MaxInboundWebhookBytes = 256 << 10
+
+	// inboundTolerance is how far the timestamp may be off the server clock. Nonces must be
+	// unique per integration forever, so replays outside it are caught too.
+	inboundTolerance = 5 * time.Minute
+	minInboundNonce  = 16
+	maxInboundNonce  = 128
+
+	maxInboundEventTypeChars  = 100
+	maxExternalReferenceChars = 255
+	maxExternalStatusChars    = 100
+)
+
+// InboundWebhookRequest is a call to an integration's inbound endpoint as received.
+type InboundWebhookRequest struct {
+	Timestamp string
+	Nonce     string
+	Signature string
+	Body      []byte
+}
+
+// InboundWebhookReceipt acknowledges a stored call.
+type InboundWebhookReceipt struct {
+	ID     uuid.UUID `json:"id"`
+	Status string    `json:"status"`
+}
+
+// inboundEvent is the body format of known events; other bodies are stored unhandled.
+type inboundEvent struct {
+	ID    string          `json:"id"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// inboundReferenceData is the data of maintenance.acknowledged and claim.updated events.
+type inboundReferenceData struct {
+	MaintenanceRecordID string  `json:"maintenance_record_id"`
+	Reference           string  `json:"reference"`
+	Status              *string `json:"status"`
+}
+
+// inboundReferenceTypes maps the known event types to the reference they attach.
+var inboundReferenceTypes = map[string]string{
+	model.InboundEventMaintenanceAcknowledged: model.ExternalReferenceAcknowledgement,
+	model.InboundEventClaimUpdated:            model.ExternalReferenceClaim,
+}
+
+type InboundWebhookQuery struct {
+	Status    string
+	EventType string
+	Page      int
+	PageSize  int
+}
+
+// InboundWebhookView is an inbound call as stored, with its body.
+type InboundWebhookView struct {
+	ID                  uuid.UUID  `json:"id"`
+	IntegrationID       uuid.UUID  `json:"integration_id"`
+	Nonce               string     `json:"nonce"`
+	SignedAt            time.Time  `json:"signed_at"`
+	EventType           *string    `json:"event_type"`
+	ExternalID          *string    `json:"external_id"`
+	Body                string     `json:"body"`
+	Status              string     `json:"status"`
+	Error               *string    `json:"error"`
+	MaintenanceRecordID *uuid.UUID `json:"maintenance_record_id"`
+	ReceivedAt          time.Time  `json:"received_at"`
+	ProcessedAt         *time.Time `json:"processed_at"`
+}
+
+type InboundWebhookPage struct {
+	Inbound  []InboundWebhookView `json:"inbound"`
+	Total    int64                `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"page_size"`
+}
+
+type ExternalReferenceView struct {
+	IntegrationID uuid.UUID `json:"integration_id"`
+	ReferenceType string    `json:"reference_type"`
+	Reference     string    `json:"reference"`
+	Status        *string   `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// InboundWebhookService receives partners' calls to /api/integrations/:id/inbound. Calls
+// are authenticated by signature, checked for replays and stored; known event types attach
+// external references (insurer acknowledgements, claims) to maintenance records.
+type InboundWebhookService struct {
+	inboundRepo         *repository.InboundWebhookRepository
+	integrationRepo     *repository.IntegrationRepository
+	maintenanceRepo     *repository.MaintenanceRepository
+	organizationService *OrganizationService
+	auditService        *AuditService
+}
+
+func NewInboundWebhookService(inboundRepo *repository.InboundWebhookRepository, integrationRepo *repository.IntegrationRepository, maintenanceRepo *repository.MaintenanceRepository, organizationService *OrganizationService, auditService *AuditService) *InboundWebhookService {
+	return &InboundWebhookService{
+		inboundRepo:         inboundRepo,
+		integrationRepo:     integrationRepo,
+		maintenanceRepo:     maintenanceRepo,
+		organizationService: organizationService,
+		auditService:        auditService,
+	}
+}
+
+// Receive verifies and stores a call, then processes it. Calls that fail verification are
+// not stored. Unknown event types and bodies that are not JSON events are stored as
+// unhandled, and known events that cannot be applied as failed; both are still accepted.
+func (s *InboundWebhookService) Receive(ctx context.Context, integrationID uuid.UUID, req InboundWebhookRequest) (*InboundWebhookReceipt, error) {
+	integration, err := s.integrationRepo.FindByID(ctx, integrationID)
+	if err != nil {
+		return nil, err
+	}
+	if integration == nil || !integration.IsActive {
+		return nil, ErrIntegrationNotFound
+	}
+	if err := s.organizationService.CheckActive(ctx, integration.OrganizationID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	signedAt, err := verifyInboundWebhook(integration.WebhookSecrets(now), req, now)
+	if err != nil {
+		return nil, err
+	}
+
+	inbound := &model.InboundWebhook{
+		ID:             uuid.New(),
+		OrganizationID: integration.OrganizationID,
+		IntegrationID:  integration.ID,
+		Nonce:          req.Nonce,
+		SignedAt:       signedAt,
+		Body:           strings.ToValidUTF8(strings.ReplaceAll(string(req.Body), "\x00", ""), "\uFFFD"),
+		Status:         model.InboundWebhookReceived,
+		ReceivedAt:     now,
+	}
+	var event inboundEvent
+	if json.Unmarshal(req.Body, &event) == nil {
+		inbound.EventType = truncatedText(event.Event, maxInboundEventTypeChars)
+		inbound.ExternalID = truncatedText(event.ID, maxExternalReferenceChars)
+	}
+
+	created, err := s.inboundRepo.Create(ctx, inbound)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrInboundWebhookReplayed
+	}
+
+	status, err := s.process(ctx, inbound, event)
+	if err != nil {
+		return nil, err
+	}
+	return &InboundWebhookReceipt{ID: inbound.ID, Status: status}, nil
+}
+
+// process applies a stored call and returns its final status.
+func (s *InboundWebhookService) process(ctx context.Context, inbound *model.InboundWebhook, event inboundEvent) (string, error) {
+	referenceType, known := inboundReferenceTypes[event.Event]
+	if inbound.EventType == nil || !known {
+		return model.InboundWebhookUnhandled, s.inboundRepo.Finish(ctx, inbound.ID, model.InboundWebhookUnhandled, nil)
+	}
+
+	data, err := parseInboundReferenceData(event.Data)
+	if err != nil {
+		return s.fail(ctx, inbound, err.Error())
+	}
+	record, err := s.maintenanceRepo.FindByID(ctx, data.recordID)
+	if err != nil {
+		return "", err
+	}
+	if record == nil || record.OrganizationID != inbound.OrganizationID {
+		return s.fail(ctx, inbound, ErrMaintenanceNotFound.Error())
+	}
+
+	now := time.Now()
+	reference := &model.MaintenanceExternalReference{
+		ID:                  uuid.New(),
+		OrganizationID:      inbound.OrganizationID,
+		MaintenanceRecordID: record.ID,
+		IntegrationID:       inbound.IntegrationID,
+		ReferenceType:       referenceType,
+		Reference:           data.reference,
+		Status:              data.status,
+		InboundWebhookID:    &inbound.ID,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	if err := s.inboundRepo.ApplyReference(ctx, reference); err != nil {
+		return "", err
+	}
+
+	if err := s.auditService.Record(ctx, AuditEntry{
+		OrganizationID: inbound.OrganizationID,
+		EntityType:     "maintenance_record",
+		EntityID:       record.ID,
+		Action:         AuditActionUpdate,
+		After: map[string]interface{}{
+			"integration_id":     reference.IntegrationID,
+			"inbound_webhook_id": inbound.ID,
+			"reference_type":     reference.ReferenceType,
+			"reference":          reference.Reference,
+			"status":             reference.Status,
+		},
+	}); err != nil {
+		log.Printf("failed to audit external reference of maintenance record %s: %v", record.ID, err)
+	}
+	return model.InboundWebhookProcessed, nil
+}
+
+// ListInbound returns a page of the calls an integration received, newest first.
+func (s *InboundWebhookService) ListInbound(ctx context.Context, organizationID, integrationID uuid.UUID, query InboundWebhookQuery) (*InboundWebhookPage, error) {
+	if err := s.checkIntegration(ctx, organizationID, integrationID); err != nil {
+		return nil, err
+	}
+	switch query.Status {
+	case "", model.InboundWebhookReceived, model.InboundWebhookProcessed, model.InboundWebhookUnhandled, model.InboundWebhookFailed:
+	default:
+		return nil, fmt.Errorf("%w: unknown inbound status %q", ErrInvalidIntegration, query.Status)
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 {
+		query.PageSize = defaultDeliveryPageSize
+	}
+	if query.PageSize > maxDeliveryPageSize {
+		query.PageSize = maxDeliveryPageSize
+	}
+
+	inbound, total, err := s.inboundRepo.FindPage(ctx, integrationID, repository.InboundWebhookFilter{
+		Status:    query.Status,
+		EventType: query.EventType,
+		Limit:     query.PageSize,
+		Offset:    (query.Page - 1) * query.PageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &InboundWebhookPage{Inbound: make([]InboundWebhookView, 0, len(inbound)), Total: total, Page: query.Page, PageSize: query.PageSize}
+	for _, call := range inbound {
+		page.Inbound = append(page.Inbound, newInboundWebhookView(call))
+	}
+	return page, nil
+}
+
+func (s *InboundWebhookService) GetInbound(ctx context.Context, organizationID, integrationID, inboundID uuid.UUID) (*InboundWebhookView, error) {
+	inbound, err := s.inboundRepo.FindByID(ctx, inboundID)
+	if err != nil {
+		return nil, err
+	}
+	if inbound == nil || inbound.OrganizationID != organizationID || inbound.IntegrationID != integrationID {
+		return nil, ErrInboundWebhookNotFound
+	}
+	view := newInboundWebhookView(inbound)
+	return &view, nil
+}
+
+// ListReferences returns the external references partners attached to a maintenance record.
+func (s *InboundWebhookService) ListReferences(ctx context.Context, organizationID, recordID uuid.UUID) ([]ExternalReferenceView, error) {
+	record, err := s.maintenanceRepo.FindByID(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil || record.OrganizationID != organizationID {
+		return nil, ErrMaintenanceNotFound
+	}
+
+	references, err := s.inboundRepo.FindReferences(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	views := make([]ExternalReferenceView, 0, len(references))
+	for _, reference := range references {
+		views = append(views, ExternalReferenceView{
+			IntegrationID: reference.IntegrationID,
+			ReferenceType: reference.ReferenceType,
+			Reference:     reference.Reference,
+			Status:        reference.Status,
+			CreatedAt:     reference.CreatedAt,
+			UpdatedAt:     reference.UpdatedAt,
+		})
+	}
+	return views, nil
+}
+
+func (s *InboundWebhookService) checkIntegration(ctx context.Context, organizationID, integrationID uuid.UUID) error {
+	integration, err := s.integrationRepo.FindByID(ctx, integrationID)
+	if err != nil {
+		return err
+	}
+	if integration == nil || integration.OrganizationID != organizationID {
+		return ErrIntegrationNotFound
+	}
+	return nil
+}
+
+func (s *InboundWebhookService) fail(ctx context.Context, inbound *model.InboundWebhook, reason string) (string, error) {
+	return model.InboundWebhookFailed, s.inboundRepo.Finish(ctx, inbound.ID, model.InboundWebhookFailed, &reason)
+}
+
+type inboundReference struct {
+	recordID  uuid.UUID
+	reference string
+	status    *string
+}
+
+// parseInboundReferenceData validates the data of a reference event. The error explains
+// why the call could not be applied and is stored with it.
+func parseInboundReferenceData(data json.RawMessage) (*inboundReference, error) {
+	var payload inboundReferenceData
+	if len(data) == 0 || json.Unmarshal(data, &payload) != nil {
+		return nil, fmt.Errorf("data must be an object with maintenance_record_id and reference")
+	}
+	recordID, err := uuid.Parse(payload.MaintenanceRecordID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid maintenance_record_id")
+	}
+	reference := strings.TrimSpace(payload.Reference)
+	if reference == "" || len([]rune(reference)) > maxExternalReferenceChars {
+		return nil, fmt.Errorf("reference must be 1-%d characters", maxExternalReferenceChars)
+	}
+	parsed := &inboundReference{recordID: recordID, reference: reference}
+	if payload.Status != nil {
+		status := strings.TrimSpace(*payload.Status)
+		if status == "" || len([]rune(status)) > maxExternalStatusChars {
+			return nil, fmt.Errorf("status must be 1-%d characters", maxExternalStatusChars)
+		}
+		parsed.status = &status
+	}
+	return parsed, nil
+}
+
+// verifyInboundWebhook checks the timestamp, the nonce format and the signature against
+// each of the secrets, and returns the signing time.
+func verifyInboundWebhook(secrets []string, req InboundWebhookRequest, now time.Time) (time.Time, error) {
+	unix, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: missing or invalid %s", ErrInvalidInboundSignature, InboundTimestampHeader)
+	}
+	signedAt := time.Unix(unix, 0)
+	if signedAt.Before(now.Add(-inboundTolerance)) || signedAt.After(now.Add(inboundTolerance)) {
+		return time.Time{}, fmt.Errorf("%w: timestamp is more than %s off", ErrInvalidInboundSignature, inboundTolerance)
+	}
+	if !validInboundNonce(req.Nonce) {
+		return time.Time{}, fmt.Errorf("%w: %s must be %d-%d letters, digits, '-' or '_'", ErrInvalidInboundSignature, InboundNonceHeader, minInboundNonce, maxInboundNonce)
+	}
+
+	signed := []byte(req.Timestamp + "." + req.Nonce + ".")
+	signed = append(signed, req.Body...)
+	for _, signature := range strings.Split(req.Signature, ",") {
+		given, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(signature), "sha256="))
+		if err != nil {
+			continue
+		}
+		for _, secret := range secrets {
+			expected, _ := hex.DecodeString(signWebhook(secret, signed))
+			if hmac.Equal(given, expected) {
+				return signedAt, nil
+			}
+		}
+	}
+	return time.Time{}, ErrInvalidInboundSignature
+}
+
+func validInboundNonce(nonce string) bool {
+	if len(nonce) < minInboundNonce || len(nonce) > maxInboundNonce {
+		return false
+	}
+	for _, r := range nonce {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// truncatedText trims value and cuts it to max characters; empty yields nil.
+func truncatedText(value string, max int) *string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	if runes := []rune(value); len(runes) > max {
+		value = string(runes[:max])
+	}
+	return &value
+}
+
+func newInboundWebhookView(inbound *model.InboundWebhook) InboundWebhookView {
+	return InboundWebhookView{
+		ID:                  inbound.ID,
+		IntegrationID:       inbound.IntegrationID,
+		Nonce:               inbound.Nonce,
+		SignedAt:            inbound.SignedAt,
+		EventType:           inbound.EventType,
+		ExternalID:          inbound.ExternalID,
+		Body:                inbound.Body,
+		Status:              inbound.Status,
+		Error:               inbound.Error,
+		MaintenanceRecordID: inbound.MaintenanceRecordID,
+		ReceivedAt:          inbound.ReceivedAt,
+		ProcessedAt:         inbound.ProcessedAt,
+	}
+}
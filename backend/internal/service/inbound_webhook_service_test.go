@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/envelope"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/NWhite12/EquipChain/internal/testdb"
+	"github.com/google/uuid"
+)
+
+const testInboundSecret = "whsec_current"
+
+// signedInboundRequest signs body as a partner would at signedAt.
+func signedInboundRequest(secret, nonce string, signedAt time.Time, body string) InboundWebhookRequest {
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	return InboundWebhookRequest{
+		Timestamp: timestamp,
+		Nonce:     nonce,
+		Signature: "sha256=" + signWebhook(secret, []byte(timestamp+"."+nonce+"."+body)),
+		Body:      []byte(body),
+	}
+}
+
+func TestVerifyInboundWebhook(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	nonce := "4f1c2a9e-77b0-4c3d"
+	body := `{"id":"evt_1","event":"claim.updated","data":{}}`
+	valid := signedInboundRequest(testInboundSecret, nonce, now, body)
+
+	with := func(change func(*InboundWebhookRequest)) InboundWebhookRequest {
+		req := valid
+		change(&req)
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		secrets []string
+		req     InboundWebhookRequest
+		ok      bool
+	}{
+		{"valid", []string{testInboundSecret}, valid, true},
+		{"signed by the previous secret", []string{"whsec_new", testInboundSecret}, valid, true},
+		{"one of several signatures", []string{testInboundSecret}, with(func(r *InboundWebhookRequest) {
+			r.Signature = "sha256=00ff, " + r.Signature
+		}), true},
+		{"at the past tolerance", []string{testInboundSecret}, signedInboundRequest(testInboundSecret, nonce, now.Add(-inboundTolerance), body), true},
+		{"at the future tolerance", []string{testInboundSecret}, signedInboundRequest(testInboundSecret, nonce, now.Add(inboundTolerance), body), true},
+		{"stale", []string{testInboundSecret}, signedInboundRequest(testInboundSecret, nonce, now.Add(-inboundTolerance-time.Second), body), false},
+		{"from the future", []string{testInboundSecret}, signedInboundRequest(testInboundSecret, nonce, now.Add(inboundTolerance+time.Second), body), false},
+		{"missing timestamp", []string{testInboundSecret}, with(func(r *InboundWebhookRequest) { r.Timestamp = "" }), false},
+		{"wrong secret", []string{"whsec_other"}, valid, false},
+		{"no secret", nil, valid, false},
+		{"tampered body", []string{testInboundSecret}, with(func(r *InboundWebhookRequest) { r.Body = []byte(`{"id":"evt_2"}`) }), false},
+		{"timestamp not signed", []string{testInboundSecret}, with(func(r *InboundWebhookRequest) {
+			r.Timestamp = strconv.FormatInt(now.Unix()+1, 10)
+		}), false},
+		{"nonce not signed", []string{testInboundSecret}, with(func(r *InboundWebhookRequest) { r.Nonce = "5f1c2a9e-77b0-4c3d" }), false},
+		{"signature of the body only", []string{testInboundSecret}, with(func(r *InboundWebhookRequest) {
+			r.Signature = "sha256=" + signWebhook(testInboundSecret, []byte(body))
+		}), false},
+		{"not hex", []string{testInboundSecret}, with(func(r *InboundWebhookRequest) { r.Signature = "sha256=zz" }), false},
+		{"no signature", []string{testInboundSecret}, with(func(r *InboundWebhookRequest) { r.Signature = "" }), false},
+		{"short nonce", []string{testInboundSecret}, signedInboundRequest(testInboundSecret, "abc", now, body), false},
+		{"nonce with separator", []string{testInboundSecret}, signedInboundRequest(testInboundSecret, "4f1c2a9e.77b0.4c3d", now, body), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signedAt, err := verifyInboundWebhook(tt.secrets, tt.req, now)
+			if tt.ok {
+				if err != nil || strconv.FormatInt(signedAt.Unix(), 10) != tt.req.Timestamp {
+					t.Fatalf("verify = %v, %v; want it signed at %s", signedAt, err, tt.req.Timestamp)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidInboundSignature) {
+				t.Fatalf("verify = %v, want ErrInvalidInboundSignature", err)
+			}
+		})
+	}
+}
+
+func TestReceiveRejectsReplayedNonces(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	organization := testdb.CreateOrganization(t, db)
+
+	cipher, err := envelope.New(map[int][]byte{1: make([]byte, envelope.KeySize)}, 1)
+	if err != nil {
+		t.Fatalf("cipher: %v", err)
+	}
+	integrationRepo := repository.NewIntegrationRepository(db, cipher)
+	secret := testInboundSecret
+	now := time.Now()
+	integration := &model.OrganizationIntegration{
+		ID:              uuid.New(),
+		OrganizationID:  organization.ID,
+		IntegrationType: model.IntegrationTypeInsuranceAPI,
+		WebhookSecret:   &secret,
+		EventTypes:      json.RawMessage("[]"),
+		Settings:        json.RawMessage("{}"),
+		IsActive:        true,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := integrationRepo.Create(ctx, integration); err != nil {
+		t.Fatalf("create integration: %v", err)
+	}
+	s := NewInboundWebhookService(repository.NewInboundWebhookRepository(db), integrationRepo, repository.NewMaintenanceRepository(db),
+		NewOrganizationService(repository.NewOrganizationRepository(db)), NewAuditService(repository.NewAuditRepository(db)))
+
+	body := `{"id":"evt_1","event":"partner.ping"}`
+	req := signedInboundRequest(secret, "4f1c2a9e-77b0-4c3d", now, body)
+	receipt, err := s.Receive(ctx, integration.ID, req)
+	if err != nil || receipt.Status != model.InboundWebhookUnhandled {
+		t.Fatalf("receive = %+v, %v; want it stored as unhandled", receipt, err)
+	}
+
+	if _, err := s.Receive(ctx, integration.ID, req); err != ErrInboundWebhookReplayed {
+		t.Fatalf("replayed call: %v, want ErrInboundWebhookReplayed", err)
+	}
+	// The nonce is spent even when the call is signed again at another time
+	again := signedInboundRequest(secret, req.Nonce, now.Add(time.Minute), body)
+	if _, err := s.Receive(ctx, integration.ID, again); err != ErrInboundWebhookReplayed {
+		t.Fatalf("nonce reused with a new timestamp: %v, want ErrInboundWebhookReplayed", err)
+	}
+	if receipt, err := s.Receive(ctx, integration.ID, signedInboundRequest(secret, "5f1c2a9e-77b0-4c3d", now, body)); err != nil || receipt == nil {
+		t.Fatalf("new nonce = %+v, %v", receipt, err)
+	}
+
+	// Calls that fail verification are not stored and do not spend their nonce
+	stale := signedInboundRequest(secret, "6f1c2a9e-77b0-4c3d", now.Add(-inboundTolerance-time.Minute), body)
+	if _, err := s.Receive(ctx, integration.ID, stale); !errors.Is(err, ErrInvalidInboundSignature) {
+		t.Fatalf("stale call: %v, want ErrInvalidInboundSignature", err)
+	}
+	if _, err := s.Receive(ctx, integration.ID, signedInboundRequest(secret, stale.Nonce, now, body)); err != nil {
+		t.Fatalf("nonce of a rejected call: %v", err)
+	}
+}
@@ -0,0 +1,673 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/events"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	webhookSecretMarker = "whsec_"
+	webhookSecretBytes  = 32
+
+	// DefaultSecretGrace is how long the previous webhook secret keeps signing deliveries
+	// after a rotation, unless the rotation says otherwise.
+	DefaultSecretGrace = 24 * time.Hour
+	maxSecretGrace     = 7 * 24 * time.Hour
+
+	maxIntegrationTextChars = 255
+
+	defaultDeliveryPageSize = 50
+	maxDeliveryPageSize     = 200
+)
+
+var integrationTypes = map[string]bool{
+	model.IntegrationTypeWebhook:      true,
+	model.IntegrationTypeInsuranceAPI: true,
+	model.IntegrationTypeProcore:      true,
+	model.IntegrationTypeAutodesk:     true,
+	model.IntegrationTypeSalesforce:   true,
+	model.IntegrationTypeSlack:        true,
+}
+
+// CreateIntegrationInput is a request to add an integration. A webhook secret is
+// generated if none is given. Empty EventTypes subscribes to every event. APIKey and
+// APISecret are the partner's credentials, e.g. the OAuth client of a procore
+// integration; they are write-only. Settings must be a JSON object, and valid
+// ProcoreSettings for a procore integration.
+type CreateIntegrationInput struct {
+	IntegrationType string
+	IntegrationName *string
+	WebhookURL      *string
+	WebhookSecret   *string
+	APIKey          *string
+	APISecret       *string
+	Settings        json.RawMessage
+	EventTypes      []string
+	IsActive        *bool
+	TestMode        *bool
+}
+
+// UpdateIntegrationInput changes the given fields; an empty name, URL or credential
+// clears it. Settings replace the previous ones as a whole. Reactivating an integration
+// resets its consecutive error count.
+type UpdateIntegrationInput struct {
+	IntegrationName *string
+	WebhookURL      *string
+	APIKey          *string
+	APISecret       *string
+	Settings        json.RawMessage
+	EventTypes      *[]string
+	IsActive        *bool
+	TestMode        *bool
+}
+
+// IntegrationView is the API representation of an integration. It never contains
+// credentials or webhook secrets.
+type IntegrationView struct {
+	ID                   uuid.UUID       `json:"id"`
+	IntegrationType      string          `json:"integration_type"`
+	IntegrationName      *string         `json:"integration_name"`
+	WebhookURL           *string         `json:"webhook_url"`
+	HasWebhookSecret     bool            `json:"has_webhook_secret"`
+	SecretRotationEndsAt *time.Time      `json:"secret_rotation_ends_at"`
+	HasAPIKey            bool            `json:"has_api_key"`
+	HasAPISecret         bool            `json:"has_api_secret"`
+	Settings             json.RawMessage `json:"settings"`
+	EventTypes           []string        `json:"event_types"`
+	IsActive             bool            `json:"is_active"`
+	TestMode             bool            `json:"test_mode"`
+	LastWebhookCall      *time.Time      `json:"last_webhook_call"`
+	WebhookCallCount     int             `json:"webhook_call_count"`
+	LastError            *string         `json:"last_error"`
+	ErrorCount           int             `json:"error_count"`
+	CreatedAt            time.Time       `json:"created_at"`
+	UpdatedAt            time.Time       `json:"updated_at"`
+}
+
+// WebhookDeliveryQuery selects a page of an integration's delivery log.
+type WebhookDeliveryQuery struct {
+	Status    string
+	EventType string
+	Page      int
+	PageSize  int
+}
+
+// WebhookDeliveryView is an entry of the delivery log: the request as sent and the
+// response of the last attempt.
+type WebhookDeliveryView struct {
+	ID             uuid.UUID       `json:"id"`
+	IntegrationID  uuid.UUID       `json:"integration_id"`
+	EventID        uuid.UUID       `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	RequestURL     *string         `json:"request_url"`
+	RequestBody    json.RawMessage `json:"request_body"`
+	ResponseStatus *int            `json:"response_status"`
+	ResponseBody   *string         `json:"response_body"`
+	LatencyMS      *int            `json:"latency_ms"`
+	LastError      *string         `json:"last_error"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	RedeliveryOf   *uuid.UUID      `json:"redelivery_of"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type WebhookDeliveryPage struct {
+	Deliveries []WebhookDeliveryView `json:"deliveries"`
+	Total      int64                 `json:"total"`
+	Page       int                   `json:"page"`
+	PageSize   int                   `json:"page_size"`
+}
+
+// IntegrationService lets organization admins manage their integrations' webhooks:
+// configuration and event subscriptions, secret rotation, test pings, the delivery log
+// and manual redelivery.
+type IntegrationService struct {
+	integrationRepo        *repository.IntegrationRepository
+	deliveryRepo           *repository.WebhookDeliveryRepository
+	webhookDeliveryService *WebhookDeliveryService
+	auditService           *AuditService
+	policy                 OutboundPolicy
+}
+
+// NewIntegrationService takes the policy that webhook URLs and Procore base URLs must
+// satisfy.
+func NewIntegrationService(integrationRepo *repository.IntegrationRepository, deliveryRepo *repository.WebhookDeliveryRepository, webhookDeliveryService *WebhookDeliveryService, auditService *AuditService, policy OutboundPolicy) *IntegrationService {
+	return &IntegrationService{
+		integrationRepo:        integrationRepo,
+		deliveryRepo:           deliveryRepo,
+		webhookDeliveryService: webhookDeliveryService,
+		auditService:           auditService,
+		policy:                 policy,
+	}
+}
+
+func (s *IntegrationService) ListIntegrations(ctx context.Context, organizationID uuid.UUID) ([]IntegrationView, error) {
+	integrations, err := s.integrationRepo.FindByOrganizationID(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]IntegrationView, 0, len(integrations))
+	for _, integration := range integrations {
+		view, err := newIntegrationView(integration)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, *view)
+	}
+	return views, nil
+}
+
+func (s *IntegrationService) GetIntegration(ctx context.Context, organizationID, integrationID uuid.UUID) (*IntegrationView, error) {
+	integration, err := s.findIntegration(ctx, organizationID, integrationID)
+	if err != nil {
+		return nil, err
+	}
+	return newIntegrationView(integration)
+}
+
+// CreateIntegration adds an integration and returns its webhook secret, which is only
+// ever returned here and by RotateSecret.
+func (s *IntegrationService) CreateIntegration(ctx context.Context, organizationID, actorID uuid.UUID, input CreateIntegrationInput, meta RequestMetadata) (*IntegrationView, string, error) {
+	integrationType := strings.TrimSpace(input.IntegrationType)
+	if !integrationTypes[integrationType] {
+		return nil, "", fmt.Errorf("%w: unknown integration_type %q", ErrInvalidIntegration, integrationType)
+	}
+	name, err := integrationText("integration_name", input.IntegrationName)
+	if err != nil {
+		return nil, "", err
+	}
+	webhookURL, err := integrationWebhookURL(s.policy, input.WebhookURL)
+	if err != nil {
+		return nil, "", err
+	}
+	eventTypesJSON, err := integrationEventTypes(input.EventTypes)
+	if err != nil {
+		return nil, "", err
+	}
+	settings, err := integrationSettings(s.policy, integrationType, input.Settings)
+	if err != nil {
+		return nil, "", err
+	}
+	apiKey, err := integrationText("api_key", input.APIKey)
+	if err != nil {
+		return nil, "", err
+	}
+	apiSecret, err := integrationText("api_secret", input.APISecret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret := ""
+	if input.WebhookSecret != nil {
+		secret = strings.TrimSpace(*input.WebhookSecret)
+		if len(secret) < 16 || len(secret) > maxIntegrationTextChars {
+			return nil, "", fmt.Errorf("%w: webhook_secret must be 16-255 characters", ErrInvalidIntegration)
+		}
+	} else if webhookURL != nil {
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	now := time.Now()
+	integration := &model.OrganizationIntegration{
+		ID:              uuid.New(),
+		OrganizationID:  organizationID,
+		IntegrationType: integrationType,
+		IntegrationName: name,
+		WebhookURL:      webhookURL,
+		APIKey:          apiKey,
+		APISecret:       apiSecret,
+		Settings:        settings,
+		EventTypes:      eventTypesJSON,
+		IsActive:        input.IsActive == nil || *input.IsActive,
+		TestMode:        input.TestMode != nil && *input.TestMode,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		CreatedBy:       &actorID,
+		UpdatedBy:       &actorID,
+	}
+	if secret != "" {
+		integration.WebhookSecret = &secret
+	}
+	if err := s.integrationRepo.Create(ctx, integration); err != nil {
+		return nil, "", err
+	}
+
+	view, err := newIntegrationView(integration)
+	if err != nil {
+		return nil, "", err
+	}
+	s.audit(ctx, organizationID, actorID, integration.ID, AuditActionCreate, nil, view, meta)
+	return view, secret, nil
+}
+
+func (s *IntegrationService) UpdateIntegration(ctx context.Context, organizationID, actorID, integrationID uuid.UUID, input UpdateIntegrationInput, meta RequestMetadata) (*IntegrationView, error) {
+	integration, err := s.findIntegration(ctx, organizationID, integrationID)
+	if err != nil {
+		return nil, err
+	}
+	before, err := newIntegrationView(integration)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{"updated_by": actorID}
+	if input.IntegrationName != nil {
+		name, err := integrationText("integration_name", input.IntegrationName)
+		if err != nil {
+			return nil, err
+		}
+		updates["integration_name"] = name
+	}
+	if input.WebhookURL != nil {
+		webhookURL, err := integrationWebhookURL(s.policy, input.WebhookURL)
+		if err != nil {
+			return nil, err
+		}
+		updates["webhook_url"] = webhookURL
+	}
+	if input.EventTypes != nil {
+		eventTypesJSON, err := integrationEventTypes(*input.EventTypes)
+		if err != nil {
+			return nil, err
+		}
+		updates["event_types"] = eventTypesJSON
+	}
+	for column, value := range map[string]*string{"api_key_encrypted": input.APIKey, "api_secret_encrypted": input.APISecret} {
+		if value == nil {
+			continue
+		}
+		field := strings.TrimSuffix(column, "_encrypted")
+		text, err := integrationText(field, value)
+		if err != nil {
+			return nil, err
+		}
+		updates[column] = text
+	}
+	if input.Settings != nil {
+		settings, err := integrationSettings(s.policy, integration.IntegrationType, input.Settings)
+		if err != nil {
+			return nil, err
+		}
+		updates["settings"] = settings
+	}
+	if input.IsActive != nil {
+		updates["is_active"] = *input.IsActive
+		// A reactivated integration gets a fresh run at the circuit breaker
+		if *input.IsActive && !integration.IsActive {
+			updates["error_count"] = 0
+		}
+	}
+	if input.TestMode != nil {
+		updates["test_mode"] = *input.TestMode
+	}
+
+	if err := s.integrationRepo.Update(ctx, integration.ID, updates); err != nil {
+		return nil, err
+	}
+
+	after, err := s.GetIntegration(ctx, organizationID, integrationID)
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, organizationID, actorID, integrationID, AuditActionUpdate, before, after, meta)
+	return after, nil
+}
+
+func (s *IntegrationService) DeleteIntegration(ctx context.Context, organizationID, actorID, integrationID uuid.UUID, meta RequestMetadata) error {
+	integration, err := s.findIntegration(ctx, organizationID, integrationID)
+	if err != nil {
+		return err
+	}
+	before, err := newIntegrationView(integration)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.integrationRepo.Delete(ctx, organizationID, integrationID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrIntegrationNotFound
+	}
+
+	s.audit(ctx, organizationID, actorID, integrationID, AuditActionDelete, before, nil, meta)
+	return nil
+}
+
+// RotateSecret replaces the webhook secret with a new one, which is returned once. For the
+// grace period deliveries carry signatures with both the new and the previous secret, so
+// that the receiver can switch over without rejecting calls. A zero grace drops the
+// previous secret right away.
+func (s *IntegrationService) RotateSecret(ctx context.Context, organizationID, actorID, integrationID uuid.UUID, grace time.Duration, meta RequestMetadata) (*IntegrationView, string, error) {
+	if grace < 0 || grace > maxSecretGrace {
+		return nil, "", fmt.Errorf("%w: grace period must be between 0 and %d hours", ErrInvalidIntegration, int(maxSecretGrace.Hours()))
+	}
+	integration, err := s.findIntegration(ctx, organizationID, integrationID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	updates := map[string]interface{}{
+		"webhook_secret":             secret,
+		"previous_webhook_secret":    nil,
+		"previous_secret_expires_at": nil,
+		"updated_by":                 actorID,
+	}
+	var graceEnds *time.Time
+	if integration.WebhookSecret != nil && grace > 0 {
+		ends := time.Now().Add(grace)
+		graceEnds = &ends
+		updates["previous_webhook_secret"] = *integration.WebhookSecret
+		updates["previous_secret_expires_at"] = ends
+	}
+	if err := s.integrationRepo.Update(ctx, integration.ID, updates); err != nil {
+		return nil, "", err
+	}
+
+	view, err := s.GetIntegration(ctx, organizationID, integrationID)
+	if err != nil {
+		return nil, "", err
+	}
+	s.audit(ctx, organizationID, actorID, integrationID, AuditActionUpdate, nil,
+		map[string]interface{}{"event": "webhook_secret_rotated", "previous_secret_valid_until": graceEnds}, meta)
+	return view, secret, nil
+}
+
+// SendTest posts a "ping" event to the integration right away and returns the delivery.
+// It is sent even to inactive integrations, so that a fixed endpoint can be checked
+// before reactivating.
+func (s *IntegrationService) SendTest(ctx context.Context, organizationID, integrationID uuid.UUID) (*WebhookDeliveryView, error) {
+	integration, err := s.findIntegration(ctx, organizationID, integrationID)
+	if err != nil {
+		return nil, err
+	}
+	if integration.WebhookURL == nil {
+		return nil, fmt.Errorf("%w: integration has no webhook_url", ErrInvalidIntegration)
+	}
+
+	eventID := uuid.New()
+	payload, err := json.Marshal(WebhookPayload{
+		ID:             eventID,
+		Event:          model.WebhookEventPing,
+		OrganizationID: organizationID,
+		OccurredAt:     time.Now().UTC(),
+		TestMode:       integration.TestMode,
+		Data:           map[string]interface{}{"integration_id": integration.ID, "message": "Test event from EquipChain"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.webhookDeliveryService.SendNow(ctx, integration, &model.WebhookDelivery{
+		ID:             uuid.New(),
+		OrganizationID: organizationID,
+		IntegrationID:  integration.ID,
+		EventID:        eventID,
+		EventType:      model.WebhookEventPing,
+		Payload:        payload,
+		CreatedAt:      time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	view := newWebhookDeliveryView(delivery)
+	return &view, nil
+}
+
+func (s *IntegrationService) ListDeliveries(ctx context.Context, organizationID, integrationID uuid.UUID, query WebhookDeliveryQuery) (*WebhookDeliveryPage, error) {
+	if _, err := s.findIntegration(ctx, organizationID, integrationID); err != nil {
+		return nil, err
+	}
+	switch query.Status {
+	case "", model.WebhookDeliveryPending, model.WebhookDeliveryDelivered, model.WebhookDeliveryFailed:
+	default:
+		return nil, fmt.Errorf("%w: unknown delivery status %q", ErrInvalidIntegration, query.Status)
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 {
+		query.PageSize = defaultDeliveryPageSize
+	}
+	if query.PageSize > maxDeliveryPageSize {
+		query.PageSize = maxDeliveryPageSize
+	}
+
+	deliveries, total, err := s.deliveryRepo.FindPage(ctx, integrationID, repository.WebhookDeliveryFilter{
+		Status:    query.Status,
+		EventType: query.EventType,
+		Limit:     query.PageSize,
+		Offset:    (query.Page - 1) * query.PageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &WebhookDeliveryPage{Deliveries: make([]WebhookDeliveryView, 0, len(deliveries)), Total: total, Page: query.Page, PageSize: query.PageSize}
+	for _, delivery := range deliveries {
+		page.Deliveries = append(page.Deliveries, newWebhookDeliveryView(delivery))
+	}
+	return page, nil
+}
+
+func (s *IntegrationService) GetDelivery(ctx context.Context, organizationID, integrationID, deliveryID uuid.UUID) (*WebhookDeliveryView, error) {
+	delivery, err := s.findDelivery(ctx, organizationID, integrationID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	view := newWebhookDeliveryView(delivery)
+	return &view, nil
+}
+
+// Redeliver posts a delivery's payload again, right away, as a new delivery linked to the
+// original. The payload keeps its event id so that receivers can recognise the repeat.
+func (s *IntegrationService) Redeliver(ctx context.Context, organizationID, integrationID, deliveryID uuid.UUID) (*WebhookDeliveryView, error) {
+	original, err := s.findDelivery(ctx, organizationID, integrationID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	integration, err := s.findIntegration(ctx, organizationID, integrationID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.webhookDeliveryService.SendNow(ctx, integration, &model.WebhookDelivery{
+		ID:             uuid.New(),
+		OrganizationID: organizationID,
+		IntegrationID:  integration.ID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		RedeliveryOf:   &original.ID,
+		CreatedAt:      time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	view := newWebhookDeliveryView(delivery)
+	return &view, nil
+}
+
+func (s *IntegrationService) findIntegration(ctx context.Context, organizationID, integrationID uuid.UUID) (*model.OrganizationIntegration, error) {
+	integration, err := s.integrationRepo.FindByID(ctx, integrationID)
+	if err != nil {
+		return nil, err
+	}
+	if integration == nil || integration.OrganizationID != organizationID {
+		return nil, ErrIntegrationNotFound
+	}
+	return integration, nil
+}
+
+func (s *IntegrationService) findDelivery(ctx context.Context, organizationID, integrationID, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	delivery, err := s.deliveryRepo.FindByID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil || delivery.OrganizationID != organizationID || delivery.IntegrationID != integrationID {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return delivery, nil
+}
+
+func (s *IntegrationService) audit(ctx context.Context, organizationID, actorID, integrationID uuid.UUID, action string, before, after interface{}, meta RequestMetadata) {
+	if err := s.auditService.Record(ctx, AuditEntry{
+		OrganizationID: organizationID,
+		ActorID:        &actorID,
+		EntityType:     "integration",
+		EntityID:       integrationID,
+		Action:         action,
+		Before:         before,
+		After:          after,
+		Metadata:       meta,
+	}); err != nil {
+		log.Printf("failed to audit %s of integration %s: %v", action, integrationID, err)
+	}
+}
+
+// integrationText trims an optional text field; empty clears it.
+func integrationText(field string, value *string) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	text := strings.TrimSpace(*value)
+	if text == "" {
+		return nil, nil
+	}
+	if len([]rune(text)) > maxIntegrationTextChars {
+		return nil, fmt.Errorf("%w: %s must be at most %d characters", ErrInvalidIntegration, field, maxIntegrationTextChars)
+	}
+	return &text, nil
+}
+
+func integrationWebhookURL(policy OutboundPolicy, value *string) (*string, error) {
+	webhookURL, err := integrationText("webhook_url", value)
+	if err != nil || webhookURL == nil {
+		return webhookURL, err
+	}
+	if err := policy.CheckURL(*webhookURL); err != nil {
+		return nil, fmt.Errorf("%w: webhook_url %v", ErrInvalidIntegration, err)
+	}
+	return webhookURL, nil
+}
+
+// integrationEventTypes validates and de-duplicates the subscribed event types.
+func integrationEventTypes(eventTypes []string) ([]byte, error) {
+	subscribed := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if !slices.Contains(events.Types, eventType) {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidIntegration, eventType)
+		}
+		if !slices.Contains(subscribed, eventType) {
+			subscribed = append(subscribed, eventType)
+		}
+	}
+	return json.Marshal(subscribed)
+}
+
+// integrationSettings validates the settings object of an integration type; nil yields an
+// empty object.
+func integrationSettings(policy OutboundPolicy, integrationType string, settings json.RawMessage) (json.RawMessage, error) {
+	if len(settings) == 0 || string(settings) == "null" {
+		settings = json.RawMessage("{}")
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(settings, &object); err != nil {
+		return nil, fmt.Errorf("%w: settings must be a JSON object", ErrInvalidIntegration)
+	}
+	if integrationType == model.IntegrationTypeProcore {
+		if _, err := ParseProcoreSettings(settings, policy); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(object)
+}
+
+func newWebhookSecret() (string, error) {
+	secret, err := randomURLToken(webhookSecretBytes)
+	if err != nil {
+		return "", err
+	}
+	return webhookSecretMarker + secret, nil
+}
+
+func newIntegrationView(integration *model.OrganizationIntegration) (*IntegrationView, error) {
+	eventTypes, err := integration.EventTypeList()
+	if err != nil {
+		return nil, err
+	}
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	view := &IntegrationView{
+		ID:               integration.ID,
+		IntegrationType:  integration.IntegrationType,
+		IntegrationName:  integration.IntegrationName,
+		WebhookURL:       integration.WebhookURL,
+		HasWebhookSecret: integration.WebhookSecret != nil && *integration.WebhookSecret != "",
+		HasAPIKey:        integration.APIKey != nil && *integration.APIKey != "",
+		HasAPISecret:     integration.APISecret != nil && *integration.APISecret != "",
+		Settings:         integration.Settings,
+		EventTypes:       eventTypes,
+		IsActive:         integration.IsActive,
+		TestMode:         integration.TestMode,
+		LastWebhookCall:  integration.LastWebhookCall,
+		WebhookCallCount: integration.WebhookCallCount,
+		LastError:        integration.LastError,
+		ErrorCount:       integration.ErrorCount,
+		CreatedAt:        integration.CreatedAt,
+		UpdatedAt:        integration.UpdatedAt,
+	}
+	if len(integration.WebhookSecrets(time.Now())) > 1 {
+		view.SecretRotationEndsAt = integration.PreviousSecretExpiresAt
+	}
+	return view, nil
+}
+
+func newWebhookDeliveryView(delivery *model.WebhookDelivery) WebhookDeliveryView {
+	view := WebhookDeliveryView{
+		ID:             delivery.ID,
+		IntegrationID:  delivery.IntegrationID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		RequestURL:     delivery.RequestURL,
+		RequestBody:    delivery.Payload,
+		ResponseStatus: delivery.ResponseStatus,
+		ResponseBody:   delivery.ResponseBody,
+		LatencyMS:      delivery.LatencyMS,
+		LastError:      delivery.LastError,
+		LastAttemptAt:  delivery.LastAttemptAt,
+		DeliveredAt:    delivery.DeliveredAt,
+		RedeliveryOf:   delivery.RedeliveryOf,
+		CreatedAt:      delivery.CreatedAt,
+	}
+	if delivery.Status == model.WebhookDeliveryPending {
+		view.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	return view
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signing algorithms supported for asymmetric JWT keys.
+const (
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmEdDSA = "EdDSA"
+)
+
+const jwtRSAKeyBits = 3072
+
+// JWTKeyring is the set of asymmetric JWT keys stored in JWT_KEYS_FILE. The active key
+// signs new tokens; every key in the ring verifies tokens and is published in the JWKS,
+// so tokens signed before a rotation stay valid until the retired key is pruned.
+type JWTKeyring struct {
+	ActiveKID string       `json:"active_kid"`
+	Keys      []JWTKeyFile `json:"keys"`
+}
+
+// JWTKeyFile is one key of the ring. PrivateKey is a PKCS#8 PEM block.
+type JWTKeyFile struct {
+	KID        string     `json:"kid"`
+	Algorithm  string     `json:"alg"`
+	PrivateKey string     `json:"private_key"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RetiredAt  *time.Time `json:"retired_at,omitempty"`
+}
+
+// jwtKey is a parsed ring entry.
+type jwtKey struct {
+	kid       string
+	algorithm string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+}
+
+// LoadJWTKeyring reads a keyring file. A missing file yields an empty ring.
+func LoadJWTKeyring(path string) (*JWTKeyring, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &JWTKeyring{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ring JWTKeyring
+	if err := json.Unmarshal(data, &ring); err != nil {
+		return nil, fmt.Errorf("parse jwt keyring %s: %w", path, err)
+	}
+	return &ring, nil
+}
+
+// Save writes the ring atomically with owner-only permissions.
+func (r *JWTKeyring) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".jwt-keys-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Generate adds a new key to the ring and returns its kid. The first key becomes active.
+func (r *JWTKeyring) Generate(algorithm string) (string, error) {
+	var private crypto.Signer
+	var err error
+	switch algorithm {
+	case JWTAlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case JWTAlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, jwtRSAKeyBits)
+	default:
+		return "", fmt.Errorf("unsupported jwt algorithm %q (use %s or %s)", algorithm, JWTAlgorithmEdDSA, JWTAlgorithmRS256)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return "", err
+	}
+	kidBytes := make([]byte, 8)
+	if _, err := rand.Read(kidBytes); err != nil {
+		return "", err
+	}
+
+	kid := hex.EncodeToString(kidBytes)
+	r.Keys = append(r.Keys, JWTKeyFile{
+		KID:        kid,
+		Algorithm:  algorithm,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		CreatedAt:  time.Now().UTC(),
+	})
+	if r.ActiveKID == "" {
+		r.ActiveKID = kid
+	}
+	return kid, nil
+}
+
+// Activate makes kid the signing key and marks the previously active key retired.
+func (r *JWTKeyring) Activate(kid string) error {
+	target := r.find(kid)
+	if target == nil {
+		return fmt.Errorf("jwt key %q not found", kid)
+	}
+	if r.ActiveKID == kid {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	if previous := r.find(r.ActiveKID); previous != nil {
+		previous.RetiredAt = &now
+	}
+	target.RetiredAt = nil
+	r.ActiveKID = kid
+	return nil
+}
+
+// Prune removes keys retired for longer than olderThan and returns their kids. It should
+// exceed the access token lifetime so that no valid token loses its verification key.
+func (r *JWTKeyring) Prune(olderThan time.Duration) []string {
+	cutoff := time.Now().Add(-olderThan)
+	var removed []string
+	kept := r.Keys[:0]
+	for _, key := range r.Keys {
+		if key.KID != r.ActiveKID && key.RetiredAt != nil && key.RetiredAt.Before(cutoff) {
+			removed = append(removed, key.KID)
+			continue
+		}
+		kept = append(kept, key)
+	}
+	r.Keys = kept
+	return removed
+}
+
+func (r *JWTKeyring) find(kid string) *JWTKeyFile {
+	for i := range r.Keys {
+		if r.Keys[i].KID == kid {
+			return &r.Keys[i]
+		}
+	}
+	return nil
+}
+
+// parse decodes every key and checks that the active key exists.
+func (r *JWTKeyring) parse() (map[string]*jwtKey, *jwtKey, error) {
+	keys := make(map[string]*jwtKey, len(r.Keys))
+	for _, file := range r.Keys {
+		key, err := file.parse()
+		if err != nil {
+			return nil, nil, err
+		}
+		keys[key.kid] = key
+	}
+
+	active, ok := keys[r.ActiveKID]
+	if !ok {
+		return nil, nil, fmt.Errorf("jwt keyring has no active key %q", r.ActiveKID)
+	}
+	return keys, active, nil
+}
+
+func (f JWTKeyFile) parse() (*jwtKey, error) {
+	block, _ := pem.Decode([]byte(f.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("jwt key %s: invalid PEM", f.KID)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("jwt key %s: %w", f.KID, err)
+	}
+
+	key := &jwtKey{kid: f.KID, algorithm: f.Algorithm}
+	switch private := parsed.(type) {
+	case ed25519.PrivateKey:
+		if f.Algorithm != JWTAlgorithmEdDSA {
+			return nil, fmt.Errorf("jwt key %s: ed25519 key must use %s", f.KID, JWTAlgorithmEdDSA)
+		}
+		key.method, key.private = jwt.SigningMethodEdDSA, private
+	case *rsa.PrivateKey:
+		if f.Algorithm != JWTAlgorithmRS256 {
+			return nil, fmt.Errorf("jwt key %s: rsa key must use %s", f.KID, JWTAlgorithmRS256)
+		}
+		if private.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("jwt key %s: rsa keys must be at least 2048 bits", f.KID)
+		}
+		key.method, key.private = jwt.SigningMethodRS256, private
+	default:
+		return nil, fmt.Errorf("jwt key %s: unsupported key type %T", f.KID, parsed)
+	}
+	return key, nil
+}
+
+// jwk renders the public half of the key as a JSON Web Key.
+func (k *jwtKey) jwk() map[string]string {
+	jwk := map[string]string{
+		"kid": k.kid,
+		"alg": k.algorithm,
+		"use": "sig",
+	}
+	switch public := k.private.Public().(type) {
+	case ed25519.PublicKey:
+		jwk["kty"] = "OKP"
+		jwk["crv"] = "Ed25519"
+		jwk["x"] = base64.RawURLEncoding.EncodeToString(public)
+	case *rsa.PublicKey:
+		jwk["kty"] = "RSA"
+		jwk["n"] = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	}
+	return jwk
+}
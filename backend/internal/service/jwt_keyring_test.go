@@ -0,0 +1,345 @@
+package service
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// writeTestKeyring saves ring to a new keyring file and returns its path.
+func writeTestKeyring(t *testing.T, ring *JWTKeyring) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwt-keys.json")
+	if err := ring.Save(path); err != nil {
+		t.Fatalf("save keyring: %v", err)
+	}
+	return path
+}
+
+func newTestJWTService(t *testing.T, keysFile string) *JWTService {
+	t.Helper()
+	s, err := NewJWTService(&config.Config{Environment: "production", JWTKeysFile: keysFile})
+	if err != nil {
+		t.Fatalf("jwt service: %v", err)
+	}
+	return s
+}
+
+func generateTestKey(t *testing.T, ring *JWTKeyring, algorithm string) string {
+	t.Helper()
+	kid, err := ring.Generate(algorithm)
+	if err != nil {
+		t.Fatalf("generate %s key: %v", algorithm, err)
+	}
+	return kid
+}
+
+func testTokenHeader(t *testing.T, token string) map[string]interface{} {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	return parsed.Header
+}
+
+func TestJWTKeyringRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwt-keys.json")
+	ring, err := LoadJWTKeyring(path)
+	if err != nil || len(ring.Keys) != 0 {
+		t.Fatalf("load a missing keyring = %+v, %v; want an empty ring", ring, err)
+	}
+
+	if _, err := ring.Generate("HS256"); err == nil {
+		t.Fatal("generated an HS256 key")
+	}
+	first := generateTestKey(t, ring, JWTAlgorithmEdDSA)
+	// A later key verifies but does not sign until it is activated
+	second := generateTestKey(t, ring, JWTAlgorithmRS256)
+	if ring.ActiveKID != first || first == second {
+		t.Fatalf("kids %s and %s, active %s; want the first active", first, second, ring.ActiveKID)
+	}
+
+	if err := ring.Save(path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("keyring mode %v, want 0600", perm)
+	}
+	loaded, err := LoadJWTKeyring(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if loaded.ActiveKID != first || len(loaded.Keys) != 2 {
+		t.Fatalf("loaded active %s with %d keys, want %s with 2", loaded.ActiveKID, len(loaded.Keys), first)
+	}
+
+	if err := loaded.Activate("0000000000000000"); err == nil {
+		t.Fatal("activated a missing key")
+	}
+	if err := loaded.Activate(second); err != nil {
+		t.Fatalf("activate: %v", err)
+	}
+	retired := loaded.find(first)
+	if loaded.ActiveKID != second || retired.RetiredAt == nil || loaded.find(second).RetiredAt != nil {
+		t.Fatalf("after activating %s: active %s, first retired at %v", second, loaded.ActiveKID, retired.RetiredAt)
+	}
+
+	// Retired keys are kept until they are older than the token lifetime
+	if removed := loaded.Prune(time.Hour); len(removed) != 0 {
+		t.Fatalf("pruned %v, want nothing retired for an hour yet", removed)
+	}
+	longAgo := time.Now().Add(-2 * time.Hour)
+	retired.RetiredAt = &longAgo
+	if removed := loaded.Prune(time.Hour); len(removed) != 1 || removed[0] != first {
+		t.Fatalf("pruned %v, want [%s]", removed, first)
+	}
+	if len(loaded.Keys) != 1 || loaded.Keys[0].KID != second {
+		t.Fatalf("keys after pruning %+v, want only %s", loaded.Keys, second)
+	}
+}
+
+func TestJWTServiceSelectsKeysByKID(t *testing.T) {
+	ring := &JWTKeyring{}
+	first := generateTestKey(t, ring, JWTAlgorithmEdDSA)
+	second := generateTestKey(t, ring, JWTAlgorithmRS256)
+	path := writeTestKeyring(t, ring)
+
+	userID, organizationID := uuid.New(), uuid.New()
+	before := newTestJWTService(t, path)
+	oldToken, err := before.GenerateToken(userID, organizationID, "ana@example.com", 2)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if header := testTokenHeader(t, oldToken); header["kid"] != first || header["alg"] != JWTAlgorithmEdDSA {
+		t.Fatalf("header %v, want kid %s and alg EdDSA", header, first)
+	}
+	if claims, err := before.ValidateToken(oldToken); err != nil || claims.UserID != userID {
+		t.Fatalf("validate = %+v, %v", claims, err)
+	}
+
+	if err := ring.Activate(second); err != nil {
+		t.Fatalf("activate: %v", err)
+	}
+	if err := ring.Save(path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	after := newTestJWTService(t, path)
+	newToken, err := after.GenerateToken(userID, organizationID, "ana@example.com", 2)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if header := testTokenHeader(t, newToken); header["kid"] != second || header["alg"] != JWTAlgorithmRS256 {
+		t.Fatalf("header %v, want kid %s and alg RS256", header, second)
+	}
+	// The retired key still verifies the tokens it signed
+	for name, token := range map[string]string{"retired key": oldToken, "active key": newToken} {
+		if _, err := after.ValidateToken(token); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	ring.find(first).RetiredAt = &time.Time{}
+	ring.Prune(time.Hour)
+	if err := ring.Save(path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	pruned := newTestJWTService(t, path)
+	if _, err := pruned.ValidateToken(oldToken); err == nil || !strings.Contains(err.Error(), "unknown kid") {
+		t.Fatalf("token of a pruned key: %v, want unknown kid", err)
+	}
+	if _, err := pruned.ValidateToken(newToken); err != nil {
+		t.Fatalf("token of the active key: %v", err)
+	}
+}
+
+func TestJWTServiceRejectsForgedKIDs(t *testing.T) {
+	ring := &JWTKeyring{}
+	edKID := generateTestKey(t, ring, JWTAlgorithmEdDSA)
+	rsaKID := generateTestKey(t, ring, JWTAlgorithmRS256)
+	s := newTestJWTService(t, writeTestKeyring(t, ring))
+
+	_, foreignKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	edKey, err := ring.find(edKID).parse()
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	claims := Claims{
+		UserID:           uuid.New(),
+		OrganizationID:   uuid.New(),
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	}
+	forge := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return signed
+	}
+
+	tests := map[string]string{
+		"no kid":                    forge(jwt.SigningMethodEdDSA, "", edKey.private),
+		"unknown kid":               forge(jwt.SigningMethodEdDSA, "0000000000000000", edKey.private),
+		"kid of another key":        forge(jwt.SigningMethodEdDSA, edKID, foreignKey),
+		"algorithm of another kid":  forge(jwt.SigningMethodEdDSA, rsaKID, edKey.private),
+		"HS256 in keyring mode":     forge(jwt.SigningMethodHS256, edKID, []byte("dev-secret-key")),
+		"HS256 with the public key": forge(jwt.SigningMethodHS256, edKID, []byte(edKey.private.Public().(ed25519.PublicKey))),
+	}
+	for name, token := range tests {
+		if _, err := s.ValidateToken(token); err == nil {
+			t.Errorf("%s: token accepted", name)
+		}
+	}
+	if _, err := s.ValidateToken(forge(jwt.SigningMethodEdDSA, edKID, edKey.private)); err != nil {
+		t.Fatalf("token signed with the ring key: %v", err)
+	}
+}
+
+func TestNewJWTServiceInProduction(t *testing.T) {
+	ring := &JWTKeyring{}
+	generateTestKey(t, ring, JWTAlgorithmEdDSA)
+	path := writeTestKeyring(t, ring)
+	empty := writeTestKeyring(t, &JWTKeyring{})
+
+	tests := []struct {
+		name        string
+		environment string
+		keysFile    string
+		mode        os.FileMode
+		ok          bool
+	}{
+		{"HS256 in production", "production", "", 0, false},
+		{"HS256 in development", "development", "", 0, true},
+		{"owner-only keyring", "production", path, 0o600, true},
+		{"group-readable keyring", "production", path, 0o640, false},
+		{"other-readable keyring", "prod", path, 0o604, false},
+		{"readable keyring in development", "development", path, 0o644, true},
+		{"missing keyring", "production", filepath.Join(t.TempDir(), "missing.json"), 0, false},
+		{"empty keyring", "production", empty, 0o600, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.mode != 0 {
+				if err := os.Chmod(tt.keysFile, tt.mode); err != nil {
+					t.Fatalf("chmod: %v", err)
+				}
+			}
+			_, err := NewJWTService(&config.Config{Environment: tt.environment, JWTSecret: "dev-secret-key", JWTKeysFile: tt.keysFile})
+			if (err == nil) != tt.ok {
+				t.Fatalf("NewJWTService = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestJWTKeyringRejectsInvalidKeys(t *testing.T) {
+	edRing := &JWTKeyring{}
+	edKID := generateTestKey(t, edRing, JWTAlgorithmEdDSA)
+	edKey := edRing.find(edKID).PrivateKey
+
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(small)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	smallKey := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	tests := map[string]*JWTKeyring{
+		"no active key":      {ActiveKID: "b", Keys: []JWTKeyFile{{KID: "a", Algorithm: JWTAlgorithmEdDSA, PrivateKey: edKey}}},
+		"invalid PEM":        {ActiveKID: "a", Keys: []JWTKeyFile{{KID: "a", Algorithm: JWTAlgorithmEdDSA, PrivateKey: "not a key"}}},
+		"mismatched alg":     {ActiveKID: "a", Keys: []JWTKeyFile{{KID: "a", Algorithm: JWTAlgorithmRS256, PrivateKey: edKey}}},
+		"short RSA key":      {ActiveKID: "a", Keys: []JWTKeyFile{{KID: "a", Algorithm: JWTAlgorithmRS256, PrivateKey: smallKey}}},
+		"one broken key":     {ActiveKID: "a", Keys: []JWTKeyFile{{KID: "a", Algorithm: JWTAlgorithmEdDSA, PrivateKey: edKey}, {KID: "b", Algorithm: JWTAlgorithmEdDSA}}},
+		"empty ring":         {},
+		"HS256 labelled key": {ActiveKID: "a", Keys: []JWTKeyFile{{KID: "a", Algorithm: "HS256", PrivateKey: edKey}}},
+	}
+	for name, ring := range tests {
+		if _, _, err := ring.parse(); err == nil {
+			t.Errorf("%s: keyring accepted", name)
+		}
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	ring := &JWTKeyring{}
+	edKID := generateTestKey(t, ring, JWTAlgorithmEdDSA)
+	rsaKID := generateTestKey(t, ring, JWTAlgorithmRS256)
+	s := newTestJWTService(t, writeTestKeyring(t, ring))
+
+	keys, _ := s.JWKS()["keys"].([]map[string]string)
+	if len(keys) != 2 {
+		t.Fatalf("JWKS has %d keys, want 2", len(keys))
+	}
+	decode := func(value string) []byte {
+		decoded, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil {
+			t.Fatalf("decode %q: %v", value, err)
+		}
+		return decoded
+	}
+
+	for _, jwk := range keys {
+		if _, ok := jwk["d"]; ok {
+			t.Fatalf("JWK %s publishes its private key", jwk["kid"])
+		}
+		key, err := ring.find(jwk["kid"]).parse()
+		if err != nil {
+			t.Fatalf("parse %s: %v", jwk["kid"], err)
+		}
+		if jwk["use"] != "sig" {
+			t.Fatalf("JWK %s use %q, want sig", jwk["kid"], jwk["use"])
+		}
+
+		switch jwk["kid"] {
+		case edKID:
+			public := key.private.Public().(ed25519.PublicKey)
+			if jwk["kty"] != "OKP" || jwk["crv"] != "Ed25519" || jwk["alg"] != JWTAlgorithmEdDSA || !bytes.Equal(decode(jwk["x"]), public) {
+				t.Fatalf("Ed25519 JWK %v does not match its public key", jwk)
+			}
+		case rsaKID:
+			public := key.private.Public().(*rsa.PublicKey)
+			n, e := new(big.Int).SetBytes(decode(jwk["n"])), new(big.Int).SetBytes(decode(jwk["e"]))
+			if jwk["kty"] != "RSA" || jwk["alg"] != JWTAlgorithmRS256 || n.Cmp(public.N) != 0 || e.Int64() != int64(public.E) {
+				t.Fatalf("RSA JWK %v does not match its public key", jwk)
+			}
+		default:
+			t.Fatalf("JWK with unknown kid %s", jwk["kid"])
+		}
+	}
+
+	hs256, err := NewJWTService(&config.Config{Environment: "development", JWTSecret: "dev-secret-key"})
+	if err != nil {
+		t.Fatalf("hs256 service: %v", err)
+	}
+	if keys, _ := hs256.JWKS()["keys"].([]map[string]string); len(keys) != 0 {
+		t.Fatalf("HS256 JWKS has %d keys, want none", len(keys))
+	}
+}
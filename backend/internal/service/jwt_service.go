@@ -3,6 +3,8 @@ package service
 import (
 	"fmt"
 	"log"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/NWhite12/EquipChain/internal/config"
@@ -10,69 +12,172 @@ import (
 	"github.com/google/uuid"
 )
 
+// JWTService issues and validates JWTs. With JWT_KEYS_FILE it signs with the keyring's
+// active RS256/EdDSA key and verifies with any key in the ring, picked by the "kid" header.
+// Without it, HS256 with JWT_SECRET is used; that mode is refused in production.
 type JWTService struct {
 	secret      string
 	environment string
+	keysFile    string
+
+	mu            sync.RWMutex
+	keys          map[string]*jwtKey
+	active        *jwtKey
+	keysModTime   time.Time
+	keysCheckedAt time.Time
 }
 
+// Token scopes. Access tokens carry no scope so that tokens issued before scopes existed
+// remain valid; every other scope is only accepted by the endpoint that asked for it.
+const (
+	TokenScopeAccess  = ""
+	TokenScopeMFA     = "mfa"
+	TokenScopeSigning = "signing"
+	// Invitation tokens carry the invitation id as jti and the invitee's email and role.
+	TokenScopeInvitation = "invitation"
+)
+
+// Authentication factors recorded in signing tokens.
+const (
+	AuthFactorPassword = "password"
+	AuthFactorTOTP     = "totp"
+)
+
+const (
+	accessTokenTTL  = 24 * time.Hour
+	mfaTokenTTL     = 5 * time.Minute
+	signingTokenTTL = 5 * time.Minute
+	// Invitations must be pruned-key safe: keyctl prune keeps retired keys longer than this.
+	invitationTokenTTL = 7 * 24 * time.Hour
+
+	// How often the keyring file is checked for changes made by keyctl.
+	jwtKeyringReloadInterval = 30 * time.Second
+)
+
 type Claims struct {
 	UserID         uuid.UUID `json:"user_id"`
 	OrganizationID uuid.UUID `json:"organization_id"`
 	Email          string    `json:"email"`
 	RoleID         int16     `json:"role_id"`
+	Scope          string    `json:"scope,omitempty"`
+	// AuthFactor is set on signing tokens: the credential re-entered at step-up.
+	AuthFactor string `json:"auth_factor,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func NewJWTService(cfg *config.Config) *JWTService {
-	secret := cfg.JWTSecret
-	env := cfg.Environment
+func NewJWTService(cfg *config.Config) (*JWTService, error) {
+	s := &JWTService{
+		secret:      cfg.JWTSecret,
+		environment: cfg.Environment,
+		keysFile:    cfg.JWTKeysFile,
+	}
 
-	// Production requires JWT_SECRET
-	if secret == "" {
-		if env == "production" || env == "prod" {
-			log.Fatal("JWT_SECRET environment variable is required in production")
+	if s.keysFile == "" {
+		if cfg.IsProduction() {
+			return nil, fmt.Errorf("JWT_KEYS_FILE is required in production; create it with keyctl generate")
 		}
-
-		log.Println("WARNING: JWT_SECRET not set, using insecure default. DO NOT USE IN PRODUCTION!")
-		secret = "dev-secret-key-change-in-production"
+		if s.secret == "" {
+			return nil, fmt.Errorf("JWT_SECRET is required when JWT_KEYS_FILE is not set")
+		}
+		log.Println("WARNING: JWT_KEYS_FILE not set, signing tokens with HS256 JWT_SECRET. DO NOT USE IN PRODUCTION!")
+		return s, nil
 	}
 
-	// Validate strength in production
-	if (env == "production" || env == "prod") && len(secret) < 32 {
-		log.Fatalf("JWT_SECRET must be at least 32 characters in production (currently %d chars)", len(secret))
+	info, err := os.Stat(s.keysFile)
+	if err != nil {
+		return nil, fmt.Errorf("JWT_KEYS_FILE: %w", err)
+	}
+	if cfg.IsProduction() && info.Mode().Perm()&0o077 != 0 {
+		return nil, fmt.Errorf("JWT_KEYS_FILE %s must not be accessible by group or others (mode %v)", s.keysFile, info.Mode().Perm())
+	}
+	if err := s.loadKeyring(info.ModTime()); err != nil {
+		return nil, err
 	}
 
-	return &JWTService{secret: secret}
+	return s, nil
 }
 
 func (s *JWTService) GenerateToken(userID, organizationID uuid.UUID, email string, roleID int16) (string, error) {
+	return s.GenerateScopedToken(userID, organizationID, email, roleID, TokenScopeAccess)
+}
+
+// GenerateScopedToken issues a token limited to one purpose, e.g. the short-lived
+// MFA-pending token handed out after a correct password.
+func (s *JWTService) GenerateScopedToken(userID, organizationID uuid.UUID, email string, roleID int16, scope string) (string, error) {
+	ttl := accessTokenTTL
+	if scope == TokenScopeMFA {
+		ttl = mfaTokenTTL
+	}
 
-	fmt.Println("organization uuid: " + organizationID.String())
+	return s.sign(Claims{
+		UserID:         userID,
+		OrganizationID: organizationID,
+		Email:          email,
+		RoleID:         roleID,
+		Scope:          scope,
+	}, ttl)
+}
 
-	claims := Claims{
+// GenerateSigningToken issues the short-lived step-up token required to approve or reject
+// maintenance. It records which factor the user re-entered, and carries a unique jti that
+// is stored with the decision it signs, so each token signs only one.
+func (s *JWTService) GenerateSigningToken(userID, organizationID uuid.UUID, email string, roleID int16, authFactor string) (string, time.Duration, error) {
+	token, err := s.sign(Claims{
 		UserID:         userID,
 		OrganizationID: organizationID,
 		Email:          email,
 		RoleID:         roleID,
+		Scope:          TokenScopeSigning,
+		AuthFactor:     authFactor,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "equipchain",
+			ID: uuid.NewString(),
 		},
+	}, signingTokenTTL)
+	return token, signingTokenTTL, err
+}
+
+// GenerateInvitationToken signs the token emailed to an invitee. It expires together
+// with the invitation row, which keeps it single-use and revocable.
+func (s *JWTService) GenerateInvitationToken(invitationID, organizationID uuid.UUID, email string, roleID int16, expiresAt time.Time) (string, error) {
+	return s.sign(Claims{
+		OrganizationID: organizationID,
+		Email:          email,
+		RoleID:         roleID,
+		Scope:          TokenScopeInvitation,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID: invitationID.String(),
+		},
+	}, time.Until(expiresAt))
+}
+
+func (s *JWTService) sign(claims Claims, ttl time.Duration) (string, error) {
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(ttl))
+	claims.IssuedAt = jwt.NewNumericDate(time.Now())
+	claims.Issuer = "equipchain"
+
+	if s.keysFile == "" {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(s.secret))
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.secret))
+	s.reloadKeyringIfChanged()
+	s.mu.RLock()
+	active := s.active
+	s.mu.RUnlock()
+
+	token := jwt.NewWithClaims(active.method, claims)
+	token.Header["kid"] = active.kid
+	return token.SignedString(active.private)
 }
 
+// ValidateToken accepts only access tokens.
 func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
+	return s.ValidateScopedToken(tokenString, TokenScopeAccess)
+}
+
+func (s *JWTService) ValidateScopedToken(tokenString, scope string) (*Claims, error) {
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.secret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, s.verificationKey)
 
 	if err != nil {
 		return nil, err
@@ -82,5 +187,102 @@ func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, fmt.Errorf("invalid token")
 	}
 
+	if claims.Scope != scope {
+		return nil, fmt.Errorf("token scope %q not accepted here", claims.Scope)
+	}
+
 	return claims, nil
 }
+
+// JWKS returns the public verification keys as a JSON Web Key Set. It is empty in HS256 mode.
+func (s *JWTService) JWKS() map[string]interface{} {
+	keys := []map[string]string{}
+	if s.keysFile != "" {
+		s.reloadKeyringIfChanged()
+		s.mu.RLock()
+		for _, key := range s.keys {
+			keys = append(keys, key.jwk())
+		}
+		s.mu.RUnlock()
+	}
+	return map[string]interface{}{"keys": keys}
+}
+
+func (s *JWTService) verificationKey(token *jwt.Token) (interface{}, error) {
+	if s.keysFile == "" {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(s.secret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, fmt.Errorf("token has no kid")
+	}
+
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	s.mu.RUnlock()
+	if !ok {
+		// The key may have been added by a rotation that this process has not seen yet
+		s.reloadKeyringIfChanged()
+		s.mu.RLock()
+		key, ok = s.keys[kid]
+		s.mu.RUnlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.private.Public(), nil
+}
+
+func (s *JWTService) loadKeyring(modTime time.Time) error {
+	ring, err := LoadJWTKeyring(s.keysFile)
+	if err != nil {
+		return err
+	}
+	if len(ring.Keys) == 0 {
+		return fmt.Errorf("JWT_KEYS_FILE %s contains no keys; create one with keyctl generate", s.keysFile)
+	}
+	keys, active, err := ring.parse()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.active = active
+	s.keysModTime = modTime
+	s.keysCheckedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// reloadKeyringIfChanged picks up rotations at most every jwtKeyringReloadInterval. A
+// broken file is logged and the current keys stay in use.
+func (s *JWTService) reloadKeyringIfChanged() {
+	s.mu.Lock()
+	if time.Since(s.keysCheckedAt) < jwtKeyringReloadInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.keysCheckedAt = time.Now()
+	modTime := s.keysModTime
+	s.mu.Unlock()
+
+	info, err := os.Stat(s.keysFile)
+	if err != nil {
+		log.Printf("failed to check jwt keyring %s: %v", s.keysFile, err)
+		return
+	}
+	if info.ModTime().Equal(modTime) {
+		return
+	}
+	if err := s.loadKeyring(info.ModTime()); err != nil {
+		log.Printf("failed to reload jwt keyring %s, keeping current keys: %v", s.keysFile, err)
+	}
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+)
+
+// LicenseAlertService warns technicians and their organization's supervisors before
+// licenses expire, once per configured threshold, and makes technicians unavailable when
+// their license has expired. It is run periodically by the jobs scheduler.
+type LicenseAlertService struct {
+	orgRepo        *repository.OrganizationRepository
+	technicianRepo *repository.TechnicianRepository
+	alertRepo      *repository.LicenseAlertRepository
+	userRepo       *repository.UserRepository
+	auditService   *AuditService
+	thresholds     []int
+}
+
+// NewLicenseAlertService takes the alert thresholds in days before expiry, e.g. 60, 30, 7.
+func NewLicenseAlertService(orgRepo *repository.OrganizationRepository, technicianRepo *repository.TechnicianRepository, alertRepo *repository.LicenseAlertRepository,
+	userRepo *repository.UserRepository, auditService *AuditService, thresholds []int) *LicenseAlertService {
+	sorted := append([]int(nil), thresholds...)
+	sort.Ints(sorted)
+	return &LicenseAlertService{
+		orgRepo:        orgRepo,
+		technicianRepo: technicianRepo,
+		alertRepo:      alertRepo,
+		userRepo:       userRepo,
+		auditService:   auditService,
+		thresholds:     sorted,
+	}
+}
+
+// Run checks every active organization. A failing organization does not stop the others.
+func (s *LicenseAlertService) Run(ctx context.Context) error {
+	organizations, err := s.orgRepo.FindAll(ctx, "active")
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, organization := range organizations {
+		if err := s.runOrganization(ctx, organization); err != nil {
+			errs = append(errs, err)
+			log.Printf("license alerts for organization %s failed: %v", organization.Code, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *LicenseAlertService) runOrganization(ctx context.Context, organization *model.Organization) error {
+	var supervisors []*model.User
+	supervisorsLoaded := false
+	loadSupervisors := func() ([]*model.User, error) {
+		if supervisorsLoaded {
+			return supervisors, nil
+		}
+		var err error
+		if supervisors, err = supervisorsOrAdmins(ctx, s.userRepo, organization.ID); err != nil {
+			return nil, err
+		}
+		supervisorsLoaded = true
+		return supervisors, nil
+	}
+
+	if len(s.thresholds) > 0 {
+		licenses, err := s.technicianRepo.FindExpiringLicenses(ctx, organization.ID, s.thresholds[len(s.thresholds)-1])
+		if err != nil {
+			return err
+		}
+		for _, license := range licenses {
+			// Only the tightest threshold fires, so a license first seen 5 days before
+			// expiry does not trigger the 60- and 30-day alerts as well
+			threshold := s.threshold(license.DaysUntilExpiry)
+			recipients, err := loadSupervisors()
+			if err != nil {
+				return err
+			}
+			alert := alertInfo{
+				technicianID:   license.TechnicianID,
+				licenseNumber:  license.LicenseNumber,
+				licenseType:    license.LicenseType,
+				expirationDate: license.LicenseExpirationDate,
+				daysLeft:       license.DaysUntilExpiry,
+			}
+			if err := s.send(ctx, organization, alert, threshold, recipients); err != nil {
+				return err
+			}
+		}
+	}
+
+	expired, err := s.alertRepo.FindUnreportedExpired(ctx, organization.ID)
+	if err != nil {
+		return err
+	}
+	for _, profile := range expired {
+		recipients, err := loadSupervisors()
+		if err != nil {
+			return err
+		}
+		alert := alertInfo{
+			profileID:      profile.ID,
+			technicianID:   profile.UserID,
+			licenseNumber:  profile.LicenseNumber,
+			licenseType:    profile.LicenseType,
+			expirationDate: *profile.LicenseExpirationDate,
+		}
+		if err := s.send(ctx, organization, alert, model.LicenseAlertExpired, recipients); err != nil {
+			return err
+		}
+	}
+
+	// The expiry is alerted once, but technicians made available again while it lasts
+	// are made unavailable on every run
+	reverted, err := s.alertRepo.MarkExpiredUnavailable(ctx, organization.ID)
+	if err != nil {
+		return err
+	}
+	for _, profile := range reverted {
+		s.auditUnavailable(ctx, organization.ID, profile.ID, *profile.LicenseExpirationDate)
+	}
+	return nil
+}
+
+type alertInfo struct {
+	profileID      uuid.UUID
+	technicianID   uuid.UUID
+	licenseNumber  *string
+	licenseType    *string
+	expirationDate time.Time
+	daysLeft       int
+}
+
+// send records the alert and queues one email per recipient. Alerts recorded before
+// (by an earlier run or another instance) are skipped.
+func (s *LicenseAlertService) send(ctx context.Context, organization *model.Organization, alert alertInfo, threshold int, supervisors []*model.User) error {
+	technician, err := s.userRepo.FindByID(ctx, alert.technicianID)
+	if err != nil {
+		return err
+	}
+
+	recipients := make([]string, 0, len(supervisors)+1)
+	if technician != nil && technician.Status != "inactive" && technician.Status != "deleted" {
+		recipients = append(recipients, technician.Email)
+	}
+	for _, supervisor := range supervisors {
+		if supervisor.ID != alert.technicianID {
+			recipients = append(recipients, supervisor.Email)
+		}
+	}
+
+	technicianEmail := ""
+	if technician != nil {
+		technicianEmail = technician.Email
+	}
+	templateData, err := json.Marshal(map[string]interface{}{
+		"organization_name":       organization.Name,
+		"technician_email":        technicianEmail,
+		"license_number":          alert.licenseNumber,
+		"license_type":            alert.licenseType,
+		"license_expiration_date": alert.expirationDate.Format(dateLayout),
+		"days_until_expiry":       alert.daysLeft,
+		"threshold_days":          threshold,
+		"expired":                 threshold == model.LicenseAlertExpired,
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	emails := make([]*model.EmailQueueEntry, 0, len(recipients))
+	for _, recipient := range recipients {
+		emails = append(emails, &model.EmailQueueEntry{
+			ID:             uuid.New(),
+			OrganizationID: organization.ID,
+			RecipientEmail: recipient,
+			EmailType:      model.EmailTypeLicenseExpirationAlert,
+			TemplateData:   templateData,
+			Status:         "pending",
+			CreatedAt:      now,
+		})
+	}
+
+	recorded, err := s.alertRepo.Record(ctx, &model.LicenseExpirationAlert{
+		ID:                    uuid.New(),
+		OrganizationID:        organization.ID,
+		TechnicianUserID:      alert.technicianID,
+		LicenseExpirationDate: alert.expirationDate,
+		ThresholdDays:         threshold,
+		RecipientCount:        len(emails),
+		CreatedAt:             now,
+	}, emails)
+	if err != nil {
+		return err
+	}
+
+	if recorded && threshold == model.LicenseAlertExpired {
+		s.auditUnavailable(ctx, organization.ID, alert.profileID, alert.expirationDate)
+	}
+	return nil
+}
+
+// auditUnavailable records that a technician was made unavailable by their expired license.
+func (s *LicenseAlertService) auditUnavailable(ctx context.Context, organizationID, profileID uuid.UUID, expirationDate time.Time) {
+	if err := s.auditService.Record(ctx, AuditEntry{
+		OrganizationID: organizationID,
+		EntityType:     "technician_profile",
+		EntityID:       profileID,
+		Action:         AuditActionUpdate,
+		Before:         map[string]interface{}{"is_available": true},
+		After: map[string]interface{}{
+			"event":                   "license_expired",
+			"is_available":            false,
+			"license_expiration_date": expirationDate.Format(dateLayout),
+		},
+	}); err != nil {
+		log.Printf("failed to audit expired license of technician profile %s: %v", profileID, err)
+	}
+}
+
+// threshold returns the smallest configured threshold not below daysLeft.
+func (s *LicenseAlertService) threshold(daysLeft int) int {
+	for _, threshold := range s.thresholds {
+		if daysLeft <= threshold {
+			return threshold
+		}
+	}
+	return s.thresholds[len(s.thresholds)-1]
+}
+
+// supervisorsOrAdmins are the organization's enabled supervisors, or its admins if it has
+// none.
+func supervisorsOrAdmins(ctx context.Context, userRepo *repository.UserRepository, organizationID uuid.UUID) ([]*model.User, error) {
+	supervisors, err := userRepo.FindEnabledByRole(ctx, organizationID, model.RoleSupervisor)
+	if err != nil || len(supervisors) > 0 {
+		return supervisors, err
+	}
+	return userRepo.FindEnabledByRole(ctx, organizationID, model.RoleAdmin)
+}
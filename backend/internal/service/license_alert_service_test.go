@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/NWhite12/EquipChain/internal/testdb"
+	"github.com/google/uuid"
+)
+
+func TestLicenseAlertsKeepExpiredTechniciansUnavailable(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	organization := testdb.CreateOrganization(t, db)
+	testdb.CreateUser(t, db, organization.ID, model.RoleSupervisor)
+	technician := testdb.CreateUser(t, db, organization.ID, model.RoleTechnician)
+
+	expired := time.Now().AddDate(0, 0, -3).Truncate(24 * time.Hour)
+	profile := &model.TechnicianProfile{
+		ID:                    uuid.New(),
+		UserID:                technician.ID,
+		OrganizationID:        organization.ID,
+		LicenseExpirationDate: &expired,
+		Certifications:        json.RawMessage("[]"),
+		IsAvailable:           true,
+		CreatedAt:             time.Now(),
+		UpdatedAt:             time.Now(),
+	}
+	if err := db.Create(profile).Error; err != nil {
+		t.Fatalf("create profile: %v", err)
+	}
+
+	s := NewLicenseAlertService(repository.NewOrganizationRepository(db), repository.NewTechnicianRepository(db), repository.NewLicenseAlertRepository(db),
+		repository.NewUserRepository(db), NewAuditService(repository.NewAuditRepository(db)), []int{30})
+	check := func(run string, alerts int64) {
+		t.Helper()
+		if err := s.runOrganization(ctx, organization); err != nil {
+			t.Fatalf("%s: %v", run, err)
+		}
+		var reloaded model.TechnicianProfile
+		if err := db.Where("id = ?", profile.ID).First(&reloaded).Error; err != nil {
+			t.Fatalf("%s: reload profile: %v", run, err)
+		}
+		if reloaded.IsAvailable {
+			t.Fatalf("%s: technician with an expired license is available", run)
+		}
+		var count int64
+		if err := db.Model(&model.LicenseExpirationAlert{}).Where("technician_user_id = ?", technician.ID).Count(&count).Error; err != nil || count != alerts {
+			t.Fatalf("%s: %d expiry alerts (%v), want %d", run, count, err, alerts)
+		}
+	}
+
+	check("first run", 1)
+
+	// Made available again without renewing: unavailable again, without another alert
+	if err := db.Model(&model.TechnicianProfile{}).Where("id = ?", profile.ID).Update("is_available", true).Error; err != nil {
+		t.Fatalf("make available: %v", err)
+	}
+	check("run after making the technician available", 1)
+
+	var audits int64
+	if err := db.Model(&model.AuditLog{}).Where("entity_id = ? AND action = ?", profile.ID, AuditActionUpdate).Count(&audits).Error; err != nil || audits != 2 {
+		t.Fatalf("%d audit entries (%v), want one per time the technician was made unavailable", audits, err)
+	}
+}
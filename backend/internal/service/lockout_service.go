@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/config"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+)
+
+// LockoutPolicy controls progressive account lockout. Durations[n] applies to the
+// (n+1)th consecutive lockout; the last entry repeats.
+type LockoutPolicy struct {
+	MaxAttempts       int
+	Durations         []time.Duration
+	ObservationWindow time.Duration
+}
+
+func LockoutPolicyFromConfig(cfg *config.Config) LockoutPolicy {
+	return LockoutPolicy{
+		MaxAttempts:       cfg.LockoutThreshold,
+		Durations:         cfg.LockoutDurations,
+		ObservationWindow: cfg.LockoutObservationWindow,
+	}
+}
+
+type LockoutService struct {
+	userRepo     *repository.UserRepository
+	auditService *AuditService
+	policy       LockoutPolicy
+}
+
+func NewLockoutService(userRepo *repository.UserRepository, auditService *AuditService, policy LockoutPolicy) *LockoutService {
+	return &LockoutService{
+		userRepo:     userRepo,
+		auditService: auditService,
+		policy:       policy,
+	}
+}
+
+// IsLocked reports whether the user is inside an active lockout period.
+func (s *LockoutService) IsLocked(user *model.User) bool {
+	return user.LockedUntil != nil && time.Now().Before(*user.LockedUntil)
+}
+
+// RegisterFailure counts a failed login and reports whether the account is now locked.
+// The attempt that triggers a lockout is written to the audit trail. If that write fails
+// the error is returned, but the account stays locked: a missing audit row must not
+// unlock it.
+func (s *LockoutService) RegisterFailure(ctx context.Context, user *model.User, meta RequestMetadata) (bool, error) {
+	isLocked, remainingSeconds, justLocked, err := s.userRepo.CheckAndUpdateLockout(ctx, user.ID, s.policy.MaxAttempts, s.policy.Durations, s.policy.ObservationWindow)
+	if err != nil {
+		return false, err
+	}
+
+	if justLocked {
+		lockedUntil := time.Now().Add(time.Duration(remainingSeconds) * time.Second)
+		if err := s.auditService.Record(ctx, AuditEntry{
+			OrganizationID: user.OrganizationID,
+			EntityType:     "user",
+			EntityID:       user.ID,
+			Action:         AuditActionUpdate,
+			Before:         map[string]interface{}{"status": user.Status},
+			After: map[string]interface{}{
+				"event":        "account_locked",
+				"status":       "locked",
+				"locked_until": lockedUntil.UTC().Format(time.RFC3339),
+				"lockout_step": user.LockoutCount + 1,
+			},
+			Metadata: meta,
+		}); err != nil {
+			return isLocked, fmt.Errorf("audit lockout of user %s: %w", user.ID, err)
+		}
+	}
+
+	return isLocked, nil
+}
+
+func (s *LockoutService) RecordSuccess(ctx context.Context, user *model.User, meta RequestMetadata) error {
+	return s.userRepo.RecordSuccessfulLogin(ctx, user.ID, meta.IPAddress)
+}
+
+// Unlock clears an account lockout on behalf of an organization admin.
+func (s *LockoutService) Unlock(ctx context.Context, organizationID, userID, unlockedBy uuid.UUID, meta RequestMetadata) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.OrganizationID != organizationID {
+		return ErrUserNotFound
+	}
+
+	if err := s.userRepo.ResetLockout(ctx, user.ID, unlockedBy); err != nil {
+		return err
+	}
+
+	status := user.Status
+	if status == "locked" {
+		status = "active"
+	}
+
+	return s.auditService.Record(ctx, AuditEntry{
+		OrganizationID: organizationID,
+		ActorID:        &unlockedBy,
+		EntityType:     "user",
+		EntityID:       user.ID,
+		Action:         AuditActionUpdate,
+		Before: map[string]interface{}{
+			"status":                user.Status,
+			"failed_login_attempts": user.FailedLoginAttempts,
+			"locked_until":          user.LockedUntil,
+		},
+		After: map[string]interface{}{
+			"event":                 "account_unlocked",
+			"status":                status,
+			"failed_login_attempts": 0,
+			"locked_until":          nil,
+		},
+		Metadata: meta,
+	})
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/NWhite12/EquipChain/internal/testdb"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var testLockoutPolicy = LockoutPolicy{
+	MaxAttempts:       3,
+	Durations:         []time.Duration{5 * time.Minute, 15 * time.Minute, time.Hour},
+	ObservationWindow: 15 * time.Minute,
+}
+
+func newTestLockoutService(db *gorm.DB) *LockoutService {
+	return NewLockoutService(repository.NewUserRepository(db), NewAuditService(repository.NewAuditRepository(db)), testLockoutPolicy)
+}
+
+func reloadUser(t *testing.T, db *gorm.DB, userID uuid.UUID) *model.User {
+	t.Helper()
+	var user model.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		t.Fatalf("reload user: %v", err)
+	}
+	return &user
+}
+
+// failLogins registers n failed logins and returns whether the last one left the account locked.
+func failLogins(t *testing.T, s *LockoutService, db *gorm.DB, userID uuid.UUID, n int) bool {
+	t.Helper()
+	var locked bool
+	for i := 0; i < n; i++ {
+		var err error
+		locked, err = s.RegisterFailure(context.Background(), reloadUser(t, db, userID), RequestMetadata{IPAddress: "203.0.113.7"})
+		if err != nil {
+			t.Fatalf("register failure %d: %v", i+1, err)
+		}
+	}
+	return locked
+}
+
+// expireLock moves the user's lockout into the past, as if its duration had elapsed.
+func expireLock(t *testing.T, db *gorm.DB, userID uuid.UUID) {
+	t.Helper()
+	err := db.Model(&model.User{}).Where("id = ?", userID).
+		Update("locked_until", gorm.Expr("CURRENT_TIMESTAMP - INTERVAL '1 second'")).Error
+	if err != nil {
+		t.Fatalf("expire lock: %v", err)
+	}
+}
+
+func auditEvents(t *testing.T, db *gorm.DB, userID uuid.UUID) []string {
+	t.Helper()
+	var logs []model.AuditLog
+	if err := db.Where("entity_type = 'user' AND entity_id = ?", userID).Order("created_at").Find(&logs).Error; err != nil {
+		t.Fatalf("load audit log: %v", err)
+	}
+	var events []string
+	for _, entry := range logs {
+		var after struct {
+			Event string `json:"event"`
+		}
+		if err := json.Unmarshal(entry.ChangesAfter, &after); err != nil {
+			t.Fatalf("decode audit changes: %v", err)
+		}
+		events = append(events, after.Event)
+	}
+	return events
+}
+
+func assertLockedFor(t *testing.T, user *model.User, duration time.Duration) {
+	t.Helper()
+	if user.LockedUntil == nil {
+		t.Fatalf("locked_until is NULL, want about %s from now", duration)
+	}
+	if remaining := time.Until(*user.LockedUntil); remaining < duration-10*time.Second || remaining > duration+10*time.Second {
+		t.Fatalf("locked for %s, want about %s", remaining.Round(time.Second), duration)
+	}
+}
+
+func TestLockoutThreshold(t *testing.T) {
+	db := testdb.Open(t)
+	organization := testdb.CreateOrganization(t, db)
+	user := testdb.CreateUser(t, db, organization.ID, model.RoleTechnician)
+	s := newTestLockoutService(db)
+
+	if failLogins(t, s, db, user.ID, testLockoutPolicy.MaxAttempts-1) {
+		t.Fatal("locked before reaching the threshold")
+	}
+	if got := reloadUser(t, db, user.ID); got.FailedLoginAttempts != int16(testLockoutPolicy.MaxAttempts-1) || got.LockedUntil != nil {
+		t.Fatalf("after %d failures: attempts %d, locked_until %v", testLockoutPolicy.MaxAttempts-1, got.FailedLoginAttempts, got.LockedUntil)
+	}
+
+	if !failLogins(t, s, db, user.ID, 1) {
+		t.Fatal("not locked at the threshold")
+	}
+	locked := reloadUser(t, db, user.ID)
+	if locked.Status != "locked" || locked.LockoutCount != 1 || locked.FailedLoginAttempts != 0 {
+		t.Fatalf("locked user: status %q, lockout_count %d, attempts %d", locked.Status, locked.LockoutCount, locked.FailedLoginAttempts)
+	}
+	assertLockedFor(t, locked, testLockoutPolicy.Durations[0])
+	if !s.IsLocked(locked) {
+		t.Fatal("IsLocked is false for a locked user")
+	}
+
+	// Attempts during the lockout neither count nor extend it
+	if !failLogins(t, s, db, user.ID, 5) {
+		t.Fatal("not locked during the lockout period")
+	}
+	stillLocked := reloadUser(t, db, user.ID)
+	if stillLocked.FailedLoginAttempts != 0 || stillLocked.LockoutCount != 1 || !stillLocked.LockedUntil.Equal(*locked.LockedUntil) {
+		t.Fatalf("attempts during lockout changed the lock: attempts %d, lockout_count %d, locked_until %v (was %v)",
+			stillLocked.FailedLoginAttempts, stillLocked.LockoutCount, stillLocked.LockedUntil, locked.LockedUntil)
+	}
+
+	if events := auditEvents(t, db, user.ID); len(events) != 1 || events[0] != "account_locked" {
+		t.Fatalf("audit events %v, want one account_locked", events)
+	}
+}
+
+func TestLockoutProgressiveDurations(t *testing.T) {
+	db := testdb.Open(t)
+	organization := testdb.CreateOrganization(t, db)
+	user := testdb.CreateUser(t, db, organization.ID, model.RoleTechnician)
+	s := newTestLockoutService(db)
+
+	// The last duration repeats once the steps run out
+	want := append(testLockoutPolicy.Durations, testLockoutPolicy.Durations[len(testLockoutPolicy.Durations)-1])
+	for step, duration := range want {
+		if !failLogins(t, s, db, user.ID, testLockoutPolicy.MaxAttempts) {
+			t.Fatalf("lockout %d: not locked", step+1)
+		}
+		locked := reloadUser(t, db, user.ID)
+		if locked.LockoutCount != int16(step+1) {
+			t.Fatalf("lockout %d: lockout_count %d", step+1, locked.LockoutCount)
+		}
+		assertLockedFor(t, locked, duration)
+		expireLock(t, db, user.ID)
+	}
+
+	// A successful login starts the progression over
+	if err := s.RecordSuccess(context.Background(), user, RequestMetadata{IPAddress: "203.0.113.7"}); err != nil {
+		t.Fatalf("record success: %v", err)
+	}
+	if !failLogins(t, s, db, user.ID, testLockoutPolicy.MaxAttempts) {
+		t.Fatal("not locked after a successful login")
+	}
+	assertLockedFor(t, reloadUser(t, db, user.ID), testLockoutPolicy.Durations[0])
+}
+
+func TestLockoutObservationWindowReset(t *testing.T) {
+	db := testdb.Open(t)
+	organization := testdb.CreateOrganization(t, db)
+	user := testdb.CreateUser(t, db, organization.ID, model.RoleTechnician)
+	s := newTestLockoutService(db)
+
+	failLogins(t, s, db, user.ID, testLockoutPolicy.MaxAttempts-1)
+
+	// The earlier failures fall out of the observation window
+	err := db.Model(&model.User{}).Where("id = ?", user.ID).
+		Update("last_failed_login_at", time.Now().Add(-testLockoutPolicy.ObservationWindow-time.Minute)).Error
+	if err != nil {
+		t.Fatalf("age failures: %v", err)
+	}
+
+	if failLogins(t, s, db, user.ID, 1) {
+		t.Fatal("failures outside the observation window counted towards the lockout")
+	}
+	if got := reloadUser(t, db, user.ID); got.FailedLoginAttempts != 1 {
+		t.Fatalf("attempts %d after the window reset, want 1", got.FailedLoginAttempts)
+	}
+
+	// Failures inside the window still add up
+	if !failLogins(t, s, db, user.ID, testLockoutPolicy.MaxAttempts-1) {
+		t.Fatal("not locked by failures inside the observation window")
+	}
+}
+
+func TestLockoutAdminUnlock(t *testing.T) {
+	db := testdb.Open(t)
+	organization := testdb.CreateOrganization(t, db)
+	admin := testdb.CreateUser(t, db, organization.ID, model.RoleAdmin)
+	user := testdb.CreateUser(t, db, organization.ID, model.RoleTechnician)
+	s := newTestLockoutService(db)
+	ctx := context.Background()
+
+	failLogins(t, s, db, user.ID, 2*testLockoutPolicy.MaxAttempts)
+
+	// Admins of another organization cannot see the user
+	other := testdb.CreateOrganization(t, db)
+	otherAdmin := testdb.CreateUser(t, db, other.ID, model.RoleAdmin)
+	if err := s.Unlock(ctx, other.ID, user.ID, otherAdmin.ID, RequestMetadata{}); err != ErrUserNotFound {
+		t.Fatalf("unlock from another organization: %v, want ErrUserNotFound", err)
+	}
+
+	if err := s.Unlock(ctx, organization.ID, user.ID, admin.ID, RequestMetadata{IPAddress: "198.51.100.2"}); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	unlocked := reloadUser(t, db, user.ID)
+	if unlocked.Status != "active" || unlocked.LockedUntil != nil || unlocked.FailedLoginAttempts != 0 || unlocked.LockoutCount != 0 {
+		t.Fatalf("unlocked user: status %q, locked_until %v, attempts %d, lockout_count %d",
+			unlocked.Status, unlocked.LockedUntil, unlocked.FailedLoginAttempts, unlocked.LockoutCount)
+	}
+	if s.IsLocked(unlocked) {
+		t.Fatal("IsLocked is true after unlock")
+	}
+	if unlocked.UpdatedBy == nil || *unlocked.UpdatedBy != admin.ID {
+		t.Fatalf("updated_by %v, want the admin %s", unlocked.UpdatedBy, admin.ID)
+	}
+
+	events := auditEvents(t, db, user.ID)
+	if len(events) != 2 || events[0] != "account_locked" || events[1] != "account_unlocked" {
+		t.Fatalf("audit events %v, want account_locked then account_unlocked", events)
+	}
+
+	// The unlock also restarts the progression
+	if !failLogins(t, s, db, user.ID, testLockoutPolicy.MaxAttempts) {
+		t.Fatal("not locked again after unlock")
+	}
+	assertLockedFor(t, reloadUser(t, db, user.ID), testLockoutPolicy.Durations[0])
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/events"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MaintenanceAlertService warns about preventive maintenance that is due soon or overdue.
+// It queues overdue_maintenance_alert emails to the equipment owner and the
+// organization's supervisors, publishes schedule events (delivered as webhooks), and
+// escalates to admins once a schedule has been overdue for escalationDays. Each alert is
+// stamped on the schedule so it is sent once per due date; rolling the schedule forward
+// re-arms them. It is run periodically by the jobs scheduler.
+type MaintenanceAlertService struct {
+	orgRepo        *repository.OrganizationRepository
+	alertRepo      *repository.MaintenanceAlertRepository
+	userRepo       *repository.UserRepository
+	bus            *events.Bus
+	dueSoonDays    int
+	escalationDays int
+}
+
+// NewMaintenanceAlertService takes how many days ahead a schedule counts as due soon and
+// after how many days overdue alerts are escalated (0 disables escalation).
+func NewMaintenanceAlertService(orgRepo *repository.OrganizationRepository, alertRepo *repository.MaintenanceAlertRepository, userRepo *repository.UserRepository,
+	bus *events.Bus, dueSoonDays, escalationDays int) *MaintenanceAlertService {
+	return &MaintenanceAlertService{
+		orgRepo:        orgRepo,
+		alertRepo:      alertRepo,
+		userRepo:       userRepo,
+		bus:            bus,
+		dueSoonDays:    dueSoonDays,
+		escalationDays: escalationDays,
+	}
+}
+
+// Run checks every active organization. A failing organization does not stop the others.
+func (s *MaintenanceAlertService) Run(ctx context.Context) error {
+	organizations, err := s.orgRepo.FindAll(ctx, "active")
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, organization := range organizations {
+		if err := s.runOrganization(ctx, organization); err != nil {
+			errs = append(errs, err)
+			log.Printf("maintenance alerts for organization %s failed: %v", organization.Code, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *MaintenanceAlertService) runOrganization(ctx context.Context, organization *model.Organization) error {
+	passes := []alertPass{
+		{model.MaintenanceAlertOverdue, 0},
+		{model.MaintenanceAlertDueSoon, s.dueSoonDays},
+	}
+	if s.escalationDays > 0 {
+		passes = append(passes, alertPass{model.MaintenanceAlertEscalation, s.escalationDays})
+	}
+
+	recipients := make(map[string][]*model.User)
+	for _, pass := range passes {
+		alerts, err := s.alertRepo.FindPending(ctx, organization.ID, pass.kind, pass.days)
+		if err != nil {
+			return err
+		}
+		if len(alerts) == 0 {
+			continue
+		}
+
+		// Escalations go to admins; other alerts to supervisors (admins if there are none)
+		group := model.MaintenanceAlertOverdue
+		if pass.kind == model.MaintenanceAlertEscalation {
+			group = model.MaintenanceAlertEscalation
+		}
+		if _, ok := recipients[group]; !ok {
+			var users []*model.User
+			if group == model.MaintenanceAlertEscalation {
+				users, err = s.userRepo.FindEnabledByRole(ctx, organization.ID, model.RoleAdmin)
+			} else {
+				users, err = supervisorsOrAdmins(ctx, s.userRepo, organization.ID)
+			}
+			if err != nil {
+				return err
+			}
+			recipients[group] = users
+		}
+
+		for _, alert := range alerts {
+			if err := s.send(ctx, organization, alert, pass.kind, recipients[group]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// send stamps the alert and queues one email per recipient, then publishes the event.
+// Alerts stamped before (by an earlier run or another instance) are skipped.
+func (s *MaintenanceAlertService) send(ctx context.Context, organization *model.Organization, alert model.MaintenanceAlert, kind string, users []*model.User) error {
+	seen := make(map[string]bool)
+	var recipients []string
+	addRecipient := func(user *model.User) {
+		if user == nil || user.OrganizationID != organization.ID || user.Status == "inactive" || user.Status == "deleted" || seen[user.Email] {
+			return
+		}
+		seen[user.Email] = true
+		recipients = append(recipients, user.Email)
+	}
+
+	// The owner hears about their equipment, escalations are for admins only
+	if alert.OwnerID != nil && kind != model.MaintenanceAlertEscalation {
+		owner, err := s.userRepo.FindByID(ctx, *alert.OwnerID)
+		if err != nil {
+			return err
+		}
+		addRecipient(owner)
+	}
+	for _, user := range users {
+		addRecipient(user)
+	}
+
+	data := map[string]interface{}{
+		"organization_name":   organization.Name,
+		"alert":               kind,
+		"schedule_id":         alert.ScheduleID,
+		"equipment_id":        alert.EquipmentID,
+		"equipment_name":      alert.EquipmentName,
+		"serial_number":       alert.SerialNumber,
+		"maintenance_type_id": alert.MaintenanceTypeID,
+		"maintenance_type":    alert.MaintenanceType,
+		"next_due_date":       alert.NextDueDate.Format(dateLayout),
+		"next_due_trigger":    alert.NextDueTrigger,
+		"days_until_due":      alert.DaysUntilDue,
+	}
+	templateData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	emails := make([]*model.EmailQueueEntry, 0, len(recipients))
+	for _, recipient := range recipients {
+		emails = append(emails, &model.EmailQueueEntry{
+			ID:             uuid.New(),
+			OrganizationID: organization.ID,
+			RecipientEmail: recipient,
+			EmailType:      model.EmailTypeOverdueMaintenance,
+			TemplateData:   templateData,
+			Status:         "pending",
+			CreatedAt:      now,
+		})
+	}
+
+	_, err = s.alertRepo.Record(ctx, alert, kind, emails, func(tx *gorm.DB) error {
+		return s.bus.Publish(ctx, tx, organization.ID, maintenanceAlertEvents[kind], data)
+	})
+	return err
+}
+
+// alertPass is one kind of alert with its day threshold, see
+// MaintenanceAlertRepository.FindPending.
+type alertPass struct {
+	kind string
+	days int
+}
+
+var maintenanceAlertEvents = map[string]string{
+	model.MaintenanceAlertDueSoon:    events.ScheduleDueSoon,
+	model.MaintenanceAlertOverdue:    events.ScheduleOverdue,
+	model.MaintenanceAlertEscalation: events.ScheduleOverdueEscalated,
+}
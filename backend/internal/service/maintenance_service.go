@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/events"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/NWhite12/EquipChain/internal/scheduling"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Signature describes how an approver authenticated for a single approval or rejection.
+// Factor comes from the step-up signing token.
+type Signature struct {
+	Factor string
+	// TokenID is the signing token's jti. A token signs one decision only.
+	TokenID  uuid.UUID
+	Metadata RequestMetadata
+}
+
+type MaintenanceService struct {
+	maintenanceRepo *repository.MaintenanceRepository
+	equipmentRepo   *repository.EquipmentRepository
+	technicianRepo  *repository.TechnicianRepository
+	scheduleRepo    *repository.ScheduleRepository
+	meterService    *MeterService
+	bus             *events.Bus
+}
+
+func NewMaintenanceService(maintenanceRepo *repository.MaintenanceRepository, equipmentRepo *repository.EquipmentRepository, technicianRepo *repository.TechnicianRepository,
+	scheduleRepo *repository.ScheduleRepository, meterService *MeterService, bus *events.Bus) *MaintenanceService {
+	return &MaintenanceService{
+		maintenanceRepo: maintenanceRepo,
+		equipmentRepo:   equipmentRepo,
+		technicianRepo:  technicianRepo,
+		scheduleRepo:    scheduleRepo,
+		meterService:    meterService,
+		bus:             bus,
+	}
+}
+
+func (s *MaintenanceService) ListRecords(ctx context.Context, organizationID uuid.UUID, filters map[string]interface{}) ([]*model.MaintenanceRecord, error) {
+	return s.maintenanceRepo.FindByOrganizationID(ctx, organizationID, filters)
+}
+
+func (s *MaintenanceService) GetRecord(ctx context.Context, organizationID, recordID uuid.UUID) (*model.MaintenanceRecord, error) {
+	record, err := s.maintenanceRepo.FindByID(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	// Records of other organizations are reported as missing so IDs cannot be probed
+	if record == nil || record.OrganizationID != organizationID {
+		return nil, ErrMaintenanceNotFound
+	}
+	return record, nil
+}
+
+func (s *MaintenanceService) ApprovalHistory(ctx context.Context, organizationID, recordID uuid.UUID) ([]*model.MaintenanceApprovalAudit, error) {
+	if _, err := s.GetRecord(ctx, organizationID, recordID); err != nil {
+		return nil, err
+	}
+	return s.maintenanceRepo.FindApprovalHistory(ctx, recordID)
+}
+
+// CreateRecord starts a draft record with the creator as technician. Meter readings taken
+// on-site are stored with it and refresh the equipment's meter-based schedules.
+func (s *MaintenanceService) CreateRecord(ctx context.Context, organizationID, createdBy uuid.UUID, record *model.MaintenanceRecord, readings []MeterReadingInput) (*model.MaintenanceRecord, error) {
+	equipment, err := s.equipmentRepo.FindByID(ctx, record.EquipmentID)
+	if err != nil {
+		return nil, err
+	}
+	if equipment == nil || equipment.OrganizationID != organizationID {
+		return nil, ErrEquipmentNotFound
+	}
+
+	allowed, err := s.equipmentRepo.StatusAllowsMaintenance(ctx, equipment.StatusID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrEquipmentNotMaintainable
+	}
+
+	validType, err := s.maintenanceRepo.IsValidTypeID(ctx, record.MaintenanceTypeID)
+	if err != nil {
+		return nil, err
+	}
+	if !validType {
+		return nil, ErrInvalidMaintenanceType
+	}
+
+	if record.GPSLatitude != nil && (*record.GPSLatitude < -90 || *record.GPSLatitude > 90) {
+		return nil, ErrInvalidGPSCoordinates
+	}
+	if record.GPSLongitude != nil && (*record.GPSLongitude < -180 || *record.GPSLongitude > 180) {
+		return nil, ErrInvalidGPSCoordinates
+	}
+
+	record.ID = uuid.New()
+	record.OrganizationID = organizationID
+	record.StatusID = model.MaintenanceStatusDraft
+	record.TechnicianID = createdBy
+	record.CreatedBy = &createdBy
+	record.UpdatedBy = &createdBy
+	record.CreatedAt = time.Now()
+	record.UpdatedAt = time.Now()
+
+	meterReadings, err := s.meterService.newRecordReadings(organizationID, createdBy, record, readings)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.maintenanceRepo.Create(ctx, record, meterReadings, func(tx *gorm.DB) error {
+		return s.bus.Publish(ctx, tx, organizationID, events.MaintenanceCreated, maintenanceEventData(record))
+	})
+	if err != nil {
+		return nil, mapMeterConflict(err)
+	}
+	s.meterService.refreshForecasts(ctx, createdBy, meterReadings)
+
+	return record, nil
+}
+
+// SubmitRecord sends a draft (or a rejected record being resubmitted) for approval. A
+// technician whose license has expired cannot submit.
+func (s *MaintenanceService) SubmitRecord(ctx context.Context, organizationID, recordID, userID uuid.UUID) (*model.MaintenanceRecord, error) {
+	record, err := s.GetRecord(ctx, organizationID, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if record.TechnicianID != userID {
+		return nil, ErrNotMaintenanceTechnician
+	}
+	if record.StatusID != model.MaintenanceStatusDraft && record.StatusID != model.MaintenanceStatusRejected {
+		return nil, ErrInvalidMaintenanceStatus
+	}
+	if record.Notes == nil || strings.TrimSpace(*record.Notes) == "" {
+		return nil, ErrNotesRequired
+	}
+	expired, err := s.technicianRepo.HasExpiredLicense(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if expired {
+		return nil, ErrTechnicianLicenseExpired
+	}
+
+	submitted, err := s.maintenanceRepo.UpdateStatus(ctx, record.ID,
+		[]int16{model.MaintenanceStatusDraft, model.MaintenanceStatusRejected},
+		map[string]interface{}{
+			"status_id":    model.MaintenanceStatusSubmitted,
+			"submitted_at": gorm.Expr("NOW()"),
+		}, userID, s.publishRecord(ctx, events.MaintenanceSubmitted))
+	if err != nil {
+		return nil, mapStaleStatus(err)
+	}
+	return submitted, nil
+}
+
+// ApproveRecord signs off a submitted record. The approval and its signature factor are
+// written to maintenance_approval_audit atomically.
+func (s *MaintenanceService) ApproveRecord(ctx context.Context, organizationID, recordID, approverID uuid.UUID, comments *string, signature Signature) (*model.MaintenanceRecord, error) {
+	return s.decide(ctx, organizationID, recordID, approverID, model.ApprovalActionApproved, comments, signature, map[string]interface{}{
+		"status_id":     model.MaintenanceStatusApproved,
+		"approved_at":   gorm.Expr("NOW()"),
+		"supervisor_id": approverID,
+	})
+}
+
+// RejectRecord returns a submitted record to the technician. A reason is mandatory.
+func (s *MaintenanceService) RejectRecord(ctx context.Context, organizationID, recordID, approverID uuid.UUID, comments *string, signature Signature) (*model.MaintenanceRecord, error) {
+	if comments == nil || strings.TrimSpace(*comments) == "" {
+		return nil, ErrRejectionReasonRequired
+	}
+
+	return s.decide(ctx, organizationID, recordID, approverID, model.ApprovalActionRejected, comments, signature, map[string]interface{}{
+		"status_id":   model.MaintenanceStatusRejected,
+		"rejected_at": gorm.Expr("NOW()"),
+	})
+}
+
+// ConfirmRecord records the on-chain confirmation of an approved record, reported by the
+// blockchain confirmation worker through POST /api/maintenance/:id/confirm. The matching
+// maintenance schedule is rolled forward in the same transaction.
+func (s *MaintenanceService) ConfirmRecord(ctx context.Context, organizationID, recordID, confirmedBy uuid.UUID, transactionSignature string) (*model.MaintenanceRecord, error) {
+	if !validTransactionSignature(transactionSignature) {
+		return nil, ErrInvalidTransactionSignature
+	}
+	record, err := s.GetRecord(ctx, organizationID, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if record.StatusID != model.MaintenanceStatusApproved {
+		return nil, ErrInvalidMaintenanceStatus
+	}
+
+	rollForward := func(schedule *model.MaintenanceSchedule) (map[string]interface{}, error) {
+		serviced := today()
+		schedule.LastMaintenanceDate = &serviced
+		meter, err := s.serviceMeter(ctx, record, schedule)
+		if err != nil {
+			return nil, err
+		}
+		due, err := nextScheduleDue(schedule, meter)
+		if err != nil {
+			return nil, err
+		}
+		applyScheduleDue(schedule, due)
+
+		return map[string]interface{}{
+			"last_maintenance_date":    serviced,
+			"last_service_meter_value": schedule.LastServiceMeterValue,
+			"next_due_date":            schedule.NextDueDate,
+			"next_due_meter_value":     schedule.NextDueMeterValue,
+			"next_due_trigger":         schedule.NextDueTrigger,
+			"overdue_alert_sent_at":    nil,
+			"due_soon_alert_sent_at":   nil,
+			"overdue_escalated_at":     nil,
+		}, nil
+	}
+
+	confirmed, err := s.maintenanceRepo.Confirm(ctx, record, transactionSignature, confirmedBy, rollForward,
+		s.publishRecord(ctx, events.MaintenanceConfirmed))
+	if err != nil {
+		return nil, mapStaleStatus(err)
+	}
+	return confirmed, nil
+}
+
+// validTransactionSignature accepts a base58 encoded 64-byte Solana transaction signature.
+func validTransactionSignature(signature string) bool {
+	if len(signature) < 64 || len(signature) > 88 {
+		return false
+	}
+	for _, r := range signature {
+		if !strings.ContainsRune(base58Alphabet, r) {
+			return false
+		}
+	}
+	return true
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// serviceMeter sets the schedule's meter baseline to the reading taken with the record, or
+// the latest reading if none was, and returns the meter state for forecasting. A lower
+// latest reading means the meter was reset since and wins.
+func (s *MaintenanceService) serviceMeter(ctx context.Context, record *model.MaintenanceRecord, schedule *model.MaintenanceSchedule) (*scheduling.MeterState, error) {
+	if schedule.MeterType == nil {
+		return nil, nil
+	}
+	meter, err := s.meterService.State(ctx, record.EquipmentID, *schedule.MeterType)
+	if err != nil || meter == nil {
+		return nil, err
+	}
+
+	baseline := meter.Value
+	readings, err := s.meterService.meterRepo.FindByMaintenanceRecord(ctx, record.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, reading := range readings {
+		if reading.MeterType == *schedule.MeterType && reading.Value < baseline {
+			baseline = reading.Value
+		}
+	}
+	schedule.LastServiceMeterValue = &baseline
+	return meter, nil
+}
+
+func (s *MaintenanceService) decide(ctx context.Context, organizationID, recordID, approverID uuid.UUID, action string, comments *string, signature Signature, updates map[string]interface{}) (*model.MaintenanceRecord, error) {
+	record, err := s.GetRecord(ctx, organizationID, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if record.StatusID != model.MaintenanceStatusSubmitted {
+		return nil, ErrInvalidMaintenanceStatus
+	}
+	if record.TechnicianID == approverID {
+		return nil, ErrSelfApproval
+	}
+
+	if comments != nil && strings.TrimSpace(*comments) == "" {
+		comments = nil
+	}
+
+	audit := &model.MaintenanceApprovalAudit{
+		ID:                  uuid.New(),
+		MaintenanceRecordID: record.ID,
+		OrganizationID:      organizationID,
+		ApproverID:          approverID,
+		Action:              action,
+		Comments:            comments,
+		AuthFactor:          &signature.Factor,
+		SigningTokenID:      &signature.TokenID,
+		CreatedAt:           time.Now(),
+	}
+	if signature.Metadata.IPAddress != "" {
+		audit.IPAddress = &signature.Metadata.IPAddress
+	}
+	if signature.Metadata.UserAgent != "" {
+		audit.UserAgent = &signature.Metadata.UserAgent
+	}
+
+	eventType := events.MaintenanceApproved
+	if action == model.ApprovalActionRejected {
+		eventType = events.MaintenanceRejected
+	}
+	decided, err := s.maintenanceRepo.RecordApproval(ctx, record.ID, updates, audit, s.publishRecord(ctx, eventType))
+	if errors.Is(err, repository.ErrSigningTokenUsed) {
+		return nil, ErrSigningTokenUsed
+	}
+	if err != nil {
+		return nil, mapStaleStatus(err)
+	}
+	return decided, nil
+}
+
+// publishRecord publishes a status change event from inside the transaction of the change.
+func (s *MaintenanceService) publishRecord(ctx context.Context, eventType string) repository.RecordPublishFunc {
+	return func(tx *gorm.DB, record *model.MaintenanceRecord) error {
+		return s.bus.Publish(ctx, tx, record.OrganizationID, eventType, maintenanceEventData(record))
+	}
+}
+
+// maintenanceEventData is the data of maintenance record events.
+func maintenanceEventData(record *model.MaintenanceRecord) map[string]interface{} {
+	return map[string]interface{}{
+		"maintenance_record_id": record.ID,
+		"equipment_id":          record.EquipmentID,
+		"maintenance_type_id":   record.MaintenanceTypeID,
+		"status_id":             record.StatusID,
+		"technician_id":         record.TechnicianID,
+		"supervisor_id":         record.SupervisorID,
+		"solana_signature":      record.SolanaSignature,
+		"submitted_at":          record.SubmittedAt,
+		"approved_at":           record.ApprovedAt,
+		"confirmed_at":          record.ConfirmedAt,
+		"rejected_at":           record.RejectedAt,
+	}
+}
+
+func mapStaleStatus(err error) error {
+	if errors.Is(err, repository.ErrStaleMaintenanceStatus) {
+		return ErrInvalidMaintenanceStatus
+	}
+	return err
+}
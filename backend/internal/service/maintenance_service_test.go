@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/events"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/NWhite12/EquipChain/internal/scheduling"
+	"github.com/NWhite12/EquipChain/internal/testdb"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var testTransactionSignature = strings.Repeat("5Kf7x3B9p2", 9)[:88]
+
+func newTestMaintenanceService(db *gorm.DB, bus *events.Bus) *MaintenanceService {
+	equipmentRepo := repository.NewEquipmentRepository(db)
+	scheduleRepo := repository.NewScheduleRepository(db)
+	meterService := NewMeterService(repository.NewMeterReadingRepository(db), equipmentRepo, scheduleRepo)
+	return NewMaintenanceService(repository.NewMaintenanceRepository(db), equipmentRepo, repository.NewTechnicianRepository(db), scheduleRepo, meterService, bus)
+}
+
+// createSubmittedRecord stores a preventive maintenance record awaiting a decision.
+func createSubmittedRecord(t *testing.T, db *gorm.DB, organizationID, equipmentID, technicianID uuid.UUID) *model.MaintenanceRecord {
+	t.Helper()
+	now := time.Now()
+	notes := "Replaced hydraulic filters"
+	record := &model.MaintenanceRecord{
+		ID:                uuid.New(),
+		OrganizationID:    organizationID,
+		EquipmentID:       equipmentID,
+		MaintenanceTypeID: 1,
+		StatusID:          model.MaintenanceStatusSubmitted,
+		TechnicianID:      technicianID,
+		Notes:             &notes,
+		SubmittedAt:       &now,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := db.Create(record).Error; err != nil {
+		t.Fatalf("create maintenance record: %v", err)
+	}
+	return record
+}
+
+// createApprovedRecord stores a preventive maintenance record that a supervisor approved.
+func createApprovedRecord(t *testing.T, db *gorm.DB, organizationID, equipmentID, technicianID, supervisorID uuid.UUID) *model.MaintenanceRecord {
+	t.Helper()
+	now := time.Now()
+	notes := "Replaced hydraulic filters"
+	record := &model.MaintenanceRecord{
+		ID:                uuid.New(),
+		OrganizationID:    organizationID,
+		EquipmentID:       equipmentID,
+		MaintenanceTypeID: 1,
+		StatusID:          model.MaintenanceStatusApproved,
+		TechnicianID:      technicianID,
+		SupervisorID:      &supervisorID,
+		Notes:             &notes,
+		SubmittedAt:       &now,
+		ApprovedAt:        &now,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := db.Create(record).Error; err != nil {
+		t.Fatalf("create maintenance record: %v", err)
+	}
+	return record
+}
+
+func TestConfirmRecordRollsScheduleForward(t *testing.T) {
+	db := testdb.Open(t)
+	organization := testdb.CreateOrganization(t, db)
+	technician := testdb.CreateUser(t, db, organization.ID, model.RoleTechnician)
+	supervisor := testdb.CreateUser(t, db, organization.ID, model.RoleSupervisor)
+	equipment := testdb.CreateEquipment(t, db, organization.ID)
+	ctx := context.Background()
+
+	bus := events.NewBus()
+	var published []string
+	bus.Subscribe(func(_ context.Context, _ *gorm.DB, event events.Event) error {
+		published = append(published, event.Type)
+		return nil
+	})
+	s := newTestMaintenanceService(db, bus)
+
+	// An overdue 30-day schedule whose alerts have all fired
+	frequency := 30
+	lastDone := today().AddDate(0, 0, -40)
+	due := today().AddDate(0, 0, -10)
+	trigger := string(scheduling.TriggerInterval)
+	alerted := time.Now().Add(-time.Hour)
+	schedule := &model.MaintenanceSchedule{
+		ID:                     uuid.New(),
+		EquipmentID:            equipment.ID,
+		OrganizationID:         organization.ID,
+		MaintenanceTypeID:      1,
+		ScheduledFrequencyDays: &frequency,
+		LastMaintenanceDate:    &lastDone,
+		NextDueDate:            &due,
+		NextDueTrigger:         &trigger,
+		OverdueAlertSentAt:     &alerted,
+		DueSoonAlertSentAt:     &alerted,
+		OverdueEscalatedAt:     &alerted,
+		CreatedAt:              time.Now(),
+		UpdatedAt:              time.Now(),
+	}
+	if err := db.Create(schedule).Error; err != nil {
+		t.Fatalf("create schedule: %v", err)
+	}
+
+	record := createApprovedRecord(t, db, organization.ID, equipment.ID, technician.ID, supervisor.ID)
+	if _, err := s.ConfirmRecord(ctx, organization.ID, record.ID, supervisor.ID, "not-a-signature"); err != ErrInvalidTransactionSignature {
+		t.Fatalf("malformed signature: %v, want ErrInvalidTransactionSignature", err)
+	}
+	if _, err := s.ConfirmRecord(ctx, uuid.New(), record.ID, supervisor.ID, testTransactionSignature); err != ErrMaintenanceNotFound {
+		t.Fatalf("record of another organization: %v, want ErrMaintenanceNotFound", err)
+	}
+
+	confirmed, err := s.ConfirmRecord(ctx, organization.ID, record.ID, supervisor.ID, testTransactionSignature)
+	if err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	if confirmed.StatusID != model.MaintenanceStatusConfirmed || confirmed.ConfirmedAt == nil ||
+		confirmed.SolanaSignature == nil || *confirmed.SolanaSignature != testTransactionSignature {
+		t.Fatalf("confirmed record: status %d, confirmed_at %v, signature %v", confirmed.StatusID, confirmed.ConfirmedAt, confirmed.SolanaSignature)
+	}
+	if confirmed.UpdatedBy == nil || *confirmed.UpdatedBy != supervisor.ID {
+		t.Fatalf("updated_by %v, want the confirming user %s", confirmed.UpdatedBy, supervisor.ID)
+	}
+	if len(published) != 1 || published[0] != events.MaintenanceConfirmed {
+		t.Fatalf("published %v, want %s", published, events.MaintenanceConfirmed)
+	}
+
+	var rolled model.MaintenanceSchedule
+	if err := db.Where("id = ?", schedule.ID).First(&rolled).Error; err != nil {
+		t.Fatalf("reload schedule: %v", err)
+	}
+	const day = "2006-01-02"
+	if rolled.LastMaintenanceDate == nil || rolled.LastMaintenanceDate.Format(day) != today().Format(day) {
+		t.Fatalf("last_maintenance_date %v, want today", rolled.LastMaintenanceDate)
+	}
+	if want := today().AddDate(0, 0, frequency).Format(day); rolled.NextDueDate == nil || rolled.NextDueDate.Format(day) != want {
+		t.Fatalf("next_due_date %v, want %s", rolled.NextDueDate, want)
+	}
+	if rolled.OverdueAlertSentAt != nil || rolled.DueSoonAlertSentAt != nil || rolled.OverdueEscalatedAt != nil {
+		t.Fatal("alert stamps were not cleared, so the next due date would not alert")
+	}
+
+	// A record is confirmed once
+	if _, err := s.ConfirmRecord(ctx, organization.ID, record.ID, supervisor.ID, testTransactionSignature); err != ErrInvalidMaintenanceStatus {
+		t.Fatalf("second confirmation: %v, want ErrInvalidMaintenanceStatus", err)
+	}
+}
+
+func TestConfirmRecordWithoutSchedule(t *testing.T) {
+	db := testdb.Open(t)
+	organization := testdb.CreateOrganization(t, db)
+	technician := testdb.CreateUser(t, db, organization.ID, model.RoleTechnician)
+	supervisor := testdb.CreateUser(t, db, organization.ID, model.RoleSupervisor)
+	equipment := testdb.CreateEquipment(t, db, organization.ID)
+	s := newTestMaintenanceService(db, events.NewBus())
+
+	record := createApprovedRecord(t, db, organization.ID, equipment.ID, technician.ID, supervisor.ID)
+	confirmed, err := s.ConfirmRecord(context.Background(), organization.ID, record.ID, supervisor.ID, testTransactionSignature)
+	if err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	if confirmed.StatusID != model.MaintenanceStatusConfirmed {
+		t.Fatalf("status %d, want confirmed", confirmed.StatusID)
+	}
+}
+
+func TestConfirmRecordRollsBackWhenPublishFails(t *testing.T) {
+	db := testdb.Open(t)
+	organization := testdb.CreateOrganization(t, db)
+	technician := testdb.CreateUser(t, db, organization.ID, model.RoleTechnician)
+	supervisor := testdb.CreateUser(t, db, organization.ID, model.RoleSupervisor)
+	equipment := testdb.CreateEquipment(t, db, organization.ID)
+
+	// A subscriber that cannot write its outbox row must not let the change commit without it
+	bus := events.NewBus()
+	bus.Subscribe(func(context.Context, *gorm.DB, events.Event) error {
+		return errors.New("outbox unavailable")
+	})
+	s := newTestMaintenanceService(db, bus)
+
+	record := createApprovedRecord(t, db, organization.ID, equipment.ID, technician.ID, supervisor.ID)
+	if _, err := s.ConfirmRecord(context.Background(), organization.ID, record.ID, supervisor.ID, testTransactionSignature); err == nil {
+		t.Fatal("confirm succeeded although its event was not recorded")
+	}
+
+	var reloaded model.MaintenanceRecord
+	if err := db.Where("id = ?", record.ID).First(&reloaded).Error; err != nil {
+		t.Fatalf("reload record: %v", err)
+	}
+	if reloaded.StatusID != model.MaintenanceStatusApproved || reloaded.SolanaSignature != nil {
+		t.Fatalf("record status %d, signature %v after a failed publish, want it still approved", reloaded.StatusID, reloaded.SolanaSignature)
+	}
+}
+
+func TestSigningTokenSignsOneDecision(t *testing.T) {
+	db := testdb.Open(t)
+	organization := testdb.CreateOrganization(t, db)
+	technician := testdb.CreateUser(t, db, organization.ID, model.RoleTechnician)
+	supervisor := testdb.CreateUser(t, db, organization.ID, model.RoleSupervisor)
+	equipment := testdb.CreateEquipment(t, db, organization.ID)
+	s := newTestMaintenanceService(db, events.NewBus())
+	ctx := context.Background()
+
+	signature := Signature{Factor: AuthFactorPassword, TokenID: uuid.New()}
+	first := createSubmittedRecord(t, db, organization.ID, equipment.ID, technician.ID)
+	if _, err := s.ApproveRecord(ctx, organization.ID, first.ID, supervisor.ID, nil, signature); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+
+	// Replaying the token, for an approval or a rejection, is refused and changes nothing
+	second := createSubmittedRecord(t, db, organization.ID, equipment.ID, technician.ID)
+	if _, err := s.ApproveRecord(ctx, organization.ID, second.ID, supervisor.ID, nil, signature); err != ErrSigningTokenUsed {
+		t.Fatalf("approve with a used token: %v, want ErrSigningTokenUsed", err)
+	}
+	reason := "Photos missing"
+	if _, err := s.RejectRecord(ctx, organization.ID, second.ID, supervisor.ID, &reason, signature); err != ErrSigningTokenUsed {
+		t.Fatalf("reject with a used token: %v, want ErrSigningTokenUsed", err)
+	}
+	var reloaded model.MaintenanceRecord
+	if err := db.Where("id = ?", second.ID).First(&reloaded).Error; err != nil {
+		t.Fatalf("reload record: %v", err)
+	}
+	if reloaded.StatusID != model.MaintenanceStatusSubmitted {
+		t.Fatalf("status %d after a replayed token, want it still submitted", reloaded.StatusID)
+	}
+
+	signature.TokenID = uuid.New()
+	if _, err := s.RejectRecord(ctx, organization.ID, second.ID, supervisor.ID, &reason, signature); err != nil {
+		t.Fatalf("reject with a fresh token: %v", err)
+	}
+}
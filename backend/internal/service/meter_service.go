@@ -0,0 +1,339 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/NWhite12/EquipChain/internal/scheduling"
+	"github.com/google/uuid"
+)
+
+const (
+	maxMeterReadingBatch     = 500
+	defaultMeterReadingLimit = 100
+	maxMeterReadingLimit     = 1000
+
+	// meterClockSkew tolerates device clocks running slightly ahead of the server.
+	meterClockSkew = 5 * time.Minute
+)
+
+// MeterReadingInput is one meter reading. read_at is RFC 3339 and defaults to now; reset
+// marks a rolled over or replaced meter that restarts at value. equipment_id is only read
+// by batch submissions.
+type MeterReadingInput struct {
+	EquipmentID uuid.UUID `json:"equipment_id"`
+	MeterType   string    `json:"meter_type"`
+	Value       *float64  `json:"value"`
+	ReadAt      *string   `json:"read_at"`
+	Reset       bool      `json:"reset"`
+	Notes       *string   `json:"notes"`
+}
+
+type MeterReadingView struct {
+	ID                  uuid.UUID  `json:"id"`
+	EquipmentID         uuid.UUID  `json:"equipment_id"`
+	MeterType           string     `json:"meter_type"`
+	Value               float64    `json:"value"`
+	ReadAt              time.Time  `json:"read_at"`
+	IsReset             bool       `json:"is_reset"`
+	Source              string     `json:"source"`
+	MaintenanceRecordID *uuid.UUID `json:"maintenance_record_id"`
+	Notes               *string    `json:"notes"`
+	RecordedBy          *uuid.UUID `json:"recorded_by"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+// MeterSummary is the latest reading of an equipment meter with its average usage per day
+// over the last 90 days of readings (nil until the readings span a day).
+type MeterSummary struct {
+	MeterType   string    `json:"meter_type"`
+	Value       float64   `json:"value"`
+	ReadAt      time.Time `json:"read_at"`
+	UsagePerDay *float64  `json:"usage_per_day"`
+}
+
+// MeterService records equipment meter readings (equipment_meter_readings) and feeds them
+// into the forecasts of meter-based maintenance schedules.
+type MeterService struct {
+	meterRepo     *repository.MeterReadingRepository
+	equipmentRepo *repository.EquipmentRepository
+	scheduleRepo  *repository.ScheduleRepository
+}
+
+func NewMeterService(meterRepo *repository.MeterReadingRepository, equipmentRepo *repository.EquipmentRepository, scheduleRepo *repository.ScheduleRepository) *MeterService {
+	return &MeterService{
+		meterRepo:     meterRepo,
+		equipmentRepo: equipmentRepo,
+		scheduleRepo:  scheduleRepo,
+	}
+}
+
+// ListReadings returns the equipment's readings, newest first, optionally of one meter type.
+func (s *MeterService) ListReadings(ctx context.Context, organizationID, equipmentID uuid.UUID, meterType string, limit int) ([]MeterReadingView, error) {
+	if meterType != "" && !meterTypes[meterType] {
+		return nil, fmt.Errorf("%w: meter_type must be hours, miles or cycles", ErrInvalidMeterReading)
+	}
+	if limit == 0 {
+		limit = defaultMeterReadingLimit
+	}
+	if limit < 1 || limit > maxMeterReadingLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidMeterReading, maxMeterReadingLimit)
+	}
+	if err := s.checkEquipment(ctx, organizationID, equipmentID); err != nil {
+		return nil, err
+	}
+
+	readings, err := s.meterRepo.FindByEquipment(ctx, equipmentID, meterType, limit)
+	if err != nil {
+		return nil, err
+	}
+	return newMeterReadingViews(readings), nil
+}
+
+// RecordReadings stores readings of the organization's equipment, all or none, and
+// refreshes the forecasts of the affected meter-based schedules.
+func (s *MeterService) RecordReadings(ctx context.Context, organizationID, actorID uuid.UUID, inputs []MeterReadingInput) ([]MeterReadingView, error) {
+	if len(inputs) == 0 || len(inputs) > maxMeterReadingBatch {
+		return nil, fmt.Errorf("%w: submit between 1 and %d readings", ErrInvalidMeterReading, maxMeterReadingBatch)
+	}
+
+	checked := make(map[uuid.UUID]bool)
+	readings := make([]*model.MeterReading, 0, len(inputs))
+	for i, input := range inputs {
+		if !checked[input.EquipmentID] {
+			if err := s.checkEquipment(ctx, organizationID, input.EquipmentID); err != nil {
+				return nil, err
+			}
+			checked[input.EquipmentID] = true
+		}
+
+		reading, err := newMeterReading(organizationID, actorID, input, model.MeterReadingSourceManual, nil)
+		if err != nil {
+			if len(inputs) > 1 {
+				return nil, fmt.Errorf("%w (reading %d)", err, i+1)
+			}
+			return nil, err
+		}
+		readings = append(readings, reading)
+	}
+
+	if err := s.meterRepo.Create(ctx, readings); err != nil {
+		return nil, mapMeterConflict(err)
+	}
+	s.refreshForecasts(ctx, actorID, readings)
+	return newMeterReadingViews(readings), nil
+}
+
+// Summaries returns the latest reading and usage rate of every meter of the equipment.
+func (s *MeterService) Summaries(ctx context.Context, equipmentIDs []uuid.UUID) (map[uuid.UUID][]MeterSummary, error) {
+	readings, err := s.meterRepo.FindUsageWindow(ctx, equipmentIDs, "", scheduling.UsageWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make(map[uuid.UUID][]MeterSummary)
+	for start := 0; start < len(readings); {
+		end := start + 1
+		for end < len(readings) && readings[end].EquipmentID == readings[start].EquipmentID && readings[end].MeterType == readings[start].MeterType {
+			end++
+		}
+
+		state := scheduling.Meter(schedulingReadings(readings[start:end]))
+		summary := MeterSummary{MeterType: readings[start].MeterType, Value: state.Value, ReadAt: state.ReadAt}
+		if state.RatePerDay > 0 {
+			rate := math.Round(state.RatePerDay*100) / 100
+			summary.UsagePerDay = &rate
+		}
+		summaries[readings[start].EquipmentID] = append(summaries[readings[start].EquipmentID], summary)
+		start = end
+	}
+	return summaries, nil
+}
+
+// State returns the equipment meter's latest value and usage rate for schedule
+// forecasting, or nil without readings.
+func (s *MeterService) State(ctx context.Context, equipmentID uuid.UUID, meterType string) (*scheduling.MeterState, error) {
+	readings, err := s.meterRepo.FindUsageWindow(ctx, []uuid.UUID{equipmentID}, meterType, scheduling.UsageWindow)
+	if err != nil {
+		return nil, err
+	}
+	return scheduling.Meter(schedulingReadings(readings)), nil
+}
+
+// RefreshForecasts recomputes the next due date of the equipment's schedules with a
+// trigger on the meter type. Manually set due dates are kept.
+func (s *MeterService) RefreshForecasts(ctx context.Context, actorID, equipmentID uuid.UUID, meterType string) error {
+	schedules, err := s.scheduleRepo.FindByEquipmentAndMeter(ctx, equipmentID, meterType)
+	if err != nil || len(schedules) == 0 {
+		return err
+	}
+	state, err := s.State(ctx, equipmentID, meterType)
+	if err != nil {
+		return err
+	}
+
+	for _, schedule := range schedules {
+		if schedule.NextDueDate != nil && schedule.NextDueTrigger == nil {
+			continue
+		}
+		due, err := nextScheduleDue(schedule, state)
+		if err != nil {
+			return err
+		}
+		previous := schedule.NextDueDate
+		applyScheduleDue(schedule, due)
+
+		updates := map[string]interface{}{
+			"next_due_date":        schedule.NextDueDate,
+			"next_due_meter_value": schedule.NextDueMeterValue,
+			"next_due_trigger":     schedule.NextDueTrigger,
+		}
+		// Lower usage pushing the due date back re-arms the due soon and overdue alerts
+		if previous != nil && schedule.NextDueDate != nil && schedule.NextDueDate.After(*previous) {
+			updates["overdue_alert_sent_at"] = nil
+			updates["due_soon_alert_sent_at"] = nil
+			updates["overdue_escalated_at"] = nil
+		}
+		if err := s.scheduleRepo.Update(ctx, schedule.ID, updates, actorID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newRecordReadings validates readings taken on-site with a maintenance record of the
+// equipment.
+func (s *MeterService) newRecordReadings(organizationID, actorID uuid.UUID, record *model.MaintenanceRecord, inputs []MeterReadingInput) ([]*model.MeterReading, error) {
+	if len(inputs) > len(meterTypes) {
+		return nil, fmt.Errorf("%w: submit at most one reading per meter type", ErrInvalidMeterReading)
+	}
+
+	readings := make([]*model.MeterReading, 0, len(inputs))
+	for _, input := range inputs {
+		input.EquipmentID = record.EquipmentID
+		reading, err := newMeterReading(organizationID, actorID, input, model.MeterReadingSourceMaintenance, &record.ID)
+		if err != nil {
+			return nil, err
+		}
+		readings = append(readings, reading)
+	}
+	return readings, nil
+}
+
+// refreshForecasts refreshes the schedules of every meter read. Readings are already
+// stored, so failures are logged rather than returned.
+func (s *MeterService) refreshForecasts(ctx context.Context, actorID uuid.UUID, readings []*model.MeterReading) {
+	type meter struct {
+		equipmentID uuid.UUID
+		meterType   string
+	}
+	refreshed := make(map[meter]bool)
+	for _, reading := range readings {
+		key := meter{reading.EquipmentID, reading.MeterType}
+		if refreshed[key] {
+			continue
+		}
+		refreshed[key] = true
+		if err := s.RefreshForecasts(ctx, actorID, reading.EquipmentID, reading.MeterType); err != nil {
+			log.Printf("failed to refresh %s schedules of equipment %s: %v", reading.MeterType, reading.EquipmentID, err)
+		}
+	}
+}
+
+func (s *MeterService) checkEquipment(ctx context.Context, organizationID, equipmentID uuid.UUID) error {
+	equipment, err := s.equipmentRepo.FindByID(ctx, equipmentID)
+	if err != nil {
+		return err
+	}
+	if equipment == nil || equipment.OrganizationID != organizationID {
+		return ErrEquipmentNotFound
+	}
+	return nil
+}
+
+func newMeterReading(organizationID, actorID uuid.UUID, input MeterReadingInput, source string, recordID *uuid.UUID) (*model.MeterReading, error) {
+	if !meterTypes[input.MeterType] {
+		return nil, fmt.Errorf("%w: meter_type must be hours, miles or cycles", ErrInvalidMeterReading)
+	}
+	if input.Value == nil || *input.Value < 0 || *input.Value > maxMeterValue {
+		return nil, fmt.Errorf("%w: value must be a non-negative number", ErrInvalidMeterReading)
+	}
+
+	now := time.Now()
+	readAt := now
+	if input.ReadAt != nil {
+		parsed, err := time.Parse(time.RFC3339, *input.ReadAt)
+		if err != nil {
+			return nil, fmt.Errorf("%w: read_at must be an RFC 3339 timestamp", ErrInvalidMeterReading)
+		}
+		if parsed.After(now.Add(meterClockSkew)) {
+			return nil, fmt.Errorf("%w: read_at is in the future", ErrInvalidMeterReading)
+		}
+		readAt = parsed
+	}
+
+	var notes *string
+	if input.Notes != nil {
+		notes = optionalText(*input.Notes)
+	}
+
+	// Values are stored with one decimal; round first so monotonicity checks agree
+	value := math.Round(*input.Value*10) / 10
+	return &model.MeterReading{
+		ID:                  uuid.New(),
+		OrganizationID:      organizationID,
+		EquipmentID:         input.EquipmentID,
+		MeterType:           input.MeterType,
+		Value:               value,
+		ReadAt:              readAt.UTC(),
+		IsReset:             input.Reset,
+		Source:              source,
+		MaintenanceRecordID: recordID,
+		Notes:               notes,
+		RecordedBy:          &actorID,
+		CreatedAt:           now,
+	}, nil
+}
+
+// mapMeterConflict turns the repository's monotonicity errors into ErrMeterReadingConflict,
+// keeping their detail.
+func mapMeterConflict(err error) error {
+	if errors.Is(err, repository.ErrMeterReadingOutOfOrder) || errors.Is(err, repository.ErrMeterResetNotLatest) {
+		return fmt.Errorf("%w: %v", ErrMeterReadingConflict, err)
+	}
+	return err
+}
+
+func schedulingReadings(readings []*model.MeterReading) []scheduling.Reading {
+	converted := make([]scheduling.Reading, len(readings))
+	for i, reading := range readings {
+		converted[i] = scheduling.Reading{Value: reading.Value, ReadAt: reading.ReadAt, Reset: reading.IsReset}
+	}
+	return converted
+}
+
+func newMeterReadingViews(readings []*model.MeterReading) []MeterReadingView {
+	views := make([]MeterReadingView, 0, len(readings))
+	for _, reading := range readings {
+		views = append(views, MeterReadingView{
+			ID:                  reading.ID,
+			EquipmentID:         reading.EquipmentID,
+			MeterType:           reading.MeterType,
+			Value:               reading.Value,
+			ReadAt:              reading.ReadAt,
+			IsReset:             reading.IsReset,
+			Source:              reading.Source,
+			MaintenanceRecordID: reading.MaintenanceRecordID,
+			Notes:               reading.Notes,
+			RecordedBy:          reading.RecordedBy,
+			CreatedAt:           reading.CreatedAt,
+		})
+	}
+	return views
+}
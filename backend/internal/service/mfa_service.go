@@ -0,0 +1,386 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/envelope"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const recoveryCodeCount = 10
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type MFAPolicy struct {
+	RequireForApprovers bool `json:"require_for_approvers"`
+}
+
+type MFAStatus struct {
+	Enabled                bool  `json:"enabled"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+// TOTPEnrollment is returned once when enrollment starts. The secret is shown for manual
+// entry; ProvisioningURI is rendered as a QR code by the client.
+type TOTPEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type MFAService struct {
+	mfaRepo           *repository.MFARepository
+	userRepo          *repository.UserRepository
+	settingsRepo      *repository.SecuritySettingsRepository
+	permissionService *PermissionService
+	auditService      *AuditService
+	lockout           *LockoutService
+	cipher            *envelope.Cipher
+	issuer            string
+}
+
+func NewMFAService(mfaRepo *repository.MFARepository, userRepo *repository.UserRepository, settingsRepo *repository.SecuritySettingsRepository, permissionService *PermissionService, auditService *AuditService, lockout *LockoutService, cipher *envelope.Cipher, issuer string) *MFAService {
+	return &MFAService{
+		mfaRepo:           mfaRepo,
+		userRepo:          userRepo,
+		settingsRepo:      settingsRepo,
+		permissionService: permissionService,
+		auditService:      auditService,
+		lockout:           lockout,
+		cipher:            cipher,
+		issuer:            issuer,
+	}
+}
+
+func (s *MFAService) GetPolicy(ctx context.Context, organizationID uuid.UUID) (MFAPolicy, error) {
+	settings, err := s.settingsRepo.FindByOrganizationID(ctx, organizationID)
+	if err != nil {
+		return MFAPolicy{}, err
+	}
+	if settings == nil {
+		return MFAPolicy{}, nil
+	}
+
+	return MFAPolicy{RequireForApprovers: settings.RequireMFAForApprovers}, nil
+}
+
+func (s *MFAService) UpdatePolicy(ctx context.Context, organizationID uuid.UUID, policy MFAPolicy, updatedBy uuid.UUID) (MFAPolicy, error) {
+	settings := newSecuritySettings(organizationID, updatedBy)
+	settings.RequireMFAForApprovers = policy.RequireForApprovers
+
+	if err := s.settingsRepo.Upsert(ctx, settings, []string{"require_mfa_for_approvers"}); err != nil {
+		return MFAPolicy{}, err
+	}
+
+	return policy, nil
+}
+
+// IsRequired reports whether the user's organization requires MFA for the user's role,
+// i.e. the organization requires it for approvers and the role grants approve:maintenance.
+func (s *MFAService) IsRequired(ctx context.Context, user *model.User) (bool, error) {
+	policy, err := s.GetPolicy(ctx, user.OrganizationID)
+	if err != nil {
+		return false, err
+	}
+	if !policy.RequireForApprovers {
+		return false, nil
+	}
+
+	return s.permissionService.RoleHasPermission(ctx, user.RoleID, model.PermissionApproveMaintenance)
+}
+
+func (s *MFAService) IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	totp, err := s.mfaRepo.FindTOTP(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return totp != nil && totp.Confirmed(), nil
+}
+
+func (s *MFAService) Status(ctx context.Context, userID uuid.UUID) (MFAStatus, error) {
+	enabled, err := s.IsEnabled(ctx, userID)
+	if err != nil || !enabled {
+		return MFAStatus{}, err
+	}
+
+	remaining, err := s.mfaRepo.CountUnusedRecoveryCodes(ctx, userID)
+	if err != nil {
+		return MFAStatus{}, err
+	}
+
+	return MFAStatus{Enabled: true, RecoveryCodesRemaining: remaining}, nil
+}
+
+// BeginEnrollment generates a new TOTP secret for the user. MFA stays disabled until the
+// user confirms a code from the authenticator with ConfirmEnrollment.
+func (s *MFAService) BeginEnrollment(ctx context.Context, userID uuid.UUID) (*TOTPEnrollment, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, err := s.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.cipher.Encrypt(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.SavePendingTOTP(ctx, user.ID, encrypted); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		Secret:          totpEncoding.EncodeToString(secret),
+		ProvisioningURI: totpProvisioningURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmEnrollment activates MFA once the user proves possession of the authenticator and
+// returns the plaintext recovery codes. They are only ever shown here.
+func (s *MFAService) ConfirmEnrollment(ctx context.Context, userID uuid.UUID, code string, meta RequestMetadata) ([]string, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	totp, err := s.mfaRepo.FindTOTP(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if totp == nil {
+		return nil, ErrMFANotEnrolled
+	}
+	if totp.Confirmed() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	step, err := s.checkTOTP(totp, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.ConfirmTOTP(ctx, user.ID, step, hashes); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMFAAlreadyEnabled
+		}
+		return nil, err
+	}
+
+	s.audit(ctx, user, "mfa_enabled", meta)
+	return codes, nil
+}
+
+// Verify checks a TOTP code or, failing that, redeems a recovery code.
+func (s *MFAService) Verify(ctx context.Context, userID uuid.UUID, code string) error {
+	totp, err := s.mfaRepo.FindTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if totp == nil || !totp.Confirmed() {
+		return ErrMFANotEnrolled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totpDigits {
+		return s.verifyTOTP(ctx, totp, code)
+	}
+
+	used, err := s.mfaRepo.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// VerifyTOTP accepts only an authenticator code, not a recovery code. Used where the
+// factor is recorded, such as step-up signing.
+func (s *MFAService) VerifyTOTP(ctx context.Context, userID uuid.UUID, code string) error {
+	totp, err := s.mfaRepo.FindTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if totp == nil || !totp.Confirmed() {
+		return ErrMFANotEnrolled
+	}
+	return s.verifyTOTP(ctx, totp, strings.TrimSpace(code))
+}
+
+// RegenerateRecoveryCodes invalidates all existing recovery codes. A current TOTP code is
+// required so a stolen session alone cannot mint new codes; wrong codes count towards
+// account lockout.
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string, meta RequestMetadata) ([]string, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if s.lockout.IsLocked(user) {
+		return nil, ErrAccountLocked
+	}
+	if err := s.VerifyTOTP(ctx, user.ID, code); err != nil {
+		return nil, s.registerFailure(ctx, user, meta, err)
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable removes the user's authenticator. It is refused while the organization requires
+// MFA for the user's role. Wrong codes count towards account lockout.
+func (s *MFAService) Disable(ctx context.Context, userID uuid.UUID, code string, meta RequestMetadata) error {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	required, err := s.IsRequired(ctx, user)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrMFARequired
+	}
+	if s.lockout.IsLocked(user) {
+		return ErrAccountLocked
+	}
+
+	if err := s.Verify(ctx, user.ID, code); err != nil {
+		return s.registerFailure(ctx, user, meta, err)
+	}
+	if err := s.mfaRepo.DeleteAll(ctx, user.ID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, user, "mfa_disabled", meta)
+	return nil
+}
+
+func (s *MFAService) findUser(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// registerFailure counts a wrong code, like a failed login, and returns ErrAccountLocked
+// if this attempt locked the account. Other errors are returned as they are.
+func (s *MFAService) registerFailure(ctx context.Context, user *model.User, meta RequestMetadata, failure error) error {
+	if failure != ErrInvalidMFACode {
+		return failure
+	}
+	locked, err := s.lockout.RegisterFailure(ctx, user, meta)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return ErrAccountLocked
+	}
+	return failure
+}
+
+func (s *MFAService) verifyTOTP(ctx context.Context, totp *model.UserTOTP, code string) error {
+	step, err := s.checkTOTP(totp, code)
+	if err != nil {
+		return err
+	}
+	if step <= totp.LastUsedStep {
+		return ErrInvalidMFACode
+	}
+
+	consumed, err := s.mfaRepo.ConsumeStep(ctx, totp.UserID, step)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *MFAService) checkTOTP(totp *model.UserTOTP, code string) (int64, error) {
+	secret, err := s.cipher.Decrypt(totp.SecretEncrypted)
+	if err != nil {
+		return 0, err
+	}
+
+	step, ok := validateTOTP(secret, code, time.Now())
+	if !ok {
+		return 0, ErrInvalidMFACode
+	}
+	return step, nil
+}
+
+func (s *MFAService) audit(ctx context.Context, user *model.User, event string, meta RequestMetadata) {
+	if err := s.auditService.Record(ctx, AuditEntry{
+		OrganizationID: user.OrganizationID,
+		ActorID:        &user.ID,
+		EntityType:     "user",
+		EntityID:       user.ID,
+		Action:         AuditActionUpdate,
+		After:          map[string]interface{}{"event": event},
+		Metadata:       meta,
+	}); err != nil {
+		log.Printf("failed to audit %s for user %s: %v", event, user.ID, err)
+	}
+}
+
+// generateRecoveryCodes returns codes formatted as "xxxxx-xxxxx" together with their hashes.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+
+		encoded := strings.ToLower(recoveryCodeEncoding.EncodeToString(raw))[:10]
+		codes = append(codes, encoded[:5]+"-"+encoded[5:])
+		hashes = append(hashes, hashRecoveryCode(encoded))
+	}
+
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
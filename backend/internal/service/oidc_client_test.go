@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/NWhite12/EquipChain/internal/mockoidc"
+)
+
+func startMockOIDC(t *testing.T) (*mockoidc.Provider, *httptest.Server) {
+	t.Helper()
+	provider, err := mockoidc.New("", "equipchain", "equipchain-secret")
+	if err != nil {
+		t.Fatalf("mock provider: %v", err)
+	}
+	server := httptest.NewUnstartedServer(provider.Handler())
+	provider.Issuer = "http://" + server.Listener.Addr().String()
+	server.Start()
+	t.Cleanup(server.Close)
+	return provider, server
+}
+
+// mockAuthorize runs the authorization request with an S256 challenge for verifier and
+// returns the code.
+func mockAuthorize(t *testing.T, discovery *oidcDiscovery, clientID, nonce, verifier, email string) string {
+	t.Helper()
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {clientID},
+		"redirect_uri":          {testOIDCRedirectURL},
+		"scope":                 {"openid email"},
+		"state":                 {"state-1"},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+		"login_hint":            {email},
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(discovery.AuthorizationEndpoint + "?" + query.Encode())
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: status %d, location %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if location.Query().Get("state") != "state-1" {
+		t.Fatalf("state %q not returned", location.Query().Get("state"))
+	}
+	return location.Query().Get("code")
+}
+
+func TestOIDCClientCodeFlowWithPKCE(t *testing.T) {
+	provider, server := startMockOIDC(t)
+	provider.SetRoles("supervisors")
+	client := NewOIDCClient(server.Client())
+	ctx := context.Background()
+
+	discovery, err := client.Discover(ctx, provider.Issuer)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	if _, err := client.Discover(ctx, provider.Issuer+"/other"); err == nil {
+		t.Fatal("discovery accepted a document for another issuer")
+	}
+
+	code := mockAuthorize(t, discovery, provider.ClientID, "nonce-1", "verifier-1", "tech@example.com")
+	rawIDToken, err := client.ExchangeCode(ctx, discovery, provider.ClientID, provider.ClientSecret, code, testOIDCRedirectURL, "verifier-1")
+	if err != nil {
+		t.Fatalf("exchange code: %v", err)
+	}
+	claims, err := client.VerifyIDToken(ctx, discovery, rawIDToken, provider.ClientID, "nonce-1")
+	if err != nil {
+		t.Fatalf("verify id token: %v", err)
+	}
+	if email, _ := claims["email"].(string); email != "tech@example.com" {
+		t.Fatalf("email claim %q", email)
+	}
+	if groups, _ := claims["groups"].([]interface{}); len(groups) != 1 || groups[0] != "supervisors" {
+		t.Fatalf("groups claim %v", claims["groups"])
+	}
+
+	// Codes are single use
+	if _, err := client.ExchangeCode(ctx, discovery, provider.ClientID, provider.ClientSecret, code, testOIDCRedirectURL, "verifier-1"); err == nil {
+		t.Fatal("a code was redeemed twice")
+	}
+
+	// The token endpoint checks the PKCE verifier, the redirect URI and the client secret
+	for name, exchange := range map[string]func(code string) error{
+		"wrong verifier": func(code string) error {
+			_, err := client.ExchangeCode(ctx, discovery, provider.ClientID, provider.ClientSecret, code, testOIDCRedirectURL, "verifier-2")
+			return err
+		},
+		"wrong redirect uri": func(code string) error {
+			_, err := client.ExchangeCode(ctx, discovery, provider.ClientID, provider.ClientSecret, code, "http://evil.test/callback", "verifier-1")
+			return err
+		},
+		"wrong client secret": func(code string) error {
+			_, err := client.ExchangeCode(ctx, discovery, provider.ClientID, "guess", code, testOIDCRedirectURL, "verifier-1")
+			return err
+		},
+	} {
+		code := mockAuthorize(t, discovery, provider.ClientID, "nonce-1", "verifier-1", "tech@example.com")
+		if err := exchange(code); err == nil {
+			t.Fatalf("%s: code exchanged", name)
+		}
+	}
+}
+
+func TestOIDCClientVerifiesNonceAndAudience(t *testing.T) {
+	provider, server := startMockOIDC(t)
+	client := NewOIDCClient(server.Client())
+	ctx := context.Background()
+
+	discovery, err := client.Discover(ctx, provider.Issuer)
+	if err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+	code := mockAuthorize(t, discovery, provider.ClientID, "nonce-1", "verifier-1", "tech@example.com")
+	rawIDToken, err := client.ExchangeCode(ctx, discovery, provider.ClientID, provider.ClientSecret, code, testOIDCRedirectURL, "verifier-1")
+	if err != nil {
+		t.Fatalf("exchange code: %v", err)
+	}
+
+	if _, err := client.VerifyIDToken(ctx, discovery, rawIDToken, provider.ClientID, "nonce-2"); err == nil || !strings.Contains(err.Error(), "nonce") {
+		t.Fatalf("nonce of another login: %v, want a nonce mismatch", err)
+	}
+	if _, err := client.VerifyIDToken(ctx, discovery, rawIDToken, provider.ClientID, ""); err == nil {
+		t.Fatal("token accepted without an expected nonce")
+	}
+	if _, err := client.VerifyIDToken(ctx, discovery, rawIDToken, "another-client", "nonce-1"); err == nil {
+		t.Fatal("token accepted for another audience")
+	}
+	tampered := rawIDToken[:len(rawIDToken)-4] + "AAAA"
+	if _, err := client.VerifyIDToken(ctx, discovery, tampered, provider.ClientID, "nonce-1"); err == nil {
+		t.Fatal("token with a broken signature accepted")
+	}
+
+	// A replayed token carries the nonce of the login it was issued for
+	provider.OverrideNonce("nonce-1")
+	code = mockAuthorize(t, discovery, provider.ClientID, "nonce-3", "verifier-3", "tech@example.com")
+	rawIDToken, err = client.ExchangeCode(ctx, discovery, provider.ClientID, provider.ClientSecret, code, testOIDCRedirectURL, "verifier-3")
+	if err != nil {
+		t.Fatalf("exchange code: %v", err)
+	}
+	if _, err := client.VerifyIDToken(ctx, discovery, rawIDToken, provider.ClientID, "nonce-3"); err == nil {
+		t.Fatal("token with a replayed nonce accepted")
+	}
+}
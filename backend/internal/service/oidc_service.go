@@ -0,0 +1,531 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/config"
+	"github.com/NWhite12/EquipChain/internal/envelope"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const oidcLoginStateTTL = 10 * time.Minute
+
+// OIDCProviderConfig is the API view of an organization's identity provider. The client
+// secret is write-only.
+type OIDCProviderConfig struct {
+	IssuerURL           string           `json:"issuer_url"`
+	ClientID            string           `json:"client_id"`
+	ClientSecret        string           `json:"client_secret,omitempty"`
+	Scopes              string           `json:"scopes"`
+	AllowedEmailDomains []string         `json:"allowed_email_domains"`
+	DefaultRoleID       int16            `json:"default_role_id"`
+	RoleClaim           *string          `json:"role_claim,omitempty"`
+	RoleMapping         map[string]int16 `json:"role_mapping"`
+	Enabled             bool             `json:"enabled"`
+}
+
+type OIDCService struct {
+	oidcRepo     *repository.OIDCRepository
+	orgRepo      *repository.OrganizationRepository
+	userRepo     *repository.UserRepository
+	roleRepo     *repository.RoleRepository
+	jwtService   *JWTService
+	lockout      *LockoutService
+	auditService *AuditService
+	cipher       *envelope.Cipher
+	client       *OIDCClient
+
+	redirectURL string
+	allowHTTP   bool
+}
+
+func NewOIDCService(oidcRepo *repository.OIDCRepository, orgRepo *repository.OrganizationRepository, userRepo *repository.UserRepository, roleRepo *repository.RoleRepository,
+	jwtService *JWTService, lockout *LockoutService, auditService *AuditService, cipher *envelope.Cipher, client *OIDCClient, cfg *config.Config) *OIDCService {
+	return &OIDCService{
+		oidcRepo:     oidcRepo,
+		orgRepo:      orgRepo,
+		userRepo:     userRepo,
+		roleRepo:     roleRepo,
+		jwtService:   jwtService,
+		lockout:      lockout,
+		auditService: auditService,
+		cipher:       cipher,
+		client:       client,
+		redirectURL:  cfg.OIDCRedirectURL,
+		// Plain-http issuers (such as a local mock provider) are only accepted outside production
+		allowHTTP: !cfg.IsProduction(),
+	}
+}
+
+func (s *OIDCService) GetProvider(ctx context.Context, organizationID uuid.UUID) (*OIDCProviderConfig, error) {
+	provider, err := s.oidcRepo.FindProvider(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		return nil, ErrOIDCNotConfigured
+	}
+
+	domains, err := provider.DomainList()
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := provider.RoleMap()
+	if err != nil {
+		return nil, err
+	}
+
+	return &OIDCProviderConfig{
+		IssuerURL:           provider.IssuerURL,
+		ClientID:            provider.ClientID,
+		Scopes:              provider.Scopes,
+		AllowedEmailDomains: domains,
+		DefaultRoleID:       provider.DefaultRoleID,
+		RoleClaim:           provider.RoleClaim,
+		RoleMapping:         mapping,
+		Enabled:             provider.Enabled,
+	}, nil
+}
+
+// ConfigureProvider validates and stores the organization's identity provider. The issuer's
+// discovery document must be reachable. An empty client secret keeps the stored one.
+func (s *OIDCService) ConfigureProvider(ctx context.Context, organizationID uuid.UUID, input OIDCProviderConfig, updatedBy uuid.UUID) (*OIDCProviderConfig, error) {
+	issuer := strings.TrimSpace(input.IssuerURL)
+	parsed, err := url.Parse(issuer)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && !(s.allowHTTP && parsed.Scheme == "http")) {
+		return nil, fmt.Errorf("%w: issuer_url must be an absolute https URL", ErrInvalidOIDCProvider)
+	}
+	if strings.TrimSpace(input.ClientID) == "" {
+		return nil, fmt.Errorf("%w: client_id is required", ErrInvalidOIDCProvider)
+	}
+
+	domains := make([]string, 0, len(input.AllowedEmailDomains))
+	for _, domain := range input.AllowedEmailDomains {
+		domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+		if domain == "" || strings.ContainsAny(domain, "@ /") {
+			return nil, fmt.Errorf("%w: invalid email domain %q", ErrInvalidOIDCProvider, domain)
+		}
+		domains = append(domains, domain)
+	}
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("%w: at least one allowed email domain is required", ErrInvalidOIDCProvider)
+	}
+
+	if input.DefaultRoleID == 0 {
+		input.DefaultRoleID = model.RoleViewer
+	}
+	if err := s.validateRole(ctx, input.DefaultRoleID); err != nil {
+		return nil, err
+	}
+	if input.RoleMapping == nil {
+		input.RoleMapping = map[string]int16{}
+	}
+	for _, roleID := range input.RoleMapping {
+		if err := s.validateRole(ctx, roleID); err != nil {
+			return nil, err
+		}
+	}
+	if input.RoleClaim != nil && strings.TrimSpace(*input.RoleClaim) == "" {
+		input.RoleClaim = nil
+	}
+	if strings.TrimSpace(input.Scopes) == "" {
+		input.Scopes = "openid email profile"
+	}
+	if !strings.Contains(" "+input.Scopes+" ", " openid ") {
+		return nil, fmt.Errorf("%w: scopes must include openid", ErrInvalidOIDCProvider)
+	}
+
+	secretEncrypted := ""
+	if input.ClientSecret != "" {
+		if secretEncrypted, err = s.cipher.Encrypt([]byte(input.ClientSecret)); err != nil {
+			return nil, err
+		}
+	} else {
+		existing, err := s.oidcRepo.FindProvider(ctx, organizationID)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			return nil, fmt.Errorf("%w: client_secret is required", ErrInvalidOIDCProvider)
+		}
+		secretEncrypted = existing.ClientSecretEncrypted
+	}
+
+	if _, err := s.client.Discover(ctx, issuer); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOIDCProvider, err)
+	}
+
+	domainsJSON, err := json.Marshal(domains)
+	if err != nil {
+		return nil, err
+	}
+	mappingJSON, err := json.Marshal(input.RoleMapping)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := &model.OIDCProvider{
+		OrganizationID:        organizationID,
+		IssuerURL:             issuer,
+		ClientID:              strings.TrimSpace(input.ClientID),
+		ClientSecretEncrypted: secretEncrypted,
+		Scopes:                input.Scopes,
+		AllowedEmailDomains:   domainsJSON,
+		DefaultRoleID:         input.DefaultRoleID,
+		RoleClaim:             input.RoleClaim,
+		RoleMapping:           mappingJSON,
+		Enabled:               input.Enabled,
+		CreatedBy:             &updatedBy,
+		UpdatedBy:             &updatedBy,
+	}
+	if err := s.oidcRepo.UpsertProvider(ctx, provider); err != nil {
+		return nil, err
+	}
+
+	return s.GetProvider(ctx, organizationID)
+}
+
+func (s *OIDCService) DeleteProvider(ctx context.Context, organizationID uuid.UUID) error {
+	return s.oidcRepo.DeleteProvider(ctx, organizationID)
+}
+
+// BeginLogin creates a single-use state with nonce and PKCE verifier and returns the
+// identity provider's authorization URL to redirect the browser to.
+func (s *OIDCService) BeginLogin(ctx context.Context, organizationCode string) (string, error) {
+	organization, err := s.orgRepo.FindByCode(ctx, organizationCode)
+	if err != nil {
+		return "", err
+	}
+	if organization == nil || organization.Status == "deleted" {
+		return "", ErrOIDCNotConfigured
+	}
+	if organization.Status == "suspended" {
+		return "", ErrOrganizationSuspended
+	}
+
+	provider, err := s.oidcRepo.FindProvider(ctx, organization.ID)
+	if err != nil {
+		return "", err
+	}
+	if provider == nil || !provider.Enabled {
+		return "", ErrOIDCNotConfigured
+	}
+
+	discovery, err := s.client.Discover(ctx, provider.IssuerURL)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomURLToken(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomURLToken(32)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomURLToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.oidcRepo.CreateLoginState(ctx, &model.OIDCLoginState{
+		State:          state,
+		OrganizationID: organization.ID,
+		Nonce:          nonce,
+		CodeVerifier:   verifier,
+		ExpiresAt:      time.Now().Add(oidcLoginStateTTL),
+		CreatedAt:      time.Now(),
+	}); err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", provider.ClientID)
+	query.Set("redirect_uri", s.redirectURL)
+	query.Set("scope", provider.Scopes)
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// CompleteLogin handles the provider callback: it consumes the state, redeems the code,
+// verifies the ID token, enforces the email domain list and finds, links or provisions the
+// user. It returns the user and an EquipChain access token.
+func (s *OIDCService) CompleteLogin(ctx context.Context, state, code string, meta RequestMetadata) (*model.User, string, error) {
+	loginState, err := s.oidcRepo.ConsumeLoginState(ctx, state)
+	if err != nil {
+		return nil, "", err
+	}
+	if loginState == nil {
+		return nil, "", ErrOIDCInvalidState
+	}
+
+	provider, err := s.oidcRepo.FindProvider(ctx, loginState.OrganizationID)
+	if err != nil {
+		return nil, "", err
+	}
+	if provider == nil || !provider.Enabled {
+		return nil, "", ErrOIDCNotConfigured
+	}
+
+	discovery, err := s.client.Discover(ctx, provider.IssuerURL)
+	if err != nil {
+		return nil, "", err
+	}
+	clientSecret, err := s.cipher.Decrypt(provider.ClientSecretEncrypted)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rawIDToken, err := s.client.ExchangeCode(ctx, discovery, provider.ClientID, string(clientSecret), code, s.redirectURL, loginState.CodeVerifier)
+	if err != nil {
+		log.Printf("oidc login for organization %s failed: %v", provider.OrganizationID, err)
+		return nil, "", ErrOIDCLoginFailed
+	}
+	claims, err := s.client.VerifyIDToken(ctx, discovery, rawIDToken, provider.ClientID, loginState.Nonce)
+	if err != nil {
+		log.Printf("oidc login for organization %s failed: %v", provider.OrganizationID, err)
+		return nil, "", ErrOIDCLoginFailed
+	}
+
+	subject, _ := claims.GetSubject()
+	email, _ := claims["email"].(string)
+	email = strings.ToLower(strings.TrimSpace(email))
+	if subject == "" || email == "" {
+		return nil, "", ErrOIDCLoginFailed
+	}
+	if verified, present := claims["email_verified"].(bool); present && !verified {
+		return nil, "", ErrOIDCEmailNotAllowed
+	}
+	if allowed, err := emailDomainAllowed(provider, email); err != nil || !allowed {
+		if err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrOIDCEmailNotAllowed
+	}
+
+	user, err := s.resolveUser(ctx, provider, subject, email, claims, meta)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if s.lockout.IsLocked(user) {
+		return nil, "", ErrAccountLocked
+	}
+	if user.Status == "inactive" || user.Status == "deleted" {
+		return nil, "", ErrAccountDisabled
+	}
+	// The organization may have been suspended while the user was at the identity provider
+	organization, err := s.orgRepo.FindByID(ctx, user.OrganizationID)
+	if err != nil {
+		return nil, "", err
+	}
+	if organization == nil || organization.Status == "deleted" {
+		return nil, "", ErrOIDCNotConfigured
+	}
+	if organization.Status == "suspended" {
+		return nil, "", ErrOrganizationSuspended
+	}
+
+	// The identity provider is responsible for MFA of SSO users
+	if err := s.lockout.RecordSuccess(ctx, user, meta); err != nil {
+		return nil, "", err
+	}
+	token, err := s.jwtService.GenerateToken(user.ID, user.OrganizationID, user.Email, user.RoleID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return user, token, nil
+}
+
+// resolveUser returns the user linked to the identity, links an existing user with the
+// same email, or provisions a new user with the mapped role.
+func (s *OIDCService) resolveUser(ctx context.Context, provider *model.OIDCProvider, subject, email string, claims jwt.MapClaims, meta RequestMetadata) (*model.User, error) {
+	identity, err := s.oidcRepo.FindIdentity(ctx, provider.OrganizationID, provider.IssuerURL, subject)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		if err := s.oidcRepo.TouchIdentity(ctx, identity.ID, email); err != nil {
+			return nil, err
+		}
+		user, err := s.userRepo.FindByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, ErrOIDCLoginFailed
+		}
+		return user, nil
+	}
+
+	now := time.Now()
+	newIdentity := &model.UserIdentity{
+		ID:             uuid.New(),
+		OrganizationID: provider.OrganizationID,
+		Issuer:         provider.IssuerURL,
+		Subject:        subject,
+		Email:          &email,
+		LastLoginAt:    &now,
+		CreatedAt:      now,
+	}
+
+	existing, err := s.userRepo.FindByEmail(ctx, provider.OrganizationID, email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		newIdentity.UserID = existing.ID
+		if err := s.oidcRepo.CreateIdentity(ctx, newIdentity); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+
+	roleID, err := s.mapRole(ctx, provider, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// SSO users never log in with a password; store a random, unknowable hash
+	randomPassword, err := randomURLToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(randomPassword), bcryptCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		ID:                     uuid.New(),
+		OrganizationID:         provider.OrganizationID,
+		Email:                  email,
+		PasswordHash:           string(hashedPassword),
+		RoleID:                 roleID,
+		Status:                 "active",
+		EmailVerified:          true,
+		EmailVerifiedAt:        &now,
+		EmailVerificationToken: uuid.New().String(),
+		PasswordChangedAt:      now,
+		CreatedAt:              now,
+		UpdatedAt:              now,
+	}
+	newIdentity.UserID = user.ID
+	if err := s.oidcRepo.CreateUserWithIdentity(ctx, user, newIdentity); err != nil {
+		return nil, err
+	}
+
+	if err := s.auditService.Record(ctx, AuditEntry{
+		OrganizationID: provider.OrganizationID,
+		EntityType:     "user",
+		EntityID:       user.ID,
+		Action:         AuditActionCreate,
+		After: map[string]interface{}{
+			"event":   "sso_provisioned",
+			"email":   email,
+			"role_id": roleID,
+			"issuer":  provider.IssuerURL,
+		},
+		Metadata: meta,
+	}); err != nil {
+		log.Printf("failed to audit sso provisioning of user %s: %v", user.ID, err)
+	}
+
+	return user, nil
+}
+
+// mapRole picks the mapped role with the lowest permission_precedence among the values of
+// the configured role claim, falling back to the default role.
+func (s *OIDCService) mapRole(ctx context.Context, provider *model.OIDCProvider, claims jwt.MapClaims) (int16, error) {
+	if provider.RoleClaim == nil {
+		return provider.DefaultRoleID, nil
+	}
+	mapping, err := provider.RoleMap()
+	if err != nil {
+		return 0, err
+	}
+
+	var values []string
+	switch claim := claims[*provider.RoleClaim].(type) {
+	case string:
+		values = []string{claim}
+	case []interface{}:
+		for _, v := range claim {
+			if value, ok := v.(string); ok {
+				values = append(values, value)
+			}
+		}
+	}
+
+	roleID := provider.DefaultRoleID
+	var bestPrecedence int16 = -1
+	for _, value := range values {
+		mapped, ok := mapping[value]
+		if !ok {
+			continue
+		}
+		role, err := s.roleRepo.FindByID(ctx, mapped)
+		if err != nil {
+			return 0, err
+		}
+		if role != nil && (bestPrecedence == -1 || role.PermissionPrecedence < bestPrecedence) {
+			roleID = role.ID
+			bestPrecedence = role.PermissionPrecedence
+		}
+	}
+
+	return roleID, nil
+}
+
+func (s *OIDCService) validateRole(ctx context.Context, roleID int16) error {
+	role, err := s.roleRepo.FindByID(ctx, roleID)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return fmt.Errorf("%w: unknown role id %d", ErrInvalidOIDCProvider, roleID)
+	}
+	return nil
+}
+
+func emailDomainAllowed(provider *model.OIDCProvider, email string) (bool, error) {
+	domains, err := provider.DomainList()
+	if err != nil {
+		return false, err
+	}
+	return domainListed(domains, email), nil
+}
+
+func randomURLToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
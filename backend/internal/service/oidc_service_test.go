@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/NWhite12/EquipChain/internal/config"
+	"github.com/NWhite12/EquipChain/internal/envelope"
+	"github.com/NWhite12/EquipChain/internal/mockoidc"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/NWhite12/EquipChain/internal/testdb"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const testOIDCRedirectURL = "http://localhost:8080/api/auth/oidc/callback"
+
+type oidcTestEnv struct {
+	db           *gorm.DB
+	service      *OIDCService
+	provider     *mockoidc.Provider
+	organization *model.Organization
+}
+
+// newOIDCTestEnv starts a mock provider and configures it as the SSO provider of a new
+// organization: example.com addresses only, viewers by default and supervisors for the
+// "supervisors" group.
+func newOIDCTestEnv(t *testing.T) *oidcTestEnv {
+	t.Helper()
+	db := testdb.Open(t)
+
+	provider, err := mockoidc.New("", "equipchain", "equipchain-secret")
+	if err != nil {
+		t.Fatalf("mock provider: %v", err)
+	}
+	server := httptest.NewUnstartedServer(provider.Handler())
+	provider.Issuer = "http://" + server.Listener.Addr().String()
+	server.Start()
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{Environment: "test", JWTSecret: "oidc-test-secret", OIDCRedirectURL: testOIDCRedirectURL}
+	jwtService, err := NewJWTService(cfg)
+	if err != nil {
+		t.Fatalf("jwt service: %v", err)
+	}
+	cipher, err := envelope.New(map[int][]byte{1: make([]byte, envelope.KeySize)}, 1)
+	if err != nil {
+		t.Fatalf("cipher: %v", err)
+	}
+	userRepo := repository.NewUserRepository(db)
+	auditService := NewAuditService(repository.NewAuditRepository(db))
+	service := NewOIDCService(repository.NewOIDCRepository(db), repository.NewOrganizationRepository(db), userRepo, repository.NewRoleRepository(db),
+		jwtService, NewLockoutService(userRepo, auditService, testLockoutPolicy), auditService, cipher, NewOIDCClient(server.Client()), cfg)
+
+	organization := testdb.CreateOrganization(t, db)
+	admin := testdb.CreateUser(t, db, organization.ID, model.RoleAdmin)
+	groupsClaim := "groups"
+	_, err = service.ConfigureProvider(context.Background(), organization.ID, OIDCProviderConfig{
+		IssuerURL:           provider.Issuer,
+		ClientID:            provider.ClientID,
+		ClientSecret:        provider.ClientSecret,
+		AllowedEmailDomains: []string{"Example.com"},
+		DefaultRoleID:       model.RoleViewer,
+		RoleClaim:           &groupsClaim,
+		RoleMapping:         map[string]int16{"supervisors": model.RoleSupervisor},
+		Enabled:             true,
+	}, admin.ID)
+	if err != nil {
+		t.Fatalf("configure provider: %v", err)
+	}
+
+	return &oidcTestEnv{db: db, service: service, provider: provider, organization: organization}
+}
+
+// authorize starts a login and follows the authorization URL as the browser would,
+// returning the state and code the provider redirects back with.
+func (e *oidcTestEnv) authorize(t *testing.T, email string) (string, string) {
+	t.Helper()
+
+	authURL, err := e.service.BeginLogin(context.Background(), e.organization.Code)
+	if err != nil {
+		t.Fatalf("begin login: %v", err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("authorization url: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" || query.Get("nonce") == "" || query.Get("state") == "" {
+		t.Fatalf("authorization url lacks PKCE, nonce or state: %s", authURL)
+	}
+	if query.Get("redirect_uri") != testOIDCRedirectURL {
+		t.Fatalf("redirect_uri %q", query.Get("redirect_uri"))
+	}
+	query.Set("login_hint", email)
+	parsed.RawQuery = query.Encode()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(parsed.String())
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: status %d", resp.StatusCode)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("callback url: %v", err)
+	}
+	callback := location.Query()
+	if callback.Get("state") != query.Get("state") {
+		t.Fatalf("provider returned state %q, sent %q", callback.Get("state"), query.Get("state"))
+	}
+	return callback.Get("state"), callback.Get("code")
+}
+
+func (e *oidcTestEnv) usersWithEmail(t *testing.T, email string) []model.User {
+	t.Helper()
+	var users []model.User
+	if err := e.db.Where("organization_id = ? AND email = ?", e.organization.ID, email).Find(&users).Error; err != nil {
+		t.Fatalf("find users: %v", err)
+	}
+	return users
+}
+
+func TestOIDCLoginProvisionsUser(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	ctx := context.Background()
+	env.provider.SetRoles("staff", "supervisors")
+
+	state, code := env.authorize(t, "New.Hire@example.com")
+	user, token, err := env.service.CompleteLogin(ctx, state, code, RequestMetadata{IPAddress: "203.0.113.9"})
+	if err != nil {
+		t.Fatalf("complete login: %v", err)
+	}
+	if token == "" {
+		t.Fatal("no access token")
+	}
+	if user.Email != "new.hire@example.com" || user.OrganizationID != env.organization.ID || user.RoleID != model.RoleSupervisor || user.Status != "active" {
+		t.Fatalf("provisioned user: email %q, organization %s, role %d, status %q", user.Email, user.OrganizationID, user.RoleID, user.Status)
+	}
+
+	var identities []model.UserIdentity
+	if err := env.db.Where("user_id = ?", user.ID).Find(&identities).Error; err != nil {
+		t.Fatalf("find identities: %v", err)
+	}
+	if len(identities) != 1 || identities[0].Issuer != env.provider.Issuer {
+		t.Fatalf("identities %+v, want one for %s", identities, env.provider.Issuer)
+	}
+	if events := auditEvents(t, env.db, user.ID); len(events) != 1 || events[0] != "sso_provisioned" {
+		t.Fatalf("audit events %v, want sso_provisioned", events)
+	}
+
+	// The next login resolves the linked identity instead of provisioning again
+	state, code = env.authorize(t, "new.hire@example.com")
+	again, _, err := env.service.CompleteLogin(ctx, state, code, RequestMetadata{})
+	if err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if again.ID != user.ID {
+		t.Fatalf("second login returned user %s, want %s", again.ID, user.ID)
+	}
+	if users := env.usersWithEmail(t, "new.hire@example.com"); len(users) != 1 {
+		t.Fatalf("%d users with the email after two logins, want 1", len(users))
+	}
+}
+
+func TestOIDCLoginDefaultRoleAndExistingUser(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	ctx := context.Background()
+
+	// Without a mapped group the default role applies
+	state, code := env.authorize(t, "viewer@example.com")
+	user, _, err := env.service.CompleteLogin(ctx, state, code, RequestMetadata{})
+	if err != nil {
+		t.Fatalf("complete login: %v", err)
+	}
+	if user.RoleID != model.RoleViewer {
+		t.Fatalf("role %d, want the default viewer role", user.RoleID)
+	}
+
+	// An existing password user is linked, keeping their role
+	existing := testdb.CreateUser(t, env.db, env.organization.ID, model.RoleTechnician)
+	env.provider.SetRoles("supervisors")
+	state, code = env.authorize(t, existing.Email)
+	linked, _, err := env.service.CompleteLogin(ctx, state, code, RequestMetadata{})
+	if err != nil {
+		t.Fatalf("login of existing user: %v", err)
+	}
+	if linked.ID != existing.ID || linked.RoleID != model.RoleTechnician {
+		t.Fatalf("linked user %s with role %d, want %s with role %d", linked.ID, linked.RoleID, existing.ID, model.RoleTechnician)
+	}
+}
+
+func TestOIDCLoginRejectsDisallowedEmailDomain(t *testing.T) {
+	env := newOIDCTestEnv(t)
+
+	for _, email := range []string{"intruder@evil.test", "intruder@example.com.evil.test", "intruder@notexample.com"} {
+		state, code := env.authorize(t, email)
+		if _, _, err := env.service.CompleteLogin(context.Background(), state, code, RequestMetadata{}); err != ErrOIDCEmailNotAllowed {
+			t.Fatalf("login as %s: %v, want ErrOIDCEmailNotAllowed", email, err)
+		}
+		if users := env.usersWithEmail(t, email); len(users) != 0 {
+			t.Fatalf("user provisioned for disallowed %s", email)
+		}
+	}
+}
+
+func TestOIDCLoginValidatesState(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	ctx := context.Background()
+
+	state, code := env.authorize(t, "state@example.com")
+	if _, _, err := env.service.CompleteLogin(ctx, "forged-"+uuid.NewString(), code, RequestMetadata{}); err != ErrOIDCInvalidState {
+		t.Fatalf("unknown state: %v, want ErrOIDCInvalidState", err)
+	}
+	if _, _, err := env.service.CompleteLogin(ctx, state, code, RequestMetadata{}); err != nil {
+		t.Fatalf("login with the issued state: %v", err)
+	}
+	// A state is single use, so a replayed callback is refused
+	if _, _, err := env.service.CompleteLogin(ctx, state, code, RequestMetadata{}); err != ErrOIDCInvalidState {
+		t.Fatalf("replayed state: %v, want ErrOIDCInvalidState", err)
+	}
+
+	// A code is bound to the PKCE challenge of its own login attempt
+	otherState, _ := env.authorize(t, "state@example.com")
+	_, code = env.authorize(t, "state@example.com")
+	if _, _, err := env.service.CompleteLogin(ctx, otherState, code, RequestMetadata{}); err != ErrOIDCLoginFailed {
+		t.Fatalf("code of another login attempt: %v, want ErrOIDCLoginFailed", err)
+	}
+}
+
+func TestOIDCLoginValidatesNonce(t *testing.T) {
+	env := newOIDCTestEnv(t)
+
+	env.provider.OverrideNonce("replayed-" + uuid.NewString())
+	state, code := env.authorize(t, "nonce@example.com")
+	_, _, err := env.service.CompleteLogin(context.Background(), state, code, RequestMetadata{})
+	if !errors.Is(err, ErrOIDCLoginFailed) {
+		t.Fatalf("mismatched nonce: %v, want ErrOIDCLoginFailed", err)
+	}
+	if users := env.usersWithEmail(t, "nonce@example.com"); len(users) != 0 {
+		t.Fatal("user provisioned despite the nonce mismatch")
+	}
+
+	env.provider.OverrideNonce("")
+	state, code = env.authorize(t, "nonce@example.com")
+	if _, _, err := env.service.CompleteLogin(context.Background(), state, code, RequestMetadata{}); err != nil {
+		t.Fatalf("login with the right nonce: %v", err)
+	}
+}
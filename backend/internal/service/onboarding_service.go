@@ -0,0 +1,423 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+)
+
+// RegistrationPolicy controls how users may join an organization without SSO.
+type RegistrationPolicy struct {
+	Mode           string   `json:"mode"`
+	AllowedDomains []string `json:"allowed_domains"`
+}
+
+// InvitationView is the API representation of an invitation.
+type InvitationView struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	RoleID    int16     `json:"role_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Expired   bool      `json:"expired"`
+	InvitedBy uuid.UUID `json:"invited_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InvitationPreview is what an invitee sees before accepting.
+type InvitationPreview struct {
+	OrganizationName string    `json:"organization_name"`
+	OrganizationCode string    `json:"organization_code"`
+	Email            string    `json:"email"`
+	RoleID           int16     `json:"role_id"`
+	RoleLabel        string    `json:"role_label"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+// OnboardingService handles invitations and self-registration.
+type OnboardingService struct {
+	orgRepo        *repository.OrganizationRepository
+	invitationRepo *repository.InvitationRepository
+	userRepo       *repository.UserRepository
+	roleRepo       *repository.RoleRepository
+	settingsRepo   *repository.SecuritySettingsRepository
+	authService    *AuthService
+	jwtService     *JWTService
+	auditService   *AuditService
+	acceptURL      string
+}
+
+func NewOnboardingService(orgRepo *repository.OrganizationRepository, invitationRepo *repository.InvitationRepository, userRepo *repository.UserRepository, roleRepo *repository.RoleRepository,
+	settingsRepo *repository.SecuritySettingsRepository, authService *AuthService, jwtService *JWTService, auditService *AuditService, acceptURL string) *OnboardingService {
+	return &OnboardingService{
+		orgRepo:        orgRepo,
+		invitationRepo: invitationRepo,
+		userRepo:       userRepo,
+		roleRepo:       roleRepo,
+		settingsRepo:   settingsRepo,
+		authService:    authService,
+		jwtService:     jwtService,
+		auditService:   auditService,
+		acceptURL:      acceptURL,
+	}
+}
+
+// GetRegistrationPolicy returns the organization's policy; invite-only when never configured.
+func (s *OnboardingService) GetRegistrationPolicy(ctx context.Context, organizationID uuid.UUID) (RegistrationPolicy, error) {
+	settings, err := s.settingsRepo.FindByOrganizationID(ctx, organizationID)
+	if err != nil {
+		return RegistrationPolicy{}, err
+	}
+	if settings == nil {
+		return RegistrationPolicy{Mode: model.RegistrationModeInviteOnly, AllowedDomains: []string{}}, nil
+	}
+
+	domains, err := settings.SignupDomainList()
+	if err != nil {
+		return RegistrationPolicy{}, err
+	}
+	return RegistrationPolicy{Mode: settings.RegistrationMode, AllowedDomains: domains}, nil
+}
+
+func (s *OnboardingService) UpdateRegistrationPolicy(ctx context.Context, organizationID uuid.UUID, policy RegistrationPolicy, updatedBy uuid.UUID) (RegistrationPolicy, error) {
+	domains := make([]string, 0, len(policy.AllowedDomains))
+	for _, domain := range policy.AllowedDomains {
+		domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+		if domain == "" || !strings.Contains(domain, ".") || strings.ContainsAny(domain, "@ /") {
+			return RegistrationPolicy{}, ErrInvalidRegistrationPolicy
+		}
+		domains = append(domains, domain)
+	}
+	switch policy.Mode {
+	case model.RegistrationModeInviteOnly:
+	case model.RegistrationModeDomain:
+		if len(domains) == 0 {
+			return RegistrationPolicy{}, ErrInvalidRegistrationPolicy
+		}
+	default:
+		return RegistrationPolicy{}, ErrInvalidRegistrationPolicy
+	}
+
+	domainsJSON, err := json.Marshal(domains)
+	if err != nil {
+		return RegistrationPolicy{}, err
+	}
+	settings := newSecuritySettings(organizationID, updatedBy)
+	settings.RegistrationMode = policy.Mode
+	settings.SignupEmailDomains = domainsJSON
+
+	if err := s.settingsRepo.Upsert(ctx, settings, []string{"registration_mode", "signup_email_domains"}); err != nil {
+		return RegistrationPolicy{}, err
+	}
+
+	return RegistrationPolicy{Mode: policy.Mode, AllowedDomains: domains}, nil
+}
+
+// Register self-registers a viewer into the organization identified by its code. Only
+// organizations in domain mode accept it, and only for their listed email domains.
+func (s *OnboardingService) Register(ctx context.Context, organizationCode, email, password string, meta RequestMetadata) (*LoginResult, error) {
+	organization, err := s.orgRepo.FindByCode(ctx, organizationCode)
+	if err != nil {
+		return nil, err
+	}
+	if organization == nil || organization.Status == "deleted" {
+		return nil, ErrRegistrationClosed
+	}
+	if organization.Status == "suspended" {
+		return nil, ErrOrganizationSuspended
+	}
+
+	policy, err := s.GetRegistrationPolicy(ctx, organization.ID)
+	if err != nil {
+		return nil, err
+	}
+	email = normalizeEmail(email)
+	if policy.Mode != model.RegistrationModeDomain || !domainListed(policy.AllowedDomains, email) {
+		return nil, ErrRegistrationClosed
+	}
+
+	user, err := s.authService.NewUser(ctx, organization.ID, email, password, model.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return s.authService.LoginUser(ctx, organization.ID, email, password, meta)
+}
+
+func (s *OnboardingService) ListInvitations(ctx context.Context, organizationID uuid.UUID) ([]InvitationView, error) {
+	invitations, err := s.invitationRepo.FindPendingByOrganizationID(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	views := make([]InvitationView, 0, len(invitations))
+	for i := range invitations {
+		views = append(views, newInvitationView(&invitations[i], now))
+	}
+	return views, nil
+}
+
+// CreateInvitation invites an email with a preset role and queues the invitation email.
+// The role may not be more privileged than the inviter's. Re-inviting an email replaces
+// its open invitation.
+func (s *OnboardingService) CreateInvitation(ctx context.Context, organizationID, inviterID uuid.UUID, email string, roleID int16, meta RequestMetadata) (*InvitationView, error) {
+	email = normalizeEmail(email)
+
+	inviter, err := s.userRepo.FindByID(ctx, inviterID)
+	if err != nil {
+		return nil, err
+	}
+	if inviter == nil || inviter.OrganizationID != organizationID {
+		return nil, ErrUnauthorized
+	}
+	if err := checkAssignableRole(ctx, s.roleRepo, inviter.RoleID, roleID); err != nil {
+		return nil, err
+	}
+	role, err := s.roleRepo.FindByID(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.userRepo.FindByEmail(ctx, organizationID, email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrEmailExists
+	}
+
+	organization, err := s.orgRepo.FindByID(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if organization == nil {
+		return nil, ErrUnauthorized
+	}
+
+	now := time.Now()
+	invitation, message, err := s.newInvitation(organization, inviter, email, role, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.invitationRepo.CreateWithEmail(ctx, invitation, message); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, organizationID, &inviterID, invitation.ID, AuditActionCreate, map[string]interface{}{
+		"event":   "invitation_created",
+		"email":   email,
+		"role_id": roleID,
+	}, meta)
+
+	view := newInvitationView(invitation, now)
+	return &view, nil
+}
+
+// newInvitation builds an invitation and its email. The signed token only travels in the
+// email; the invitation row is how it is revoked or marked accepted.
+func (s *OnboardingService) newInvitation(organization *model.Organization, inviter *model.User, email string, role *model.Role, now time.Time) (*model.Invitation, *model.EmailQueueEntry, error) {
+	invitation := &model.Invitation{
+		ID:             uuid.New(),
+		OrganizationID: organization.ID,
+		Email:          email,
+		RoleID:         role.ID,
+		ExpiresAt:      now.Add(invitationTokenTTL),
+		InvitedBy:      inviter.ID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	token, err := s.jwtService.GenerateInvitationToken(invitation.ID, organization.ID, email, role.ID, invitation.ExpiresAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	templateData, err := json.Marshal(map[string]interface{}{
+		"organization_name": organization.Name,
+		"invited_by_email":  inviter.Email,
+		"role_label":        role.Label,
+		"accept_url":        s.acceptURL + "?" + url.Values{"token": {token}}.Encode(),
+		"expires_at":        invitation.ExpiresAt.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	message := &model.EmailQueueEntry{
+		ID:             uuid.New(),
+		OrganizationID: organization.ID,
+		RecipientEmail: email,
+		EmailType:      model.EmailTypeOrganizationInvitation,
+		TemplateData:   templateData,
+		Status:         "pending",
+		CreatedAt:      now,
+	}
+	return invitation, message, nil
+}
+
+func (s *OnboardingService) RevokeInvitation(ctx context.Context, organizationID, invitationID, revokedBy uuid.UUID, meta RequestMetadata) error {
+	revoked, err := s.invitationRepo.Revoke(ctx, organizationID, invitationID, revokedBy)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrInvitationNotFound
+	}
+
+	s.audit(ctx, organizationID, &revokedBy, invitationID, AuditActionDelete, map[string]interface{}{
+		"event": "invitation_revoked",
+	}, meta)
+	return nil
+}
+
+// PreviewInvitation checks an invitation token and describes the invitation.
+func (s *OnboardingService) PreviewInvitation(ctx context.Context, token string) (*InvitationPreview, error) {
+	invitation, err := s.pendingInvitation(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	organization, err := s.orgRepo.FindByID(ctx, invitation.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	role, err := s.roleRepo.FindByID(ctx, invitation.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	if organization == nil || role == nil {
+		return nil, ErrInvalidInvitation
+	}
+
+	return &InvitationPreview{
+		OrganizationName: organization.Name,
+		OrganizationCode: organization.Code,
+		Email:            invitation.Email,
+		RoleID:           role.ID,
+		RoleLabel:        role.Label,
+		ExpiresAt:        invitation.ExpiresAt,
+	}, nil
+}
+
+// AcceptInvitation creates the invited user with the preset role and logs them in. The
+// email is considered verified because the token was delivered to it.
+func (s *OnboardingService) AcceptInvitation(ctx context.Context, token, password string, meta RequestMetadata) (*model.User, *LoginResult, error) {
+	invitation, err := s.pendingInvitation(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	organization, err := s.orgRepo.FindByID(ctx, invitation.OrganizationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if organization == nil || organization.Status == "deleted" {
+		return nil, nil, ErrInvalidInvitation
+	}
+	if organization.Status == "suspended" {
+		return nil, nil, ErrOrganizationSuspended
+	}
+
+	user, err := s.authService.NewUser(ctx, invitation.OrganizationID, invitation.Email, password, invitation.RoleID)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	user.EmailVerified = true
+	user.EmailVerifiedAt = &now
+	user.CreatedBy = &invitation.InvitedBy
+
+	if err := s.invitationRepo.AcceptWithUser(ctx, invitation.ID, user); err != nil {
+		if errors.Is(err, repository.ErrInvitationNotPending) {
+			return nil, nil, ErrInvalidInvitation
+		}
+		return nil, nil, err
+	}
+
+	s.audit(ctx, invitation.OrganizationID, &user.ID, invitation.ID, AuditActionUpdate, map[string]interface{}{
+		"event":   "invitation_accepted",
+		"user_id": user.ID,
+		"role_id": user.RoleID,
+	}, meta)
+
+	result, err := s.authService.LoginUser(ctx, invitation.OrganizationID, user.Email, password, meta)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, result, nil
+}
+
+// pendingInvitation validates the signed token and loads its still-open invitation.
+func (s *OnboardingService) pendingInvitation(ctx context.Context, token string) (*model.Invitation, error) {
+	claims, err := s.jwtService.ValidateScopedToken(token, TokenScopeInvitation)
+	if err != nil {
+		return nil, ErrInvalidInvitation
+	}
+	invitationID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, ErrInvalidInvitation
+	}
+
+	invitation, err := s.invitationRepo.FindByID(ctx, invitationID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil || !invitation.Pending(time.Now()) ||
+		invitation.OrganizationID != claims.OrganizationID || invitation.Email != claims.Email {
+		return nil, ErrInvalidInvitation
+	}
+	return invitation, nil
+}
+
+func (s *OnboardingService) audit(ctx context.Context, organizationID uuid.UUID, actorID *uuid.UUID, invitationID uuid.UUID, action string, after map[string]interface{}, meta RequestMetadata) {
+	if err := s.auditService.Record(ctx, AuditEntry{
+		OrganizationID: organizationID,
+		ActorID:        actorID,
+		EntityType:     "invitation",
+		EntityID:       invitationID,
+		Action:         action,
+		After:          after,
+		Metadata:       meta,
+	}); err != nil {
+		log.Printf("failed to audit invitation %s: %v", invitationID, err)
+	}
+}
+
+func newInvitationView(invitation *model.Invitation, now time.Time) InvitationView {
+	return InvitationView{
+		ID:        invitation.ID,
+		Email:     invitation.Email,
+		RoleID:    invitation.RoleID,
+		ExpiresAt: invitation.ExpiresAt,
+		Expired:   !now.Before(invitation.ExpiresAt),
+		InvitedBy: invitation.InvitedBy,
+		CreatedAt: invitation.CreatedAt,
+	}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func domainListed(domains []string, email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := email[at+1:]
+	for _, allowed := range domains {
+		if domain == allowed {
+			return true
+		}
+	}
+	return false
+}
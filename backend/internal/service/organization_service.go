@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+)
+
+// OrganizationService answers whether an organization may be used. Lookups are not cached,
+// so suspending an organization takes effect on its very next request.
+type OrganizationService struct {
+	orgRepo *repository.OrganizationRepository
+}
+
+func NewOrganizationService(orgRepo *repository.OrganizationRepository) *OrganizationService {
+	return &OrganizationService{orgRepo: orgRepo}
+}
+
+// CheckActive returns ErrOrganizationSuspended for a suspended organization and
+// ErrOrganizationNotFound for one that is missing or deleted.
+func (s *OrganizationService) CheckActive(ctx context.Context, organizationID uuid.UUID) error {
+	organization, err := s.orgRepo.FindByID(ctx, organizationID)
+	if err != nil {
+		return err
+	}
+	if organization == nil || organization.Status == "deleted" {
+		return ErrOrganizationNotFound
+	}
+	if organization.Status == "suspended" {
+		return ErrOrganizationSuspended
+	}
+	return nil
+}
+
+// ResolveCode returns the ID of the organization with the given code. Deleted organizations
+// are not found; suspended ones are, so that logins can report the suspension.
+func (s *OrganizationService) ResolveCode(ctx context.Context, code string) (uuid.UUID, error) {
+	organization, err := s.orgRepo.FindByCode(ctx, strings.ToLower(strings.TrimSpace(code)))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if organization == nil || organization.Status == "deleted" {
+		return uuid.Nil, ErrOrganizationNotFound
+	}
+	return organization.ID, nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/config"
+)
+
+// ErrForbiddenDestination is returned for calls to addresses outbound calls may not reach.
+var ErrForbiddenDestination = errors.New("destination address is not allowed")
+
+// nonPublicPrefixes are the ranges that are global unicast to net/netip but not public:
+// "this network" and RFC 6598 carrier-grade NAT space, which some clouds use for their
+// metadata services.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// OutboundPolicy restricts the calls the server makes to URLs chosen by organizations:
+// webhooks and Procore's base_url. Without it an organization admin could make the server
+// call internal services, such as a cloud metadata endpoint, and read the reply back from
+// the delivery log or the sync's last error.
+type OutboundPolicy struct {
+	// AllowHTTP accepts plain http URLs; https is required in production.
+	AllowHTTP bool
+	// AllowPrivate lets calls reach loopback, private and link-local addresses, e.g. a
+	// local mock server during development.
+	AllowPrivate bool
+}
+
+// NewOutboundPolicy requires https in production and private addresses to be allowed
+// explicitly.
+func NewOutboundPolicy(cfg *config.Config) OutboundPolicy {
+	return OutboundPolicy{
+		AllowHTTP:    !cfg.IsProduction(),
+		AllowPrivate: cfg.OutboundAllowPrivateNetworks,
+	}
+}
+
+// CheckURL accepts absolute https URLs (and http ones if allowed) whose host is not an
+// address the policy forbids. Host names are checked again at dial time, once resolved.
+func (p OutboundPolicy) CheckURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" || (parsed.Scheme != "https" && !(p.AllowHTTP && parsed.Scheme == "http")) {
+		if p.AllowHTTP {
+			return fmt.Errorf("must be an absolute http or https URL")
+		}
+		return fmt.Errorf("must be an absolute https URL")
+	}
+	if p.AllowPrivate {
+		return nil
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenDestination
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !publicAddress(addr) {
+		return ErrForbiddenDestination
+	}
+	return nil
+}
+
+// Client returns an HTTP client for calls under the policy. Every connection's resolved
+// address is checked when dialing, so a host name cannot be pointed (or re-pointed, by
+// DNS rebinding) at a forbidden address, and redirects are not followed: the redirect
+// response is returned as it is.
+func (p OutboundPolicy) Client(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	if !p.AllowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !publicAddress(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenDestination, address)
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would dial on the server's behalf, out of reach of the address check
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, address)
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// publicAddress reports whether addr is a global unicast address: not loopback,
+// link-local, unspecified, multicast, private (RFC 1918, unique local) or shared.
+func publicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
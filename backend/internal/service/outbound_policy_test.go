@@ -0,0 +1,90 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOutboundPolicyCheckURL(t *testing.T) {
+	production := OutboundPolicy{}
+	development := OutboundPolicy{AllowHTTP: true}
+	local := OutboundPolicy{AllowHTTP: true, AllowPrivate: true}
+
+	tests := []struct {
+		policy OutboundPolicy
+		url    string
+		ok     bool
+	}{
+		{production, "https://hooks.example.com/equipchain", true},
+		{production, "https://203.0.113.10:8443/hook", true},
+		{production, "http://hooks.example.com/equipchain", false},
+		{development, "http://hooks.example.com/equipchain", true},
+		{production, "ftp://hooks.example.com", false},
+		{production, "/relative", false},
+		{production, "https://", false},
+
+		{development, "http://169.254.169.254/latest/meta-data/", false},
+		{development, "http://localhost:9500", false},
+		{development, "http://api.localhost", false},
+		{development, "http://127.0.0.1:8080", false},
+		{development, "http://[::1]:8080", false},
+		{development, "http://10.0.0.5", false},
+		{development, "http://172.16.3.4", false},
+		{development, "http://192.168.1.1", false},
+		{development, "http://100.100.100.200", false},
+		{development, "http://0.0.0.0", false},
+		{development, "http://[fd00::1]", false},
+		{development, "http://[fe80::1]", false},
+		{development, "http://[::ffff:127.0.0.1]", false},
+
+		{local, "http://localhost:9500", true},
+		{local, "http://10.0.0.5", true},
+	}
+	for _, tt := range tests {
+		err := tt.policy.CheckURL(tt.url)
+		if (err == nil) != tt.ok {
+			t.Errorf("%+v CheckURL(%q) = %v, want ok %v", tt.policy, tt.url, err, tt.ok)
+		}
+	}
+}
+
+func TestOutboundPolicyClientRefusesPrivateAddressesAtDialTime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// The URL check passes for a host name; the dial-time check catches where it resolves
+	_, err := OutboundPolicy{AllowHTTP: true}.Client(time.Second).Get(server.URL)
+	if !errors.Is(err, ErrForbiddenDestination) {
+		t.Fatalf("call to a loopback address: %v, want ErrForbiddenDestination", err)
+	}
+
+	resp, err := OutboundPolicy{AllowHTTP: true, AllowPrivate: true}.Client(time.Second).Get(server.URL)
+	if err != nil {
+		t.Fatalf("call with private addresses allowed: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestOutboundPolicyClientDoesNotFollowRedirects(t *testing.T) {
+	var followed bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal" {
+			followed = true
+			return
+		}
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	}))
+	defer server.Close()
+
+	resp, err := OutboundPolicy{AllowHTTP: true, AllowPrivate: true}.Client(time.Second).Get(server.URL + "/hook")
+	if err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound || followed {
+		t.Fatalf("status %d, followed %v: want the redirect returned, not followed", resp.StatusCode, followed)
+	}
+}
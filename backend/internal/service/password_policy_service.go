@@ -0,0 +1,278 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcrypt silently ignores everything past 72 bytes, so longer passwords are rejected outright.
+const maxPasswordBytes = 72
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+var commonPasswords = loadCommonPasswords(commonPasswordList)
+
+var passwordPolicyColumns = []string{
+	"password_min_length",
+	"password_require_uppercase",
+	"password_require_lowercase",
+	"password_require_digit",
+	"password_require_symbol",
+	"password_history_count",
+	"password_reject_common",
+}
+
+type PasswordPolicy struct {
+	MinLength        int  `json:"min_length"`
+	RequireUppercase bool `json:"require_uppercase"`
+	RequireLowercase bool `json:"require_lowercase"`
+	RequireDigit     bool `json:"require_digit"`
+	RequireSymbol    bool `json:"require_symbol"`
+	HistoryCount     int  `json:"history_count"`
+	RejectCommon     bool `json:"reject_common"`
+}
+
+// DefaultPasswordPolicy applies to organizations that have not configured their own policy.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:        12,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    false,
+		HistoryCount:     5,
+		RejectCommon:     true,
+	}
+}
+
+// PasswordPolicyError lists every rule a password failed. It unwraps to ErrWeakPassword.
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return ErrWeakPassword.Error() + ": " + strings.Join(e.Violations, "; ")
+}
+
+func (e *PasswordPolicyError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+type PasswordPolicyService struct {
+	settingsRepo *repository.SecuritySettingsRepository
+	userRepo     *repository.UserRepository
+}
+
+func NewPasswordPolicyService(settingsRepo *repository.SecuritySettingsRepository, userRepo *repository.UserRepository) *PasswordPolicyService {
+	return &PasswordPolicyService{
+		settingsRepo: settingsRepo,
+		userRepo:     userRepo,
+	}
+}
+
+func (s *PasswordPolicyService) GetPolicy(ctx context.Context, organizationID uuid.UUID) (PasswordPolicy, error) {
+	settings, err := s.settingsRepo.FindByOrganizationID(ctx, organizationID)
+	if err != nil {
+		return PasswordPolicy{}, err
+	}
+	if settings == nil {
+		return DefaultPasswordPolicy(), nil
+	}
+
+	return PasswordPolicy{
+		MinLength:        int(settings.PasswordMinLength),
+		RequireUppercase: settings.PasswordRequireUppercase,
+		RequireLowercase: settings.PasswordRequireLowercase,
+		RequireDigit:     settings.PasswordRequireDigit,
+		RequireSymbol:    settings.PasswordRequireSymbol,
+		HistoryCount:     int(settings.PasswordHistoryCount),
+		RejectCommon:     settings.PasswordRejectCommon,
+	}, nil
+}
+
+func (s *PasswordPolicyService) UpdatePolicy(ctx context.Context, organizationID uuid.UUID, policy PasswordPolicy, updatedBy uuid.UUID) (PasswordPolicy, error) {
+	if policy.MinLength < 8 || policy.MinLength > maxPasswordBytes {
+		return PasswordPolicy{}, ErrInvalidPasswordPolicy
+	}
+	if policy.HistoryCount < 0 || policy.HistoryCount > 24 {
+		return PasswordPolicy{}, ErrInvalidPasswordPolicy
+	}
+
+	settings := newSecuritySettings(organizationID, updatedBy)
+	settings.PasswordMinLength = int16(policy.MinLength)
+	settings.PasswordRequireUppercase = policy.RequireUppercase
+	settings.PasswordRequireLowercase = policy.RequireLowercase
+	settings.PasswordRequireDigit = policy.RequireDigit
+	settings.PasswordRequireSymbol = policy.RequireSymbol
+	settings.PasswordHistoryCount = int16(policy.HistoryCount)
+	settings.PasswordRejectCommon = policy.RejectCommon
+
+	if err := s.settingsRepo.Upsert(ctx, settings, passwordPolicyColumns); err != nil {
+		return PasswordPolicy{}, err
+	}
+
+	return policy, nil
+}
+
+// newSecuritySettings returns a settings row populated with the application defaults, used
+// when an organization configures any security setting for the first time.
+func newSecuritySettings(organizationID, updatedBy uuid.UUID) *model.OrganizationSecuritySettings {
+	defaults := DefaultPasswordPolicy()
+	return &model.OrganizationSecuritySettings{
+		OrganizationID:           organizationID,
+		PasswordMinLength:        int16(defaults.MinLength),
+		PasswordRequireUppercase: defaults.RequireUppercase,
+		PasswordRequireLowercase: defaults.RequireLowercase,
+		PasswordRequireDigit:     defaults.RequireDigit,
+		PasswordRequireSymbol:    defaults.RequireSymbol,
+		PasswordHistoryCount:     int16(defaults.HistoryCount),
+		PasswordRejectCommon:     defaults.RejectCommon,
+		RegistrationMode:         model.RegistrationModeInviteOnly,
+		SignupEmailDomains:       json.RawMessage("[]"),
+		CreatedBy:                &updatedBy,
+		UpdatedBy:                &updatedBy,
+	}
+}
+
+// ValidatePassword checks a new password against the organization's composition rules
+// and the common password list.
+func (s *PasswordPolicyService) ValidatePassword(ctx context.Context, organizationID uuid.UUID, password string) error {
+	policy, err := s.GetPolicy(ctx, organizationID)
+	if err != nil {
+		return err
+	}
+
+	if violations := policy.Check(password); len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// ValidatePasswordChange runs ValidatePassword and additionally rejects the user's current
+// password and the last HistoryCount passwords recorded in user_password_history.
+func (s *PasswordPolicyService) ValidatePasswordChange(ctx context.Context, user *model.User, newPassword string) (PasswordPolicy, error) {
+	policy, err := s.GetPolicy(ctx, user.OrganizationID)
+	if err != nil {
+		return PasswordPolicy{}, err
+	}
+
+	violations := policy.Check(newPassword)
+	if len(violations) == 0 {
+		reused, err := s.isReused(ctx, user, newPassword, policy.HistoryCount)
+		if err != nil {
+			return PasswordPolicy{}, err
+		}
+		if reused {
+			violations = append(violations, fmt.Sprintf("must not match your current or last %d passwords", policy.HistoryCount))
+		}
+	}
+
+	if len(violations) > 0 {
+		return PasswordPolicy{}, &PasswordPolicyError{Violations: violations}
+	}
+	return policy, nil
+}
+
+func (s *PasswordPolicyService) isReused(ctx context.Context, user *model.User, password string, historyCount int) (bool, error) {
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) == nil {
+		return true, nil
+	}
+
+	hashes, err := s.userRepo.FindRecentPasswordHashes(ctx, user.ID, historyCount)
+	if err != nil {
+		return false, err
+	}
+	for _, hash := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Check returns a human-readable message for every rule the password breaks.
+func (p PasswordPolicy) Check(password string) []string {
+	var violations []string
+
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters", p.MinLength))
+	}
+	if len(password) > maxPasswordBytes {
+		violations = append(violations, fmt.Sprintf("must be at most %d bytes", maxPasswordBytes))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.RequireUppercase && !hasUpper {
+		violations = append(violations, "must contain an uppercase letter")
+	}
+	if p.RequireLowercase && !hasLower {
+		violations = append(violations, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, "must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, "must contain a symbol")
+	}
+	if p.RejectCommon && isCommonPassword(password) {
+		violations = append(violations, "is too common or has appeared in a data breach")
+	}
+
+	return violations
+}
+
+// isCommonPassword matches the password case-insensitively, and also with trailing digits
+// and symbols removed so that "Password2025!" is caught by the "password" entry.
+func isCommonPassword(password string) bool {
+	normalized := strings.ToLower(password)
+	if _, ok := commonPasswords[normalized]; ok {
+		return true
+	}
+
+	stem := strings.TrimRightFunc(normalized, func(r rune) bool {
+		return unicode.IsDigit(r) || unicode.IsPunct(r) || unicode.IsSymbol(r)
+	})
+	if stem != normalized && stem != "" {
+		if _, ok := commonPasswords[stem]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func loadCommonPasswords(list string) map[string]struct{} {
+	passwords := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+	return passwords
+}
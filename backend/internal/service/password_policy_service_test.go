@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/NWhite12/EquipChain/internal/testdb"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	all := PasswordPolicy{MinLength: 12, RequireUppercase: true, RequireLowercase: true, RequireDigit: true, RequireSymbol: true, RejectCommon: true}
+	lengthOnly := PasswordPolicy{MinLength: 8}
+
+	tests := []struct {
+		name     string
+		policy   PasswordPolicy
+		password string
+		want     []string
+	}{
+		{"meets every rule", all, "Copper-Meadow-73", nil},
+		{"too short", all, "Cu-Mead-7", []string{"must be at least 12 characters"}},
+		{"length counts characters, not bytes", PasswordPolicy{MinLength: 5}, "äöüßé", nil},
+		{"too long for bcrypt", lengthOnly, strings.Repeat("a", maxPasswordBytes+1), []string{"must be at most 72 bytes"}},
+		{"multibyte past 72 bytes", lengthOnly, strings.Repeat("ä", 37), []string{"must be at most 72 bytes"}},
+		{"no uppercase", all, "copper-meadow-73", []string{"must contain an uppercase letter"}},
+		{"no lowercase", all, "COPPER-MEADOW-73", []string{"must contain a lowercase letter"}},
+		{"no digit", all, "Copper-Meadow-xx", []string{"must contain a digit"}},
+		{"no symbol", all, "CopperMeadow73", []string{"must contain a symbol"}},
+		{"space counts as a symbol", all, "Copper Meadow 73", nil},
+		{"common", all, "Password2025!", []string{"is too common or has appeared in a data breach"}},
+		{"common allowed when not rejected", lengthOnly, "password123", nil},
+		{"every violation listed", all, "abc", []string{
+			"must be at least 12 characters",
+			"must contain an uppercase letter",
+			"must contain a digit",
+			"must contain a symbol",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Check(tt.password); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Check(%q) = %q, want %q", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsCommonPassword(t *testing.T) {
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"password", true},
+		{"PASSWORD", true},
+		{"Qwerty", true},
+		{"Password2025!", true},
+		{"letmein!!", true},
+		{"welcome#1", true},
+		{"!!!", false},
+		{"2025", false},
+		{"passwordx", false},
+		{"my password", false},
+		{"Copper-Meadow-73", false},
+	}
+	for _, tt := range tests {
+		if got := isCommonPassword(tt.password); got != tt.want {
+			t.Errorf("isCommonPassword(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePasswordChangeRejectsRecentPasswords(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	organization := testdb.CreateOrganization(t, db)
+	user := testdb.CreateUser(t, db, organization.ID, model.RoleTechnician)
+	userRepo := repository.NewUserRepository(db)
+	s := NewPasswordPolicyService(repository.NewSecuritySettingsRepository(db), userRepo)
+
+	policy := DefaultPasswordPolicy()
+	policy.HistoryCount = 2
+	if _, err := s.UpdatePolicy(ctx, organization.ID, policy, user.ID); err != nil {
+		t.Fatalf("update policy: %v", err)
+	}
+
+	// testdb.Password, then first, second and current: only the last two before current
+	// are kept
+	first, second, current := "Bridge-Lantern-42", "Silent-Harbor-19", "Copper-Meadow-73"
+	for _, password := range []string{first, second, current} {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		if err != nil {
+			t.Fatalf("hash: %v", err)
+		}
+		if err := userRepo.UpdatePassword(ctx, user.ID, reloadUser(t, db, user.ID).PasswordHash, string(hash), policy.HistoryCount, user.ID); err != nil {
+			t.Fatalf("update password: %v", err)
+		}
+	}
+	user = reloadUser(t, db, user.ID)
+
+	tests := []struct {
+		name     string
+		password string
+		reused   bool
+	}{
+		{"current", current, true},
+		{"previous", second, true},
+		{"second to last", first, true},
+		{"pruned from history", testdb.Password, false},
+		{"new", "Granite-Orchard-58", false},
+	}
+	for _, tt := range tests {
+		_, err := s.ValidatePasswordChange(ctx, user, tt.password)
+		var policyErr *PasswordPolicyError
+		if reused := errors.As(err, &policyErr); reused != tt.reused {
+			t.Errorf("%s: ValidatePasswordChange = %v, want reuse rejected %v", tt.name, err, tt.reused)
+			continue
+		}
+		if tt.reused && !reflect.DeepEqual(policyErr.Violations, []string{"must not match your current or last 2 passwords"}) {
+			t.Errorf("%s: violations %q", tt.name, policyErr.Violations)
+		}
+		if !tt.reused && err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/repository"
+)
+
+type cachedPermissions struct {
+	permissions []string
+	expiresAt   time.Time
+}
+
+// PermissionService resolves role_lookup.permissions for a role, caching each role's
+// list for ttl so authorization does not cost a query per request.
+type PermissionService struct {
+	roleRepo *repository.RoleRepository
+	ttl      time.Duration
+
+	mu    sync.RWMutex
+	cache map[int16]cachedPermissions
+}
+
+func NewPermissionService(roleRepo *repository.RoleRepository, ttl time.Duration) *PermissionService {
+	return &PermissionService{
+		roleRepo: roleRepo,
+		ttl:      ttl,
+		cache:    make(map[int16]cachedPermissions),
+	}
+}
+
+// PermissionsForRole returns the role's permissions. Unknown or inactive roles have none.
+func (s *PermissionService) PermissionsForRole(ctx context.Context, roleID int16) ([]string, error) {
+	s.mu.RLock()
+	cached, ok := s.cache[roleID]
+	s.mu.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.permissions, nil
+	}
+
+	role, err := s.roleRepo.FindByID(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	var permissions []string
+	if role != nil {
+		if permissions, err = role.PermissionList(); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	s.cache[roleID] = cachedPermissions{permissions: permissions, expiresAt: time.Now().Add(s.ttl)}
+	s.mu.Unlock()
+
+	return permissions, nil
+}
+
+func (s *PermissionService) RoleHasPermission(ctx context.Context, roleID int16, required string) (bool, error) {
+	permissions, err := s.PermissionsForRole(ctx, roleID)
+	if err != nil {
+		return false, err
+	}
+	return HasPermission(permissions, required), nil
+}
+
+// Invalidate drops all cached role permissions, e.g. after role_lookup changes.
+func (s *PermissionService) Invalidate() {
+	s.mu.Lock()
+	s.cache = make(map[int16]cachedPermissions)
+	s.mu.Unlock()
+}
+
+// HasPermission reports whether any granted permission covers required. "*" grants
+// everything; a "*" segment matches any single segment ("create:*" covers
+// "create:equipment"), and a trailing "*" segment also matches any remaining segments.
+func HasPermission(granted []string, required string) bool {
+	requiredParts := strings.Split(required, ":")
+	for _, permission := range granted {
+		if permission == "*" || permission == required {
+			return true
+		}
+		if permissionMatches(strings.Split(permission, ":"), requiredParts) {
+			return true
+		}
+	}
+	return false
+}
+
+func permissionMatches(granted, required []string) bool {
+	for i, part := range granted {
+		if i >= len(required) {
+			return false
+		}
+		if part == "*" {
+			if i == len(granted)-1 {
+				return true
+			}
+			continue
+		}
+		if part != required[i] {
+			return false
+		}
+	}
+	return len(granted) == len(required)
+}
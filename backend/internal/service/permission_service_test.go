@@ -0,0 +1,45 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+)
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  []string
+		required string
+		want     bool
+	}{
+		{"exact", []string{model.PermissionViewEquipment}, model.PermissionViewEquipment, true},
+		{"other permission", []string{model.PermissionViewEquipment}, model.PermissionCreateEquipment, false},
+		{"none granted", nil, model.PermissionViewEquipment, false},
+		{"any of several", []string{model.PermissionViewReports, model.PermissionCreateEquipment}, model.PermissionCreateEquipment, true},
+
+		{"everything", []string{"*"}, model.PermissionManageOrganization, true},
+		{"everything covers deeper permissions", []string{"*"}, "view:reports:costs", true},
+
+		{"any resource of an action", []string{"create:*"}, model.PermissionCreateEquipment, true},
+		{"any resource of another action", []string{"create:*"}, model.PermissionDeleteEquipment, false},
+		{"trailing wildcard covers deeper permissions", []string{"view:*"}, "view:reports:costs", true},
+		{"trailing wildcard needs a segment", []string{"view:*"}, "view", false},
+
+		{"any action on a resource", []string{"*:equipment"}, model.PermissionDeleteEquipment, true},
+		{"any action on another resource", []string{"*:equipment"}, model.PermissionApproveMaintenance, false},
+		{"inner wildcard matches one segment", []string{"*:equipment"}, "view:equipment:photos", false},
+		{"inner wildcard with a suffix", []string{"view:*:photos"}, "view:equipment:photos", true},
+		{"inner wildcard with another suffix", []string{"view:*:photos"}, "view:equipment:notes", false},
+
+		{"prefix is not a permission", []string{"view"}, model.PermissionViewEquipment, false},
+		{"longer grant", []string{"view:equipment:photos"}, model.PermissionViewEquipment, false},
+		{"segments are not substrings", []string{"view:equip*"}, model.PermissionViewEquipment, false},
+		{"required wildcard is literal", []string{model.PermissionViewEquipment}, "view:*", false},
+	}
+	for _, tt := range tests {
+		if got := HasPermission(tt.granted, tt.required); got != tt.want {
+			t.Errorf("%s: HasPermission(%q, %q) = %v, want %v", tt.name, tt.granted, tt.required, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+)
+
+var organizationCodePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{1,99}$`)
+
+// OrganizationInput creates an organization and invites its first admin.
+type OrganizationInput struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	AdminEmail  string `json:"admin_email"`
+}
+
+type OrganizationView struct {
+	ID               uuid.UUID  `json:"id"`
+	Code             string     `json:"code"`
+	Name             string     `json:"name"`
+	Description      *string    `json:"description"`
+	Status           string     `json:"status"`
+	SuspendedAt      *time.Time `json:"suspended_at"`
+	SuspensionReason *string    `json:"suspension_reason"`
+	DeletedAt        *time.Time `json:"deleted_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// PlatformService manages the lifecycle of organizations on behalf of platform admins
+// (users with users.is_platform_admin), who act across all organizations.
+type PlatformService struct {
+	orgRepo      *repository.OrganizationRepository
+	userRepo     *repository.UserRepository
+	roleRepo     *repository.RoleRepository
+	onboarding   *OnboardingService
+	auditService *AuditService
+}
+
+func NewPlatformService(orgRepo *repository.OrganizationRepository, userRepo *repository.UserRepository, roleRepo *repository.RoleRepository, onboarding *OnboardingService, auditService *AuditService) *PlatformService {
+	return &PlatformService{
+		orgRepo:      orgRepo,
+		userRepo:     userRepo,
+		roleRepo:     roleRepo,
+		onboarding:   onboarding,
+		auditService: auditService,
+	}
+}
+
+// IsPlatformAdmin is checked on every platform request, so revoking the flag is immediate.
+func (s *PlatformService) IsPlatformAdmin(ctx context.Context, userID uuid.UUID) (bool, error) {
+	return s.userRepo.IsPlatformAdmin(ctx, userID)
+}
+
+func (s *PlatformService) ListOrganizations(ctx context.Context, status string) ([]OrganizationView, error) {
+	organizations, err := s.orgRepo.FindAll(ctx, status)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]OrganizationView, 0, len(organizations))
+	for _, organization := range organizations {
+		views = append(views, newOrganizationView(organization))
+	}
+	return views, nil
+}
+
+func (s *PlatformService) GetOrganization(ctx context.Context, organizationID uuid.UUID) (*OrganizationView, error) {
+	organization, err := s.orgRepo.FindByID(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if organization == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	view := newOrganizationView(organization)
+	return &view, nil
+}
+
+// CreateOrganization creates an active organization and invites its first admin by email.
+func (s *PlatformService) CreateOrganization(ctx context.Context, actorID uuid.UUID, input OrganizationInput, meta RequestMetadata) (*OrganizationView, *InvitationView, error) {
+	code := strings.ToLower(strings.TrimSpace(input.Code))
+	name := strings.TrimSpace(input.Name)
+	if !organizationCodePattern.MatchString(code) || name == "" {
+		return nil, nil, ErrInvalidOrganization
+	}
+	email := normalizeEmail(input.AdminEmail)
+	if !strings.Contains(email, "@") {
+		return nil, nil, ErrInvalidInvitation
+	}
+
+	existing, err := s.orgRepo.FindByCode(ctx, code)
+	if err != nil {
+		return nil, nil, err
+	}
+	if existing != nil {
+		return nil, nil, ErrOrganizationCodeExists
+	}
+
+	actor, role, err := s.invitationParties(ctx, actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	organization := &model.Organization{
+		ID:        uuid.New(),
+		Code:      code,
+		Name:      name,
+		Status:    "active",
+		CreatedAt: now,
+		UpdatedAt: now,
+		CreatedBy: &actorID,
+		UpdatedBy: &actorID,
+	}
+	if description := strings.TrimSpace(input.Description); description != "" {
+		organization.Description = &description
+	}
+
+	invitation, message, err := s.onboarding.newInvitation(organization, actor, email, role, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.orgRepo.CreateWithInvitation(ctx, organization, invitation, message); err != nil {
+		return nil, nil, err
+	}
+
+	s.audit(ctx, organization.ID, actorID, AuditActionCreate, nil, map[string]interface{}{
+		"event":       "organization_created",
+		"code":        code,
+		"admin_email": email,
+	}, meta)
+
+	view := newOrganizationView(organization)
+	invitationView := newInvitationView(invitation, now)
+	return &view, &invitationView, nil
+}
+
+// InviteAdmin invites another admin to an active organization, e.g. when the first admin's
+// invitation expired or the organization has lost all of its admins.
+func (s *PlatformService) InviteAdmin(ctx context.Context, actorID, organizationID uuid.UUID, email string, meta RequestMetadata) (*InvitationView, error) {
+	email = normalizeEmail(email)
+	if !strings.Contains(email, "@") {
+		return nil, ErrInvalidInvitation
+	}
+
+	organization, err := s.orgRepo.FindByID(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if organization == nil || organization.Status == "deleted" {
+		return nil, ErrOrganizationNotFound
+	}
+	if organization.Status != "active" {
+		return nil, ErrInvalidOrganizationState
+	}
+
+	existing, err := s.userRepo.FindByEmail(ctx, organizationID, email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrEmailExists
+	}
+
+	actor, role, err := s.invitationParties(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	invitation, message, err := s.onboarding.newInvitation(organization, actor, email, role, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.onboarding.invitationRepo.CreateWithEmail(ctx, invitation, message); err != nil {
+		return nil, err
+	}
+
+	s.onboarding.audit(ctx, organizationID, &actorID, invitation.ID, AuditActionCreate, map[string]interface{}{
+		"event":   "invitation_created",
+		"email":   email,
+		"role_id": role.ID,
+		"by":      "platform_admin",
+	}, meta)
+
+	view := newInvitationView(invitation, now)
+	return &view, nil
+}
+
+// Suspend blocks all logins and authenticated requests of an active organization.
+func (s *PlatformService) Suspend(ctx context.Context, actorID, organizationID uuid.UUID, reason string, meta RequestMetadata) (*OrganizationView, error) {
+	updates := map[string]interface{}{
+		"status":            "suspended",
+		"suspended_at":      time.Now(),
+		"suspension_reason": nil,
+	}
+	if reason = strings.TrimSpace(reason); reason != "" {
+		updates["suspension_reason"] = reason
+	}
+	return s.transition(ctx, actorID, organizationID, []string{"active"}, updates, "organization_suspended", meta)
+}
+
+// Reactivate lifts a suspension.
+func (s *PlatformService) Reactivate(ctx context.Context, actorID, organizationID uuid.UUID, meta RequestMetadata) (*OrganizationView, error) {
+	updates := map[string]interface{}{
+		"status":            "active",
+		"suspended_at":      nil,
+		"suspension_reason": nil,
+	}
+	return s.transition(ctx, actorID, organizationID, []string{"suspended"}, updates, "organization_reactivated", meta)
+}
+
+// Delete soft-deletes an active or suspended organization. Its data is retained.
+func (s *PlatformService) Delete(ctx context.Context, actorID, organizationID uuid.UUID, meta RequestMetadata) error {
+	updates := map[string]interface{}{
+		"status":            "deleted",
+		"deleted_at":        time.Now(),
+		"suspended_at":      nil,
+		"suspension_reason": nil,
+	}
+	_, err := s.transition(ctx, actorID, organizationID, []string{"active", "suspended"}, updates, "organization_deleted", meta)
+	return err
+}
+
+func (s *PlatformService) transition(ctx context.Context, actorID, organizationID uuid.UUID, fromStatuses []string, updates map[string]interface{}, event string, meta RequestMetadata) (*OrganizationView, error) {
+	organization, err := s.orgRepo.FindByID(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if organization == nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	actor, err := s.userRepo.FindByID(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if actor == nil {
+		return nil, ErrPlatformAdminRequired
+	}
+	if actor.OrganizationID == organizationID && updates["status"] != "active" {
+		return nil, ErrOwnOrganization
+	}
+
+	updated, err := s.orgRepo.UpdateStatus(ctx, organizationID, fromStatuses, updates, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrInvalidOrganizationState
+	}
+
+	action := AuditActionUpdate
+	if event == "organization_deleted" {
+		action = AuditActionDelete
+	}
+	after := map[string]interface{}{"event": event, "status": updates["status"]}
+	if reason, ok := updates["suspension_reason"].(string); ok {
+		after["suspension_reason"] = reason
+	}
+	s.audit(ctx, organizationID, actorID, action, map[string]interface{}{"status": organization.Status}, after, meta)
+
+	return s.GetOrganization(ctx, organizationID)
+}
+
+// invitationParties loads the acting platform admin and the admin role new organization
+// admins are invited with.
+func (s *PlatformService) invitationParties(ctx context.Context, actorID uuid.UUID) (*model.User, *model.Role, error) {
+	actor, err := s.userRepo.FindByID(ctx, actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if actor == nil {
+		return nil, nil, ErrPlatformAdminRequired
+	}
+	role, err := s.roleRepo.FindByID(ctx, model.RoleAdmin)
+	if err != nil {
+		return nil, nil, err
+	}
+	if role == nil {
+		return nil, nil, ErrInvalidRole
+	}
+	return actor, role, nil
+}
+
+func (s *PlatformService) audit(ctx context.Context, organizationID, actorID uuid.UUID, action string, before, after interface{}, meta RequestMetadata) {
+	if err := s.auditService.Record(ctx, AuditEntry{
+		OrganizationID: organizationID,
+		ActorID:        &actorID,
+		EntityType:     "organization",
+		EntityID:       organizationID,
+		Action:         action,
+		Before:         before,
+		After:          after,
+		Metadata:       meta,
+	}); err != nil {
+		log.Printf("failed to audit organization %s: %v", organizationID, err)
+	}
+}
+
+func newOrganizationView(organization *model.Organization) OrganizationView {
+	return OrganizationView{
+		ID:               organization.ID,
+		Code:             organization.Code,
+		Name:             organization.Name,
+		Description:      organization.Description,
+		Status:           organization.Status,
+		SuspendedAt:      organization.SuspendedAt,
+		SuspensionReason: organization.SuspensionReason,
+		DeletedAt:        organization.DeletedAt,
+		CreatedAt:        organization.CreatedAt,
+	}
+}
@@ -0,0 +1,701 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/events"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/procore"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Directions of a field mapping.
+const (
+	SyncDirectionPush = "push"
+	SyncDirectionPull = "pull"
+)
+
+const (
+	// An integration's sync is leased for procoreSyncLease and a run stops taking on work
+	// after procoreSyncBudget, leaving the rest to the next run. The budget plus one call
+	// of procoreTimeout must fit in the lease.
+	procoreSyncLease  = 5 * time.Minute
+	procoreSyncBudget = 2 * time.Minute
+	procoreTimeout    = 30 * time.Second
+
+	procorePageSize  = 100
+	procoreBatchSize = 50
+
+	// Equipment updated_at is the start time of the updating transaction, so equipment may
+	// commit behind the push cursor. Each cycle pushes again from procoreCommitOverlap
+	// before the cursor, which must exceed the longest equipment transaction.
+	procoreCommitOverlap = 5 * time.Minute
+
+	maxProcoreLogNotesChars = 2000
+)
+
+// procoreLocalFields are the equipment fields a mapping may name, with their column
+// lengths (0 for unlimited). The serial number is the match key and is not mapped.
+var procoreLocalFields = map[string]struct {
+	maxChars int
+	required bool
+}{
+	"make":     {maxChars: 100, required: true},
+	"model":    {maxChars: 100, required: true},
+	"location": {maxChars: 255},
+	"notes":    {},
+}
+
+var procoreRemoteFieldPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,99}$`)
+
+// defaultProcoreFieldMapping pushes make and model and pulls the location.
+var defaultProcoreFieldMapping = []ProcoreFieldMapping{
+	{Local: "make", Remote: "make", Direction: SyncDirectionPush},
+	{Local: "model", Remote: "model", Direction: SyncDirectionPush},
+	{Local: "location", Remote: "location", Direction: SyncDirectionPull},
+}
+
+// ProcoreSettings is the settings object of a procore integration. The integration's
+// api_key and api_secret are the OAuth client id and secret.
+type ProcoreSettings struct {
+	BaseURL   string `json:"base_url"`
+	CompanyID int64  `json:"company_id"`
+	ProjectID int64  `json:"project_id"`
+	// SerialNumberField is the remote field matched against equipment serial numbers.
+	SerialNumberField string `json:"serial_number_field"`
+	// CreateRemote pushes equipment that matches no remote record as new managed equipment.
+	CreateRemote bool                  `json:"create_remote"`
+	FieldMapping []ProcoreFieldMapping `json:"field_mapping"`
+}
+
+// ProcoreFieldMapping maps a local equipment field onto a remote field. Push writes the
+// local value to Procore; pull writes remote changes locally.
+type ProcoreFieldMapping struct {
+	Local     string `json:"local"`
+	Remote    string `json:"remote"`
+	Direction string `json:"direction"`
+}
+
+// ParseProcoreSettings decodes and validates a procore integration's settings, filling in
+// defaults. An omitted field_mapping uses the default mapping; an empty one maps nothing.
+// The base_url must satisfy policy, since the sync sends the client credentials there.
+func ParseProcoreSettings(raw json.RawMessage, policy OutboundPolicy) (*ProcoreSettings, error) {
+	var settings ProcoreSettings
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&settings); err != nil {
+		return nil, fmt.Errorf("%w: invalid procore settings: %v", ErrInvalidIntegration, err)
+	}
+
+	settings.BaseURL = strings.TrimRight(strings.TrimSpace(settings.BaseURL), "/")
+	if settings.BaseURL == "" {
+		settings.BaseURL = procore.DefaultBaseURL
+	}
+	if err := policy.CheckURL(settings.BaseURL); err != nil {
+		return nil, fmt.Errorf("%w: settings.base_url %v", ErrInvalidIntegration, err)
+	}
+	if settings.CompanyID < 1 || settings.ProjectID < 1 {
+		return nil, fmt.Errorf("%w: settings.company_id and settings.project_id are required", ErrInvalidIntegration)
+	}
+	if settings.SerialNumberField == "" {
+		settings.SerialNumberField = "serial_number"
+	}
+	if !procoreRemoteFieldPattern.MatchString(settings.SerialNumberField) {
+		return nil, fmt.Errorf("%w: invalid settings.serial_number_field %q", ErrInvalidIntegration, settings.SerialNumberField)
+	}
+	if settings.FieldMapping == nil {
+		settings.FieldMapping = defaultProcoreFieldMapping
+	}
+
+	locals := make(map[string]bool)
+	remotes := map[string]bool{settings.SerialNumberField: true}
+	for _, mapping := range settings.FieldMapping {
+		if _, ok := procoreLocalFields[mapping.Local]; !ok {
+			return nil, fmt.Errorf("%w: field_mapping: unknown local field %q", ErrInvalidIntegration, mapping.Local)
+		}
+		if !procoreRemoteFieldPattern.MatchString(mapping.Remote) {
+			return nil, fmt.Errorf("%w: field_mapping: invalid remote field %q", ErrInvalidIntegration, mapping.Remote)
+		}
+		if mapping.Direction != SyncDirectionPush && mapping.Direction != SyncDirectionPull {
+			return nil, fmt.Errorf("%w: field_mapping: direction of %q must be push or pull", ErrInvalidIntegration, mapping.Local)
+		}
+		if locals[mapping.Local] || remotes[mapping.Remote] {
+			return nil, fmt.Errorf("%w: field_mapping: %q or %q is mapped twice", ErrInvalidIntegration, mapping.Local, mapping.Remote)
+		}
+		locals[mapping.Local] = true
+		remotes[mapping.Remote] = true
+	}
+	return &settings, nil
+}
+
+// remoteScope identifies the remote project the sync's links belong to.
+func (s *ProcoreSettings) remoteScope() string {
+	return fmt.Sprintf("%s|%d|%d", s.BaseURL, s.CompanyID, s.ProjectID)
+}
+
+// IntegrationSyncView is the API representation of an integration's sync checkpoint.
+type IntegrationSyncView struct {
+	IntegrationID       uuid.UUID       `json:"integration_id"`
+	Running             bool            `json:"running"`
+	Phase               string          `json:"phase"`
+	RemotePage          int             `json:"remote_page"`
+	EquipmentCursorAt   *time.Time      `json:"equipment_cursor_at"`
+	MaintenanceCursorAt *time.Time      `json:"maintenance_cursor_at"`
+	CycleStartedAt      *time.Time      `json:"cycle_started_at"`
+	CycleCounts         json.RawMessage `json:"cycle_counts"`
+	LastCompletedAt     *time.Time      `json:"last_completed_at"`
+	LastError           *string         `json:"last_error"`
+}
+
+// ProcoreSyncService runs the two-way equipment sync of procore integrations. Each cycle
+// pulls the project's managed equipment page by page, links it to local equipment by
+// serial number and applies remote changes of pulled fields; pushes local equipment
+// changes of pushed fields; and pushes confirmed maintenance as equipment log entries.
+// Run is the periodic job; it checkpoints after every page or item, so a cycle that does
+// not fit in one run continues in the next.
+type ProcoreSyncService struct {
+	integrationRepo *repository.IntegrationRepository
+	syncRepo        *repository.IntegrationSyncRepository
+	equipmentRepo   *repository.EquipmentRepository
+	auditService    *AuditService
+	bus             *events.Bus
+	policy          OutboundPolicy
+	httpClient      *http.Client
+}
+
+// NewProcoreSyncService calls Procore under policy, with a 30 second timeout.
+func NewProcoreSyncService(integrationRepo *repository.IntegrationRepository, syncRepo *repository.IntegrationSyncRepository, equipmentRepo *repository.EquipmentRepository, auditService *AuditService, bus *events.Bus, policy OutboundPolicy) *ProcoreSyncService {
+	return &ProcoreSyncService{
+		integrationRepo: integrationRepo,
+		syncRepo:        syncRepo,
+		equipmentRepo:   equipmentRepo,
+		auditService:    auditService,
+		bus:             bus,
+		policy:          policy,
+		httpClient:      policy.Client(procoreTimeout),
+	}
+}
+
+// Run continues the sync of every active procore integration. Integrations leased by
+// another server instance are skipped.
+func (s *ProcoreSyncService) Run(ctx context.Context) error {
+	integrations, err := s.integrationRepo.FindActiveByType(ctx, model.IntegrationTypeProcore)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, integration := range integrations {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.Sync(ctx, integration); err != nil && !errors.Is(err, ErrIntegrationSyncInProgress) {
+			errs = append(errs, fmt.Errorf("integration %s: %w", integration.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Sync continues the integration's sync from its checkpoint for up to procoreSyncBudget.
+// The error, if any, is also kept as the checkpoint's last_error.
+func (s *ProcoreSyncService) Sync(ctx context.Context, integration *model.OrganizationIntegration) error {
+	state, err := s.syncRepo.Claim(ctx, integration, procoreSyncLease)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return ErrIntegrationSyncInProgress
+	}
+
+	syncErr := s.resume(ctx, integration, state)
+
+	state.LockedUntil = nil
+	state.LastError = nil
+	if syncErr != nil {
+		state.LastError = truncatedText(syncErr.Error(), maxWebhookErrorChars)
+	}
+	// Release the lease even if the run was cancelled
+	if err := s.syncRepo.SaveCheckpoint(context.WithoutCancel(ctx), state); err != nil {
+		return errors.Join(syncErr, err)
+	}
+	return syncErr
+}
+
+func (s *ProcoreSyncService) resume(ctx context.Context, integration *model.OrganizationIntegration, state *model.IntegrationSyncState) error {
+	settings, err := ParseProcoreSettings(integration.Settings, s.policy)
+	if err != nil {
+		return err
+	}
+	if integration.APIKey == nil || integration.APISecret == nil {
+		return fmt.Errorf("%w: api_key and api_secret (the Procore client id and secret) are required", ErrInvalidIntegration)
+	}
+
+	if state.RemoteScope != settings.remoteScope() {
+		// Pointed at another project: the links and the position are meaningless there
+		if err := s.syncRepo.DeleteLinks(ctx, integration.ID); err != nil {
+			return err
+		}
+		state.RemoteScope = settings.remoteScope()
+		state.Phase = model.SyncPhasePullEquipment
+		state.RemotePage = 1
+		state.EquipmentCursorAt, state.EquipmentCursorID = nil, nil
+		state.CycleStartedAt = nil
+	}
+
+	run := &procoreSyncRun{
+		service:     s,
+		integration: integration,
+		settings:    settings,
+		state:       state,
+		client:      procore.NewClient(s.httpClient, settings.BaseURL, settings.CompanyID, *integration.APIKey, *integration.APISecret),
+		deadline:    time.Now().Add(procoreSyncBudget),
+		counts:      make(map[string]int),
+	}
+	return run.resume(ctx)
+}
+
+// GetSyncState returns the sync checkpoint of a procore integration.
+func (s *ProcoreSyncService) GetSyncState(ctx context.Context, organizationID, integrationID uuid.UUID) (*IntegrationSyncView, error) {
+	integration, err := s.integrationRepo.FindByID(ctx, integrationID)
+	if err != nil {
+		return nil, err
+	}
+	if integration == nil || integration.OrganizationID != organizationID {
+		return nil, ErrIntegrationNotFound
+	}
+	if integration.IntegrationType != model.IntegrationTypeProcore {
+		return nil, fmt.Errorf("%w: %s integrations do not sync equipment", ErrInvalidIntegration, integration.IntegrationType)
+	}
+
+	state, err := s.syncRepo.FindState(ctx, integrationID)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		// Not picked up by the sync job yet
+		return &IntegrationSyncView{IntegrationID: integrationID, Phase: model.SyncPhasePullEquipment, RemotePage: 1, CycleCounts: json.RawMessage("{}")}, nil
+	}
+
+	return &IntegrationSyncView{
+		IntegrationID:       state.IntegrationID,
+		Running:             state.LockedUntil != nil && state.LockedUntil.After(time.Now()),
+		Phase:               state.Phase,
+		RemotePage:          state.RemotePage,
+		EquipmentCursorAt:   state.EquipmentCursorAt,
+		MaintenanceCursorAt: &state.MaintenanceCursorAt,
+		CycleStartedAt:      state.CycleStartedAt,
+		CycleCounts:         state.CycleCounts,
+		LastCompletedAt:     state.LastCompletedAt,
+		LastError:           state.LastError,
+	}, nil
+}
+
+// procoreSyncRun is one run of an integration's sync.
+type procoreSyncRun struct {
+	service     *ProcoreSyncService
+	integration *model.OrganizationIntegration
+	settings    *ProcoreSettings
+	state       *model.IntegrationSyncState
+	client      *procore.Client
+	deadline    time.Time
+	counts      map[string]int
+}
+
+// resume runs the cycle's phases from the checkpoint until the cycle completes or the
+// budget is spent.
+func (r *procoreSyncRun) resume(ctx context.Context) error {
+	if r.state.CycleStartedAt == nil {
+		now := time.Now()
+		r.state.CycleStartedAt = &now
+		r.state.Phase = model.SyncPhasePullEquipment
+		r.state.RemotePage = 1
+	} else if len(r.state.CycleCounts) > 0 {
+		if err := json.Unmarshal(r.state.CycleCounts, &r.counts); err != nil {
+			return err
+		}
+	}
+
+	for time.Now().Before(r.deadline) {
+		var done bool
+		var err error
+		switch r.state.Phase {
+		case model.SyncPhasePullEquipment:
+			if done, err = r.pullPage(ctx); done {
+				r.state.Phase = model.SyncPhasePushEquipment
+				r.state.RemotePage = 1
+				r.rewindEquipmentCursor()
+			}
+		case model.SyncPhasePushEquipment:
+			if done, err = r.pushEquipment(ctx); done {
+				r.state.Phase = model.SyncPhasePushMaintenance
+			}
+		case model.SyncPhasePushMaintenance:
+			if done, err = r.pushMaintenance(ctx); done {
+				now := time.Now()
+				r.state.Phase = model.SyncPhasePullEquipment
+				r.state.CycleStartedAt = nil
+				r.state.LastCompletedAt = &now
+			}
+		default:
+			return fmt.Errorf("unknown sync phase %q", r.state.Phase)
+		}
+		if err != nil {
+			return err
+		}
+		if err := r.checkpoint(ctx); err != nil {
+			return err
+		}
+		if r.state.CycleStartedAt == nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *procoreSyncRun) checkpoint(ctx context.Context) error {
+	counts, err := json.Marshal(r.counts)
+	if err != nil {
+		return err
+	}
+	r.state.CycleCounts = counts
+	return r.service.syncRepo.SaveCheckpoint(ctx, r.state)
+}
+
+// pullPage pulls the checkpoint's remote page and reports whether it was the last. A page
+// is pulled again if the run stops part way; pulling is idempotent.
+func (r *procoreSyncRun) pullPage(ctx context.Context) (bool, error) {
+	page, err := r.client.ListEquipment(ctx, r.settings.ProjectID, r.state.RemotePage, procorePageSize)
+	if err != nil {
+		return false, err
+	}
+	for _, remote := range page {
+		if err := r.pullEquipment(ctx, remote); err != nil {
+			return false, err
+		}
+	}
+	if len(page) < procorePageSize {
+		return true, nil
+	}
+	r.state.RemotePage++
+	return false, nil
+}
+
+// pullEquipment links a remote record to the local equipment with its serial number, if
+// it is not linked yet, and applies the pulled fields that changed remotely since they
+// were last seen.
+func (r *procoreSyncRun) pullEquipment(ctx context.Context, remote procore.Equipment) error {
+	externalID := remote.ID()
+	if externalID == 0 {
+		return nil
+	}
+	r.counts["pulled"]++
+
+	link, err := r.service.syncRepo.FindLinkByExternalID(ctx, r.integration.ID, externalID)
+	if err != nil {
+		return err
+	}
+	var equipment *model.Equipment
+	if link != nil {
+		if equipment, err = r.service.equipmentRepo.FindByID(ctx, link.EquipmentID); err != nil || equipment == nil {
+			return err
+		}
+	} else {
+		serialNumber, _ := remote.Value(r.settings.SerialNumberField)
+		serialNumber = strings.TrimSpace(serialNumber)
+		if serialNumber != "" {
+			if equipment, err = r.service.equipmentRepo.FindBySerialNumber(ctx, r.integration.OrganizationID, serialNumber); err != nil {
+				return err
+			}
+		}
+		if equipment == nil {
+			r.counts["unmatched"]++
+			return nil
+		}
+
+		now := time.Now()
+		link = &model.IntegrationEquipmentLink{
+			ID:             uuid.New(),
+			OrganizationID: r.integration.OrganizationID,
+			IntegrationID:  r.integration.ID,
+			EquipmentID:    equipment.ID,
+			ExternalID:     externalID,
+			RemoteValues:   json.RawMessage("{}"),
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		created, err := r.service.syncRepo.CreateLink(ctx, link)
+		if err != nil {
+			return err
+		}
+		if !created {
+			// Another remote record with the same serial number is linked already
+			r.counts["duplicates"]++
+			return nil
+		}
+		r.counts["linked"]++
+	}
+
+	var seen map[string]*string
+	if err := json.Unmarshal(link.RemoteValues, &seen); err != nil {
+		return err
+	}
+	current := make(map[string]*string)
+	updates := make(map[string]interface{})
+	before := make(map[string]interface{})
+	for _, mapping := range r.settings.FieldMapping {
+		if mapping.Direction != SyncDirectionPull {
+			continue
+		}
+		var value *string
+		if text, ok := remote.Value(mapping.Remote); ok {
+			if text = strings.TrimSpace(text); text != "" {
+				value = &text
+			}
+		}
+		current[mapping.Local] = value
+		if previous, ok := seen[mapping.Local]; ok && optionalString(previous) == optionalString(value) {
+			continue
+		}
+
+		field := procoreLocalFields[mapping.Local]
+		if (field.required && value == nil) || (value != nil && field.maxChars > 0 && len([]rune(*value)) > field.maxChars) {
+			r.counts["rejected_values"]++
+			continue
+		}
+		if old, changed := setEquipmentField(equipment, mapping.Local, value); changed {
+			before[mapping.Local] = old
+			updates[mapping.Local] = value
+		}
+	}
+
+	if link.RemoteValues, err = json.Marshal(current); err != nil {
+		return err
+	}
+	err = r.service.syncRepo.ApplyPull(ctx, link, updates, func(tx *gorm.DB) error {
+		return r.service.bus.Publish(ctx, tx, equipment.OrganizationID, events.EquipmentUpdated, equipmentEventData(equipment))
+	})
+	if err != nil {
+		return err
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	r.counts["pulled_updates"]++
+	after := map[string]interface{}{"integration_id": r.integration.ID}
+	for field, value := range updates {
+		after[field] = value
+	}
+	if err := r.service.auditService.Record(ctx, AuditEntry{
+		OrganizationID: equipment.OrganizationID,
+		EntityType:     "equipment",
+		EntityID:       equipment.ID,
+		Action:         AuditActionUpdate,
+		Before:         before,
+		After:          after,
+	}); err != nil {
+		log.Printf("failed to audit procore sync of equipment %s: %v", equipment.ID, err)
+	}
+	return nil
+}
+
+// pushEquipment pushes a batch of equipment changed after the checkpoint and reports
+// whether it was the last.
+func (r *procoreSyncRun) pushEquipment(ctx context.Context) (bool, error) {
+	batch, err := r.service.syncRepo.FindEquipmentAfter(ctx, r.integration.OrganizationID, r.state.EquipmentCursorAt, r.state.EquipmentCursorID, procoreBatchSize)
+	if err != nil {
+		return false, err
+	}
+	for _, equipment := range batch {
+		if !time.Now().Before(r.deadline) {
+			return false, nil
+		}
+		if err := r.pushOne(ctx, equipment); err != nil {
+			return false, err
+		}
+		r.state.EquipmentCursorAt, r.state.EquipmentCursorID = &equipment.UpdatedAt, &equipment.ID
+		if err := r.checkpoint(ctx); err != nil {
+			return false, err
+		}
+	}
+	return len(batch) < procoreBatchSize, nil
+}
+
+// rewindEquipmentCursor moves the push cursor back by procoreCommitOverlap, so that
+// equipment committed behind it since the last cycle is pushed too.
+func (r *procoreSyncRun) rewindEquipmentCursor() {
+	if r.state.EquipmentCursorAt == nil {
+		return
+	}
+	cursorAt := r.state.EquipmentCursorAt.Add(-procoreCommitOverlap)
+	cursorID := uuid.Nil
+	r.state.EquipmentCursorAt, r.state.EquipmentCursorID = &cursorAt, &cursorID
+}
+
+// pushOne writes the pushed fields of linked equipment to its remote record. Unlinked
+// equipment is created remotely if the settings ask for it.
+func (r *procoreSyncRun) pushOne(ctx context.Context, equipment *model.Equipment) error {
+	fields := make(map[string]interface{})
+	for _, mapping := range r.settings.FieldMapping {
+		if mapping.Direction == SyncDirectionPush {
+			fields[mapping.Remote] = equipmentField(equipment, mapping.Local)
+		}
+	}
+
+	link, err := r.service.syncRepo.FindLinkByEquipmentID(ctx, r.integration.ID, equipment.ID)
+	if err != nil {
+		return err
+	}
+	if link == nil {
+		if !r.settings.CreateRemote {
+			return nil
+		}
+		fields[r.settings.SerialNumberField] = equipment.SerialNumber
+		if _, ok := fields["name"]; !ok {
+			fields["name"] = equipment.Make + " " + equipment.Model
+		}
+		created, err := r.client.CreateEquipment(ctx, r.settings.ProjectID, fields)
+		if rejected(err) {
+			r.counts["rejected"]++
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if created.ID() == 0 {
+			return fmt.Errorf("procore created equipment without an id")
+		}
+
+		now := time.Now()
+		if _, err := r.service.syncRepo.CreateLink(ctx, &model.IntegrationEquipmentLink{
+			ID:             uuid.New(),
+			OrganizationID: r.integration.OrganizationID,
+			IntegrationID:  r.integration.ID,
+			EquipmentID:    equipment.ID,
+			ExternalID:     created.ID(),
+			RemoteValues:   json.RawMessage("{}"),
+			PushedAt:       &now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}); err != nil {
+			return err
+		}
+		r.counts["created"]++
+		return nil
+	}
+	// Pushed after any transaction that changed it committed, e.g. in the previous cycle
+	if len(fields) == 0 || (link.PushedAt != nil && link.PushedAt.After(equipment.UpdatedAt.Add(procoreCommitOverlap))) {
+		return nil
+	}
+
+	_, err = r.client.UpdateEquipment(ctx, r.settings.ProjectID, link.ExternalID, fields)
+	var apiErr *procore.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		// Deleted in Procore; the next pull can match the equipment again
+		r.counts["unlinked"]++
+		return r.service.syncRepo.DeleteLink(ctx, link.ID)
+	}
+	if rejected(err) {
+		r.counts["rejected"]++
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	r.counts["pushed"]++
+	return r.service.syncRepo.MarkPushed(ctx, link.ID)
+}
+
+// pushMaintenance pushes a batch of maintenance confirmed after the checkpoint as
+// equipment log entries and reports whether it was the last. An entry is pushed again if
+// the run stops between the call and the checkpoint.
+func (r *procoreSyncRun) pushMaintenance(ctx context.Context) (bool, error) {
+	records, err := r.service.syncRepo.FindConfirmedMaintenanceAfter(ctx, r.integration.ID, r.state.MaintenanceCursorAt, r.state.MaintenanceCursorID, procoreBatchSize)
+	if err != nil {
+		return false, err
+	}
+	for _, record := range records {
+		if !time.Now().Before(r.deadline) {
+			return false, nil
+		}
+
+		notes := fmt.Sprintf("%s confirmed in EquipChain (maintenance record %s)", record.MaintenanceType, record.ID)
+		if record.Notes != nil && strings.TrimSpace(*record.Notes) != "" {
+			notes += ": " + strings.TrimSpace(*record.Notes)
+		}
+		_, err := r.client.CreateEquipmentLog(ctx, r.settings.ProjectID, procore.EquipmentLog{
+			ManagedEquipmentID: record.ExternalID,
+			Date:               record.ConfirmedAt.UTC().Format("2006-01-02"),
+			Notes:              *truncatedText(notes, maxProcoreLogNotesChars),
+		})
+		if rejected(err) {
+			r.counts["rejected"]++
+		} else if err != nil {
+			return false, err
+		} else {
+			r.counts["equipment_logs"]++
+		}
+
+		r.state.MaintenanceCursorAt, r.state.MaintenanceCursorID = record.ConfirmedAt, &record.ID
+		if err := r.checkpoint(ctx); err != nil {
+			return false, err
+		}
+	}
+	return len(records) < procoreBatchSize, nil
+}
+
+// rejected reports whether Procore refused an item as invalid. Such items are skipped,
+// since retrying them cannot succeed; other errors stop the run and are retried.
+func rejected(err error) bool {
+	var apiErr *procore.APIError
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusBadRequest || apiErr.StatusCode == http.StatusUnprocessableEntity)
+}
+
+// equipmentField returns a mappable field of the equipment; nil for an unset optional one.
+func equipmentField(equipment *model.Equipment, field string) *string {
+	switch field {
+	case "make":
+		return &equipment.Make
+	case "model":
+		return &equipment.Model
+	case "location":
+		return equipment.Location
+	case "notes":
+		return equipment.Notes
+	}
+	return nil
+}
+
+// setEquipmentField sets a mappable field of the equipment and returns its old value and
+// whether it changed.
+func setEquipmentField(equipment *model.Equipment, field string, value *string) (*string, bool) {
+	old := equipmentField(equipment, field)
+	if optionalString(old) == optionalString(value) && (old == nil) == (value == nil) {
+		return old, false
+	}
+	if old != nil {
+		copied := *old
+		old = &copied
+	}
+	switch field {
+	case "make":
+		equipment.Make = *value
+	case "model":
+		equipment.Model = *value
+	case "location":
+		equipment.Location = value
+	case "notes":
+		equipment.Notes = value
+	}
+	return old, true
+}
@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/envelope"
+	"github.com/NWhite12/EquipChain/internal/events"
+	"github.com/NWhite12/EquipChain/internal/mockprocore"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/NWhite12/EquipChain/internal/testdb"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type procoreTestEnv struct {
+	db           *gorm.DB
+	service      *ProcoreSyncService
+	remote       *mockprocore.Server
+	organization *model.Organization
+	integration  *model.OrganizationIntegration
+
+	mu sync.Mutex
+	// requests counts the calls to each path and query; failing answers the matching
+	// call with a 503 once, as a Procore outage in the middle of a run would.
+	requests map[string]int
+	failing  string
+}
+
+// newProcoreTestEnv starts a mock Procore project and connects a new organization's
+// procore integration to it. The serial number is matched on the remote "serial" field;
+// make and model are pushed (model as "model_number"), location and notes pulled (notes
+// from "description").
+func newProcoreTestEnv(t *testing.T) *procoreTestEnv {
+	t.Helper()
+	db := testdb.Open(t)
+	env := &procoreTestEnv{
+		db:           db,
+		remote:       mockprocore.New("equipchain", "equipchain-secret", 7, 42),
+		organization: testdb.CreateOrganization(t, db),
+		requests:     make(map[string]int),
+	}
+
+	handler := env.remote.Handler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := r.Method + " " + r.URL.RequestURI()
+		env.mu.Lock()
+		env.requests[call]++
+		failing := env.failing != "" && call == env.failing
+		if failing {
+			env.failing = ""
+		}
+		env.mu.Unlock()
+		if failing {
+			http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	settings, err := json.Marshal(map[string]interface{}{
+		"base_url":            server.URL,
+		"company_id":          env.remote.CompanyID,
+		"project_id":          env.remote.ProjectID,
+		"serial_number_field": "serial",
+		"field_mapping": []ProcoreFieldMapping{
+			{Local: "make", Remote: "make", Direction: SyncDirectionPush},
+			{Local: "model", Remote: "model_number", Direction: SyncDirectionPush},
+			{Local: "location", Remote: "location", Direction: SyncDirectionPull},
+			{Local: "notes", Remote: "description", Direction: SyncDirectionPull},
+		},
+	})
+	if err != nil {
+		t.Fatalf("settings: %v", err)
+	}
+	now := time.Now()
+	env.integration = &model.OrganizationIntegration{
+		ID:              uuid.New(),
+		OrganizationID:  env.organization.ID,
+		IntegrationType: model.IntegrationTypeProcore,
+		APIKey:          &env.remote.ClientID,
+		APISecret:       &env.remote.ClientSecret,
+		EventTypes:      json.RawMessage("[]"),
+		Settings:        settings,
+		IsActive:        true,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	cipher, err := envelope.New(map[int][]byte{1: make([]byte, envelope.KeySize)}, 1)
+	if err != nil {
+		t.Fatalf("cipher: %v", err)
+	}
+	integrationRepo := repository.NewIntegrationRepository(db, cipher)
+	if err := integrationRepo.Create(context.Background(), env.integration); err != nil {
+		t.Fatalf("create integration: %v", err)
+	}
+	env.service = NewProcoreSyncService(integrationRepo, repository.NewIntegrationSyncRepository(db), repository.NewEquipmentRepository(db),
+		NewAuditService(repository.NewAuditRepository(db)), events.NewBus(), OutboundPolicy{AllowHTTP: true, AllowPrivate: true})
+	return env
+}
+
+func (env *procoreTestEnv) calls(call string) int {
+	env.mu.Lock()
+	defer env.mu.Unlock()
+	return env.requests[call]
+}
+
+func (env *procoreTestEnv) failOnce(call string) {
+	env.mu.Lock()
+	defer env.mu.Unlock()
+	env.failing = call
+}
+
+func (env *procoreTestEnv) listCall(page int) string {
+	return fmt.Sprintf("GET /rest/v1.0/projects/%d/managed_equipment?page=%d&per_page=%d", env.remote.ProjectID, page, procorePageSize)
+}
+
+// sync runs the integration's sync and returns its checkpoint.
+func (env *procoreTestEnv) sync(t *testing.T) (*model.IntegrationSyncState, error) {
+	t.Helper()
+	syncErr := env.service.Sync(context.Background(), env.integration)
+	state, err := env.service.syncRepo.FindState(context.Background(), env.integration.ID)
+	if err != nil || state == nil {
+		t.Fatalf("sync state: %v, %v", state, err)
+	}
+	return state, syncErr
+}
+
+func (env *procoreTestEnv) reloadEquipment(t *testing.T, id uuid.UUID) *model.Equipment {
+	t.Helper()
+	var equipment model.Equipment
+	if err := env.db.Where("id = ?", id).First(&equipment).Error; err != nil {
+		t.Fatalf("reload equipment: %v", err)
+	}
+	return &equipment
+}
+
+func cycleCounts(t *testing.T, state *model.IntegrationSyncState) map[string]int {
+	t.Helper()
+	var counts map[string]int
+	if err := json.Unmarshal(state.CycleCounts, &counts); err != nil {
+		t.Fatalf("cycle counts %s: %v", state.CycleCounts, err)
+	}
+	return counts
+}
+
+func TestProcoreSyncMatchesSerialNumbersAndMapsFields(t *testing.T) {
+	env := newProcoreTestEnv(t)
+	excavator := testdb.CreateEquipment(t, env.db, env.organization.ID)
+	loader := testdb.CreateEquipment(t, env.db, env.organization.ID)
+	localOnly := testdb.CreateEquipment(t, env.db, env.organization.ID)
+
+	excavatorID := env.remote.AddEquipment(map[string]interface{}{
+		"name": "Excavator", "serial": excavator.SerialNumber, "location": "Yard 3", "description": "Leased from Acme",
+		// Not mapped: the serial_number field is not the configured match key
+		"serial_number": loader.SerialNumber,
+	})
+	// Matched with surrounding whitespace; a blank location is not a value
+	loaderID := env.remote.AddEquipment(map[string]interface{}{"name": "Loader", "serial": " " + loader.SerialNumber + " ", "location": "  "})
+	env.remote.AddEquipment(map[string]interface{}{"name": "Crane", "serial": "SN-UNKNOWN"})
+	env.remote.AddEquipment(map[string]interface{}{"name": "Excavator copy", "serial": excavator.SerialNumber})
+
+	state, err := env.sync(t)
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if state.CycleStartedAt != nil || state.LastCompletedAt == nil || state.LastError != nil {
+		t.Fatalf("cycle not completed: started %v, completed %v, error %v", state.CycleStartedAt, state.LastCompletedAt, state.LastError)
+	}
+	counts := cycleCounts(t, state)
+	if counts["pulled"] != 4 || counts["linked"] != 2 || counts["unmatched"] != 1 || counts["duplicates"] != 1 || counts["pushed"] != 2 {
+		t.Fatalf("cycle counts %v, want 4 pulled, 2 linked, 1 unmatched, 1 duplicate and 2 pushed", counts)
+	}
+
+	// Pulled fields
+	pulled := env.reloadEquipment(t, excavator.ID)
+	if optionalString(pulled.Location) != "Yard 3" || optionalString(pulled.Notes) != "Leased from Acme" {
+		t.Fatalf("excavator location %v, notes %v, want the Procore values", pulled.Location, pulled.Notes)
+	}
+	if pulled.UpdatedBy != nil {
+		t.Fatalf("pulled equipment updated_by %v, want none", pulled.UpdatedBy)
+	}
+	if loader := env.reloadEquipment(t, loader.ID); loader.Location != nil {
+		t.Fatalf("loader location %q, want a blank remote value ignored", *loader.Location)
+	}
+
+	// Pushed fields, under their remote names
+	for _, id := range []int64{excavatorID, loaderID} {
+		remote := env.remote.Equipment(id)
+		if remote["make"] != "Caterpillar" || remote["model_number"] != "320" {
+			t.Fatalf("remote equipment %d: make %v, model_number %v, want the local values", id, remote["make"], remote["model_number"])
+		}
+		if _, ok := remote["model"]; ok {
+			t.Fatalf("remote equipment %d has a model field, want it pushed as model_number", id)
+		}
+	}
+
+	// Unmatched local equipment is not created remotely without create_remote
+	var links int64
+	if err := env.db.Model(&model.IntegrationEquipmentLink{}).Where("equipment_id = ?", localOnly.ID).Count(&links).Error; err != nil || links != 0 {
+		t.Fatalf("local-only equipment has %d links (%v), want none", links, err)
+	}
+
+	// A local edit of a pulled field survives until Procore changes it again
+	if err := env.db.Model(&model.Equipment{}).Where("id = ?", excavator.ID).Update("location", "Workshop").Error; err != nil {
+		t.Fatalf("local edit: %v", err)
+	}
+	if _, err := env.sync(t); err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if location := optionalString(env.reloadEquipment(t, excavator.ID).Location); location != "Workshop" {
+		t.Fatalf("location %q after an unchanged remote value, want the local edit kept", location)
+	}
+
+	env.remote.SetEquipment(excavatorID, map[string]interface{}{"location": "Site B"})
+	if _, err := env.sync(t); err != nil {
+		t.Fatalf("third sync: %v", err)
+	}
+	if location := optionalString(env.reloadEquipment(t, excavator.ID).Location); location != "Site B" {
+		t.Fatalf("location %q after a remote change, want Site B", location)
+	}
+}
+
+func TestProcoreSyncResumesFromCheckpointAfterFailure(t *testing.T) {
+	env := newProcoreTestEnv(t)
+	equipment := testdb.CreateEquipment(t, env.db, env.organization.ID)
+
+	// A full first page of unknown equipment, then the local equipment on the second page
+	for i := 0; i < procorePageSize; i++ {
+		env.remote.AddEquipment(map[string]interface{}{"name": fmt.Sprintf("Unknown %d", i), "serial": fmt.Sprintf("SN-REMOTE-%03d", i)})
+	}
+	env.remote.AddEquipment(map[string]interface{}{"name": "Excavator", "serial": equipment.SerialNumber, "location": "Yard 3"})
+
+	env.failOnce(env.listCall(2))
+	state, err := env.sync(t)
+	if err == nil {
+		t.Fatal("sync succeeded although Procore failed")
+	}
+	if state.Phase != model.SyncPhasePullEquipment || state.RemotePage != 2 || state.CycleStartedAt == nil {
+		t.Fatalf("checkpoint phase %s, page %d, cycle started %v, want the second page of the pull", state.Phase, state.RemotePage, state.CycleStartedAt)
+	}
+	if state.LastError == nil || !strings.Contains(*state.LastError, "503") {
+		t.Fatalf("last error %v, want the Procore failure", state.LastError)
+	}
+	if state.LockedUntil != nil {
+		t.Fatalf("lease held until %s after the failed run", state.LockedUntil)
+	}
+	if counts := cycleCounts(t, state); counts["pulled"] != procorePageSize {
+		t.Fatalf("cycle counts %v, want the first page counted", counts)
+	}
+
+	state, err = env.sync(t)
+	if err != nil {
+		t.Fatalf("resumed sync: %v", err)
+	}
+	if state.LastCompletedAt == nil || state.LastError != nil {
+		t.Fatalf("cycle not completed: completed %v, error %v", state.LastCompletedAt, state.LastError)
+	}
+	if calls := env.calls(env.listCall(1)); calls != 1 {
+		t.Fatalf("first page requested %d times, want once: the run resumes from its checkpoint", calls)
+	}
+	if counts := cycleCounts(t, state); counts["pulled"] != procorePageSize+1 || counts["linked"] != 1 {
+		t.Fatalf("cycle counts %v, want %d pulled and 1 linked over both runs", counts, procorePageSize+1)
+	}
+	if location := optionalString(env.reloadEquipment(t, equipment.ID).Location); location != "Yard 3" {
+		t.Fatalf("location %q, want the value from the second page", location)
+	}
+}
+
+func TestProcoreSyncPushesConfirmedMaintenanceAsEquipmentLogs(t *testing.T) {
+	env := newProcoreTestEnv(t)
+	equipment := testdb.CreateEquipment(t, env.db, env.organization.ID)
+	technician := testdb.CreateUser(t, env.db, env.organization.ID, model.RoleTechnician)
+	supervisor := testdb.CreateUser(t, env.db, env.organization.ID, model.RoleSupervisor)
+	remoteID := env.remote.AddEquipment(map[string]interface{}{"name": "Excavator", "serial": equipment.SerialNumber})
+
+	// Maintenance confirmed after the integration was set up is pushed
+	if _, err := env.sync(t); err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	record := createApprovedRecord(t, env.db, env.organization.ID, equipment.ID, technician.ID, supervisor.ID)
+	confirmed, err := newTestMaintenanceService(env.db, events.NewBus()).ConfirmRecord(context.Background(), env.organization.ID, record.ID, supervisor.ID, testTransactionSignature)
+	if err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+
+	state, err := env.sync(t)
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	logs := env.remote.EquipmentLogs()
+	if len(logs) != 1 {
+		t.Fatalf("%d equipment logs, want 1", len(logs))
+	}
+	entry := logs[0]
+	if id, _ := entry["managed_equipment_id"].(float64); int64(id) != remoteID {
+		t.Fatalf("log of managed equipment %v, want %d", entry["managed_equipment_id"], remoteID)
+	}
+	if entry["date"] != confirmed.ConfirmedAt.UTC().Format("2006-01-02") {
+		t.Fatalf("log date %v, want the confirmation date", entry["date"])
+	}
+	notes, _ := entry["notes"].(string)
+	if !strings.Contains(notes, record.ID.String()) || !strings.HasSuffix(notes, ": Replaced hydraulic filters") {
+		t.Fatalf("log notes %q, want the record id and its notes", notes)
+	}
+	if counts := cycleCounts(t, state); counts["equipment_logs"] != 1 {
+		t.Fatalf("cycle counts %v, want 1 equipment log", counts)
+	}
+	if state.MaintenanceCursorID == nil || *state.MaintenanceCursorID != record.ID {
+		t.Fatalf("maintenance cursor %v, want the pushed record", state.MaintenanceCursorID)
+	}
+
+	// The cursor keeps the entry from being pushed twice
+	if _, err := env.sync(t); err != nil {
+		t.Fatalf("third sync: %v", err)
+	}
+	if logs := env.remote.EquipmentLogs(); len(logs) != 1 {
+		t.Fatalf("%d equipment logs after another sync, want 1", len(logs))
+	}
+}
+
+func TestProcoreSyncPushesEquipmentCommittedBehindTheCursor(t *testing.T) {
+	env := newProcoreTestEnv(t)
+	early := testdb.CreateEquipment(t, env.db, env.organization.ID)
+	later := testdb.CreateEquipment(t, env.db, env.organization.ID)
+	earlyID := env.remote.AddEquipment(map[string]interface{}{"name": "Excavator", "serial": early.SerialNumber})
+	env.remote.AddEquipment(map[string]interface{}{"name": "Loader", "serial": later.SerialNumber})
+	if _, err := env.sync(t); err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+
+	// updated_at is the transaction's start time: the early change commits after a later
+	// one has moved the cursor past it
+	tx := env.db.Begin()
+	defer tx.Rollback()
+	if err := tx.Model(&model.Equipment{}).Where("id = ?", early.ID).Update("make", "Komatsu").Error; err != nil {
+		t.Fatalf("early change: %v", err)
+	}
+	if err := env.db.Model(&model.Equipment{}).Where("id = ?", later.ID).Update("model", "950M").Error; err != nil {
+		t.Fatalf("later change: %v", err)
+	}
+	state, err := env.sync(t)
+	if err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if state.EquipmentCursorID == nil || *state.EquipmentCursorID != later.ID {
+		t.Fatalf("equipment cursor %v, want the later change", state.EquipmentCursorID)
+	}
+	if remoteMake := env.remote.Equipment(earlyID)["make"]; remoteMake != "Caterpillar" {
+		t.Fatalf("remote make %v before the early change committed", remoteMake)
+	}
+	if err := tx.Commit().Error; err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	if _, err := env.sync(t); err != nil {
+		t.Fatalf("third sync: %v", err)
+	}
+	if remoteMake := env.remote.Equipment(earlyID)["make"]; remoteMake != "Komatsu" {
+		t.Fatalf("remote make %v, want the change committed behind the cursor pushed", remoteMake)
+	}
+}
@@ -0,0 +1,443 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/NWhite12/EquipChain/internal/scheduling"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultDueWindow         = 30
+	maxDueWindow             = 365
+	maxScheduleFrequencyDays = 3650
+	maxMeterValue            = 99999999999.9
+)
+
+var meterTypes = map[string]bool{
+	model.MeterTypeHours:  true,
+	model.MeterTypeMiles:  true,
+	model.MeterTypeCycles: true,
+}
+
+// CreateScheduleInput starts a recurring schedule with at least one trigger: a fixed
+// frequency in days, an RRULE-style calendar rule (anchored at calendar_rule_start,
+// default today) and/or a meter interval counted from last_service_meter_value (default
+// the latest reading). With several triggers the schedule is due at
+// whichever comes first. next_due_date overrides the computed first due date. Dates use
+// YYYY-MM-DD.
+type CreateScheduleInput struct {
+	EquipmentID            uuid.UUID `json:"equipment_id"`
+	MaintenanceTypeID      int16     `json:"maintenance_type_id"`
+	ScheduledFrequencyDays *int      `json:"scheduled_frequency_days"`
+	CalendarRule           *string   `json:"calendar_rule"`
+	CalendarRuleStart      *string   `json:"calendar_rule_start"`
+	MeterType              *string   `json:"meter_type"`
+	MeterInterval          *float64  `json:"meter_interval"`
+	LastMaintenanceDate    *string   `json:"last_maintenance_date"`
+	LastServiceMeterValue  *float64  `json:"last_service_meter_value"`
+	NextDueDate            *string   `json:"next_due_date"`
+}
+
+// UpdateScheduleInput reschedules the next occurrence. Triggers are immutable (see
+// equipment_maintenance_schedule.scheduled_frequency_days); recreate the schedule to
+// change them.
+type UpdateScheduleInput struct {
+	NextDueDate *string `json:"next_due_date"`
+}
+
+type ScheduleView struct {
+	ID                     uuid.UUID `json:"id"`
+	EquipmentID            uuid.UUID `json:"equipment_id"`
+	MaintenanceTypeID      int16     `json:"maintenance_type_id"`
+	ScheduledFrequencyDays *int      `json:"scheduled_frequency_days"`
+	CalendarRule           *string   `json:"calendar_rule"`
+	CalendarRuleStart      *string   `json:"calendar_rule_start"`
+	MeterType              *string   `json:"meter_type"`
+	MeterInterval          *float64  `json:"meter_interval"`
+	LastMaintenanceDate    *string   `json:"last_maintenance_date"`
+	LastServiceMeterValue  *float64  `json:"last_service_meter_value"`
+	NextDueDate            *string   `json:"next_due_date"`
+	NextDueMeterValue      *float64  `json:"next_due_meter_value"`
+	NextDueTrigger         *string   `json:"next_due_trigger"`
+	IsOverdue              bool      `json:"is_overdue"`
+	CreatedAt              time.Time `json:"created_at"`
+	UpdatedAt              time.Time `json:"updated_at"`
+}
+
+type DueMaintenanceView struct {
+	ScheduleID        uuid.UUID `json:"schedule_id"`
+	EquipmentID       uuid.UUID `json:"equipment_id"`
+	EquipmentName     string    `json:"equipment_name"`
+	MaintenanceTypeID int16     `json:"maintenance_type_id"`
+	MaintenanceType   string    `json:"maintenance_type"`
+	NextDueDate       string    `json:"next_due_date"`
+	DaysUntilDue      int       `json:"days_until_due"`
+	IsOverdue         bool      `json:"is_overdue"`
+}
+
+// ScheduleService manages preventive maintenance schedules (equipment_maintenance_schedule).
+// Due dates are computed by the scheduling package; schedules roll forward when a
+// matching record is confirmed, see MaintenanceService.ConfirmRecord.
+type ScheduleService struct {
+	scheduleRepo    *repository.ScheduleRepository
+	equipmentRepo   *repository.EquipmentRepository
+	maintenanceRepo *repository.MaintenanceRepository
+	meterService    *MeterService
+	auditService    *AuditService
+}
+
+func NewScheduleService(scheduleRepo *repository.ScheduleRepository, equipmentRepo *repository.EquipmentRepository, maintenanceRepo *repository.MaintenanceRepository, meterService *MeterService, auditService *AuditService) *ScheduleService {
+	return &ScheduleService{
+		scheduleRepo:    scheduleRepo,
+		equipmentRepo:   equipmentRepo,
+		maintenanceRepo: maintenanceRepo,
+		meterService:    meterService,
+		auditService:    auditService,
+	}
+}
+
+func (s *ScheduleService) ListSchedules(ctx context.Context, organizationID uuid.UUID, filters map[string]interface{}) ([]ScheduleView, error) {
+	schedules, err := s.scheduleRepo.FindByOrganizationID(ctx, organizationID, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]ScheduleView, 0, len(schedules))
+	for _, schedule := range schedules {
+		views = append(views, newScheduleView(schedule))
+	}
+	return views, nil
+}
+
+func (s *ScheduleService) GetSchedule(ctx context.Context, organizationID, scheduleID uuid.UUID) (*ScheduleView, error) {
+	schedule, err := s.findSchedule(ctx, organizationID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	view := newScheduleView(schedule)
+	return &view, nil
+}
+
+func (s *ScheduleService) CreateSchedule(ctx context.Context, organizationID, actorID uuid.UUID, input CreateScheduleInput, meta RequestMetadata) (*ScheduleView, error) {
+	now := time.Now()
+	schedule := &model.MaintenanceSchedule{
+		ID:                uuid.New(),
+		OrganizationID:    organizationID,
+		MaintenanceTypeID: input.MaintenanceTypeID,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		CreatedBy:         &actorID,
+		UpdatedBy:         &actorID,
+	}
+	if err := applyScheduleTriggers(schedule, input); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if input.LastMaintenanceDate != nil {
+		if schedule.LastMaintenanceDate, err = optionalDate(*input.LastMaintenanceDate); err != nil {
+			return nil, fmt.Errorf("%w: last_maintenance_date must be YYYY-MM-DD", ErrInvalidSchedule)
+		}
+	}
+	if input.LastServiceMeterValue != nil {
+		if schedule.MeterType == nil {
+			return nil, fmt.Errorf("%w: last_service_meter_value needs a meter trigger", ErrInvalidSchedule)
+		}
+		if *input.LastServiceMeterValue < 0 || *input.LastServiceMeterValue > maxMeterValue {
+			return nil, fmt.Errorf("%w: last_service_meter_value is out of range", ErrInvalidSchedule)
+		}
+		schedule.LastServiceMeterValue = input.LastServiceMeterValue
+	}
+
+	var nextDate *time.Time
+	if input.NextDueDate != nil {
+		if nextDate, err = optionalDate(*input.NextDueDate); err != nil {
+			return nil, fmt.Errorf("%w: next_due_date must be YYYY-MM-DD", ErrInvalidSchedule)
+		}
+	}
+
+	equipment, err := s.equipmentRepo.FindByID(ctx, input.EquipmentID)
+	if err != nil {
+		return nil, err
+	}
+	if equipment == nil || equipment.OrganizationID != organizationID {
+		return nil, ErrEquipmentNotFound
+	}
+	schedule.EquipmentID = equipment.ID
+	validType, err := s.maintenanceRepo.IsValidTypeID(ctx, input.MaintenanceTypeID)
+	if err != nil {
+		return nil, err
+	}
+	if !validType {
+		return nil, ErrInvalidMaintenanceType
+	}
+	existing, err := s.scheduleRepo.FindByEquipmentAndType(ctx, equipment.ID, input.MaintenanceTypeID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrScheduleExists
+	}
+
+	// Meter triggers count from the current reading unless a service reading is given
+	var meter *scheduling.MeterState
+	if schedule.MeterType != nil {
+		if meter, err = s.meterService.State(ctx, equipment.ID, *schedule.MeterType); err != nil {
+			return nil, err
+		}
+		if meter != nil && schedule.LastServiceMeterValue == nil {
+			value := meter.Value
+			schedule.LastServiceMeterValue = &value
+		}
+	}
+	due, err := nextScheduleDue(schedule, meter)
+	if err != nil {
+		return nil, err
+	}
+	applyScheduleDue(schedule, due)
+	if nextDate != nil {
+		schedule.NextDueDate = nextDate
+		schedule.NextDueTrigger = nil
+	}
+	if schedule.LastMaintenanceDate != nil && schedule.NextDueDate != nil && !schedule.NextDueDate.After(*schedule.LastMaintenanceDate) {
+		return nil, fmt.Errorf("%w: next_due_date must be after last_maintenance_date", ErrInvalidSchedule)
+	}
+
+	if err := s.scheduleRepo.Create(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	created, err := s.scheduleRepo.FindByID(ctx, schedule.ID)
+	if err != nil {
+		return nil, err
+	}
+	view := newScheduleView(created)
+	s.audit(ctx, organizationID, actorID, schedule.ID, AuditActionCreate, nil, view, meta)
+	return &view, nil
+}
+
+func (s *ScheduleService) UpdateSchedule(ctx context.Context, organizationID, actorID, scheduleID uuid.UUID, input UpdateScheduleInput, meta RequestMetadata) (*ScheduleView, error) {
+	schedule, err := s.findSchedule(ctx, organizationID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	before := newScheduleView(schedule)
+
+	if input.NextDueDate == nil {
+		return &before, nil
+	}
+	nextDate, err := optionalDate(*input.NextDueDate)
+	if err != nil || nextDate == nil {
+		return nil, fmt.Errorf("%w: next_due_date must be YYYY-MM-DD", ErrInvalidSchedule)
+	}
+	if schedule.LastMaintenanceDate != nil && !nextDate.After(*schedule.LastMaintenanceDate) {
+		return nil, fmt.Errorf("%w: next_due_date must be after last_maintenance_date", ErrInvalidSchedule)
+	}
+
+	// A manual due date has no trigger and re-arms the due soon and overdue alerts
+	if err := s.scheduleRepo.Update(ctx, schedule.ID, map[string]interface{}{
+		"next_due_date":          *nextDate,
+		"next_due_trigger":       nil,
+		"overdue_alert_sent_at":  nil,
+		"due_soon_alert_sent_at": nil,
+		"overdue_escalated_at":   nil,
+	}, actorID); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.scheduleRepo.FindByID(ctx, schedule.ID)
+	if err != nil {
+		return nil, err
+	}
+	after := newScheduleView(updated)
+	s.audit(ctx, organizationID, actorID, schedule.ID, AuditActionUpdate, before, after, meta)
+	return &after, nil
+}
+
+func (s *ScheduleService) DeleteSchedule(ctx context.Context, organizationID, actorID, scheduleID uuid.UUID, meta RequestMetadata) error {
+	schedule, err := s.findSchedule(ctx, organizationID, scheduleID)
+	if err != nil {
+		return err
+	}
+	if err := s.scheduleRepo.Delete(ctx, schedule.ID); err != nil {
+		return err
+	}
+	s.audit(ctx, organizationID, actorID, schedule.ID, AuditActionDelete, newScheduleView(schedule), nil, meta)
+	return nil
+}
+
+// DueSchedules lists schedules that are overdue or due within the next days (default 30,
+// at most 365), most urgent first.
+func (s *ScheduleService) DueSchedules(ctx context.Context, organizationID uuid.UUID, days int) ([]DueMaintenanceView, error) {
+	if days == 0 {
+		days = defaultDueWindow
+	}
+	if days < 1 || days > maxDueWindow {
+		return nil, ErrInvalidDueWindow
+	}
+
+	due, err := s.scheduleRepo.FindDue(ctx, organizationID, days)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]DueMaintenanceView, 0, len(due))
+	for _, d := range due {
+		views = append(views, DueMaintenanceView{
+			ScheduleID:        d.ScheduleID,
+			EquipmentID:       d.EquipmentID,
+			EquipmentName:     d.EquipmentName,
+			MaintenanceTypeID: d.MaintenanceTypeID,
+			MaintenanceType:   d.MaintenanceType,
+			NextDueDate:       d.NextDueDate.Format(dateLayout),
+			DaysUntilDue:      d.DaysUntilDue,
+			IsOverdue:         d.IsOverdue,
+		})
+	}
+	return views, nil
+}
+
+func (s *ScheduleService) findSchedule(ctx context.Context, organizationID, scheduleID uuid.UUID) (*model.MaintenanceSchedule, error) {
+	schedule, err := s.scheduleRepo.FindByID(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil || schedule.OrganizationID != organizationID {
+		return nil, ErrScheduleNotFound
+	}
+	return schedule, nil
+}
+
+func (s *ScheduleService) audit(ctx context.Context, organizationID, actorID, scheduleID uuid.UUID, action string, before, after interface{}, meta RequestMetadata) {
+	if err := s.auditService.Record(ctx, AuditEntry{
+		OrganizationID: organizationID,
+		ActorID:        &actorID,
+		EntityType:     "maintenance_schedule",
+		EntityID:       scheduleID,
+		Action:         action,
+		Before:         before,
+		After:          after,
+		Metadata:       meta,
+	}); err != nil {
+		log.Printf("failed to audit maintenance schedule %s: %v", scheduleID, err)
+	}
+}
+
+// applyScheduleTriggers validates and sets the schedule's triggers.
+func applyScheduleTriggers(schedule *model.MaintenanceSchedule, input CreateScheduleInput) error {
+	if input.ScheduledFrequencyDays != nil {
+		days := *input.ScheduledFrequencyDays
+		if days < 1 || days > maxScheduleFrequencyDays {
+			return fmt.Errorf("%w: scheduled_frequency_days must be between 1 and %d", ErrInvalidSchedule, maxScheduleFrequencyDays)
+		}
+		schedule.ScheduledFrequencyDays = &days
+	}
+
+	if input.CalendarRule != nil && strings.TrimSpace(*input.CalendarRule) != "" {
+		rule, err := scheduling.ParseRule(*input.CalendarRule)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+		}
+		canonical := rule.String()
+		schedule.CalendarRule = &canonical
+
+		start := today()
+		if input.CalendarRuleStart != nil {
+			ruleStart, err := optionalDate(*input.CalendarRuleStart)
+			if err != nil {
+				return fmt.Errorf("%w: calendar_rule_start must be YYYY-MM-DD", ErrInvalidSchedule)
+			}
+			if ruleStart != nil {
+				start = *ruleStart
+			}
+		}
+		schedule.CalendarRuleStart = &start
+	}
+
+	if input.MeterType != nil && *input.MeterType != "" {
+		if !meterTypes[*input.MeterType] {
+			return fmt.Errorf("%w: meter_type must be hours, miles or cycles", ErrInvalidSchedule)
+		}
+		if input.MeterInterval == nil || *input.MeterInterval <= 0 || *input.MeterInterval > maxMeterValue {
+			return fmt.Errorf("%w: meter_interval must be positive", ErrInvalidSchedule)
+		}
+		meterType := *input.MeterType
+		schedule.MeterType = &meterType
+		schedule.MeterInterval = input.MeterInterval
+	} else if input.MeterInterval != nil {
+		return fmt.Errorf("%w: meter_interval needs meter_type", ErrInvalidSchedule)
+	}
+
+	if schedule.ScheduledFrequencyDays == nil && schedule.CalendarRule == nil && schedule.MeterType == nil {
+		return fmt.Errorf("%w: scheduled_frequency_days, calendar_rule or meter_type is required", ErrInvalidSchedule)
+	}
+	return nil
+}
+
+// nextScheduleDue computes when the schedule is next due from its triggers, service
+// history and, for meter triggers, the latest meter state (nil if unknown).
+func nextScheduleDue(schedule *model.MaintenanceSchedule, meter *scheduling.MeterState) (scheduling.Due, error) {
+	plan := scheduling.Plan{
+		Start:            scheduling.DateOf(schedule.CreatedAt),
+		LastServiceDate:  schedule.LastMaintenanceDate,
+		LastServiceMeter: schedule.LastServiceMeterValue,
+	}
+	if schedule.ScheduledFrequencyDays != nil {
+		plan.FrequencyDays = *schedule.ScheduledFrequencyDays
+	}
+	if schedule.CalendarRule != nil && schedule.CalendarRuleStart != nil {
+		rule, err := scheduling.ParseRule(*schedule.CalendarRule)
+		if err != nil {
+			return scheduling.Due{}, err
+		}
+		plan.Rule = rule
+		plan.RuleStart = *schedule.CalendarRuleStart
+	}
+	if schedule.MeterInterval != nil {
+		plan.MeterInterval = *schedule.MeterInterval
+	}
+	return plan.NextDue(meter), nil
+}
+
+func applyScheduleDue(schedule *model.MaintenanceSchedule, due scheduling.Due) {
+	schedule.NextDueDate = due.Date
+	schedule.NextDueMeterValue = due.Meter
+	schedule.NextDueTrigger = nil
+	if due.Date != nil {
+		trigger := string(due.Trigger)
+		schedule.NextDueTrigger = &trigger
+	}
+}
+
+func newScheduleView(schedule *model.MaintenanceSchedule) ScheduleView {
+	return ScheduleView{
+		ID:                     schedule.ID,
+		EquipmentID:            schedule.EquipmentID,
+		MaintenanceTypeID:      schedule.MaintenanceTypeID,
+		ScheduledFrequencyDays: schedule.ScheduledFrequencyDays,
+		CalendarRule:           schedule.CalendarRule,
+		CalendarRuleStart:      formatDate(schedule.CalendarRuleStart),
+		MeterType:              schedule.MeterType,
+		MeterInterval:          schedule.MeterInterval,
+		LastMaintenanceDate:    formatDate(schedule.LastMaintenanceDate),
+		LastServiceMeterValue:  schedule.LastServiceMeterValue,
+		NextDueDate:            formatDate(schedule.NextDueDate),
+		NextDueMeterValue:      schedule.NextDueMeterValue,
+		NextDueTrigger:         schedule.NextDueTrigger,
+		IsOverdue:              schedule.IsOverdue,
+		CreatedAt:              schedule.CreatedAt,
+		UpdatedAt:              schedule.UpdatedAt,
+	}
+}
+
+// today is the current date at midnight UTC, comparable with DATE columns.
+func today() time.Time {
+	return scheduling.DateOf(time.Now())
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/NWhite12/EquipChain/internal/config"
+	"github.com/NWhite12/EquipChain/internal/envelope"
+)
+
+// NewSecretCipher builds the envelope cipher for secrets stored in the database (TOTP
+// seeds, OIDC client secrets, integration credentials) from the ENCRYPTION_KEYS_FILE
+// keyring, or from ENCRYPTION_KEY as master key version 1 when no keyring is configured.
+func NewSecretCipher(cfg *config.Config) (*envelope.Cipher, error) {
+	if cfg.EncryptionKeysFile != "" {
+		if cfg.EncryptionKey != "" {
+			return nil, fmt.Errorf("set ENCRYPTION_KEYS_FILE or ENCRYPTION_KEY, not both; import the key into the keyring with enckeyctl import")
+		}
+		info, err := os.Stat(cfg.EncryptionKeysFile)
+		if err != nil {
+			return nil, fmt.Errorf("ENCRYPTION_KEYS_FILE: %w", err)
+		}
+		if cfg.IsProduction() && info.Mode().Perm()&0o077 != 0 {
+			return nil, fmt.Errorf("ENCRYPTION_KEYS_FILE %s must not be accessible by group or others (mode %v)", cfg.EncryptionKeysFile, info.Mode().Perm())
+		}
+		ring, err := envelope.LoadKeyring(cfg.EncryptionKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		return envelope.FromKeyring(ring)
+	}
+
+	var key []byte
+	if cfg.EncryptionKey == "" {
+		if cfg.IsProduction() {
+			return nil, fmt.Errorf("ENCRYPTION_KEYS_FILE or ENCRYPTION_KEY is required in production")
+		}
+
+		log.Println("WARNING: ENCRYPTION_KEY not set, using insecure development key. DO NOT USE IN PRODUCTION!")
+		sum := sha256.Sum256([]byte("equipchain-development-encryption-key"))
+		key = sum[:]
+	} else {
+		decoded, err := base64.StdEncoding.DecodeString(cfg.EncryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("ENCRYPTION_KEY must be base64: %w", err)
+		}
+		if len(decoded) != envelope.KeySize {
+			return nil, fmt.Errorf("ENCRYPTION_KEY must decode to %d bytes (got %d)", envelope.KeySize, len(decoded))
+		}
+		key = decoded
+	}
+
+	return envelope.New(map[int][]byte{1: key}, 1)
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+
+	"github.com/NWhite12/EquipChain/internal/envelope"
+	"github.com/NWhite12/EquipChain/internal/repository"
+)
+
+// encryptedSecretColumn is a column encrypted with the envelope cipher. Columns with
+// legacyPlaintext may still hold values stored before they were encrypted.
+type encryptedSecretColumn struct {
+	repository.SecretColumn
+	legacyPlaintext bool
+}
+
+var encryptedSecretColumns = []encryptedSecretColumn{
+	{SecretColumn: repository.SecretColumn{Table: "user_mfa_totp", KeyColumn: "user_id", Column: "secret_encrypted"}},
+	{SecretColumn: repository.SecretColumn{Table: "organization_oidc_providers", KeyColumn: "organization_id", Column: "client_secret_encrypted"}},
+	{SecretColumn: repository.SecretColumn{Table: "organizations_integrations", KeyColumn: "id", Column: "api_key_encrypted"}},
+	{SecretColumn: repository.SecretColumn{Table: "organizations_integrations", KeyColumn: "id", Column: "api_secret_encrypted"}},
+	{SecretColumn: repository.SecretColumn{Table: "organizations_integrations", KeyColumn: "id", Column: "webhook_secret"}, legacyPlaintext: true},
+	{SecretColumn: repository.SecretColumn{Table: "organizations_integrations", KeyColumn: "id", Column: "previous_webhook_secret"}, legacyPlaintext: true},
+}
+
+// SecretReencryptionResult counts the values of a column rewrapped by Reencrypt.
+type SecretReencryptionResult struct {
+	Column    string
+	Rewrapped int
+}
+
+// SecretReencryptionService moves stored secrets to the active master key after a
+// rotation, so that the previous key can be removed from the keyring.
+type SecretReencryptionService struct {
+	secretColumnRepo *repository.SecretColumnRepository
+	cipher           *envelope.Cipher
+}
+
+func NewSecretReencryptionService(secretColumnRepo *repository.SecretColumnRepository, cipher *envelope.Cipher) *SecretReencryptionService {
+	return &SecretReencryptionService{secretColumnRepo: secretColumnRepo, cipher: cipher}
+}
+
+// Reencrypt rewraps the data key of every stored secret not wrapped by the active master
+// key. Legacy v1 values, and webhook secrets stored in plaintext before they were
+// encrypted, are encrypted afresh. It can be run repeatedly and while servers are running.
+func (s *SecretReencryptionService) Reencrypt(ctx context.Context) ([]SecretReencryptionResult, error) {
+	results := make([]SecretReencryptionResult, 0, len(encryptedSecretColumns))
+	for _, column := range encryptedSecretColumns {
+		rewrapped, err := s.secretColumnRepo.Rewrite(ctx, column.SecretColumn, s.rewriter(column))
+		results = append(results, SecretReencryptionResult{Column: column.String(), Rewrapped: rewrapped})
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// rewriter returns the function that moves a value of the column to the active key.
+func (s *SecretReencryptionService) rewriter(column encryptedSecretColumn) func(string) (string, bool, error) {
+	if column.legacyPlaintext {
+		return s.rewrapOrEncrypt
+	}
+	return s.cipher.Rewrap
+}
+
+func (s *SecretReencryptionService) rewrapOrEncrypt(value string) (string, bool, error) {
+	if !envelope.IsCiphertext(value) {
+		ciphertext, err := s.cipher.Encrypt([]byte(value))
+		return ciphertext, err == nil, err
+	}
+	return s.cipher.Rewrap(value)
+}
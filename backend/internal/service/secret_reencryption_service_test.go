@@ -0,0 +1,72 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/NWhite12/EquipChain/internal/envelope"
+)
+
+func TestSecretReencryptionMovesValuesToTheActiveKey(t *testing.T) {
+	oldKey, newKey := bytes.Repeat([]byte{1}, envelope.KeySize), bytes.Repeat([]byte{2}, envelope.KeySize)
+	before, err := envelope.New(map[int][]byte{1: oldKey}, 1)
+	if err != nil {
+		t.Fatalf("cipher: %v", err)
+	}
+	rotated, err := envelope.New(map[int][]byte{1: oldKey, 2: newKey}, 2)
+	if err != nil {
+		t.Fatalf("cipher: %v", err)
+	}
+	afterRemoval, err := envelope.New(map[int][]byte{2: newKey}, 2)
+	if err != nil {
+		t.Fatalf("cipher: %v", err)
+	}
+	s := NewSecretReencryptionService(nil, rotated)
+
+	stored, err := before.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	current, err := rotated.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	type rewriteCase struct {
+		name    string
+		value   string
+		changed bool
+	}
+	for _, column := range encryptedSecretColumns {
+		rewrite := s.rewriter(column)
+
+		tests := []rewriteCase{
+			{"wrapped by the previous key", stored, true},
+			{"wrapped by the active key", current, false},
+		}
+		if column.legacyPlaintext {
+			tests = append(tests, rewriteCase{"stored in plaintext", "secret", true})
+		}
+		for _, tt := range tests {
+			value, changed, err := rewrite(tt.value)
+			if err != nil || changed != tt.changed {
+				t.Fatalf("%s, %s: changed %v, %v; want changed %v", column, tt.name, changed, err, tt.changed)
+			}
+			if !strings.HasPrefix(value, "v2:2:") {
+				t.Fatalf("%s, %s: %q is not wrapped by the active key", column, tt.name, value)
+			}
+			if decrypted, err := afterRemoval.Decrypt(value); err != nil || string(decrypted) != "secret" {
+				t.Fatalf("%s, %s: decrypt without the previous key = %q, %v", column, tt.name, decrypted, err)
+			}
+		}
+
+		// Only webhook secrets predate encryption; anything else that is not a ciphertext
+		// is an error rather than something to encrypt
+		if !column.legacyPlaintext {
+			if _, _, err := rewrite("secret"); err == nil {
+				t.Fatalf("%s: plaintext value accepted", column)
+			}
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	dateLayout                 = "2006-01-02"
+	defaultLicenseExpiryWindow = 30
+	maxLicenseExpiryWindow     = 365
+	maxCertificationLength     = 100
+)
+
+var licenseStatePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// TechnicianProfileInput creates or partially updates a profile. Omitted fields are left
+// unchanged; an empty string clears a license field. Dates use YYYY-MM-DD.
+type TechnicianProfileInput struct {
+	LicenseNumber         *string   `json:"license_number"`
+	LicenseType           *string   `json:"license_type"`
+	LicenseState          *string   `json:"license_state"`
+	LicenseIssuedDate     *string   `json:"license_issued_date"`
+	LicenseExpirationDate *string   `json:"license_expiration_date"`
+	Certifications        *[]string `json:"certifications"`
+	IsAvailable           *bool     `json:"is_available"`
+	HourlyRate            *float64  `json:"hourly_rate"`
+}
+
+type TechnicianProfileView struct {
+	ID                    uuid.UUID `json:"id"`
+	UserID                uuid.UUID `json:"user_id"`
+	LicenseNumber         *string   `json:"license_number"`
+	LicenseType           *string   `json:"license_type"`
+	LicenseState          *string   `json:"license_state"`
+	LicenseIssuedDate     *string   `json:"license_issued_date"`
+	LicenseExpirationDate *string   `json:"license_expiration_date"`
+	LicenseExpired        bool      `json:"license_expired"`
+	Certifications        []string  `json:"certifications"`
+	IsAvailable           bool      `json:"is_available"`
+	HourlyRate            *float64  `json:"hourly_rate"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+type CertifiedTechnicianView struct {
+	TechnicianID  uuid.UUID `json:"technician_id"`
+	LicenseNumber *string   `json:"license_number"`
+	LicenseType   *string   `json:"license_type"`
+	IsAvailable   bool      `json:"is_available"`
+	HourlyRate    *float64  `json:"hourly_rate"`
+}
+
+type ExpiringLicenseView struct {
+	TechnicianID          uuid.UUID `json:"technician_id"`
+	LicenseNumber         *string   `json:"license_number"`
+	LicenseType           *string   `json:"license_type"`
+	LicenseExpirationDate string    `json:"license_expiration_date"`
+	DaysUntilExpiry       int       `json:"days_until_expiry"`
+}
+
+// TechnicianService manages technician_profiles: licensing, certifications, availability
+// and rates of an organization's technicians.
+type TechnicianService struct {
+	technicianRepo *repository.TechnicianRepository
+	userRepo       *repository.UserRepository
+	auditService   *AuditService
+}
+
+func NewTechnicianService(technicianRepo *repository.TechnicianRepository, userRepo *repository.UserRepository, auditService *AuditService) *TechnicianService {
+	return &TechnicianService{
+		technicianRepo: technicianRepo,
+		userRepo:       userRepo,
+		auditService:   auditService,
+	}
+}
+
+// ListProfiles returns the organization's profiles, optionally only (un)available ones.
+func (s *TechnicianService) ListProfiles(ctx context.Context, organizationID uuid.UUID, available *bool) ([]TechnicianProfileView, error) {
+	filters := map[string]interface{}{}
+	if available != nil {
+		filters["is_available"] = *available
+	}
+
+	profiles, err := s.technicianRepo.FindByOrganizationID(ctx, organizationID, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]TechnicianProfileView, 0, len(profiles))
+	for _, profile := range profiles {
+		view, err := newTechnicianProfileView(profile)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+func (s *TechnicianService) GetProfile(ctx context.Context, organizationID, userID uuid.UUID) (*TechnicianProfileView, error) {
+	profile, err := s.findProfile(ctx, organizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	view, err := newTechnicianProfileView(profile)
+	if err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+// CreateProfile adds a profile for an enabled, non-viewer user of the organization.
+func (s *TechnicianService) CreateProfile(ctx context.Context, organizationID, actorID, userID uuid.UUID, input TechnicianProfileInput, meta RequestMetadata) (*TechnicianProfileView, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.OrganizationID != organizationID || user.Status == "inactive" || user.Status == "deleted" || user.RoleID == model.RoleViewer {
+		return nil, ErrInvalidTechnicianUser
+	}
+
+	existing, err := s.technicianRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrTechnicianProfileExists
+	}
+
+	now := time.Now()
+	profile := &model.TechnicianProfile{
+		ID:             uuid.New(),
+		UserID:         userID,
+		OrganizationID: organizationID,
+		Certifications: json.RawMessage("[]"),
+		IsAvailable:    true,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		CreatedBy:      &actorID,
+		UpdatedBy:      &actorID,
+	}
+	if err := applyTechnicianProfileInput(profile, input); err != nil {
+		return nil, err
+	}
+
+	if err := s.technicianRepo.Create(ctx, profile); err != nil {
+		return nil, err
+	}
+
+	view, err := newTechnicianProfileView(profile)
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, organizationID, actorID, profile.ID, AuditActionCreate, nil, view, meta)
+	return &view, nil
+}
+
+func (s *TechnicianService) UpdateProfile(ctx context.Context, organizationID, actorID, userID uuid.UUID, input TechnicianProfileInput, meta RequestMetadata) (*TechnicianProfileView, error) {
+	profile, err := s.findProfile(ctx, organizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	before, err := newTechnicianProfileView(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyTechnicianProfileInput(profile, input); err != nil {
+		return nil, err
+	}
+
+	err = s.technicianRepo.Update(ctx, profile.ID, map[string]interface{}{
+		"license_number":          profile.LicenseNumber,
+		"license_type":            profile.LicenseType,
+		"license_state":           profile.LicenseState,
+		"license_issued_date":     profile.LicenseIssuedDate,
+		"license_expiration_date": profile.LicenseExpirationDate,
+		"certifications":          profile.Certifications,
+		"is_available":            profile.IsAvailable,
+		"hourly_rate":             profile.HourlyRate,
+	}, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err = s.technicianRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	view, err := newTechnicianProfileView(profile)
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, organizationID, actorID, profile.ID, AuditActionUpdate, before, view, meta)
+	return &view, nil
+}
+
+func (s *TechnicianService) DeleteProfile(ctx context.Context, organizationID, actorID, userID uuid.UUID, meta RequestMetadata) error {
+	profile, err := s.findProfile(ctx, organizationID, userID)
+	if err != nil {
+		return err
+	}
+	before, err := newTechnicianProfileView(profile)
+	if err != nil {
+		return err
+	}
+
+	if err := s.technicianRepo.Delete(ctx, profile.ID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, organizationID, actorID, profile.ID, AuditActionDelete, before, nil, meta)
+	return nil
+}
+
+// SearchByCertification finds technicians holding a certification with a valid license.
+func (s *TechnicianService) SearchByCertification(ctx context.Context, organizationID uuid.UUID, certification string) ([]CertifiedTechnicianView, error) {
+	certification = normalizeCertification(certification)
+	if certification == "" || len(certification) > maxCertificationLength {
+		return nil, ErrCertificationRequired
+	}
+
+	technicians, err := s.technicianRepo.FindByCertification(ctx, organizationID, certification)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]CertifiedTechnicianView, 0, len(technicians))
+	for _, technician := range technicians {
+		views = append(views, CertifiedTechnicianView{
+			TechnicianID:  technician.TechnicianID,
+			LicenseNumber: technician.LicenseNumber,
+			LicenseType:   technician.LicenseType,
+			IsAvailable:   technician.IsAvailable,
+			HourlyRate:    technician.HourlyRate,
+		})
+	}
+	return views, nil
+}
+
+// ExpiringLicenses lists licenses expiring within the next days (default 30, at most 365).
+func (s *TechnicianService) ExpiringLicenses(ctx context.Context, organizationID uuid.UUID, days int) ([]ExpiringLicenseView, error) {
+	if days == 0 {
+		days = defaultLicenseExpiryWindow
+	}
+	if days < 1 || days > maxLicenseExpiryWindow {
+		return nil, ErrInvalidExpiryWindow
+	}
+
+	licenses, err := s.technicianRepo.FindExpiringLicenses(ctx, organizationID, days)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]ExpiringLicenseView, 0, len(licenses))
+	for _, license := range licenses {
+		views = append(views, ExpiringLicenseView{
+			TechnicianID:          license.TechnicianID,
+			LicenseNumber:         license.LicenseNumber,
+			LicenseType:           license.LicenseType,
+			LicenseExpirationDate: license.LicenseExpirationDate.Format(dateLayout),
+			DaysUntilExpiry:       license.DaysUntilExpiry,
+		})
+	}
+	return views, nil
+}
+
+func (s *TechnicianService) findProfile(ctx context.Context, organizationID, userID uuid.UUID) (*model.TechnicianProfile, error) {
+	profile, err := s.technicianRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil || profile.OrganizationID != organizationID {
+		return nil, ErrTechnicianProfileNotFound
+	}
+	return profile, nil
+}
+
+func (s *TechnicianService) audit(ctx context.Context, organizationID, actorID, profileID uuid.UUID, action string, before, after interface{}, meta RequestMetadata) {
+	if err := s.auditService.Record(ctx, AuditEntry{
+		OrganizationID: organizationID,
+		ActorID:        &actorID,
+		EntityType:     "technician_profile",
+		EntityID:       profileID,
+		Action:         action,
+		Before:         before,
+		After:          after,
+		Metadata:       meta,
+	}); err != nil {
+		log.Printf("failed to audit technician profile %s: %v", profileID, err)
+	}
+}
+
+// applyTechnicianProfileInput validates the input against the resulting profile, mirroring
+// the table's CHECK constraints, and applies it.
+func applyTechnicianProfileInput(profile *model.TechnicianProfile, input TechnicianProfileInput) error {
+	if input.LicenseNumber != nil {
+		profile.LicenseNumber = optionalText(*input.LicenseNumber)
+		if profile.LicenseNumber != nil && len(*profile.LicenseNumber) > 50 {
+			return fmt.Errorf("%w: license_number is too long", ErrInvalidTechnicianProfile)
+		}
+	}
+	if input.LicenseType != nil {
+		profile.LicenseType = optionalText(*input.LicenseType)
+		if profile.LicenseType != nil && len(*profile.LicenseType) > 100 {
+			return fmt.Errorf("%w: license_type is too long", ErrInvalidTechnicianProfile)
+		}
+	}
+	if input.LicenseState != nil {
+		profile.LicenseState = optionalText(strings.ToUpper(*input.LicenseState))
+		if profile.LicenseState != nil && !licenseStatePattern.MatchString(*profile.LicenseState) {
+			return fmt.Errorf("%w: license_state must be a two-letter state code", ErrInvalidTechnicianProfile)
+		}
+	}
+	if input.LicenseIssuedDate != nil {
+		date, err := optionalDate(*input.LicenseIssuedDate)
+		if err != nil {
+			return fmt.Errorf("%w: license_issued_date must use YYYY-MM-DD", ErrInvalidTechnicianProfile)
+		}
+		profile.LicenseIssuedDate = date
+	}
+	if input.LicenseExpirationDate != nil {
+		date, err := optionalDate(*input.LicenseExpirationDate)
+		if err != nil {
+			return fmt.Errorf("%w: license_expiration_date must use YYYY-MM-DD", ErrInvalidTechnicianProfile)
+		}
+		profile.LicenseExpirationDate = date
+	}
+	if profile.LicenseIssuedDate != nil && profile.LicenseExpirationDate != nil && !profile.LicenseExpirationDate.After(*profile.LicenseIssuedDate) {
+		return fmt.Errorf("%w: license_expiration_date must be after license_issued_date", ErrInvalidTechnicianProfile)
+	}
+
+	if input.Certifications != nil {
+		certifications := make([]string, 0, len(*input.Certifications))
+		seen := make(map[string]bool)
+		for _, certification := range *input.Certifications {
+			certification = normalizeCertification(certification)
+			if certification == "" || len(certification) > maxCertificationLength {
+				return fmt.Errorf("%w: certifications must be non-empty codes of at most %d characters", ErrInvalidTechnicianProfile, maxCertificationLength)
+			}
+			if !seen[certification] {
+				seen[certification] = true
+				certifications = append(certifications, certification)
+			}
+		}
+		encoded, err := json.Marshal(certifications)
+		if err != nil {
+			return err
+		}
+		profile.Certifications = encoded
+	}
+
+	if input.IsAvailable != nil {
+		profile.IsAvailable = *input.IsAvailable
+	}
+	if input.HourlyRate != nil {
+		if *input.HourlyRate <= 0 || *input.HourlyRate >= 1e8 {
+			return fmt.Errorf("%w: hourly_rate must be positive", ErrInvalidTechnicianProfile)
+		}
+		rate := *input.HourlyRate
+		profile.HourlyRate = &rate
+	}
+	return nil
+}
+
+// normalizeCertification lower-cases codes such as "Diesel Engine" to "diesel_engine".
+func normalizeCertification(certification string) string {
+	return strings.Join(strings.Fields(strings.ToLower(certification)), "_")
+}
+
+func optionalText(value string) *string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func optionalDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+func formatDate(date *time.Time) *string {
+	if date == nil {
+		return nil
+	}
+	formatted := date.Format(dateLayout)
+	return &formatted
+}
+
+func newTechnicianProfileView(profile *model.TechnicianProfile) (TechnicianProfileView, error) {
+	certifications, err := profile.CertificationList()
+	if err != nil {
+		return TechnicianProfileView{}, err
+	}
+	return TechnicianProfileView{
+		ID:                    profile.ID,
+		UserID:                profile.UserID,
+		LicenseNumber:         profile.LicenseNumber,
+		LicenseType:           profile.LicenseType,
+		LicenseState:          profile.LicenseState,
+		LicenseIssuedDate:     formatDate(profile.LicenseIssuedDate),
+		LicenseExpirationDate: formatDate(profile.LicenseExpirationDate),
+		LicenseExpired:        profile.LicenseExpired(time.Now()),
+		Certifications:        certifications,
+		IsAvailable:           profile.IsAvailable,
+		HourlyRate:            profile.HourlyRate,
+		CreatedAt:             profile.CreatedAt,
+		UpdatedAt:             profile.UpdatedAt,
+	}, nil
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/envelope"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/NWhite12/EquipChain/internal/testdb"
+)
+
+// rfc6238Secret is the SHA-1 seed of the RFC 6238 appendix B test vectors.
+var rfc6238Secret = []byte("12345678901234567890")
+
+func TestTOTPCodeMatchesRFC6238(t *testing.T) {
+	// The RFC lists 8-digit values; 6-digit codes are their last six digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		if got := totpCode(rfc6238Secret, totpStep(time.Unix(tt.unix, 0))); got != tt.code {
+			t.Errorf("code at %d = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestValidateTOTPAcceptsOneStepOfSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := totpStep(now)
+
+	tests := []struct {
+		name  string
+		delta int64
+		ok    bool
+	}{
+		{"current step", 0, true},
+		{"previous step", -1, true},
+		{"next step", 1, true},
+		{"two steps behind", -2, false},
+		{"two steps ahead", 2, false},
+	}
+	for _, tt := range tests {
+		step, ok := validateTOTP(rfc6238Secret, totpCode(rfc6238Secret, current+tt.delta), now)
+		if ok != tt.ok || (ok && step != current+tt.delta) {
+			t.Errorf("%s: step %d, ok %v; want ok %v", tt.name, step, ok, tt.ok)
+		}
+	}
+
+	for _, code := range []string{"", "05047", "0504711", "abcdef"} {
+		if _, ok := validateTOTP(rfc6238Secret, code, now); ok {
+			t.Errorf("malformed code %q accepted", code)
+		}
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := totpProvisioningURI("EquipChain", "ana@example.com", rfc6238Secret)
+	want := "otpauth://totp/EquipChain:ana@example.com?algorithm=SHA1&digits=6&issuer=EquipChain&period=30&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	if uri != want {
+		t.Fatalf("uri %s, want %s", uri, want)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if len(codes) != recoveryCodeCount || len(hashes) != recoveryCodeCount {
+		t.Fatalf("%d codes and %d hashes, want %d", len(codes), len(hashes), recoveryCodeCount)
+	}
+
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		if len(code) != 11 || code[5] != '-' || strings.ToLower(code) != code {
+			t.Fatalf("code %q is not formatted xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Fatalf("code %q generated twice", code)
+		}
+		seen[code] = true
+
+		// Only the hash is stored; it ignores case, dashes and spaces as typed by users
+		if len(hashes[i]) != 64 || hashes[i] == code {
+			t.Fatalf("hash %q of %q is not a SHA-256 hex digest", hashes[i], code)
+		}
+		for _, typed := range []string{code, strings.ToUpper(code), strings.ReplaceAll(code, "-", ""), code[:5] + " " + code[6:]} {
+			if hashRecoveryCode(typed) != hashes[i] {
+				t.Fatalf("code typed as %q does not match its hash", typed)
+			}
+		}
+	}
+	if hashRecoveryCode(codes[0]) == hashRecoveryCode(codes[1]) {
+		t.Fatal("different codes have the same hash")
+	}
+}
+
+func TestMFAVerifyRejectsReusedCodes(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	organization := testdb.CreateOrganization(t, db)
+	user := testdb.CreateUser(t, db, organization.ID, model.RoleSupervisor)
+
+	cipher, err := envelope.New(map[int][]byte{1: bytes.Repeat([]byte{7}, envelope.KeySize)}, 1)
+	if err != nil {
+		t.Fatalf("cipher: %v", err)
+	}
+	mfaRepo := repository.NewMFARepository(db)
+	userRepo := repository.NewUserRepository(db)
+	s := NewMFAService(mfaRepo, userRepo, repository.NewSecuritySettingsRepository(db),
+		NewPermissionService(repository.NewRoleRepository(db), 0), NewAuditService(repository.NewAuditRepository(db)),
+		newTestLockoutService(db), cipher, "EquipChain")
+
+	secretEncrypted, err := cipher.Encrypt(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("recovery codes: %v", err)
+	}
+	if err := mfaRepo.SavePendingTOTP(ctx, user.ID, secretEncrypted); err != nil {
+		t.Fatalf("save totp: %v", err)
+	}
+	if err := mfaRepo.ConfirmTOTP(ctx, user.ID, 0, hashes); err != nil {
+		t.Fatalf("confirm totp: %v", err)
+	}
+
+	step := totpStep(time.Now())
+	if err := s.Verify(ctx, user.ID, totpCode(rfc6238Secret, step)); err != nil {
+		t.Fatalf("current code: %v", err)
+	}
+	if err := s.Verify(ctx, user.ID, totpCode(rfc6238Secret, step)); err != ErrInvalidMFACode {
+		t.Fatalf("replayed code: %v, want ErrInvalidMFACode", err)
+	}
+	// An earlier step still within the skew is not accepted after a later one was used
+	if err := s.VerifyTOTP(ctx, user.ID, totpCode(rfc6238Secret, step-1)); err != ErrInvalidMFACode {
+		t.Fatalf("code of an earlier step: %v, want ErrInvalidMFACode", err)
+	}
+
+	if err := s.Verify(ctx, user.ID, strings.ToUpper(codes[0])); err != nil {
+		t.Fatalf("recovery code: %v", err)
+	}
+	if err := s.Verify(ctx, user.ID, codes[0]); err != ErrInvalidMFACode {
+		t.Fatalf("reused recovery code: %v, want ErrInvalidMFACode", err)
+	}
+	// Step-up only takes authenticator codes
+	if err := s.VerifyTOTP(ctx, user.ID, codes[1]); err != ErrInvalidMFACode {
+		t.Fatalf("recovery code at step-up: %v, want ErrInvalidMFACode", err)
+	}
+}
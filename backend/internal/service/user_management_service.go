@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultUserPageSize = 50
+	maxUserPageSize     = 200
+	passwordResetTTL    = 24 * time.Hour
+)
+
+// UserListQuery filters and paginates the user list. Page starts at 1.
+type UserListQuery struct {
+	Status   string
+	RoleID   int16
+	Search   string
+	Page     int
+	PageSize int
+}
+
+// UserView is the API representation of a user; it never includes credentials.
+type UserView struct {
+	ID                    uuid.UUID  `json:"id"`
+	Email                 string     `json:"email"`
+	RoleID                int16      `json:"role_id"`
+	Status                string     `json:"status"`
+	EmailVerified         bool       `json:"email_verified"`
+	FailedLoginAttempts   int16      `json:"failed_login_attempts"`
+	LockedUntil           *time.Time `json:"locked_until"`
+	LastLoginAt           *time.Time `json:"last_login_at"`
+	PasswordResetRequired bool       `json:"password_reset_required"`
+	CreatedAt             time.Time  `json:"created_at"`
+}
+
+type UserPage struct {
+	Users    []UserView `json:"users"`
+	Total    int64      `json:"total"`
+	Page     int        `json:"page"`
+	PageSize int        `json:"page_size"`
+}
+
+// UserManagementService implements the organization admin's user management. Actors can
+// only manage users whose role is not more privileged than their own, and can only assign
+// such roles, so nobody can escalate their own role.
+type UserManagementService struct {
+	userRepo *repository.UserRepository
+	roleRepo *repository.RoleRepository
+	lockout  *LockoutService
+	resetURL string
+}
+
+func NewUserManagementService(userRepo *repository.UserRepository, roleRepo *repository.RoleRepository, lockout *LockoutService, resetURL string) *UserManagementService {
+	return &UserManagementService{
+		userRepo: userRepo,
+		roleRepo: roleRepo,
+		lockout:  lockout,
+		resetURL: resetURL,
+	}
+}
+
+func (s *UserManagementService) ListUsers(ctx context.Context, organizationID uuid.UUID, query UserListQuery) (*UserPage, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 {
+		query.PageSize = defaultUserPageSize
+	}
+	if query.PageSize > maxUserPageSize {
+		query.PageSize = maxUserPageSize
+	}
+
+	users, total, err := s.userRepo.FindPage(ctx, organizationID, repository.UserListFilter{
+		Status: query.Status,
+		RoleID: query.RoleID,
+		Search: query.Search,
+		Limit:  query.PageSize,
+		Offset: (query.Page - 1) * query.PageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &UserPage{Users: make([]UserView, 0, len(users)), Total: total, Page: query.Page, PageSize: query.PageSize}
+	for _, user := range users {
+		page.Users = append(page.Users, newUserView(user))
+	}
+	return page, nil
+}
+
+func (s *UserManagementService) GetUser(ctx context.Context, organizationID, userID uuid.UUID) (*UserView, error) {
+	user, err := s.findUser(ctx, organizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	view := newUserView(user)
+	return &view, nil
+}
+
+// ChangeRole assigns a new role. The last admin of an organization cannot be demoted.
+func (s *UserManagementService) ChangeRole(ctx context.Context, organizationID, actorID uuid.UUID, actorRoleID int16, userID uuid.UUID, roleID int16, meta RequestMetadata) (*UserView, error) {
+	user, err := s.manageableUser(ctx, organizationID, actorRoleID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAssignableRole(ctx, s.roleRepo, actorRoleID, roleID); err != nil {
+		return nil, err
+	}
+	if user.RoleID == roleID {
+		view := newUserView(user)
+		return &view, nil
+	}
+
+	audit, err := userAuditLog(organizationID, actorID, user.ID,
+		map[string]interface{}{"role_id": user.RoleID},
+		map[string]interface{}{"event": "role_changed", "role_id": roleID}, meta)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.update(ctx, organizationID, user.ID, map[string]interface{}{"role_id": roleID}, actorID, audit); err != nil {
+		return nil, err
+	}
+
+	user.RoleID = roleID
+	view := newUserView(user)
+	return &view, nil
+}
+
+// SetActive deactivates or reactivates a user. Users cannot deactivate themselves and the
+// last admin cannot be deactivated.
+func (s *UserManagementService) SetActive(ctx context.Context, organizationID, actorID uuid.UUID, actorRoleID int16, userID uuid.UUID, active bool, meta RequestMetadata) (*UserView, error) {
+	if !active && actorID == userID {
+		return nil, ErrCannotDeactivateSelf
+	}
+	user, err := s.manageableUser(ctx, organizationID, actorRoleID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status, event := "inactive", "user_deactivated"
+	if active {
+		if user.Status != "inactive" {
+			return nil, ErrUserNotInactive
+		}
+		status, event = "active", "user_reactivated"
+	} else if user.Status == "inactive" {
+		view := newUserView(user)
+		return &view, nil
+	}
+
+	audit, err := userAuditLog(organizationID, actorID, user.ID,
+		map[string]interface{}{"status": user.Status},
+		map[string]interface{}{"event": event, "status": status}, meta)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.update(ctx, organizationID, user.ID, map[string]interface{}{"status": status}, actorID, audit); err != nil {
+		return nil, err
+	}
+
+	user.Status = status
+	view := newUserView(user)
+	return &view, nil
+}
+
+// Unlock clears a lockout of a user the actor may manage.
+func (s *UserManagementService) Unlock(ctx context.Context, organizationID, actorID uuid.UUID, actorRoleID int16, userID uuid.UUID, meta RequestMetadata) error {
+	if _, err := s.manageableUser(ctx, organizationID, actorRoleID, userID); err != nil {
+		return err
+	}
+	return s.lockout.Unlock(ctx, organizationID, userID, actorID, meta)
+}
+
+// ForcePasswordReset blocks password login for the user and emails a single-use reset link.
+func (s *UserManagementService) ForcePasswordReset(ctx context.Context, organizationID, actorID uuid.UUID, actorRoleID int16, userID uuid.UUID, meta RequestMetadata) error {
+	user, err := s.manageableUser(ctx, organizationID, actorRoleID, userID)
+	if err != nil {
+		return err
+	}
+
+	token, err := randomURLToken(32)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(passwordResetTTL)
+
+	templateData, err := json.Marshal(map[string]interface{}{
+		"reset_url":  s.resetURL + "?" + url.Values{"token": {token}}.Encode(),
+		"expires_at": expiresAt.UTC().Format(time.RFC3339),
+		"forced":     true,
+	})
+	if err != nil {
+		return err
+	}
+	message := &model.EmailQueueEntry{
+		ID:             uuid.New(),
+		OrganizationID: organizationID,
+		RecipientEmail: user.Email,
+		EmailType:      model.EmailTypePasswordReset,
+		TemplateData:   templateData,
+		Status:         "pending",
+		CreatedAt:      time.Now(),
+	}
+	audit, err := userAuditLog(organizationID, actorID, user.ID,
+		map[string]interface{}{"password_reset_required": user.PasswordResetRequired},
+		map[string]interface{}{"event": "password_reset_forced", "password_reset_required": true}, meta)
+	if err != nil {
+		return err
+	}
+	return s.userRepo.SetPasswordReset(ctx, user.ID, hashPasswordResetToken(token), expiresAt, actorID, message, audit)
+}
+
+func (s *UserManagementService) findUser(ctx context.Context, organizationID, userID uuid.UUID) (*model.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.OrganizationID != organizationID || user.Status == "deleted" {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// manageableUser loads a user of the organization whose role is not more privileged than
+// the actor's.
+func (s *UserManagementService) manageableUser(ctx context.Context, organizationID uuid.UUID, actorRoleID int16, userID uuid.UUID) (*model.User, error) {
+	user, err := s.findUser(ctx, organizationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	actorRole, err := s.roleRepo.FindByID(ctx, actorRoleID)
+	if err != nil {
+		return nil, err
+	}
+	userRole, err := s.roleRepo.FindByID(ctx, user.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	if actorRole == nil || (userRole != nil && userRole.PermissionPrecedence < actorRole.PermissionPrecedence) {
+		return nil, ErrUserNotManageable
+	}
+	return user, nil
+}
+
+func (s *UserManagementService) update(ctx context.Context, organizationID, userID uuid.UUID, updates map[string]interface{}, actorID uuid.UUID, audit *model.AuditLog) error {
+	err := s.userRepo.UpdateRoleOrStatus(ctx, organizationID, userID, updates, actorID, audit)
+	if errors.Is(err, repository.ErrLastAdmin) {
+		return ErrLastAdmin
+	}
+	return err
+}
+
+// userAuditLog builds the audit log entry of a change to a user, which the repository
+// stores in the change's transaction so that no change goes unaudited.
+func userAuditLog(organizationID, actorID, userID uuid.UUID, before, after map[string]interface{}, meta RequestMetadata) (*model.AuditLog, error) {
+	return NewAuditLog(AuditEntry{
+		OrganizationID: organizationID,
+		ActorID:        &actorID,
+		EntityType:     "user",
+		EntityID:       userID,
+		Action:         AuditActionUpdate,
+		Before:         before,
+		After:          after,
+		Metadata:       meta,
+	})
+}
+
+// checkAssignableRole rejects roles that are unknown, inactive or more privileged (lower
+// permission_precedence) than the acting user's role.
+func checkAssignableRole(ctx context.Context, roleRepo *repository.RoleRepository, actorRoleID, roleID int16) error {
+	actorRole, err := roleRepo.FindByID(ctx, actorRoleID)
+	if err != nil {
+		return err
+	}
+	role, err := roleRepo.FindByID(ctx, roleID)
+	if err != nil {
+		return err
+	}
+	if role == nil || role.Status != "active" {
+		return ErrInvalidRole
+	}
+	if actorRole == nil || role.PermissionPrecedence < actorRole.PermissionPrecedence {
+		return ErrRoleNotAssignable
+	}
+	return nil
+}
+
+func newUserView(user *model.User) UserView {
+	return UserView{
+		ID:                    user.ID,
+		Email:                 user.Email,
+		RoleID:                user.RoleID,
+		Status:                user.Status,
+		EmailVerified:         user.EmailVerified,
+		FailedLoginAttempts:   user.FailedLoginAttempts,
+		LockedUntil:           user.LockedUntil,
+		LastLoginAt:           user.LastLoginAt,
+		PasswordResetRequired: user.PasswordResetRequired,
+		CreatedAt:             user.CreatedAt,
+	}
+}
+
+func hashPasswordResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
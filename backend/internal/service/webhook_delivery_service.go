@@ -0,0 +1,283 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NWhite12/EquipChain/internal/events"
+	"github.com/NWhite12/EquipChain/internal/model"
+	"github.com/NWhite12/EquipChain/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// WebhookSignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body keyed
+	// with the integration's webhook_secret. During a secret rotation grace window it holds
+	// a comma-separated signature for each secret, current first. It is omitted if the
+	// integration has no secret.
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookEventHeader     = "X-EquipChain-Event"
+	WebhookDeliveryHeader  = "X-EquipChain-Delivery"
+)
+
+const (
+	// webhookBatchSize deliveries are claimed at a time, for webhookLease. At most
+	// webhookBatchSize calls of webhookTimeout must fit in the lease.
+	webhookBatchSize = 20
+	webhookLease     = 5 * time.Minute
+	webhookTimeout   = 10 * time.Second
+
+	// Failed attempts are retried after webhookRetryBase, doubling up to webhookRetryMax.
+	webhookRetryBase = 30 * time.Second
+	webhookRetryMax  = 6 * time.Hour
+
+	maxWebhookErrorChars = 1000
+	// maxWebhookResponseBytes of the response body are kept for the delivery log.
+	maxWebhookResponseBytes = 1024
+)
+
+// WebhookPayload is the JSON body of a webhook call. ID is the domain event's id, the same
+// across retries.
+type WebhookPayload struct {
+	ID             uuid.UUID   `json:"id"`
+	Event          string      `json:"event"`
+	OrganizationID uuid.UUID   `json:"organization_id"`
+	OccurredAt     time.Time   `json:"occurred_at"`
+	TestMode       bool        `json:"test_mode"`
+	Data           interface{} `json:"data"`
+}
+
+// WebhookDeliveryService delivers domain events to the webhook_url of organizations'
+// integrations through the webhook_deliveries outbox. Enqueue subscribes to the event bus;
+// Run is the periodic delivery job. Every attempt is counted on the integration, which is
+// deactivated after model.OrganizationIntegrationErrorLimit consecutive failures.
+type WebhookDeliveryService struct {
+	integrationRepo *repository.IntegrationRepository
+	deliveryRepo    *repository.WebhookDeliveryRepository
+	policy          OutboundPolicy
+	httpClient      *http.Client
+	maxAttempts     int
+}
+
+// NewWebhookDeliveryService takes how many attempts a delivery gets before it is marked
+// failed. Webhooks are called under policy, with a 10 second timeout.
+func NewWebhookDeliveryService(integrationRepo *repository.IntegrationRepository, deliveryRepo *repository.WebhookDeliveryRepository, policy OutboundPolicy, maxAttempts int) *WebhookDeliveryService {
+	return &WebhookDeliveryService{
+		integrationRepo: integrationRepo,
+		deliveryRepo:    deliveryRepo,
+		policy:          policy,
+		httpClient:      policy.Client(webhookTimeout),
+		maxAttempts:     maxAttempts,
+	}
+}
+
+// Enqueue queues the event, in the transaction it was published in, for every active
+// webhook integration of its organization that subscribes to its type.
+func (s *WebhookDeliveryService) Enqueue(ctx context.Context, tx *gorm.DB, event events.Event) error {
+	integrations, err := s.integrationRepo.WithTx(tx).FindActiveWebhooks(ctx, event.OrganizationID, event.Type)
+	if err != nil || len(integrations) == 0 {
+		return err
+	}
+
+	now := time.Now()
+	deliveries := make([]*model.WebhookDelivery, 0, len(integrations))
+	for _, integration := range integrations {
+		payload, err := json.Marshal(WebhookPayload{
+			ID:             event.ID,
+			Event:          event.Type,
+			OrganizationID: event.OrganizationID,
+			OccurredAt:     event.OccurredAt,
+			TestMode:       integration.TestMode,
+			Data:           event.Data,
+		})
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, &model.WebhookDelivery{
+			ID:             uuid.New(),
+			OrganizationID: event.OrganizationID,
+			IntegrationID:  integration.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        payload,
+			Status:         model.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		})
+	}
+	return s.deliveryRepo.WithTx(tx).Enqueue(ctx, deliveries)
+}
+
+// Run attempts due deliveries batch by batch until none are left. A failed call only
+// reschedules its delivery; errors are returned for database failures.
+func (s *WebhookDeliveryService) Run(ctx context.Context) error {
+	for ctx.Err() == nil {
+		deliveries, err := s.deliveryRepo.ClaimDue(ctx, webhookBatchSize, webhookLease)
+		if err != nil {
+			return err
+		}
+
+		var errs []error
+		for _, delivery := range deliveries {
+			if err := s.deliver(ctx, delivery); err != nil {
+				errs = append(errs, fmt.Errorf("delivery %s: %w", delivery.ID, err))
+			}
+		}
+		if len(errs) > 0 {
+			return errors.Join(errs...)
+		}
+		if len(deliveries) < webhookBatchSize {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+// deliver makes a queued delivery's next attempt and schedules a retry if it fails.
+func (s *WebhookDeliveryService) deliver(ctx context.Context, delivery *model.WebhookDelivery) error {
+	integration, err := s.integrationRepo.FindByID(ctx, delivery.IntegrationID)
+	if err != nil {
+		return err
+	}
+	if integration == nil || !integration.IsActive || integration.WebhookURL == nil || *integration.WebhookURL == "" {
+		// Deactivated or reconfigured since the delivery was claimed; leave it pending
+		return nil
+	}
+
+	attempt := s.attempt(ctx, integration, delivery)
+	var retryAt *time.Time
+	if attempt.Error != nil {
+		if attempts := delivery.Attempts + 1; attempts < s.maxAttempts {
+			next := time.Now().Add(webhookRetryDelay(attempts))
+			retryAt = &next
+		}
+	}
+	return s.deliveryRepo.RecordAttempt(ctx, delivery.ID, attempt, retryAt)
+}
+
+// SendNow stores the delivery and attempts it once, right away, without retries. It is
+// used for test pings and manual redeliveries, and returns the delivery as recorded.
+func (s *WebhookDeliveryService) SendNow(ctx context.Context, integration *model.OrganizationIntegration, delivery *model.WebhookDelivery) (*model.WebhookDelivery, error) {
+	// Keep the delivery job's hands off it while it is being attempted here
+	delivery.Status = model.WebhookDeliveryPending
+	delivery.NextAttemptAt = time.Now().Add(webhookLease)
+	if err := s.deliveryRepo.Create(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	attempt := s.attempt(ctx, integration, delivery)
+	if err := s.deliveryRepo.RecordAttempt(ctx, delivery.ID, attempt, nil); err != nil {
+		return nil, err
+	}
+	return s.deliveryRepo.FindByID(ctx, delivery.ID)
+}
+
+// attempt posts the delivery, or logs it for test mode integrations, and counts the call
+// on the integration.
+func (s *WebhookDeliveryService) attempt(ctx context.Context, integration *model.OrganizationIntegration, delivery *model.WebhookDelivery) model.WebhookAttempt {
+	var attempt model.WebhookAttempt
+	var callErr error
+	if integration.TestMode {
+		log.Printf("webhook test mode: integration %s event %s (%s) to %s: %s",
+			integration.ID, delivery.EventType, delivery.EventID, optionalString(integration.WebhookURL), delivery.Payload)
+	} else if integration.WebhookURL == nil || *integration.WebhookURL == "" {
+		callErr = fmt.Errorf("integration has no webhook url")
+	} else {
+		attempt.RequestURL = integration.WebhookURL
+		callErr = s.post(ctx, *integration.WebhookURL, integration.WebhookSecrets(time.Now()), delivery, &attempt)
+	}
+
+	if err := s.integrationRepo.RecordWebhookCall(ctx, integration.ID, callErr); err != nil {
+		log.Printf("failed to record webhook call of integration %s: %v", integration.ID, err)
+	}
+	if callErr != nil {
+		message := callErr.Error()
+		if len(message) > maxWebhookErrorChars {
+			message = message[:maxWebhookErrorChars]
+		}
+		attempt.Error = &message
+	}
+	return attempt
+}
+
+// post sends the stored payload, signed with each secret, and records the response status,
+// a snippet of the body and the latency on attempt.
+func (s *WebhookDeliveryService) post(ctx context.Context, webhookURL string, secrets []string, delivery *model.WebhookDelivery, attempt *model.WebhookAttempt) error {
+	if err := s.policy.CheckURL(webhookURL); err != nil {
+		return fmt.Errorf("webhook url: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.String())
+	if len(secrets) > 0 {
+		signatures := make([]string, len(secrets))
+		for i, secret := range secrets {
+			signatures[i] = "sha256=" + signWebhook(secret, delivery.Payload)
+		}
+		req.Header.Set(WebhookSignatureHeader, strings.Join(signatures, ","))
+	}
+
+	started := time.Now()
+	resp, err := s.httpClient.Do(req)
+	latency := int(time.Since(started).Milliseconds())
+	attempt.LatencyMS = &latency
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBytes))
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	status := resp.StatusCode
+	body := strings.ToValidUTF8(string(snippet), "")
+	attempt.ResponseStatus = &status
+	attempt.ResponseBody = &body
+	if status < 200 || status > 299 {
+		return fmt.Errorf("webhook returned HTTP %d", status)
+	}
+	return nil
+}
+
+func optionalString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// webhookRetryDelay is the backoff after the given number of failed attempts, with up to
+// 10% jitter so that retries of many deliveries spread out.
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempts && delay < webhookRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > webhookRetryMax {
+		delay = webhookRetryMax
+	}
+	return delay + rand.N(delay/10+1)
+}
+
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,101 @@
+-- ================================================================================
+-- Migration 004: Organization Security Settings
+-- Description: Per-organization password policy. Organizations without a row
+-- fall back to the application default policy.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+-- ================================================================================
+-- Create organization_security_settings Table
+-- Description: Password policy overrides per organization
+-- ================================================================================
+
+CREATE TABLE organization_security_settings (
+  organization_id UUID PRIMARY KEY,
+
+  password_min_length SMALLINT NOT NULL DEFAULT 12,
+  CONSTRAINT password_min_length_range CHECK (
+    password_min_length >= 8 AND password_min_length <= 72
+  ),
+
+  password_require_uppercase BOOLEAN NOT NULL DEFAULT true,
+  password_require_lowercase BOOLEAN NOT NULL DEFAULT true,
+  password_require_digit BOOLEAN NOT NULL DEFAULT true,
+  password_require_symbol BOOLEAN NOT NULL DEFAULT false,
+
+  password_history_count SMALLINT NOT NULL DEFAULT 5,
+  CONSTRAINT password_history_count_range CHECK (
+    password_history_count >= 0 AND password_history_count <= 24
+  ),
+
+  password_reject_common BOOLEAN NOT NULL DEFAULT true,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  created_by UUID,
+  updated_by UUID
+);
+
+COMMENT ON TABLE organization_security_settings IS
+'Security policy overrides per organization. One row per organization.
+Organizations without a row use the application default password policy.';
+
+COMMENT ON COLUMN organization_security_settings.organization_id IS
+'Primary key and foreign key to organizations. CASCADE on delete.';
+
+COMMENT ON COLUMN organization_security_settings.password_min_length IS
+'Minimum password length in characters. Range 8-72 (bcrypt truncates beyond 72 bytes).';
+
+COMMENT ON COLUMN organization_security_settings.password_require_uppercase IS
+'true=password must contain at least one uppercase letter.';
+
+COMMENT ON COLUMN organization_security_settings.password_require_lowercase IS
+'true=password must contain at least one lowercase letter.';
+
+COMMENT ON COLUMN organization_security_settings.password_require_digit IS
+'true=password must contain at least one digit.';
+
+COMMENT ON COLUMN organization_security_settings.password_require_symbol IS
+'true=password must contain at least one symbol or punctuation character.';
+
+COMMENT ON COLUMN organization_security_settings.password_history_count IS
+'Number of previous password hashes (user_password_history) a new password may not match.
+0 disables the reuse check. The current password is always rejected.';
+
+COMMENT ON COLUMN organization_security_settings.password_reject_common IS
+'true=reject passwords found in the embedded breached/common password list.';
+
+COMMENT ON COLUMN organization_security_settings.created_by IS
+'User ID of admin who first configured the policy.';
+
+COMMENT ON COLUMN organization_security_settings.updated_by IS
+'User ID of admin who last modified the policy.';
+
+ALTER TABLE organization_security_settings
+  ADD CONSTRAINT fk_organization_security_settings_organization_id
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE organization_security_settings
+  ADD CONSTRAINT fk_organization_security_settings_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE organization_security_settings
+  ADD CONSTRAINT fk_organization_security_settings_updated_by
+    FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE TRIGGER trigger_organization_security_settings_update_at
+  BEFORE UPDATE ON organization_security_settings
+  FOR EACH ROW
+  EXECUTE FUNCTION update_user_timestamp();
+
+COMMENT ON TRIGGER trigger_organization_security_settings_update_at ON organization_security_settings IS
+'Automatically updates organization_security_settings.updated_at timestamp on row modification.';
+
+-- ================================================================================
+-- user_password_history Indexes
+-- ================================================================================
+
+CREATE INDEX idx_user_password_history_user_set_at ON user_password_history(user_id, set_at DESC);
+COMMENT ON INDEX idx_user_password_history_user_set_at IS
+'Fast lookup of the most recent N password hashes for reuse checks.';
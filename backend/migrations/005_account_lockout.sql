@@ -0,0 +1,125 @@
+-- ================================================================================
+-- Migration 005: Progressive Account Lockout
+-- Description: Replaces check_and_update_lockout with a configurable, progressive
+-- implementation and removes the time-dependent locked_until CHECK constraint.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+-- ================================================================================
+-- users: lockout bookkeeping
+-- ================================================================================
+
+-- CHECK constraints are re-evaluated on every UPDATE, so once a lock expired any
+-- unrelated update to the row (e.g. recording a login) failed. Expiry is now handled
+-- by the lockout function and the application instead.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS locked_until_logic;
+
+ALTER TABLE users
+  ADD COLUMN lockout_count SMALLINT NOT NULL DEFAULT 0;
+
+ALTER TABLE users
+  ADD CONSTRAINT lockout_count_positive CHECK (lockout_count >= 0);
+
+COMMENT ON COLUMN users.lockout_count IS
+'Number of consecutive lockouts since the last successful login or admin unlock.
+Selects the lockout duration step: 1st lockout uses durations[1], 2nd durations[2], and so on.';
+
+COMMENT ON COLUMN users.locked_until IS
+'Timestamp when account lockout expires and login attempts are allowed again.
+NULL = account not locked. Durations escalate with lockout_count (see check_and_update_lockout).';
+
+-- ================================================================================
+-- Replace Function check_and_update_lockout
+-- ================================================================================
+
+DROP FUNCTION IF EXISTS check_and_update_lockout(UUID, INT);
+
+CREATE OR REPLACE FUNCTION check_and_update_lockout(
+  p_user_id UUID,
+  p_max_attempts INT DEFAULT 5,
+  p_lockout_durations INT[] DEFAULT ARRAY[300],
+  p_observation_window_seconds INT DEFAULT 900
+)
+RETURNS TABLE(
+  is_locked BOOLEAN,
+  remaining_lockout_seconds INT,
+  just_locked BOOLEAN
+) AS $$
+DECLARE
+  v_locked_until TIMESTAMP WITH TIME ZONE;
+  v_last_failed_at TIMESTAMP WITH TIME ZONE;
+  v_attempts INT;
+  v_lockout_count INT;
+  v_step INT;
+  v_duration INT;
+BEGIN
+  SELECT u.locked_until, u.last_failed_login_at, u.failed_login_attempts, u.lockout_count
+  INTO v_locked_until, v_last_failed_at, v_attempts, v_lockout_count
+  FROM users u
+  WHERE u.id = p_user_id
+  FOR UPDATE;
+
+  IF NOT FOUND THEN
+    RETURN QUERY SELECT false, 0, false;
+    RETURN;
+  END IF;
+
+  -- Still locked: do not count attempts made during the lockout period
+  IF v_locked_until IS NOT NULL AND v_locked_until > CURRENT_TIMESTAMP THEN
+    RETURN QUERY SELECT true, CEIL(EXTRACT(EPOCH FROM (v_locked_until - CURRENT_TIMESTAMP)))::INT, false;
+    RETURN;
+  END IF;
+
+  -- Failures older than the observation window no longer count towards a lockout
+  IF v_last_failed_at IS NULL
+     OR v_last_failed_at < CURRENT_TIMESTAMP - (p_observation_window_seconds || ' seconds')::INTERVAL THEN
+    v_attempts := 0;
+  END IF;
+
+  v_attempts := v_attempts + 1;
+
+  IF v_attempts >= p_max_attempts THEN
+    v_step := LEAST(v_lockout_count + 1, COALESCE(array_length(p_lockout_durations, 1), 1));
+    v_duration := COALESCE(p_lockout_durations[v_step], 300);
+
+    UPDATE users SET
+      failed_login_attempts = 0,
+      lockout_count = v_lockout_count + 1,
+      locked_until = CURRENT_TIMESTAMP + (v_duration || ' seconds')::INTERVAL,
+      last_failed_login_at = CURRENT_TIMESTAMP,
+      status = CASE WHEN status = 'active' THEN 'locked' ELSE status END
+    WHERE id = p_user_id;
+
+    RETURN QUERY SELECT true, v_duration, true;
+    RETURN;
+  END IF;
+
+  UPDATE users SET
+    failed_login_attempts = v_attempts,
+    locked_until = NULL,
+    last_failed_login_at = CURRENT_TIMESTAMP,
+    status = CASE WHEN status = 'locked' THEN 'active' ELSE status END
+  WHERE id = p_user_id;
+
+  RETURN QUERY SELECT false, 0, false;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON FUNCTION check_and_update_lockout(UUID, INT, INT[], INT) IS
+'Records a failed login attempt and applies progressive lockout.
+- Attempts made while locked are not counted; returns the remaining lockout time
+- Failures older than p_observation_window_seconds are forgotten before counting
+- When attempts reach p_max_attempts the account is locked for
+  p_lockout_durations[lockout_count + 1] seconds (last element repeats), status becomes
+  ''locked'', lockout_count is incremented and failed_login_attempts restarts at 0
+- Returns:
+    • is_locked: TRUE if account is currently locked
+    • remaining_lockout_seconds: Seconds until unlock (0 if not locked)
+    • just_locked: TRUE only on the call that triggered the lockout (for audit logging)
+- Row is locked FOR UPDATE so concurrent failures are counted exactly once
+
+Usage:
+  SELECT * FROM check_and_update_lockout(user_id, 5, ARRAY[300, 900, 3600, 86400], 900);
+
+On success:
+  UPDATE users SET failed_login_attempts = 0, lockout_count = 0, locked_until = NULL WHERE id = user_id;';
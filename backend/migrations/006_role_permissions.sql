@@ -0,0 +1,23 @@
+-- ================================================================================
+-- Migration 006: Role Permissions for Route Authorization
+-- Description: Every API route is now guarded by a permission from
+-- role_lookup.permissions. Adds the equipment write permissions to supervisors so
+-- that viewers and technicians can no longer create, update or delete equipment.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+-- Permission catalogue (wildcards: "*" grants everything, "create:*" any create):
+--   view:equipment, create:equipment, update:equipment, delete:equipment
+--   create:maintenance, approve:maintenance
+--   manage:users, manage:organization, view:reports
+
+UPDATE role_lookup
+SET permissions = '["view:equipment", "create:equipment", "update:equipment", "delete:equipment",
+"approve:maintenance", "manage:users", "view:reports"]'::jsonb
+WHERE code = 'supervisor';
+
+COMMENT ON COLUMN role_lookup.permissions IS
+'JSONB array of permission strings checked by the API RequirePermission middleware.
+Example: ["create:equipment", "approve:maintenance", "view:reports", "create:*"].
+"*" grants every permission; a "*" segment matches any value in that position.
+Null permissions means no specific permissions (deny all).';
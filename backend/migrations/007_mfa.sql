@@ -0,0 +1,97 @@
+-- ================================================================================
+-- Migration 007: TOTP Multi-Factor Authentication
+-- Description: RFC 6238 TOTP enrollment per user, hashed one-time recovery codes,
+-- and an organization setting requiring MFA for roles that approve maintenance.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+-- ================================================================================
+-- Create user_mfa_totp Table
+-- Description: One TOTP authenticator per user
+-- ================================================================================
+
+CREATE TABLE user_mfa_totp (
+  user_id UUID PRIMARY KEY,
+
+  secret_encrypted TEXT NOT NULL,
+
+  confirmed_at TIMESTAMP WITH TIME ZONE,
+
+  last_used_step BIGINT NOT NULL DEFAULT 0,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE user_mfa_totp IS
+'TOTP (RFC 6238, SHA-1, 6 digits, 30 second period) authenticator per user.
+A row with confirmed_at NULL is a pending enrollment and does not enable MFA.';
+
+COMMENT ON COLUMN user_mfa_totp.user_id IS
+'Primary key and foreign key to users. CASCADE on delete.';
+
+COMMENT ON COLUMN user_mfa_totp.secret_encrypted IS
+'Shared TOTP seed encrypted with the application ENCRYPTION_KEY (AES-256-GCM). Never stored in plaintext.';
+
+COMMENT ON COLUMN user_mfa_totp.confirmed_at IS
+'Timestamp when the user proved possession of the authenticator. NULL = enrollment pending.';
+
+COMMENT ON COLUMN user_mfa_totp.last_used_step IS
+'Highest accepted time step (unix time / 30). Codes at or below this step are rejected to prevent replay.';
+
+ALTER TABLE user_mfa_totp
+  ADD CONSTRAINT fk_user_mfa_totp_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE TRIGGER trigger_user_mfa_totp_update_at
+  BEFORE UPDATE ON user_mfa_totp
+  FOR EACH ROW
+  EXECUTE FUNCTION update_user_timestamp();
+
+COMMENT ON TRIGGER trigger_user_mfa_totp_update_at ON user_mfa_totp IS
+'Automatically updates user_mfa_totp.updated_at timestamp on row modification.';
+
+-- ================================================================================
+-- Create user_mfa_recovery_codes Table
+-- Description: Single-use recovery codes issued when TOTP is confirmed
+-- ================================================================================
+
+CREATE TABLE user_mfa_recovery_codes (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL,
+
+  code_hash VARCHAR(64) NOT NULL,
+
+  used_at TIMESTAMP WITH TIME ZONE,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT unique_user_recovery_code UNIQUE (user_id, code_hash)
+);
+
+COMMENT ON TABLE user_mfa_recovery_codes IS
+'Single-use MFA recovery codes. Regenerating codes deletes every previous code for the user.';
+
+COMMENT ON COLUMN user_mfa_recovery_codes.code_hash IS
+'Hex SHA-256 of the normalized code (lowercase, separators removed). Codes carry 50 random bits.';
+
+COMMENT ON COLUMN user_mfa_recovery_codes.used_at IS
+'Timestamp when the code was redeemed. NULL = still valid.';
+
+ALTER TABLE user_mfa_recovery_codes
+  ADD CONSTRAINT fk_user_mfa_recovery_codes_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_user_mfa_recovery_codes_user_unused ON user_mfa_recovery_codes(user_id) WHERE used_at IS NULL;
+COMMENT ON INDEX idx_user_mfa_recovery_codes_user_unused IS
+'Fast lookup of remaining recovery codes for a user.';
+
+-- ================================================================================
+-- organization_security_settings: MFA requirement
+-- ================================================================================
+
+ALTER TABLE organization_security_settings
+  ADD COLUMN require_mfa_for_approvers BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN organization_security_settings.require_mfa_for_approvers IS
+'true=users whose role grants approve:maintenance must enroll in and pass TOTP MFA to log in.';
@@ -0,0 +1,24 @@
+-- ================================================================================
+-- Migration 008: Step-up Authentication for Approval Signatures
+-- Description: Approving or rejecting maintenance requires a short-lived signing
+-- token obtained by re-entering a password or TOTP code. The factor used is
+-- recorded with each approval event.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+-- ================================================================================
+-- maintenance_approval_audit: authentication factor
+-- ================================================================================
+
+ALTER TABLE maintenance_approval_audit
+  ADD COLUMN auth_factor VARCHAR(20);
+
+ALTER TABLE maintenance_approval_audit
+  ADD CONSTRAINT approval_auth_factor_valid CHECK (
+    auth_factor IS NULL OR auth_factor IN ('password', 'totp')
+  );
+
+COMMENT ON COLUMN maintenance_approval_audit.auth_factor IS
+'Credential the approver re-entered to obtain the signing token for this action:
+"password" or "totp". NULL only for rows recorded before step-up signing existed.';
+
@@ -0,0 +1,171 @@
+-- ================================================================================
+-- Migration 009: OpenID Connect Single Sign-On
+-- Description: Per-organization OIDC identity provider configuration, pending
+-- authorization code + PKCE login state, and links between users and external
+-- identities for just-in-time provisioning.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+-- ================================================================================
+-- Create organization_oidc_providers Table
+-- Description: One OIDC identity provider per organization
+-- ================================================================================
+
+CREATE TABLE organization_oidc_providers (
+  organization_id UUID PRIMARY KEY,
+
+  issuer_url VARCHAR(500) NOT NULL,
+  CONSTRAINT oidc_issuer_url_not_empty CHECK (TRIM(issuer_url) != ''),
+
+  client_id VARCHAR(255) NOT NULL,
+  CONSTRAINT oidc_client_id_not_empty CHECK (TRIM(client_id) != ''),
+
+  client_secret_encrypted TEXT NOT NULL,
+
+  scopes VARCHAR(500) NOT NULL DEFAULT 'openid email profile',
+
+  allowed_email_domains JSONB NOT NULL DEFAULT '[]'::jsonb,
+  CONSTRAINT oidc_allowed_email_domains_not_empty CHECK (
+    jsonb_typeof(allowed_email_domains) = 'array' AND jsonb_array_length(allowed_email_domains) > 0
+  ),
+
+  default_role_id SMALLINT NOT NULL DEFAULT 4,
+
+  role_claim VARCHAR(100),
+  role_mapping JSONB NOT NULL DEFAULT '{}'::jsonb,
+
+  enabled BOOLEAN NOT NULL DEFAULT true,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  created_by UUID,
+  updated_by UUID
+);
+
+COMMENT ON TABLE organization_oidc_providers IS
+'OpenID Connect identity provider used for single sign-on into one organization.
+Login uses the authorization code flow with PKCE (S256).';
+
+COMMENT ON COLUMN organization_oidc_providers.issuer_url IS
+'OIDC issuer. Discovery is loaded from {issuer_url}/.well-known/openid-configuration and
+the ID token "iss" claim must match exactly.';
+
+COMMENT ON COLUMN organization_oidc_providers.client_secret_encrypted IS
+'OAuth client secret encrypted with the application ENCRYPTION_KEY. Never returned by the API.';
+
+COMMENT ON COLUMN organization_oidc_providers.allowed_email_domains IS
+'JSONB array of lowercase email domains allowed to sign in. Example: ["acme.com", "acme.co.uk"].
+At least one domain is required.';
+
+COMMENT ON COLUMN organization_oidc_providers.default_role_id IS
+'Role assigned to just-in-time provisioned users when no role_mapping entry matches.';
+
+COMMENT ON COLUMN organization_oidc_providers.role_claim IS
+'ID token claim holding group/role values (string or array). Example: "groups". NULL disables mapping.';
+
+COMMENT ON COLUMN organization_oidc_providers.role_mapping IS
+'Map of claim value to role_lookup.id. Example: {"eq-supervisors": 2, "eq-techs": 3}.
+When several values match, the role with the lowest permission_precedence wins.';
+
+ALTER TABLE organization_oidc_providers
+  ADD CONSTRAINT fk_organization_oidc_providers_organization_id
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE organization_oidc_providers
+  ADD CONSTRAINT fk_organization_oidc_providers_default_role_id
+    FOREIGN KEY (default_role_id) REFERENCES role_lookup(id) ON DELETE RESTRICT;
+
+ALTER TABLE organization_oidc_providers
+  ADD CONSTRAINT fk_organization_oidc_providers_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE organization_oidc_providers
+  ADD CONSTRAINT fk_organization_oidc_providers_updated_by
+    FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE TRIGGER trigger_organization_oidc_providers_update_at
+  BEFORE UPDATE ON organization_oidc_providers
+  FOR EACH ROW
+  EXECUTE FUNCTION update_user_timestamp();
+
+COMMENT ON TRIGGER trigger_organization_oidc_providers_update_at ON organization_oidc_providers IS
+'Automatically updates organization_oidc_providers.updated_at timestamp on row modification.';
+
+-- ================================================================================
+-- Create oidc_login_states Table
+-- Description: Pending SSO logins between the authorization redirect and callback
+-- ================================================================================
+
+CREATE TABLE oidc_login_states (
+  state VARCHAR(64) PRIMARY KEY,
+  organization_id UUID NOT NULL,
+
+  nonce VARCHAR(64) NOT NULL,
+  code_verifier VARCHAR(128) NOT NULL,
+
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE oidc_login_states IS
+'Single-use OIDC login state. Deleted when the callback consumes it; expired rows are
+removed opportunistically when new logins start.';
+
+COMMENT ON COLUMN oidc_login_states.state IS
+'Random CSRF state sent to the identity provider and echoed back on the callback.';
+
+COMMENT ON COLUMN oidc_login_states.nonce IS
+'Random nonce that must appear in the ID token to prevent token replay.';
+
+COMMENT ON COLUMN oidc_login_states.code_verifier IS
+'PKCE code verifier; its S256 challenge was sent with the authorization request.';
+
+ALTER TABLE oidc_login_states
+  ADD CONSTRAINT fk_oidc_login_states_organization_id
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_oidc_login_states_expires_at ON oidc_login_states(expires_at);
+COMMENT ON INDEX idx_oidc_login_states_expires_at IS
+'Cleanup of expired login states.';
+
+-- ================================================================================
+-- Create user_identities Table
+-- Description: External identities linked to EquipChain users
+-- ================================================================================
+
+CREATE TABLE user_identities (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL,
+  organization_id UUID NOT NULL,
+
+  issuer VARCHAR(500) NOT NULL,
+  subject VARCHAR(255) NOT NULL,
+  email VARCHAR(255),
+
+  last_login_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT unique_user_identity_issuer_subject UNIQUE (organization_id, issuer, subject)
+);
+
+COMMENT ON TABLE user_identities IS
+'Links an identity provider account (issuer + sub) to a user. Created on first SSO login,
+either by linking an existing user with the same verified email or by provisioning a new user.';
+
+COMMENT ON COLUMN user_identities.subject IS
+'ID token "sub" claim. Stable identifier of the account at the identity provider.';
+
+COMMENT ON COLUMN user_identities.email IS
+'Email asserted by the identity provider at the last login.';
+
+ALTER TABLE user_identities
+  ADD CONSTRAINT fk_user_identities_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE user_identities
+  ADD CONSTRAINT fk_user_identities_organization_id
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_user_identities_user_id ON user_identities(user_id);
+COMMENT ON INDEX idx_user_identities_user_id IS
+'Find external identities of a user.';
@@ -0,0 +1,88 @@
+-- ================================================================================
+-- Migration 010: Organization API Keys
+-- Description: Hashed, organization-scoped API keys for machine integrations
+-- (telematics, ERP). Each key carries a subset of role_lookup permissions.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+-- ================================================================================
+-- Create api_keys Table
+-- Description: API keys accepted as "Authorization: ApiKey <key>"
+-- ================================================================================
+
+CREATE TABLE api_keys (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  organization_id UUID NOT NULL,
+
+  name VARCHAR(100) NOT NULL,
+  CONSTRAINT api_key_name_not_empty CHECK (TRIM(name) != ''),
+
+  key_prefix VARCHAR(16) NOT NULL,
+  key_hash CHAR(64) NOT NULL,
+
+  permissions JSONB NOT NULL DEFAULT '[]'::jsonb,
+  CONSTRAINT api_key_permissions_not_empty CHECK (
+    jsonb_typeof(permissions) = 'array' AND jsonb_array_length(permissions) > 0
+  ),
+
+  expires_at TIMESTAMP WITH TIME ZONE,
+  last_used_at TIMESTAMP WITH TIME ZONE,
+  last_used_ip INET,
+  revoked_at TIMESTAMP WITH TIME ZONE,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  created_by UUID NOT NULL,
+  updated_by UUID,
+
+  CONSTRAINT unique_api_key_prefix UNIQUE (key_prefix)
+);
+
+COMMENT ON TABLE api_keys IS
+'Organization-scoped API keys for machine integrations. The plaintext key is shown once at
+creation; only its SHA-256 hash is stored. Requests act as the creating user, limited to the
+key permissions that the creator''s current role still grants.';
+
+COMMENT ON COLUMN api_keys.key_prefix IS
+'Random public identifier embedded in the key (eck_<prefix>_<secret>) used to look the key up.
+Safe to display so users can recognise their keys.';
+
+COMMENT ON COLUMN api_keys.key_hash IS
+'Lowercase hex SHA-256 of the full plaintext key.';
+
+COMMENT ON COLUMN api_keys.permissions IS
+'JSONB array of role_lookup permission strings granted to the key. Example: ["view:equipment", "create:maintenance"].
+Must be covered by the creator''s role at creation time.';
+
+COMMENT ON COLUMN api_keys.expires_at IS
+'Optional expiry. NULL means the key does not expire.';
+
+COMMENT ON COLUMN api_keys.last_used_at IS
+'Last successful authentication, updated at most once per minute.';
+
+COMMENT ON COLUMN api_keys.revoked_at IS
+'Set when the key is revoked. Revoked keys are kept for audit purposes.';
+
+ALTER TABLE api_keys
+  ADD CONSTRAINT fk_api_keys_organization_id
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE api_keys
+  ADD CONSTRAINT fk_api_keys_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE api_keys
+  ADD CONSTRAINT fk_api_keys_updated_by
+    FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_api_keys_organization_id ON api_keys(organization_id);
+COMMENT ON INDEX idx_api_keys_organization_id IS
+'List the API keys of an organization.';
+
+CREATE TRIGGER trigger_api_keys_update_at
+  BEFORE UPDATE ON api_keys
+  FOR EACH ROW
+  EXECUTE FUNCTION update_user_timestamp();
+
+COMMENT ON TRIGGER trigger_api_keys_update_at ON api_keys IS
+'Automatically updates api_keys.updated_at timestamp on row modification.';
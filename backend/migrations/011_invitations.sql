@@ -0,0 +1,123 @@
+-- ================================================================================
+-- Migration 011: Organization Invitations and Registration Policy
+-- Description: Replaces open registration with admin invitations carrying a preset
+-- role. Organizations are invite-only by default and may allow self-signup for
+-- listed email domains.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+-- ================================================================================
+-- Registration policy on organization_security_settings
+-- ================================================================================
+
+ALTER TABLE organization_security_settings
+  ADD COLUMN registration_mode VARCHAR(20) NOT NULL DEFAULT 'invite_only',
+  ADD COLUMN signup_email_domains JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+ALTER TABLE organization_security_settings
+  ADD CONSTRAINT registration_mode_valid CHECK (registration_mode IN ('invite_only', 'domain'));
+
+ALTER TABLE organization_security_settings
+  ADD CONSTRAINT signup_email_domains_is_array CHECK (jsonb_typeof(signup_email_domains) = 'array');
+
+COMMENT ON COLUMN organization_security_settings.registration_mode IS
+'invite_only: users join only by accepting an invitation.
+domain: additionally, anyone with an email in signup_email_domains may self-register as a viewer.';
+
+COMMENT ON COLUMN organization_security_settings.signup_email_domains IS
+'JSONB array of lowercase email domains allowed to self-register when registration_mode = ''domain''.';
+
+-- ================================================================================
+-- Allow invitation emails in email_queue
+-- ================================================================================
+
+ALTER TABLE email_queue DROP CONSTRAINT email_type_valid;
+
+ALTER TABLE email_queue
+  ADD CONSTRAINT email_type_valid CHECK (email_type IN (
+    'supervisor_approval_needed',
+    'approval_receipt',
+    'maintenance_confirmed',
+    'email_verification',
+    'password_reset',
+    'overdue_maintenance_alert',
+    'license_expiration_alert',
+    'maintenance_rejected',
+    'technician_assigned',
+    'organization_invitation'
+  ));
+
+-- ================================================================================
+-- Create organization_invitations Table
+-- Description: Pending, accepted and revoked invitations to join an organization
+-- ================================================================================
+
+CREATE TABLE organization_invitations (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  organization_id UUID NOT NULL,
+
+  email VARCHAR(255) NOT NULL,
+  CONSTRAINT invitation_email_valid CHECK (
+    email ~ '^[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Z|a-z]{2,}$'
+  ),
+
+  role_id SMALLINT NOT NULL,
+
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  accepted_at TIMESTAMP WITH TIME ZONE,
+  accepted_user_id UUID,
+  revoked_at TIMESTAMP WITH TIME ZONE,
+
+  invited_by UUID NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_by UUID
+);
+
+COMMENT ON TABLE organization_invitations IS
+'Invitations to join an organization with a preset role. The invitee receives a signed token
+(JWT with scope "invitation" and the invitation id as jti) by email; the row makes the token
+single-use and revocable.';
+
+COMMENT ON COLUMN organization_invitations.email IS
+'Lowercase email of the invitee. The account created on acceptance uses this address.';
+
+COMMENT ON COLUMN organization_invitations.role_id IS
+'Role given to the user on acceptance. Cannot be more privileged than the inviter''s role.';
+
+COMMENT ON COLUMN organization_invitations.expires_at IS
+'Same expiry as the signed token. Expired invitations cannot be accepted.';
+
+ALTER TABLE organization_invitations
+  ADD CONSTRAINT fk_organization_invitations_organization_id
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE organization_invitations
+  ADD CONSTRAINT fk_organization_invitations_role_id
+    FOREIGN KEY (role_id) REFERENCES role_lookup(id) ON DELETE RESTRICT;
+
+ALTER TABLE organization_invitations
+  ADD CONSTRAINT fk_organization_invitations_accepted_user_id
+    FOREIGN KEY (accepted_user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE organization_invitations
+  ADD CONSTRAINT fk_organization_invitations_invited_by
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE organization_invitations
+  ADD CONSTRAINT fk_organization_invitations_updated_by
+    FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX idx_organization_invitations_pending_email
+  ON organization_invitations(organization_id, email)
+  WHERE accepted_at IS NULL AND revoked_at IS NULL;
+COMMENT ON INDEX idx_organization_invitations_pending_email IS
+'At most one open invitation per email and organization; re-inviting revokes the previous one.';
+
+CREATE TRIGGER trigger_organization_invitations_update_at
+  BEFORE UPDATE ON organization_invitations
+  FOR EACH ROW
+  EXECUTE FUNCTION update_user_timestamp();
+
+COMMENT ON TRIGGER trigger_organization_invitations_update_at ON organization_invitations IS
+'Automatically updates organization_invitations.updated_at timestamp on row modification.';
@@ -0,0 +1,31 @@
+-- ================================================================================
+-- Migration 012: User Management
+-- Description: Admin-forced password resets. A forced reset blocks password login
+-- until the user sets a new password with the emailed single-use token.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+ALTER TABLE users
+  ADD COLUMN password_reset_required BOOLEAN NOT NULL DEFAULT false,
+  ADD COLUMN password_reset_token_hash CHAR(64),
+  ADD COLUMN password_reset_expires_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE users
+  ADD CONSTRAINT password_reset_token_logic CHECK (
+    (password_reset_token_hash IS NULL) = (password_reset_expires_at IS NULL)
+  );
+
+COMMENT ON COLUMN users.password_reset_required IS
+'true=an admin forced a password reset; password login is refused until the password is changed.';
+
+COMMENT ON COLUMN users.password_reset_token_hash IS
+'Lowercase hex SHA-256 of the emailed reset token. Cleared when the password changes.';
+
+COMMENT ON COLUMN users.password_reset_expires_at IS
+'Expiry of the reset token. An admin can force a new reset to issue a fresh token.';
+
+CREATE UNIQUE INDEX idx_users_password_reset_token_hash
+  ON users(password_reset_token_hash)
+  WHERE password_reset_token_hash IS NOT NULL;
+COMMENT ON INDEX idx_users_password_reset_token_hash IS
+'Look up a user by password reset token.';
@@ -0,0 +1,40 @@
+-- ================================================================================
+-- Migration 013: Organization Lifecycle
+-- Description: Platform administrators, who create, suspend and soft-delete
+-- organizations, and timestamps recording when an organization was suspended or deleted.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+ALTER TABLE users
+  ADD COLUMN is_platform_admin BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN users.is_platform_admin IS
+'true=platform super-admin who may manage all organizations through /api/platform.
+Granted only directly in the database; independent of the user''s role in their own organization.';
+
+ALTER TABLE organizations
+  ADD COLUMN suspended_at TIMESTAMP WITH TIME ZONE,
+  ADD COLUMN suspension_reason TEXT,
+  ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE organizations SET suspended_at = updated_at WHERE status = 'suspended';
+UPDATE organizations SET deleted_at = updated_at WHERE status = 'deleted';
+
+ALTER TABLE organizations
+  ADD CONSTRAINT organization_suspension_logic CHECK (
+    (status = 'suspended') = (suspended_at IS NOT NULL)
+  ),
+  ADD CONSTRAINT organization_deletion_logic CHECK (
+    (status = 'deleted') = (deleted_at IS NOT NULL)
+  );
+
+COMMENT ON COLUMN organizations.suspended_at IS
+'When a platform admin suspended the organization. Set exactly while status = suspended.
+Suspended organizations cannot log in, and every authenticated request for them is rejected.';
+
+COMMENT ON COLUMN organizations.suspension_reason IS
+'Optional explanation recorded by the platform admin, e.g. "invoice overdue".';
+
+COMMENT ON COLUMN organizations.deleted_at IS
+'When a platform admin soft-deleted the organization. Set exactly while status = deleted.
+Data is retained; the organization and its code can no longer be used.';
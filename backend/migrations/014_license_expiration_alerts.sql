@@ -0,0 +1,55 @@
+-- ================================================================================
+-- Migration 014: License Expiration Alerts
+-- Description: Records which license expiration alerts the background job has sent,
+-- so each threshold (e.g. 60/30/7 days) fires once per license expiration date and
+-- an expired license is reported (and the technician made unavailable) only once.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+CREATE TABLE license_expiration_alerts (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  organization_id UUID NOT NULL,
+  technician_user_id UUID NOT NULL,
+
+  license_expiration_date DATE NOT NULL,
+
+  threshold_days INT NOT NULL,
+  CONSTRAINT license_alert_threshold_valid CHECK (threshold_days >= 0),
+
+  recipient_count INT NOT NULL DEFAULT 0,
+  CONSTRAINT license_alert_recipient_count_valid CHECK (recipient_count >= 0),
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT license_alert_unique UNIQUE (technician_user_id, license_expiration_date, threshold_days)
+);
+
+COMMENT ON TABLE license_expiration_alerts IS
+'One row per license expiration alert sent. The unique key makes the alert job idempotent,
+also when several API instances run it at the same time.';
+
+COMMENT ON COLUMN license_expiration_alerts.technician_user_id IS
+'Technician (technician_profiles.user_id) whose license the alert is about.';
+
+COMMENT ON COLUMN license_expiration_alerts.license_expiration_date IS
+'Expiration date the alert refers to. Renewing the license (new date) re-arms all thresholds.';
+
+COMMENT ON COLUMN license_expiration_alerts.threshold_days IS
+'Threshold that fired, in days before expiry. 0 = the license has expired; the technician
+was set unavailable when this alert was recorded.';
+
+COMMENT ON COLUMN license_expiration_alerts.recipient_count IS
+'Number of license_expiration_alert emails queued (technician and supervisors).';
+
+ALTER TABLE license_expiration_alerts
+  ADD CONSTRAINT fk_license_expiration_alerts_organization_id
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE license_expiration_alerts
+  ADD CONSTRAINT fk_license_expiration_alerts_technician_user_id
+    FOREIGN KEY (technician_user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_license_expiration_alerts_organization_id
+  ON license_expiration_alerts(organization_id, created_at DESC);
+COMMENT ON INDEX idx_license_expiration_alerts_organization_id IS
+'List recent alerts of an organization.';
@@ -0,0 +1,75 @@
+-- ================================================================================
+-- Migration 015: Maintenance Assignments
+-- Description: Supervisors dispatch draft (or rejected) maintenance records to a
+-- technician. Each (re)assignment is kept as a row; the current one has no
+-- unassigned_at. Assigning a record also makes the assignee its technician_id.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+CREATE TABLE maintenance_assignments (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  organization_id UUID NOT NULL,
+  maintenance_record_id UUID NOT NULL,
+  technician_id UUID NOT NULL,
+
+  assigned_by UUID,
+  due_date DATE,
+  notes TEXT,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  unassigned_at TIMESTAMP WITH TIME ZONE,
+  unassigned_by UUID
+);
+
+COMMENT ON TABLE maintenance_assignments IS
+'Dispatch history of maintenance records. At most one current (unassigned_at IS NULL)
+assignment per record. A technician''s open load is the number of current assignments
+whose record is still draft or rejected.';
+
+COMMENT ON COLUMN maintenance_assignments.technician_id IS
+'Assigned technician (users.id, must have an available technician profile with a valid license).';
+
+COMMENT ON COLUMN maintenance_assignments.assigned_by IS
+'Supervisor who dispatched the work. SET NULL on delete.';
+
+COMMENT ON COLUMN maintenance_assignments.due_date IS
+'Optional date the work should be done by. Shown in the technician''s assignment list.';
+
+COMMENT ON COLUMN maintenance_assignments.unassigned_at IS
+'When the record was reassigned to another technician. NULL for the current assignment.';
+
+ALTER TABLE maintenance_assignments
+  ADD CONSTRAINT fk_maintenance_assignments_organization_id
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE maintenance_assignments
+  ADD CONSTRAINT fk_maintenance_assignments_maintenance_record_id
+    FOREIGN KEY (maintenance_record_id) REFERENCES maintenance_records(id) ON DELETE CASCADE;
+
+ALTER TABLE maintenance_assignments
+  ADD CONSTRAINT fk_maintenance_assignments_technician_id
+    FOREIGN KEY (technician_id) REFERENCES users(id) ON DELETE RESTRICT;
+
+ALTER TABLE maintenance_assignments
+  ADD CONSTRAINT fk_maintenance_assignments_assigned_by
+    FOREIGN KEY (assigned_by) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE maintenance_assignments
+  ADD CONSTRAINT fk_maintenance_assignments_unassigned_by
+    FOREIGN KEY (unassigned_by) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX idx_maintenance_assignments_current
+  ON maintenance_assignments(maintenance_record_id) WHERE unassigned_at IS NULL;
+COMMENT ON INDEX idx_maintenance_assignments_current IS
+'One current assignment per maintenance record.';
+
+CREATE INDEX idx_maintenance_assignments_technician_id
+  ON maintenance_assignments(technician_id) WHERE unassigned_at IS NULL;
+COMMENT ON INDEX idx_maintenance_assignments_technician_id IS
+'A technician''s current assignments (open load, "my assignments").';
+
+CREATE INDEX idx_maintenance_records_gps_fix
+  ON maintenance_records(technician_id, created_at DESC)
+  WHERE gps_latitude IS NOT NULL AND gps_longitude IS NOT NULL;
+COMMENT ON INDEX idx_maintenance_records_gps_fix IS
+'Last GPS fix of a technician, used to rank assignment candidates by distance.';
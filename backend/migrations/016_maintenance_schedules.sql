@@ -0,0 +1,59 @@
+-- ================================================================================
+-- Migration 016: Preventive Maintenance Schedules
+-- Description: Enforces one schedule per equipment + maintenance type (as documented
+-- on equipment_maintenance_schedule) and reworks get_equipment_due_for_maintenance
+-- for the schedule API: it now returns every overdue schedule (not only those one
+-- day late), skips soft-deleted equipment and identifies the schedule row.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+CREATE UNIQUE INDEX idx_equipment_maintenance_schedule_equipment_type
+  ON equipment_maintenance_schedule(equipment_id, maintenance_type_id);
+COMMENT ON INDEX idx_equipment_maintenance_schedule_equipment_type IS
+'One schedule per equipment + maintenance_type combination. update_maintenance_schedule_after_completion
+relies on this to roll the right schedule forward.';
+
+DROP FUNCTION IF EXISTS get_equipment_due_for_maintenance(UUID, INT);
+
+CREATE FUNCTION get_equipment_due_for_maintenance(
+  p_org_id UUID,
+  p_days_until_due INT DEFAULT 30,
+  OUT schedule_id UUID,
+  OUT equipment_id UUID,
+  OUT equipment_name VARCHAR(255),
+  OUT maintenance_type_id SMALLINT,
+  OUT maintenance_type VARCHAR(100),
+  OUT next_due_date DATE,
+  OUT days_until_due INT,
+  OUT is_overdue BOOLEAN
+)
+RETURNS SETOF record
+LANGUAGE SQL
+STABLE
+SECURITY DEFINER
+AS $$
+  SELECT
+    ems.id AS schedule_id,
+    ems.equipment_id,
+    CONCAT(e.make, ' ', e.model) AS equipment_name,
+    ems.maintenance_type_id,
+    mtl.label AS maintenance_type,
+    ems.next_due_date,
+    (ems.next_due_date - CURRENT_DATE)::INT AS days_until_due,
+    (ems.next_due_date < CURRENT_DATE) AS is_overdue
+  FROM equipment_maintenance_schedule ems
+  JOIN equipment e ON ems.equipment_id = e.id
+  JOIN maintenance_type_lookup mtl ON ems.maintenance_type_id = mtl.id
+  WHERE
+    ems.organization_id = p_org_id
+    AND e.deleted_at IS NULL
+    AND ems.next_due_date <= CURRENT_DATE + p_days_until_due
+  ORDER BY ems.next_due_date ASC;
+$$;
+
+COMMENT ON FUNCTION get_equipment_due_for_maintenance(UUID, INT) IS
+'Get all maintenance schedules that are overdue or due in the next N days (default 30).
+Overdue schedules have a negative days_until_due and is_overdue = true.
+Used by the "due soon / overdue" API listing, dashboard widgets and email alerts.
+Results ordered by next_due_date (most urgent first).
+Example: SELECT * FROM get_equipment_due_for_maintenance(org_id, 30)';
@@ -0,0 +1,70 @@
+-- ================================================================================
+-- Migration 017: Calendar Rule and Meter Schedule Triggers
+-- Description: Besides a fixed scheduled_frequency_days, a schedule can now recur on
+-- an RRULE-style calendar rule ("first Monday of each quarter") and/or a runtime
+-- meter interval ("every 250 engine hours"). When several triggers are set the
+-- schedule is due at whichever comes first. The backend computes next_due_date
+-- (internal/scheduling) and stores it here so SQL reporting keeps working.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+ALTER TABLE equipment_maintenance_schedule
+  ALTER COLUMN scheduled_frequency_days DROP NOT NULL,
+  ALTER COLUMN next_due_date DROP NOT NULL,
+  ADD COLUMN calendar_rule VARCHAR(255),
+  ADD COLUMN calendar_rule_start DATE,
+  ADD COLUMN meter_type VARCHAR(20),
+  ADD COLUMN meter_interval NUMERIC(12, 1),
+  ADD COLUMN last_service_meter_value NUMERIC(12, 1),
+  ADD COLUMN next_due_meter_value NUMERIC(12, 1),
+  ADD COLUMN next_due_trigger VARCHAR(20);
+
+ALTER TABLE equipment_maintenance_schedule
+  ADD CONSTRAINT schedule_has_trigger CHECK (
+    scheduled_frequency_days IS NOT NULL OR calendar_rule IS NOT NULL OR meter_type IS NOT NULL
+  ),
+  ADD CONSTRAINT calendar_rule_has_start CHECK (
+    (calendar_rule IS NULL) = (calendar_rule_start IS NULL)
+  ),
+  ADD CONSTRAINT meter_type_valid CHECK (
+    meter_type IS NULL OR meter_type IN ('hours', 'miles', 'cycles')
+  ),
+  ADD CONSTRAINT meter_interval_with_type CHECK (
+    (meter_type IS NULL) = (meter_interval IS NULL)
+  ),
+  ADD CONSTRAINT meter_interval_positive CHECK (
+    meter_interval IS NULL OR meter_interval > 0
+  ),
+  ADD CONSTRAINT next_due_trigger_valid CHECK (
+    next_due_trigger IS NULL OR next_due_trigger IN ('interval', 'calendar', 'meter')
+  );
+
+COMMENT ON COLUMN equipment_maintenance_schedule.scheduled_frequency_days IS
+'Fixed interval in days between services. NULL when the schedule only uses a calendar rule
+and/or a meter trigger. Immutable after creation.';
+
+COMMENT ON COLUMN equipment_maintenance_schedule.next_due_date IS
+'Earliest date any trigger falls due, computed by the backend. Meter triggers are forecast
+from the equipment''s usage rate. NULL for a meter-only schedule that cannot be forecast yet.';
+
+COMMENT ON COLUMN equipment_maintenance_schedule.calendar_rule IS
+'RRULE subset (FREQ, INTERVAL, BYMONTH, BYMONTHDAY, BYDAY, UNTIL), stored canonically.
+Example: "FREQ=MONTHLY;INTERVAL=3;BYDAY=1MO" = first Monday of each quarter.';
+
+COMMENT ON COLUMN equipment_maintenance_schedule.calendar_rule_start IS
+'Anchor of calendar_rule (its DTSTART); INTERVAL counts periods from here.';
+
+COMMENT ON COLUMN equipment_maintenance_schedule.meter_type IS
+'Runtime meter the schedule follows: hours, miles or cycles. NULL = no meter trigger.';
+
+COMMENT ON COLUMN equipment_maintenance_schedule.meter_interval IS
+'Meter units between services. Example: 250 (engine hours).';
+
+COMMENT ON COLUMN equipment_maintenance_schedule.last_service_meter_value IS
+'Meter reading when the schedule was last serviced. NULL = never (counts from 0).';
+
+COMMENT ON COLUMN equipment_maintenance_schedule.next_due_meter_value IS
+'Reading at which the meter trigger fires: last_service_meter_value + meter_interval.';
+
+COMMENT ON COLUMN equipment_maintenance_schedule.next_due_trigger IS
+'Trigger that determined next_due_date: interval, calendar or meter.';
@@ -0,0 +1,77 @@
+-- ================================================================================
+-- Migration 018: Equipment Meter Readings
+-- Description: Time series of runtime meters (hour meters, odometers, cycle
+-- counters) per equipment. Readings never decrease within a meter; rollovers and
+-- replaced meters are recorded as explicit resets. Technicians may submit readings
+-- with a maintenance record. Meter-based schedules (migration 017) are forecast
+-- from the latest reading and the usage rate.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+CREATE TABLE equipment_meter_readings (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  organization_id UUID NOT NULL,
+  equipment_id UUID NOT NULL,
+  meter_type VARCHAR(20) NOT NULL,
+
+  value NUMERIC(12,1) NOT NULL,
+  read_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  is_reset BOOLEAN NOT NULL DEFAULT false,
+
+  source VARCHAR(20) NOT NULL DEFAULT 'manual',
+  maintenance_record_id UUID,
+  notes TEXT,
+
+  recorded_by UUID,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT meter_reading_type_valid CHECK (meter_type IN ('hours', 'miles', 'cycles')),
+  CONSTRAINT meter_reading_value_nonnegative CHECK (value >= 0),
+  CONSTRAINT meter_reading_source_valid CHECK (source IN ('manual', 'maintenance'))
+);
+
+COMMENT ON TABLE equipment_meter_readings IS
+'Runtime meter readings per equipment and meter type. Within a meter, values never
+decrease over read_at; a reset starts a new meter (rollover or replacement) and must be
+the latest reading when recorded.';
+
+COMMENT ON COLUMN equipment_meter_readings.meter_type IS
+'Meter read: hours (hour meter), miles (odometer) or cycles (cycle counter). Matches
+equipment_maintenance_schedule.meter_type.';
+
+COMMENT ON COLUMN equipment_meter_readings.is_reset IS
+'The meter rolled over or was replaced and restarts at value. Meter-based schedules of
+the equipment are rebased onto the new meter so the remaining interval is kept.';
+
+COMMENT ON COLUMN equipment_meter_readings.source IS
+'manual (readings API) or maintenance (read on-site with maintenance_record_id).';
+
+ALTER TABLE equipment_meter_readings
+  ADD CONSTRAINT fk_equipment_meter_readings_organization_id
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE equipment_meter_readings
+  ADD CONSTRAINT fk_equipment_meter_readings_equipment_id
+    FOREIGN KEY (equipment_id) REFERENCES equipment(id) ON DELETE CASCADE;
+
+ALTER TABLE equipment_meter_readings
+  ADD CONSTRAINT fk_equipment_meter_readings_maintenance_record_id
+    FOREIGN KEY (maintenance_record_id) REFERENCES maintenance_records(id) ON DELETE SET NULL;
+
+ALTER TABLE equipment_meter_readings
+  ADD CONSTRAINT fk_equipment_meter_readings_recorded_by
+    FOREIGN KEY (recorded_by) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_equipment_meter_readings_series
+  ON equipment_meter_readings(equipment_id, meter_type, read_at DESC);
+COMMENT ON INDEX idx_equipment_meter_readings_series IS
+'Latest reading, neighbours of a backdated reading and usage windows of one meter.';
+
+CREATE INDEX idx_equipment_meter_readings_maintenance_record_id
+  ON equipment_meter_readings(maintenance_record_id) WHERE maintenance_record_id IS NOT NULL;
+
+-- Supervisors and technicians record meter readings (admins hold "*")
+UPDATE role_lookup
+SET permissions = permissions || '["record:meters"]'::jsonb
+WHERE code IN ('supervisor', 'technician')
+  AND NOT permissions ? 'record:meters';
@@ -0,0 +1,26 @@
+-- ================================================================================
+-- Migration 019: Maintenance Due Alerts
+-- Description: The maintenance alert job stamps overdue_alert_sent_at and
+-- due_soon_alert_sent_at when it queues overdue_maintenance_alert emails, and
+-- overdue_escalated_at when a schedule stays overdue long enough to be escalated
+-- to admins. All three are cleared when the schedule rolls forward.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+ALTER TABLE equipment_maintenance_schedule
+  ADD COLUMN overdue_escalated_at TIMESTAMP WITH TIME ZONE;
+
+COMMENT ON COLUMN equipment_maintenance_schedule.overdue_escalated_at IS
+'When the overdue alert was escalated to the organization''s admins
+(MAINTENANCE_ESCALATION_DAYS after the due date). NULL if not escalated.
+Reset to NULL with the other alert timestamps when the schedule rolls forward.';
+
+COMMENT ON COLUMN equipment_maintenance_schedule.overdue_alert_sent_at IS
+'When the "overdue" alert was queued to the equipment owner and supervisors. NULL if not
+sent. Set by the maintenance alert job once next_due_date has passed; reset to NULL
+when the schedule rolls forward or is rescheduled.';
+
+COMMENT ON COLUMN equipment_maintenance_schedule.due_soon_alert_sent_at IS
+'When the "due soon" alert was queued (next_due_date within MAINTENANCE_DUE_SOON_DAYS,
+default 30). NULL if not sent. Reset to NULL when the schedule rolls forward or is
+rescheduled.';
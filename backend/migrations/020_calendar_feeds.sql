@@ -0,0 +1,51 @@
+-- ================================================================================
+-- Migration 020: Maintenance Calendar Feeds
+-- Description: Per-user tokens for the iCalendar (.ics) feed of scheduled
+-- maintenance and open assignments. Calendar clients cannot send an
+-- Authorization header, so the token is part of the feed URL.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+-- ================================================================================
+-- Create calendar_feed_tokens Table
+-- Description: The current feed token of a user
+-- ================================================================================
+
+CREATE TABLE calendar_feed_tokens (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  organization_id UUID NOT NULL,
+  user_id UUID NOT NULL,
+
+  token_prefix VARCHAR(16) NOT NULL,
+  token_hash CHAR(64) NOT NULL,
+
+  last_used_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT unique_calendar_feed_token_user UNIQUE (user_id),
+  CONSTRAINT unique_calendar_feed_token_prefix UNIQUE (token_prefix)
+);
+
+COMMENT ON TABLE calendar_feed_tokens IS
+'Calendar feed tokens, at most one per user. The plaintext token is shown once when it is
+created; only its SHA-256 hash is stored. Rotating the token replaces the row, so old feed
+URLs stop working. The feed is only served while the user is enabled and their role grants
+view:reports.';
+
+COMMENT ON COLUMN calendar_feed_tokens.token_prefix IS
+'Random public identifier embedded in the token (ecf_<prefix>_<secret>) used to look the
+token up.';
+
+COMMENT ON COLUMN calendar_feed_tokens.token_hash IS
+'Lowercase hex SHA-256 of the full plaintext token.';
+
+COMMENT ON COLUMN calendar_feed_tokens.last_used_at IS
+'Last time a calendar client fetched the feed, updated at most once per minute.';
+
+ALTER TABLE calendar_feed_tokens
+  ADD CONSTRAINT fk_calendar_feed_tokens_organization_id
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE calendar_feed_tokens
+  ADD CONSTRAINT fk_calendar_feed_tokens_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
@@ -0,0 +1,93 @@
+-- ================================================================================
+-- Migration 021: Webhook Delivery Outbox
+-- Description: Domain events (equipment.created, maintenance.approved,
+-- schedule.overdue, ...) are queued here, one row per active webhook integration,
+-- and delivered by the webhook delivery job with HMAC-SHA256 signatures and
+-- retries with exponential backoff.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+-- ================================================================================
+-- Create webhook_deliveries Table
+-- Description: Outbox of webhook calls to organizations_integrations
+-- ================================================================================
+
+CREATE TABLE webhook_deliveries (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  organization_id UUID NOT NULL,
+  integration_id UUID NOT NULL,
+
+  event_id UUID NOT NULL,
+  event_type VARCHAR(100) NOT NULL,
+  payload JSONB NOT NULL,
+
+  status VARCHAR(20) NOT NULL DEFAULT 'pending',
+  CONSTRAINT webhook_delivery_status_valid CHECK (status IN ('pending', 'delivered', 'failed')),
+
+  attempts INTEGER NOT NULL DEFAULT 0,
+  CONSTRAINT webhook_delivery_attempts_positive CHECK (attempts >= 0),
+
+  next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  last_attempt_at TIMESTAMP WITH TIME ZONE,
+  response_status SMALLINT,
+  last_error TEXT,
+  delivered_at TIMESTAMP WITH TIME ZONE,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT unique_webhook_delivery_event UNIQUE (event_id, integration_id)
+);
+
+COMMENT ON TABLE webhook_deliveries IS
+'Persistent outbox of webhook calls. Publishing a domain event queues one delivery per
+active integration with a webhook_url; the webhook delivery job posts it, signed with the
+integration''s webhook_secret, and updates the integration''s call and error counters.
+Deliveries of test_mode integrations are written to the server log instead of posted.';
+
+COMMENT ON COLUMN webhook_deliveries.event_id IS
+'Id of the domain event, sent as the payload id. Receivers should use it to drop
+duplicates, since a delivery may be retried after a call that actually succeeded.';
+
+COMMENT ON COLUMN webhook_deliveries.payload IS
+'The JSON body posted to webhook_url: {id, event, organization_id, occurred_at, test_mode, data}.
+The X-Webhook-Signature header carries "sha256=" and the hex HMAC-SHA256 of the body.';
+
+COMMENT ON COLUMN webhook_deliveries.status IS
+'pending = waiting for its (next) attempt at next_attempt_at.
+delivered = posted with a 2xx response, or logged in test mode.
+failed = gave up after WEBHOOK_MAX_ATTEMPTS attempts.';
+
+COMMENT ON COLUMN webhook_deliveries.next_attempt_at IS
+'When the delivery is due. Failed attempts back off exponentially (30s, 1m, 2m, ... capped
+at 6h). The delivery job also pushes it forward while an attempt is in flight, so other
+server instances skip the delivery.';
+
+COMMENT ON COLUMN webhook_deliveries.response_status IS
+'HTTP status of the last attempt. NULL if the endpoint could not be reached.';
+
+ALTER TABLE webhook_deliveries
+  ADD CONSTRAINT fk_webhook_deliveries_organization_id
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE webhook_deliveries
+  ADD CONSTRAINT fk_webhook_deliveries_integration_id
+    FOREIGN KEY (integration_id) REFERENCES organizations_integrations(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at)
+  WHERE status = 'pending';
+COMMENT ON INDEX idx_webhook_deliveries_due IS
+'Find pending deliveries that are due.';
+
+CREATE INDEX idx_webhook_deliveries_integration ON webhook_deliveries(integration_id, created_at DESC);
+COMMENT ON INDEX idx_webhook_deliveries_integration IS
+'List the recent deliveries of an integration.';
+
+COMMENT ON COLUMN organizations_integrations.webhook_url IS
+'URL where EquipChain posts webhook events (see webhook_deliveries).
+Examples: "https://acme-insurance.com/equipchain/webhook", "https://procore.api.com/webhooks/maintenance".
+Optional: some integrations may not support webhooks.';
+
+COMMENT ON COLUMN organizations_integrations.test_mode IS
+'false = production mode (webhooks are posted to webhook_url).
+true = test mode: webhook deliveries are written to the server log instead of posted, so
+integrations can be set up without sending data to the third party.';
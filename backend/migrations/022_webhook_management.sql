@@ -0,0 +1,63 @@
+-- ================================================================================
+-- Migration 022: Webhook Management
+-- Description: Event type subscriptions and secret rotation for integrations, and
+-- the request and response details shown in the webhook delivery log. Manual
+-- redeliveries and test pings are stored as deliveries of their own.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+ALTER TABLE organizations_integrations
+  ADD COLUMN event_types JSONB NOT NULL DEFAULT '[]'::jsonb,
+  ADD COLUMN previous_webhook_secret VARCHAR(255),
+  ADD COLUMN previous_secret_expires_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE organizations_integrations
+  ADD CONSTRAINT integration_event_types_array CHECK (jsonb_typeof(event_types) = 'array');
+
+COMMENT ON COLUMN organizations_integrations.event_types IS
+'JSONB array of the event types the webhook subscribes to. Example: ["maintenance.approved", "schedule.overdue"].
+An empty array subscribes to every event.';
+
+COMMENT ON COLUMN organizations_integrations.previous_webhook_secret IS
+'The webhook_secret replaced by the last rotation. Until previous_secret_expires_at,
+deliveries are signed with both secrets so receivers can switch over without dropping calls.';
+
+COMMENT ON COLUMN organizations_integrations.previous_secret_expires_at IS
+'End of the secret rotation grace window. NULL if no rotation is in progress.';
+
+ALTER TABLE webhook_deliveries
+  ADD COLUMN request_url VARCHAR(255),
+  ADD COLUMN response_body TEXT,
+  ADD COLUMN latency_ms INTEGER,
+  ADD COLUMN redelivery_of UUID;
+
+COMMENT ON COLUMN webhook_deliveries.event_type IS
+'Domain event type, or "ping" for test events sent from the webhook management API.';
+
+COMMENT ON COLUMN webhook_deliveries.request_url IS
+'URL the last attempt was posted to. NULL before the first attempt and for test mode
+deliveries.';
+
+COMMENT ON COLUMN webhook_deliveries.response_body IS
+'First 1 KB of the response body of the last attempt.';
+
+COMMENT ON COLUMN webhook_deliveries.latency_ms IS
+'Duration of the last attempt in milliseconds, until the response headers arrived or the
+call failed.';
+
+COMMENT ON COLUMN webhook_deliveries.redelivery_of IS
+'For manual redeliveries, the delivery being redelivered. Redeliveries and pings are
+attempted once, immediately, and not retried.';
+
+ALTER TABLE webhook_deliveries
+  ADD CONSTRAINT fk_webhook_deliveries_redelivery_of
+    FOREIGN KEY (redelivery_of) REFERENCES webhook_deliveries(id) ON DELETE SET NULL;
+
+-- Redeliveries repeat the event, so only the original delivery is unique
+ALTER TABLE webhook_deliveries
+  DROP CONSTRAINT unique_webhook_delivery_event;
+
+CREATE UNIQUE INDEX unique_webhook_delivery_event ON webhook_deliveries(event_id, integration_id)
+  WHERE redelivery_of IS NULL;
+COMMENT ON INDEX unique_webhook_delivery_event IS
+'An event is queued once per integration; manual redeliveries are extra rows.';
@@ -0,0 +1,39 @@
+-- ================================================================================
+-- Migration 023: Envelope Encryption of Stored Secrets
+-- Description: Integration credentials and webhook secrets are encrypted at rest
+-- like TOTP seeds and OIDC client secrets: each value with its own AES-256-GCM data
+-- key, wrapped by a versioned master key from ENCRYPTION_KEYS_FILE (or
+-- ENCRYPTION_KEY). Webhook secret columns grow to hold the ciphertext.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+ALTER TABLE organizations_integrations
+  ALTER COLUMN webhook_secret TYPE TEXT,
+  ALTER COLUMN previous_webhook_secret TYPE TEXT;
+
+COMMENT ON COLUMN organizations_integrations.api_key_encrypted IS
+'API key for authentication, envelope encrypted: "v2:<master key version>:<wrapped data key>:<sealed value>".
+Never stored in plaintext. Encrypted and decrypted by the integration repository.
+Example plaintext: "sk_live_51234567890abcdef" or "Bearer token...".';
+
+COMMENT ON COLUMN organizations_integrations.api_secret_encrypted IS
+'API secret or password, envelope encrypted like api_key_encrypted.
+Examples: OAuth client secret, webhook signing key, database password.';
+
+COMMENT ON COLUMN organizations_integrations.webhook_secret IS
+'Secret key for webhook signatures (HMAC-SHA256 in X-Webhook-Signature), envelope encrypted
+like api_key_encrypted. Secrets stored before migration 023 stay plaintext until
+enckeyctl reencrypt runs.';
+
+COMMENT ON COLUMN organizations_integrations.previous_webhook_secret IS
+'The webhook_secret replaced by the last rotation, envelope encrypted. Until
+previous_secret_expires_at, deliveries are signed with both secrets so receivers can switch
+over without dropping calls.';
+
+COMMENT ON COLUMN user_mfa_totp.secret_encrypted IS
+'Shared TOTP seed, envelope encrypted with the master keyring (AES-256-GCM). Never stored
+in plaintext. Values starting with "v1:" are sealed directly with master key version 1
+(the former ENCRYPTION_KEY) until enckeyctl reencrypt runs.';
+
+COMMENT ON COLUMN organization_oidc_providers.client_secret_encrypted IS
+'OAuth client secret, envelope encrypted with the master keyring. Never returned by the API.';
@@ -0,0 +1,134 @@
+-- ================================================================================
+-- Migration 024: Inbound Webhooks
+-- Description: Calls from partners (e.g. insurer acknowledgements and claim
+-- references) to /api/integrations/:id/inbound, verified with the integration's
+-- webhook secret, and the external references they attach to maintenance records.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+-- ================================================================================
+-- Create inbound_webhooks Table
+-- Description: Every verified inbound call, whether or not it could be processed
+-- ================================================================================
+
+CREATE TABLE inbound_webhooks (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  organization_id UUID NOT NULL,
+  integration_id UUID NOT NULL,
+
+  nonce VARCHAR(128) NOT NULL,
+  signed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+
+  event_type VARCHAR(100),
+  external_id VARCHAR(255),
+  body TEXT NOT NULL,
+
+  status VARCHAR(20) NOT NULL DEFAULT 'received',
+  CONSTRAINT inbound_webhook_status_valid CHECK (status IN ('received', 'processed', 'unhandled', 'failed')),
+  error TEXT,
+  maintenance_record_id UUID,
+
+  received_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  processed_at TIMESTAMP WITH TIME ZONE,
+
+  CONSTRAINT unique_inbound_webhook_nonce UNIQUE (integration_id, nonce)
+);
+
+COMMENT ON TABLE inbound_webhooks IS
+'Calls received from partners at /api/integrations/:id/inbound. Only calls with a valid
+signature and a fresh timestamp are stored; they are kept whether or not their event type
+is known, so that unknown payloads can be inspected.';
+
+COMMENT ON COLUMN inbound_webhooks.nonce IS
+'X-EquipChain-Nonce of the call. Unique per integration, so a replayed call is rejected.';
+
+COMMENT ON COLUMN inbound_webhooks.signed_at IS
+'X-EquipChain-Timestamp of the call. Calls more than 5 minutes off the server clock are
+rejected.';
+
+COMMENT ON COLUMN inbound_webhooks.event_type IS
+'The "event" of a JSON body: "maintenance.acknowledged" or "claim.updated" are processed.
+NULL if the body is not a JSON object with an event.';
+
+COMMENT ON COLUMN inbound_webhooks.external_id IS
+'The partner''s id of the event, the "id" of a JSON body.';
+
+COMMENT ON COLUMN inbound_webhooks.body IS
+'Raw request body as received (up to 256 KB).';
+
+COMMENT ON COLUMN inbound_webhooks.status IS
+'received = stored, not yet processed.
+processed = mapped onto maintenance_record_id.
+unhandled = unknown event type or not a JSON event; kept for inspection.
+failed = known event type that could not be applied (see error).';
+
+ALTER TABLE inbound_webhooks
+  ADD CONSTRAINT fk_inbound_webhooks_organization_id
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE inbound_webhooks
+  ADD CONSTRAINT fk_inbound_webhooks_integration_id
+    FOREIGN KEY (integration_id) REFERENCES organizations_integrations(id) ON DELETE CASCADE;
+
+ALTER TABLE inbound_webhooks
+  ADD CONSTRAINT fk_inbound_webhooks_maintenance_record_id
+    FOREIGN KEY (maintenance_record_id) REFERENCES maintenance_records(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_inbound_webhooks_integration ON inbound_webhooks(integration_id, received_at DESC);
+COMMENT ON INDEX idx_inbound_webhooks_integration IS
+'List the recent inbound calls of an integration.';
+
+-- ================================================================================
+-- Create maintenance_external_references Table
+-- Description: Partner references (insurer acknowledgements, claims) of records
+-- ================================================================================
+
+CREATE TABLE maintenance_external_references (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  organization_id UUID NOT NULL,
+  maintenance_record_id UUID NOT NULL,
+  integration_id UUID NOT NULL,
+
+  reference_type VARCHAR(50) NOT NULL,
+  CONSTRAINT external_reference_type_valid CHECK (reference_type IN ('acknowledgement', 'claim')),
+  reference VARCHAR(255) NOT NULL,
+  CONSTRAINT external_reference_not_empty CHECK (TRIM(reference) != ''),
+  status VARCHAR(100),
+
+  inbound_webhook_id UUID,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT unique_maintenance_external_reference UNIQUE (maintenance_record_id, integration_id, reference_type)
+);
+
+COMMENT ON TABLE maintenance_external_references IS
+'References a partner returned for a maintenance record, e.g. an insurer''s acknowledgement
+number or claim reference. One per record, integration and type; later calls update it.';
+
+COMMENT ON COLUMN maintenance_external_references.reference_type IS
+'acknowledgement = from a "maintenance.acknowledged" event.
+claim = from a "claim.updated" event.';
+
+COMMENT ON COLUMN maintenance_external_references.status IS
+'Partner-defined status, e.g. the claim status ("open", "approved", "paid").';
+
+COMMENT ON COLUMN maintenance_external_references.inbound_webhook_id IS
+'The inbound call that last set the reference.';
+
+ALTER TABLE maintenance_external_references
+  ADD CONSTRAINT fk_maintenance_external_references_organization_id
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE maintenance_external_references
+  ADD CONSTRAINT fk_maintenance_external_references_maintenance_record_id
+    FOREIGN KEY (maintenance_record_id) REFERENCES maintenance_records(id) ON DELETE CASCADE;
+
+ALTER TABLE maintenance_external_references
+  ADD CONSTRAINT fk_maintenance_external_references_integration_id
+    FOREIGN KEY (integration_id) REFERENCES organizations_integrations(id) ON DELETE CASCADE;
+
+ALTER TABLE maintenance_external_references
+  ADD CONSTRAINT fk_maintenance_external_references_inbound_webhook_id
+    FOREIGN KEY (inbound_webhook_id) REFERENCES inbound_webhooks(id) ON DELETE SET NULL;
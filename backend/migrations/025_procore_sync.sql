@@ -0,0 +1,135 @@
+-- ================================================================================
+-- Migration 025: Procore Equipment Sync
+-- Description: Per-integration settings, the checkpoint of the resumable sync job
+-- and the links between local equipment and Procore managed equipment.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+-- ================================================================================
+-- Integration Settings
+-- ================================================================================
+
+ALTER TABLE organizations_integrations
+  ADD COLUMN settings JSONB NOT NULL DEFAULT '{}'::jsonb,
+  ADD CONSTRAINT integration_settings_is_object CHECK (jsonb_typeof(settings) = 'object');
+
+COMMENT ON COLUMN organizations_integrations.settings IS
+'Type-specific, non-secret configuration. For procore: base_url, company_id, project_id,
+create_remote and field_mapping ([{"local", "remote", "direction": "push"|"pull"}]).
+Credentials live in api_key_encrypted (client id) and api_secret_encrypted (client secret).';
+
+-- ================================================================================
+-- Create integration_sync_states Table
+-- Description: Checkpoint of an integration's sync job, one row per integration
+-- ================================================================================
+
+CREATE TABLE integration_sync_states (
+  integration_id UUID PRIMARY KEY,
+  organization_id UUID NOT NULL,
+
+  remote_scope VARCHAR(500) NOT NULL DEFAULT '',
+
+  phase VARCHAR(30) NOT NULL DEFAULT 'pull_equipment',
+  CONSTRAINT integration_sync_phase_valid CHECK (phase IN ('pull_equipment', 'push_equipment', 'push_maintenance')),
+  remote_page INTEGER NOT NULL DEFAULT 1,
+  CONSTRAINT integration_sync_remote_page_positive CHECK (remote_page > 0),
+
+  equipment_cursor_at TIMESTAMP WITH TIME ZONE,
+  equipment_cursor_id UUID,
+  maintenance_cursor_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  maintenance_cursor_id UUID,
+
+  cycle_started_at TIMESTAMP WITH TIME ZONE,
+  cycle_counts JSONB NOT NULL DEFAULT '{}'::jsonb,
+  last_completed_at TIMESTAMP WITH TIME ZONE,
+  last_error TEXT,
+  locked_until TIMESTAMP WITH TIME ZONE,
+
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE integration_sync_states IS
+'Where an integration''s sync stopped. A cycle runs its phases in order and saves the
+checkpoint after every page or item, so a run cut short by a deadline, an API error or a
+restart resumes where it stopped instead of starting over.';
+
+COMMENT ON COLUMN integration_sync_states.remote_scope IS
+'The remote account the checkpoint and links belong to (for procore: base URL, company and
+project). When the settings point elsewhere, links are dropped and the sync starts over.';
+
+COMMENT ON COLUMN integration_sync_states.phase IS
+'pull_equipment = reading remote equipment page by page (remote_page).
+push_equipment = pushing local equipment changed after the equipment cursor.
+push_maintenance = pushing maintenance confirmed after the maintenance cursor.';
+
+COMMENT ON COLUMN integration_sync_states.equipment_cursor_at IS
+'(updated_at, id) of the last local equipment pushed. NULL pushes all equipment.';
+
+COMMENT ON COLUMN integration_sync_states.maintenance_cursor_at IS
+'(confirmed_at, id) of the last confirmed maintenance record pushed. Starts when the sync
+is set up, so that historical confirmations are not pushed.';
+
+COMMENT ON COLUMN integration_sync_states.cycle_counts IS
+'Counters of the current cycle, or of the last one once it completed.';
+
+COMMENT ON COLUMN integration_sync_states.locked_until IS
+'Lease of the server instance running the sync; other instances skip the integration
+until it expires.';
+
+ALTER TABLE integration_sync_states
+  ADD CONSTRAINT fk_integration_sync_states_integration_id
+    FOREIGN KEY (integration_id) REFERENCES organizations_integrations(id) ON DELETE CASCADE;
+
+ALTER TABLE integration_sync_states
+  ADD CONSTRAINT fk_integration_sync_states_organization_id
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+-- ================================================================================
+-- Create integration_equipment_links Table
+-- Description: Local equipment matched to a remote record by serial number
+-- ================================================================================
+
+CREATE TABLE integration_equipment_links (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  organization_id UUID NOT NULL,
+  integration_id UUID NOT NULL,
+  equipment_id UUID NOT NULL,
+
+  external_id BIGINT NOT NULL,
+  remote_values JSONB NOT NULL DEFAULT '{}'::jsonb,
+
+  pulled_at TIMESTAMP WITH TIME ZONE,
+  pushed_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT unique_integration_equipment UNIQUE (integration_id, equipment_id),
+  CONSTRAINT unique_integration_external_equipment UNIQUE (integration_id, external_id)
+);
+
+COMMENT ON TABLE integration_equipment_links IS
+'Local equipment and the remote record it was matched to by serial number.';
+
+COMMENT ON COLUMN integration_equipment_links.external_id IS
+'The remote record''s id (Procore managed equipment id).';
+
+COMMENT ON COLUMN integration_equipment_links.remote_values IS
+'Last seen remote values of the pulled fields. A pulled field is only written locally when
+its remote value changed, so local edits are not overwritten by stale remote data.';
+
+ALTER TABLE integration_equipment_links
+  ADD CONSTRAINT fk_integration_equipment_links_organization_id
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE integration_equipment_links
+  ADD CONSTRAINT fk_integration_equipment_links_integration_id
+    FOREIGN KEY (integration_id) REFERENCES organizations_integrations(id) ON DELETE CASCADE;
+
+ALTER TABLE integration_equipment_links
+  ADD CONSTRAINT fk_integration_equipment_links_equipment_id
+    FOREIGN KEY (equipment_id) REFERENCES equipment(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_integration_equipment_links_equipment ON integration_equipment_links(equipment_id);
+COMMENT ON INDEX idx_integration_equipment_links_equipment IS
+'Find the remote records of a piece of equipment, e.g. to push its maintenance.';
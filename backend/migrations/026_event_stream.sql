@@ -0,0 +1,72 @@
+-- ================================================================================
+-- Migration 026: Event Stream
+-- Description: A short-lived log of domain events for the /api/events Server-Sent
+-- Events stream. Inserts are announced with NOTIFY so that every server replica can
+-- push them to its connected clients, and the sequential ids let clients resume
+-- with Last-Event-ID.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+-- ================================================================================
+-- Create domain_events Table
+-- ================================================================================
+
+CREATE TABLE domain_events (
+  id BIGSERIAL PRIMARY KEY,
+  event_id UUID NOT NULL,
+  organization_id UUID NOT NULL,
+
+  event_type VARCHAR(100) NOT NULL,
+  data JSONB NOT NULL DEFAULT '{}'::jsonb,
+
+  occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  CONSTRAINT unique_domain_event UNIQUE (event_id)
+);
+
+COMMENT ON TABLE domain_events IS
+'Domain events published by any server replica, kept for EVENT_STREAM_RETENTION (default
+24 hours) so that stream clients can resume after a disconnect.';
+
+COMMENT ON COLUMN domain_events.id IS
+'The SSE event id. Clients send the last one they received as Last-Event-ID to replay
+what they missed.';
+
+COMMENT ON COLUMN domain_events.event_id IS
+'The domain event''s id, the same as the "id" of its webhook deliveries.';
+
+ALTER TABLE domain_events
+  ADD CONSTRAINT fk_domain_events_organization_id
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_domain_events_organization ON domain_events(organization_id, id);
+COMMENT ON INDEX idx_domain_events_organization IS
+'Replay an organization''s events after a Last-Event-ID.';
+
+CREATE INDEX idx_domain_events_created_at ON domain_events(created_at);
+COMMENT ON INDEX idx_domain_events_created_at IS
+'Prune events older than the retention period.';
+
+-- ================================================================================
+-- Announce New Events
+-- ================================================================================
+
+CREATE OR REPLACE FUNCTION notify_domain_event() RETURNS TRIGGER AS $$
+BEGIN
+  PERFORM pg_notify('equipchain_domain_events', NEW.id::text);
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON FUNCTION notify_domain_event() IS
+'Sends the new event''s id on the equipchain_domain_events channel. Notifications are
+delivered when the inserting transaction commits.';
+
+CREATE TRIGGER trigger_domain_events_notify
+  AFTER INSERT ON domain_events
+  FOR EACH ROW
+  EXECUTE FUNCTION notify_domain_event();
+
+COMMENT ON TRIGGER trigger_domain_events_notify ON domain_events IS
+'Announces every new event to the listening server replicas.';
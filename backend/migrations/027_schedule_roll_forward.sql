@@ -0,0 +1,20 @@
+-- ================================================================================
+-- Migration 027: Schedule Roll-Forward
+-- Description: Confirmed maintenance records roll their schedule forward in the
+-- backend (POST /api/maintenance/:id/confirm), which also handles calendar rules
+-- and meter triggers. Drop the SQL helper that only covered fixed intervals and
+-- was never called, and point the comments at the backend.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+DROP FUNCTION IF EXISTS update_maintenance_schedule_after_completion(UUID);
+
+COMMENT ON COLUMN equipment_maintenance_schedule.next_due_date IS
+'Calculated date when this maintenance is next due.
+Critical for dashboard queries: "is equipment overdue?" = (next_due_date < CURRENT_DATE).
+Critical for alert queries: "equipment due in 30 days?" = (next_due_date BETWEEN NOW() AND NOW() + 30 DAYS).
+Rolled forward by the backend in the transaction that confirms a matching maintenance record.';
+
+COMMENT ON INDEX idx_equipment_maintenance_schedule_equipment_type IS
+'One schedule per equipment + maintenance_type combination, so a confirmed maintenance
+record rolls exactly one schedule forward.';
@@ -0,0 +1,18 @@
+-- ================================================================================
+-- Migration 028: Single-Use Signing Tokens
+-- Description: Each step-up signing token carries a unique id (jti) that is stored
+-- with the approval or rejection it signed. The unique index makes a token good for
+-- one decision, so a token cannot be replayed for others during its lifetime.
+-- ================================================================================
+SET search_path TO equipchain, public;
+
+ALTER TABLE maintenance_approval_audit
+  ADD COLUMN signing_token_id UUID;
+
+CREATE UNIQUE INDEX idx_maintenance_approval_audit_signing_token
+  ON maintenance_approval_audit(signing_token_id)
+  WHERE signing_token_id IS NOT NULL;
+
+COMMENT ON COLUMN maintenance_approval_audit.signing_token_id IS
+'jti of the step-up signing token used for this action; each token signs one action.
+NULL only for rows recorded before signing tokens were single-use.';
@@ -5,7 +5,7 @@ import { useNavigate } from 'react-router-dom'
 export default function LoginForm() {
   const [email, setEmail] = useState('')
   const [password, setPassword] = useState('')
-  const [organizationId, setOrganizationId] = useState('')
+  const [orgCode, setOrgCode] = useState('')
   const [error, setError] = useState(null)
   const { login, loading } = useAuth()
   const navigate = useNavigate()
@@ -13,7 +13,7 @@ export default function LoginForm() {
   const handleSubmit = async (e) => {
     e.preventDefault()
     try {
-      await login(email, password, organizationId)
+      await login(email, password, orgCode)
       navigate('/dashboard')
     } catch (err) {
       setError(err.message)
@@ -46,9 +46,9 @@ export default function LoginForm() {
 
       <input
         type="text"
-        placeholder="Organization ID"
-        value={organizationId}
-        onChange={(e) => setOrganizationId(e.target.value)}
+        placeholder="Organization Code"
+        value={orgCode}
+        onChange={(e) => setOrgCode(e.target.value)}
         className="w-full mb-4 px-4 py-2 border rounded"
         required
       />
@@ -7,7 +7,7 @@ export default function RegisterForm() {
   const [email, setEmail] = useState('')
   const [password, setPassword] = useState('')
   const [confirmPassword, setConfirmPassword] = useState('')
-  const [orgId, setOrgId] = useState('')
+  const [orgCode, setOrgCode] = useState('')
   const [error, setError] = useState(null)
   const { register, loading } = useAuth()
   const navigate = useNavigate()
@@ -26,7 +26,7 @@ export default function RegisterForm() {
     }
 
     try {
-      await register(email, password, orgId)
+      await register(email, password, orgCode)
       navigate('/dashboard')
     } catch (err) {
       setError(err.message)
@@ -68,9 +68,9 @@ export default function RegisterForm() {
 
       <input
         type="text"
-        placeholder="Organization ID"
-        value={orgId}
-        onChange={(e) => setOrgId(e.target.value)}
+        placeholder="Organization Code"
+        value={orgCode}
+        onChange={(e) => setOrgCode(e.target.value)}
         className="w-full mb-4 px-4 py-2 border rounded"
         required
       />
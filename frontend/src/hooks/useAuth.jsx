@@ -8,14 +8,14 @@ export const AuthProvider = ({ children }) => {
   const [loading, setLoading] = useState(false)
   const [error, setError] = useState(null)
 
-  const register = async (email, password, organizationId) => {
+  const register = async (email, password, organizationCode) => {
     setLoading(true)
     setError(null)
     try {
       const response = await fetch(`${API_URL}/api/auth/register`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify({ email, password, organization_id: organizationId }),
+        body: JSON.stringify({ email, password, organization_code: organizationCode }),
       })
       if (!response.ok) throw new Error('Registration failed')
 
@@ -31,14 +31,14 @@ export const AuthProvider = ({ children }) => {
     }
   }
 
-  const login = async (email, password, organizationId) => {
+  const login = async (email, password, organizationCode) => {
     setLoading(true)
     setError(null)
     try {
       const response = await fetch(`${API_URL}/api/auth/login`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify({ email, password, organization_id: organizationId }),
+        body: JSON.stringify({ email, password, organization_code: organizationCode }),
       })
       if (!response.ok) throw new Error('Login failed')
 
@@ -206,12 +206,39 @@ fi
 declare -a MIGRATION_FILES=(
   "$MIGRATIONS_DIR/001_create_core_tables.sql"
   "$MIGRATIONS_DIR/002_add_foreign_keys_and_constraints.sql"
+  "$MIGRATIONS_DIR/004_organization_security_settings.sql"
+  "$MIGRATIONS_DIR/005_account_lockout.sql"
+  "$MIGRATIONS_DIR/006_role_permissions.sql"
+  "$MIGRATIONS_DIR/007_mfa.sql"
+  "$MIGRATIONS_DIR/008_approval_step_up.sql"
+  "$MIGRATIONS_DIR/009_oidc_sso.sql"
+  "$MIGRATIONS_DIR/010_api_keys.sql"
+  "$MIGRATIONS_DIR/011_invitations.sql"
+  "$MIGRATIONS_DIR/012_user_management.sql"
+  "$MIGRATIONS_DIR/013_organization_lifecycle.sql"
+  "$MIGRATIONS_DIR/014_license_expiration_alerts.sql"
+  "$MIGRATIONS_DIR/015_maintenance_assignments.sql"
+  "$MIGRATIONS_DIR/016_maintenance_schedules.sql"
+  "$MIGRATIONS_DIR/017_schedule_triggers.sql"
+  "$MIGRATIONS_DIR/018_meter_readings.sql"
+  "$MIGRATIONS_DIR/019_maintenance_alerts.sql"
+  "$MIGRATIONS_DIR/020_calendar_feeds.sql"
+  "$MIGRATIONS_DIR/021_webhook_outbox.sql"
+  "$MIGRATIONS_DIR/022_webhook_management.sql"
+  "$MIGRATIONS_DIR/023_envelope_encryption.sql"
+  "$MIGRATIONS_DIR/024_inbound_webhooks.sql"
+  "$MIGRATIONS_DIR/025_procore_sync.sql"
+  "$MIGRATIONS_DIR/026_event_stream.sql"
+  "$MIGRATIONS_DIR/027_schedule_roll_forward.sql"
+  "$MIGRATIONS_DIR/028_single_use_signing_tokens.sql"
 )
 
 
-# Add seed data if --seed flag was used
+# Add seed data if --seed flag was used. It runs in its numbered position, right after
+# the schema, so that later migrations (e.g. 006 and 018 granting role permissions)
+# also apply to the seeded rows.
 if [ $SEED_DATA -eq 1 ]; then
-  MIGRATION_FILES+=("$MIGRATIONS_DIR/003_seed_data.sql")
+  MIGRATION_FILES=("${MIGRATION_FILES[@]:0:2}" "$MIGRATIONS_DIR/003_seed_data.sql" "${MIGRATION_FILES[@]:2}")
 fi
 
 for file in "${MIGRATION_FILES[@]}"; do 